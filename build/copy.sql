@@ -1,4 +1,22 @@
-\copy actors FROM '/home/data/actors.csv' DELIMITER ';';
-\copy movies FROM '/home/data/movies.csv' DELIMITER ';';
-\copy movies_actors FROM '/home/data/moviesActors.csv' DELIMITER ';';
-\copy users FROM '/home/data/users.csv' DELIMITER ';';
\ No newline at end of file
+-- Loads build/data, mounted at /home/data, into a migrated database with
+-- empty tables, in one transaction: `make loadData`. The columns are
+-- listed, the ones added since keep their defaults. The rows keep the ids
+-- of the files, so the identities are moved past them. Like the seed it is
+-- a bulk load: one event lists every movie and actor instead of one per
+-- row, see migrations/0023_outbox_bulk_load.
+set local intern.bulk_load = on;
+
+\copy actors (id, first_name, last_name, gender, birthday) FROM '/home/data/actors.csv' DELIMITER ';';
+\copy movies (id, title, description, release_date, rating) FROM '/home/data/movies.csv' DELIMITER ';';
+\copy movies_actors (id, movie_id, actor_id) FROM '/home/data/moviesActors.csv' DELIMITER ';';
+\copy users (id, login, password, user_role) FROM '/home/data/users.csv' DELIMITER ';';
+
+select setval(pg_get_serial_sequence('public.actors', 'id'), coalesce(max(id), 0) + 1, false) from public.actors;
+select setval(pg_get_serial_sequence('public.movies', 'id'), coalesce(max(id), 0) + 1, false) from public.movies;
+select setval(pg_get_serial_sequence('public.movies_actors', 'id'), coalesce(max(id), 0) + 1, false) from public.movies_actors;
+select setval(pg_get_serial_sequence('public.users', 'id'), coalesce(max(id), 0) + 1, false) from public.users;
+
+insert into public.outbox_events (type, payload)
+select 'catalogue.loaded', jsonb_build_object('source', 'seed',
+    'movies', (select coalesce(jsonb_agg(id order by id), '[]') from public.movies),
+    'actors', (select coalesce(jsonb_agg(id order by id), '[]') from public.actors));
@@ -1,43 +1,675 @@
 package main
 
 import (
+	"expvar"
+	"flag"
 	"fmt"
 	"intern/cmd/server"
 	actorDel "intern/internal/actor/delivery"
+	actorRep "intern/internal/actor/repository"
+	cachedActor "intern/internal/actor/repository/cached"
+	memActor "intern/internal/actor/repository/memory"
 	pgActor "intern/internal/actor/repository/postgres"
 	actorUseCase "intern/internal/actor/usecase"
+	auditDel "intern/internal/audit/delivery"
+	auditRep "intern/internal/audit/repository"
+	memAudit "intern/internal/audit/repository/memory"
+	pgAudit "intern/internal/audit/repository/postgres"
+	auditUseCase "intern/internal/audit/usecase"
+	autocompleteDel "intern/internal/autocomplete/delivery"
+	autocompleteRep "intern/internal/autocomplete/repository"
+	memAutocomplete "intern/internal/autocomplete/repository/memory"
+	pgAutocomplete "intern/internal/autocomplete/repository/postgres"
+	autocompleteUseCase "intern/internal/autocomplete/usecase"
+	"intern/internal/cachesync"
+	castGraphDel "intern/internal/castgraph/delivery"
+	castGraphRep "intern/internal/castgraph/repository"
+	memCastGraph "intern/internal/castgraph/repository/memory"
+	pgCastGraph "intern/internal/castgraph/repository/postgres"
+	castGraphUseCase "intern/internal/castgraph/usecase"
+	eventDel "intern/internal/event/delivery"
+	eventRep "intern/internal/event/repository"
+	memEvent "intern/internal/event/repository/memory"
+	pgEvent "intern/internal/event/repository/postgres"
+	eventUseCase "intern/internal/event/usecase"
+	exportDel "intern/internal/export/delivery"
+	pgImdb "intern/internal/imdb/repository/postgres"
+	imdbUseCase "intern/internal/imdb/usecase"
+	importDel "intern/internal/importer/delivery"
+	importRep "intern/internal/importer/repository"
+	memImport "intern/internal/importer/repository/memory"
+	pgImport "intern/internal/importer/repository/postgres"
+	importUseCase "intern/internal/importer/usecase"
+	listDel "intern/internal/list/delivery"
+	listRep "intern/internal/list/repository"
+	memList "intern/internal/list/repository/memory"
+	pgList "intern/internal/list/repository/postgres"
+	listUseCase "intern/internal/list/usecase"
+	"intern/internal/memdb"
 	movieDel "intern/internal/movie/delivery"
+	movieRep "intern/internal/movie/repository"
+	cachedMovie "intern/internal/movie/repository/cached"
+	memMovie "intern/internal/movie/repository/memory"
 	pgMovie "intern/internal/movie/repository/postgres"
 	movieUseCase "intern/internal/movie/usecase"
+	ratingDel "intern/internal/rating/delivery"
+	ratingRep "intern/internal/rating/repository"
+	cachedRating "intern/internal/rating/repository/cached"
+	memRating "intern/internal/rating/repository/memory"
+	pgRating "intern/internal/rating/repository/postgres"
+	ratingUseCase "intern/internal/rating/usecase"
+	recommendationDel "intern/internal/recommendation/delivery"
+	recommendationRep "intern/internal/recommendation/repository"
+	memRecommendation "intern/internal/recommendation/repository/memory"
+	pgRecommendation "intern/internal/recommendation/repository/postgres"
+	recommendationUseCase "intern/internal/recommendation/usecase"
+	reviewDel "intern/internal/review/delivery"
+	reviewRep "intern/internal/review/repository"
+	memReview "intern/internal/review/repository/memory"
+	pgReview "intern/internal/review/repository/postgres"
+	reviewUseCase "intern/internal/review/usecase"
+	"intern/internal/seed"
+	statsDel "intern/internal/stats/delivery"
+	statsRep "intern/internal/stats/repository"
+	memStats "intern/internal/stats/repository/memory"
+	pgStats "intern/internal/stats/repository/postgres"
+	statsUseCase "intern/internal/stats/usecase"
+	trashDel "intern/internal/trash/delivery"
+	trashRep "intern/internal/trash/repository"
+	memTrash "intern/internal/trash/repository/memory"
+	pgTrash "intern/internal/trash/repository/postgres"
+	trashUseCase "intern/internal/trash/usecase"
 	userDel "intern/internal/user/delivery"
+	userRep "intern/internal/user/repository"
+	memUser "intern/internal/user/repository/memory"
 	pgUser "intern/internal/user/repository/postgres"
 	userUseCase "intern/internal/user/usecase"
+	watchlistDel "intern/internal/watchlist/delivery"
+	watchlistRep "intern/internal/watchlist/repository"
+	memWatchlist "intern/internal/watchlist/repository/memory"
+	pgWatchlist "intern/internal/watchlist/repository/postgres"
+	watchlistUseCase "intern/internal/watchlist/usecase"
+	webhookDel "intern/internal/webhook/delivery"
+	webhookRep "intern/internal/webhook/repository"
+	memWebhook "intern/internal/webhook/repository/memory"
+	pgWebhook "intern/internal/webhook/repository/postgres"
+	webhookUseCase "intern/internal/webhook/usecase"
+	"intern/migrations"
+	"intern/models"
+	"intern/pkg/cache"
+	"intern/pkg/config"
 	"intern/pkg/context"
+	"intern/pkg/logger"
 	"intern/pkg/middleware"
+	"intern/pkg/migrate"
+	"intern/pkg/ratelimit"
 	"intern/pkg/session"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var prodCfgPg = postgres.Config{DSN: "host=db user=postgres password=postgres port=5432"}
+type repositories struct {
+	movies          movieRep.MovieRepositoryI
+	actors          actorRep.ActorRepositoryI
+	users           userRep.UserRepositoryI
+	autocomplete    autocompleteRep.AutocompleteRepositoryI
+	importJobs      importRep.JobRepositoryI
+	ratings         ratingRep.RatingRepositoryI
+	reviews         reviewRep.ReviewRepositoryI
+	watchlist       watchlistRep.WatchlistRepositoryI
+	lists           listRep.ListRepositoryI
+	recommendations recommendationRep.RecommendationRepositoryI
+	castGraph       castGraphRep.CastGraphRepositoryI
+	stats           statsRep.StatsRepositoryI
+	trash           trashRep.TrashRepositoryI
+	audit           auditRep.AuditRepositoryI
+	webhooks        webhookRep.WebhookRepositoryI
+	events          eventRep.EventRepositoryI
+}
+
+func openPostgres(cfg config.Config) (*gorm.DB, *migrate.Migrator, error) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: cfg.PostgresDSN}), &gorm.Config{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, migrator, nil
+}
+
+// newRepositories opens the configured storage. materializedStats serves
+// the statistics from the Postgres views, which then need refreshing.
+func newRepositories(cfg config.Config, materializedStats bool, logger logger.Logger) (*repositories, error) {
+	switch cfg.Storage {
+	case config.StoragePostgres:
+		db, migrator, err := openPostgres(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := migrator.Check(); err != nil {
+			return nil, fmt.Errorf("%w; run `main migrate up`", err)
+		}
+
+		stats := pgStats.New(logger, db)
+		if materializedStats {
+			stats = pgStats.NewMaterialized(logger, db)
+		}
+
+		return &repositories{
+			movies:          pgMovie.New(logger, db),
+			actors:          pgActor.New(logger, db),
+			users:           pgUser.New(logger, db),
+			autocomplete:    pgAutocomplete.New(logger, db),
+			importJobs:      pgImport.New(logger, db),
+			ratings:         pgRating.New(logger, db),
+			reviews:         pgReview.New(logger, db),
+			watchlist:       pgWatchlist.New(logger, db),
+			lists:           pgList.New(logger, db),
+			recommendations: pgRecommendation.New(logger, db),
+			castGraph:       pgCastGraph.New(logger, db),
+			stats:           stats,
+			trash:           pgTrash.New(logger, db),
+			audit:           pgAudit.New(logger, db),
+			webhooks:        pgWebhook.New(logger, db),
+			events:          pgEvent.New(logger, db),
+		}, nil
+	case config.StorageMemory:
+		db := memdb.New()
+
+		if cfg.MemorySeedDir != "" {
+			if err := db.LoadCSV(cfg.MemorySeedDir); err != nil {
+				return nil, err
+			}
+		}
+
+		return &repositories{
+			movies:          memMovie.New(logger, db),
+			actors:          memActor.New(logger, db),
+			users:           memUser.New(logger, db),
+			autocomplete:    memAutocomplete.New(logger, db),
+			importJobs:      memImport.New(logger, db),
+			ratings:         memRating.New(logger, db),
+			reviews:         memReview.New(logger, db),
+			watchlist:       memWatchlist.New(logger, db),
+			lists:           memList.New(logger, db),
+			recommendations: memRecommendation.New(logger, db),
+			castGraph:       memCastGraph.New(logger, db),
+			stats:           memStats.New(logger, db),
+			trash:           memTrash.New(logger, db),
+			audit:           memAudit.New(logger, db),
+			webhooks:        memWebhook.New(logger, db),
+			events:          memEvent.New(logger, db),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
+	}
+}
+
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: main migrate up|down|status")
+	}
+
+	_, migrator, err := openPostgres(cfg)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Run(args[0], os.Stdout)
+}
+
+func runSeed(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+
+	var genCfg seed.Config
+	fs.Int64Var(&genCfg.Seed, "seed", 1, "random seed, equal seeds give equal datasets but for the passwords")
+	fs.IntVar(&genCfg.Users, "users", 1000, "number of users")
+	fs.IntVar(&genCfg.Actors, "actors", 1000, "number of actors")
+	fs.IntVar(&genCfg.Movies, "movies", 500, "number of movies")
+	fs.IntVar(&genCfg.Links, "links", 5000, "number of movie-actor links")
+
+	out := fs.String("out", "", "write CSV files to this directory instead of loading into Postgres")
+	batch := fs.Int("batch", 1000, "rows per INSERT statement")
+	truncate := fs.Bool("truncate", false, "empty the tables before loading")
+	hashCost := fs.Int("hash-cost", bcrypt.DefaultCost, "bcrypt cost for user passwords")
+	credentials := fs.String("credentials", "", "when loading into Postgres, write the generated logins and passwords to this file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ds, err := seed.Generate(genCfg)
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		return seed.WriteCSV(*out, ds, *hashCost)
+	}
+
+	db, migrator, err := openPostgres(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("%w; run `main migrate up`", err)
+	}
+
+	err = seed.Load(db, ds, seed.LoadOptions{
+		BatchSize: *batch,
+		Truncate:  *truncate,
+		HashCost:  *hashCost,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The passwords are random, without the file nobody knows them.
+	if *credentials != "" {
+		return seed.WriteCredentials(*credentials, ds.Users)
+	}
+
+	return nil
+}
+
+func runImdb(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("imdb", flag.ContinueOnError)
+
+	var opts imdbUseCase.Options
+	fs.StringVar(&opts.Dir, "dir", "build/imdb", "directory with title.basics, name.basics and title.principals (.tsv.gz or .tsv)")
+	fs.IntVar(&opts.BatchSize, "batch", imdbUseCase.DefaultBatchSize, "rows per stored batch")
+	types := fs.String("types", strings.Join(imdbUseCase.DefaultTitleTypes, ","), "comma-separated titleType values imported as movies")
+	fs.BoolVar(&opts.IncludeAdult, "include-adult", false, "import adult titles")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts.TitleTypes = strings.Split(*types, ",")
+
+	db, migrator, err := openPostgres(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("%w; run `main migrate up`", err)
+	}
+
+	zapLogger := zap.Must(zap.NewDevelopment())
+	logger := zapLogger.Sugar()
+
+	audit := auditUseCase.New(pgAudit.New(logger, db), context.Manager{})
+
+	return imdbUseCase.New(pgImdb.New(logger, db), audit, logger).Run(opts)
+}
+
+func runSimilarity(cfg config.Config) error {
+	db, migrator, err := openPostgres(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("%w; run `main migrate up`", err)
+	}
+
+	zapLogger := zap.Must(zap.NewDevelopment())
+	logger := zapLogger.Sugar()
+
+	stats, err := recommendationUseCase.New(pgRecommendation.New(logger, db), pgMovie.New(logger, db)).Recompute()
+	if err != nil {
+		return err
+	}
+
+	logger.Infow("similarities recomputed", "cast", stats.CastPairs, "ratings", stats.RatingsPairs)
+
+	return nil
+}
+
+func runPurge(cfg config.Config) error {
+	retention, err := time.ParseDuration(cfg.TrashRetention)
+	if err != nil {
+		return fmt.Errorf("invalid TRASH_RETENTION: %w", err)
+	}
+
+	if retention <= 0 {
+		return fmt.Errorf("TRASH_RETENTION is %s, the trash is kept", cfg.TrashRetention)
+	}
+
+	db, migrator, err := openPostgres(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("%w; run `main migrate up`", err)
+	}
+
+	zapLogger := zap.Must(zap.NewDevelopment())
+	logger := zapLogger.Sugar()
+
+	stats, err := trashUseCase.New(pgTrash.New(logger, db), retention).Purge()
+	if err != nil {
+		return err
+	}
+
+	logger.Infow("trash purged", "movies", stats.Movies, "actors", stats.Actors)
+
+	return nil
+}
+
+// refreshSimilarities runs the similarity job at start and then every
+// interval, so recommendation requests only read its tables.
+func refreshSimilarities(uc recommendationUseCase.RecommendationUseCaseI, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := uc.Recompute()
+		if err != nil {
+			logger.Errorw("can`t recompute similarities",
+				"err:", err.Error())
+		} else {
+			logger.Infow("similarities recomputed", "cast", stats.CastPairs, "ratings", stats.RatingsPairs)
+		}
+
+		<-ticker.C
+	}
+}
+
+// refreshStats brings the materialized statistics up to date at start and
+// then every interval.
+func refreshStats(uc statsUseCase.StatsUseCaseI, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := uc.Refresh(); err != nil {
+			logger.Errorw("can`t refresh stats",
+				"err:", err.Error())
+		} else {
+			logger.Infow("stats refreshed")
+		}
+
+		<-ticker.C
+	}
+}
+
+// purgeInterval is how often the server looks for expired trash, the
+// retention itself is TRASH_RETENTION.
+const purgeInterval = time.Hour
+
+// purgeTrash empties the trash of what is older than the retention at
+// start and then every purgeInterval.
+func purgeTrash(uc trashUseCase.TrashUseCaseI, logger logger.Logger) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := uc.Purge()
+		if err != nil {
+			logger.Errorw("can`t purge trash",
+				"err:", err.Error())
+		} else {
+			logger.Infow("trash purged", "movies", stats.Movies, "actors", stats.Actors)
+		}
+
+		<-ticker.C
+	}
+}
+
+// failStaleImports fails the import jobs that stopped saving progress at
+// start and then every importUseCase.StaleAfter, the server running them
+// is gone.
+func failStaleImports(uc importUseCase.ImportUseCaseI, logger logger.Logger) {
+	ticker := time.NewTicker(importUseCase.StaleAfter)
+	defer ticker.Stop()
+
+	for {
+		n, err := uc.FailStale()
+		if err != nil {
+			logger.Errorw("can`t fail stale import jobs",
+				"err:", err.Error())
+		} else if n > 0 {
+			logger.Infow("stale import jobs failed", "jobs", n)
+		}
+
+		<-ticker.C
+	}
+}
+
+// webhookTimeout bounds each request to a webhook.
+const webhookTimeout = 10 * time.Second
+
+// How often an event stream looks for new events and sends a heartbeat
+// when there are none.
+const (
+	eventPollInterval = time.Second
+	eventHeartbeat    = 15 * time.Second
+)
+
+// dispatchWebhooks sends the events of the outbox to the webhooks every
+// interval.
+func dispatchWebhooks(uc webhookUseCase.WebhookUseCaseI, interval time.Duration, logger logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := uc.Dispatch()
+		if err != nil {
+			logger.Errorw("can`t dispatch webhooks",
+				"err:", err.Error())
+		} else if stats != (models.DispatchStats{}) {
+			logger.Infow("webhooks dispatched", "events", stats.Events, "delivered", stats.Delivered,
+				"retried", stats.Retried, "dead", stats.Dead)
+		}
+
+		<-ticker.C
+	}
+}
+
+// syncCache invalidates what the outbox shows was written around the
+// cached repositories, as often as the event streams look for events.
+func syncCache(syncer *cachesync.Syncer, logger logger.Logger) {
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := syncer.Sync(); err != nil {
+			logger.Errorw("can`t sync cache",
+				"err:", err.Error())
+		}
+
+		<-ticker.C
+	}
+}
+
+// redisTimeout bounds each command to the Redis cache.
+const redisTimeout = time.Second
+
+// newCache returns the configured cache of movies and actors, nil when it
+// is disabled.
+func newCache(cfg config.Config, logger logger.Logger) (*cache.Cache, error) {
+	ttl, err := time.ParseDuration(cfg.CacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_TTL: %w", err)
+	}
+
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	if cfg.RedisAddr != "" {
+		return cache.New(cache.NewRedis(cfg.RedisAddr, redisTimeout), ttl, logger), nil
+	}
+
+	size, err := strconv.Atoi(cfg.CacheSize)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("invalid CACHE_SIZE %q", cfg.CacheSize)
+	}
+
+	return cache.New(cache.NewLRU(size), ttl, logger), nil
+}
+
+type rateLimits struct {
+	all, login, lists ratelimit.Limit
+}
+
+func parseRateLimits(cfg config.Config) (rateLimits, error) {
+	var (
+		limits rateLimits
+		err    error
+	)
+
+	if limits.all, err = ratelimit.Parse(cfg.RateLimit); err != nil {
+		return limits, fmt.Errorf("invalid RATE_LIMIT: %w", err)
+	}
+	if limits.login, err = ratelimit.Parse(cfg.RateLimitLogin); err != nil {
+		return limits, fmt.Errorf("invalid RATE_LIMIT_LOGIN: %w", err)
+	}
+	if limits.lists, err = ratelimit.Parse(cfg.RateLimitLists); err != nil {
+		return limits, fmt.Errorf("invalid RATE_LIMIT_LISTS: %w", err)
+	}
+
+	return limits, nil
+}
+
+// parseTrustedProxies reads TRUSTED_PROXIES, a bare address is a range of
+// its own.
+func parseTrustedProxies(cfg config.Config) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(proxy); ip != nil {
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
 
 // @title MovieDataBase Swagger API
 // @version 1.0
 // @host localhost:8085
 func main() {
+	cfg := config.FromEnv()
+
+	if len(os.Args) > 1 {
+		var err error
+
+		switch os.Args[1] {
+		case "migrate":
+			err = runMigrate(cfg, os.Args[2:])
+		case "seed":
+			err = runSeed(cfg, os.Args[2:])
+		case "imdb":
+			err = runImdb(cfg, os.Args[2:])
+		case "similarity":
+			err = runSimilarity(cfg)
+		case "purge":
+			err = runPurge(cfg)
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
 	zapLogger := zap.Must(zap.NewDevelopment())
 	logger := zapLogger.Sugar()
 
-	db, err := gorm.Open(postgres.New(prodCfgPg), &gorm.Config{})
+	similarityInterval, err := time.ParseDuration(cfg.SimilarityInterval)
+	if err != nil {
+		log.Fatal(fmt.Errorf("invalid SIMILARITY_INTERVAL: %w", err))
+	}
+
+	statsInterval, err := time.ParseDuration(cfg.StatsRefreshInterval)
+	if err != nil {
+		log.Fatal(fmt.Errorf("invalid STATS_REFRESH_INTERVAL: %w", err))
+	}
+
+	trashRetention, err := time.ParseDuration(cfg.TrashRetention)
+	if err != nil {
+		log.Fatal(fmt.Errorf("invalid TRASH_RETENTION: %w", err))
+	}
+
+	webhookInterval, err := time.ParseDuration(cfg.WebhookInterval)
+	if err != nil {
+		log.Fatal(fmt.Errorf("invalid WEBHOOK_INTERVAL: %w", err))
+	}
+
+	writeTimeout, err := time.ParseDuration(cfg.WriteTimeout)
+	if err != nil {
+		log.Fatal(fmt.Errorf("invalid WRITE_TIMEOUT: %w", err))
+	}
+
+	repos, err := newRepositories(cfg, statsInterval > 0, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	limits, err := parseRateLimits(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repoCache, err := newCache(cfg, logger)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var cacheSyncer *cachesync.Syncer
+
+	if repoCache != nil {
+		cacheSyncer, err = cachesync.New(repos.events, repos.movies, repos.actors, repoCache, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		repos.ratings = cachedRating.New(logger, repos.ratings, repos.movies, repoCache)
+		repos.movies = cachedMovie.New(logger, repos.movies, repoCache)
+		repos.actors = cachedActor.New(logger, repos.actors, repoCache)
+		expvar.Publish("cache", repoCache)
+	}
+
 	sessionManager := session.JWTSessionsManager{}
 	contextManager := context.Manager{}
 
@@ -47,44 +679,252 @@ func main() {
 		ContextManager: contextManager,
 	}
 
+	auditHandler := auditDel.AuditHandler{
+		AuditUseCase: auditUseCase.New(repos.audit, contextManager),
+		Logger:       logger,
+	}
+
 	actorHandler := actorDel.ActorHandler{
-		ActorUseCase: actorUseCase.New(pgActor.New(logger, db)),
+		ActorUseCase: actorUseCase.New(repos.actors, auditHandler.AuditUseCase, contextManager),
 		Logger:       logger,
 	}
 
 	movieHandler := movieDel.MovieHandler{
-		MovieUseCase: movieUseCase.New(pgMovie.New(logger, db)),
+		MovieUseCase: movieUseCase.New(repos.movies, auditHandler.AuditUseCase, contextManager),
 		Logger:       logger,
 	}
 
 	userHandler := userDel.UserHandler{
-		UserUseCase: userUseCase.New(pgUser.New(logger, db)),
+		UserUseCase: userUseCase.New(repos.users),
 		Logger:      logger,
 		Sessions:    sessionManager,
 	}
 
+	autocompleteHandler := autocompleteDel.AutocompleteHandler{
+		AutocompleteUseCase: autocompleteUseCase.New(repos.autocomplete),
+		Logger:              logger,
+	}
+
+	exportHandler := exportDel.ExportHandler{
+		MovieUseCase: movieHandler.MovieUseCase,
+		ActorUseCase: actorHandler.ActorUseCase,
+		Logger:       logger,
+		WriteTimeout: writeTimeout,
+	}
+
+	importHandler := importDel.ImportHandler{
+		ImportUseCase: importUseCase.New(repos.movies, repos.actors, repos.importJobs, auditHandler.AuditUseCase, logger),
+		Logger:        logger,
+	}
+
+	ratingHandler := ratingDel.RatingHandler{
+		RatingUseCase: ratingUseCase.New(repos.ratings),
+		Logger:        logger,
+		Context:       contextManager,
+	}
+
+	reviewHandler := reviewDel.ReviewHandler{
+		ReviewUseCase: reviewUseCase.New(repos.reviews, repos.movies),
+		Logger:        logger,
+		Context:       contextManager,
+	}
+
+	watchlistHandler := watchlistDel.WatchlistHandler{
+		WatchlistUseCase: watchlistUseCase.New(repos.watchlist, repos.movies),
+		Logger:           logger,
+		Context:          contextManager,
+	}
+
+	listHandler := listDel.ListHandler{
+		ListUseCase: listUseCase.New(repos.lists, repos.movies),
+		Logger:      logger,
+		Context:     contextManager,
+	}
+
+	recommendationHandler := recommendationDel.RecommendationHandler{
+		RecommendationUseCase: recommendationUseCase.New(repos.recommendations, repos.movies),
+		Logger:                logger,
+		Context:               contextManager,
+	}
+
+	castGraphHandler := castGraphDel.CastGraphHandler{
+		CastGraphUseCase: castGraphUseCase.New(repos.castGraph, repos.actors, repos.movies),
+		Logger:           logger,
+	}
+
+	statsHandler := statsDel.StatsHandler{
+		StatsUseCase: statsUseCase.New(repos.stats),
+		Logger:       logger,
+	}
+
+	trashHandler := trashDel.TrashHandler{
+		TrashUseCase: trashUseCase.New(repos.trash, trashRetention),
+		Logger:       logger,
+	}
+
+	webhookHandler := webhookDel.WebhookHandler{
+		WebhookUseCase: webhookUseCase.New(repos.webhooks, &http.Client{Timeout: webhookTimeout}),
+		Logger:         logger,
+	}
+
+	eventHandler := eventDel.EventHandler{
+		EventUseCase: eventUseCase.New(repos.events),
+		Logger:       logger,
+		PollInterval: eventPollInterval,
+		Heartbeat:    eventHeartbeat,
+		WriteTimeout: writeTimeout,
+	}
+
+	if statsInterval > 0 {
+		go refreshStats(statsHandler.StatsUseCase, statsInterval, logger)
+	}
+
+	if similarityInterval > 0 {
+		go refreshSimilarities(recommendationHandler.RecommendationUseCase, similarityInterval, logger)
+	}
+
+	if trashRetention > 0 {
+		go purgeTrash(trashHandler.TrashUseCase, logger)
+	}
+
+	go failStaleImports(importHandler.ImportUseCase, logger)
+
+	if webhookInterval > 0 {
+		go dispatchWebhooks(webhookHandler.WebhookUseCase, webhookInterval, logger)
+	}
+
+	if cacheSyncer != nil {
+		go syncCache(cacheSyncer, logger)
+	}
+
+	rateLimiter := middleware.RateLimiter{
+		ContextManager: contextManager,
+		Store:          ratelimit.NewMemory(),
+		Logger:         logger,
+		TrustedProxies: trustedProxies,
+	}
+	if cfg.RedisAddr != "" {
+		rateLimiter.Store = ratelimit.NewRedis(cache.NewRedis(cfg.RedisAddr, redisTimeout))
+	}
+
+	// The responses of the catalogue are the same for every user, so they
+	// are cached as they are. The lists are the costly ones to hammer.
+	entityRoute := func(h http.HandlerFunc) http.Handler {
+		return middleware.Conditional(h, cfg.CacheControlEntities)
+	}
+	// The global limit runs behind Auth to count each user, whatever
+	// address they come from; the public routes count the address.
+	auth := func(h http.Handler, roles ...string) http.Handler {
+		return authManager.Auth(rateLimiter.Limit(h, "all", limits.all), roles...)
+	}
+	public := func(h http.Handler) http.Handler {
+		return rateLimiter.Limit(h, "all", limits.all)
+	}
+	listRoute := func(h http.HandlerFunc) http.Handler {
+		return rateLimiter.Limit(middleware.Conditional(h, cfg.CacheControlLists), "lists", limits.lists)
+	}
+
 	r := http.NewServeMux()
 
-	r.HandleFunc("POST /users/login", userHandler.Login)
+	r.Handle("POST /users/login", public(rateLimiter.Limit(http.HandlerFunc(userHandler.Login), "login", limits.login)))
+	r.Handle("GET /users/me/watchlist", auth(http.HandlerFunc(watchlistHandler.Watchlist), "user", "admin"))
+	r.Handle("PUT /users/me/watchlist/{MOV_ID}", auth(http.HandlerFunc(watchlistHandler.Add), "user", "admin"))
+	r.Handle("DELETE /users/me/watchlist/{MOV_ID}", auth(http.HandlerFunc(watchlistHandler.Remove), "user", "admin"))
+	r.Handle("GET /users/me/history", auth(http.HandlerFunc(watchlistHandler.History), "user", "admin"))
+	r.Handle("GET /users/me/history/stats", auth(http.HandlerFunc(watchlistHandler.Stats), "user", "admin"))
+	r.Handle("PUT /users/me/history/{MOV_ID}", auth(http.HandlerFunc(watchlistHandler.SetWatched), "user", "admin"))
+	r.Handle("DELETE /users/me/history/{MOV_ID}", auth(http.HandlerFunc(watchlistHandler.RemoveWatched), "user", "admin"))
+	r.Handle("GET /users/me/lists", auth(http.HandlerFunc(listHandler.Mine), "user", "admin"))
+	r.Handle("GET /users/me/recommendations", auth(http.HandlerFunc(recommendationHandler.Recommend), "user", "admin"))
+
+	r.Handle("POST /lists", auth(http.HandlerFunc(listHandler.Create), "user", "admin"))
+	r.Handle("GET /lists/{LIST_ID}", auth(http.HandlerFunc(listHandler.Get), "user", "admin"))
+	r.Handle("PUT /lists/{LIST_ID}", auth(http.HandlerFunc(listHandler.Update), "user", "admin"))
+	r.Handle("DELETE /lists/{LIST_ID}", auth(http.HandlerFunc(listHandler.Delete), "user", "admin"))
+	r.Handle("POST /lists/{LIST_ID}/share-token", auth(http.HandlerFunc(listHandler.RotateToken), "user", "admin"))
+	r.Handle("POST /lists/{LIST_ID}/entries", auth(http.HandlerFunc(listHandler.AddEntry), "user", "admin"))
+	r.Handle("PUT /lists/{LIST_ID}/entries/{MOV_ID}", auth(http.HandlerFunc(listHandler.UpdateEntry), "user", "admin"))
+	r.Handle("DELETE /lists/{LIST_ID}/entries/{MOV_ID}", auth(http.HandlerFunc(listHandler.RemoveEntry), "user", "admin"))
+	r.Handle("PUT /lists/{LIST_ID}/order", auth(http.HandlerFunc(listHandler.Reorder), "user", "admin"))
+	r.Handle("GET /shared/lists/{TOKEN}", public(http.HandlerFunc(listHandler.GetShared)))
+
+	r.Handle("GET /actors", auth(listRoute(actorHandler.List), "user", "admin"))
+	r.Handle("GET /actors/{ACT_ID}", auth(entityRoute(actorHandler.Get), "user", "admin"))
+	r.Handle("POST /actors", auth(http.HandlerFunc(actorHandler.Create), "admin"))
+	r.Handle("PUT /actors/{ACT_ID}", auth(http.HandlerFunc(actorHandler.Update), "admin"))
+	r.Handle("DELETE /actors/{ACT_ID}", auth(http.HandlerFunc(actorHandler.Delete), "admin"))
+	r.Handle("POST /actors/{ACT_ID}/restore", auth(http.HandlerFunc(actorHandler.Restore), "admin"))
+	r.Handle("GET /actors/{ACT_ID}/revisions", auth(http.HandlerFunc(actorHandler.Revisions), "admin"))
+	r.Handle("GET /actors/{ACT_ID}/revisions/{REV}/diff", auth(http.HandlerFunc(actorHandler.RevisionDiff), "admin"))
+	r.Handle("POST /actors/{ACT_ID}/revisions/{REV}/revert", auth(http.HandlerFunc(actorHandler.Revert), "admin"))
+	r.Handle("GET /actors/{ACT_ID}/movies", auth(entityRoute(actorHandler.GetMoviesByActor), "user", "admin"))
+	r.Handle("GET /actors/{ACT_ID}/costars", auth(listRoute(actorHandler.Costars), "user", "admin"))
+	r.Handle("GET /actors/by-external/{SOURCE}/{EXT_ID}", auth(entityRoute(actorHandler.GetByExternalID), "user", "admin"))
+	r.Handle("POST /actors/{ACT_ID}/external-ids", auth(http.HandlerFunc(actorHandler.AddExternalID), "admin"))
+	r.Handle("DELETE /actors/{ACT_ID}/external-ids/{SOURCE}/{EXT_ID}", auth(http.HandlerFunc(actorHandler.DeleteExternalID), "admin"))
+
+	// Not under /actors/{ACT_ID}/path/{OTHER_ID}: the mux can't rank that
+	// against /actors/by-external/{SOURCE}/{EXT_ID}, both match
+	// /actors/by-external/path/....
+	r.Handle("GET /graph/path/{ACT_ID}/{OTHER_ID}", auth(http.HandlerFunc(castGraphHandler.Path), "user", "admin"))
+
+	r.Handle("GET /movies/{MOV_ID}", auth(entityRoute(movieHandler.Get), "user", "admin"))
+	r.Handle("POST /movies", auth(http.HandlerFunc(movieHandler.Create), "admin"))
+	r.Handle("PUT /movies/{MOV_ID}", auth(http.HandlerFunc(movieHandler.Update), "admin"))
+	r.Handle("DELETE /movies/{MOV_ID}", auth(http.HandlerFunc(movieHandler.Delete), "admin"))
+	r.Handle("POST /movies/{MOV_ID}/restore", auth(http.HandlerFunc(movieHandler.Restore), "admin"))
+	r.Handle("GET /movies/{MOV_ID}/revisions", auth(http.HandlerFunc(movieHandler.Revisions), "admin"))
+	r.Handle("GET /movies/{MOV_ID}/revisions/{REV}/diff", auth(http.HandlerFunc(movieHandler.RevisionDiff), "admin"))
+	r.Handle("POST /movies/{MOV_ID}/revisions/{REV}/revert", auth(http.HandlerFunc(movieHandler.Revert), "admin"))
+	r.Handle("GET /movies/{MOV_ID}/actors", auth(entityRoute(movieHandler.GetActorsByMovie), "user", "admin"))
+	r.Handle("GET /movies/{MOV_ID}/similar", auth(http.HandlerFunc(recommendationHandler.Similar), "user", "admin"))
+	r.Handle("GET /movies/{MOV_ID}/my-rating", auth(http.HandlerFunc(ratingHandler.Get), "user", "admin"))
+	r.Handle("PUT /movies/{MOV_ID}/my-rating", auth(http.HandlerFunc(ratingHandler.Set), "user", "admin"))
+	r.Handle("DELETE /movies/{MOV_ID}/my-rating", auth(http.HandlerFunc(ratingHandler.Delete), "user", "admin"))
+	r.Handle("POST /movies/{MOV_ID}/reviews", auth(http.HandlerFunc(reviewHandler.Create), "user", "admin"))
+	r.Handle("GET /movies/{MOV_ID}/reviews", auth(http.HandlerFunc(reviewHandler.ListByMovie), "user", "admin"))
+	r.Handle("GET /movies/by-external/{SOURCE}/{EXT_ID}", auth(entityRoute(movieHandler.GetByExternalID), "user", "admin"))
+	r.Handle("POST /movies/{MOV_ID}/external-ids", auth(http.HandlerFunc(movieHandler.AddExternalID), "admin"))
+	r.Handle("DELETE /movies/{MOV_ID}/external-ids/{SOURCE}/{EXT_ID}", auth(http.HandlerFunc(movieHandler.DeleteExternalID), "admin"))
+	r.Handle("GET /movies/sorted", auth(listRoute(movieHandler.GetMoviesSorted), "user", "admin"))
+	r.Handle("GET /movies/title", auth(listRoute(movieHandler.GetMoviesByTitle), "user", "admin"))
+
+	r.Handle("GET /reviews", auth(http.HandlerFunc(reviewHandler.List), "admin"))
+	r.Handle("GET /reviews/{REVIEW_ID}", auth(http.HandlerFunc(reviewHandler.Get), "user", "admin"))
+	r.Handle("PUT /reviews/{REVIEW_ID}", auth(http.HandlerFunc(reviewHandler.Update), "user", "admin"))
+	r.Handle("DELETE /reviews/{REVIEW_ID}", auth(http.HandlerFunc(reviewHandler.Delete), "user", "admin"))
+	r.Handle("PUT /reviews/{REVIEW_ID}/status", auth(http.HandlerFunc(reviewHandler.SetStatus), "admin"))
+	r.Handle("PUT /reviews/{REVIEW_ID}/vote", auth(http.HandlerFunc(reviewHandler.Vote), "user", "admin"))
+	r.Handle("DELETE /reviews/{REVIEW_ID}/vote", auth(http.HandlerFunc(reviewHandler.DeleteVote), "user", "admin"))
+
+	r.Handle("GET /stats/movies/years", auth(http.HandlerFunc(statsHandler.MoviesPerYear), "user", "admin"))
+	r.Handle("GET /stats/actors/prolific", auth(http.HandlerFunc(statsHandler.ProlificActors), "user", "admin"))
+	r.Handle("GET /stats/cast/genders", auth(http.HandlerFunc(statsHandler.CastGenders), "user", "admin"))
+	r.Handle("GET /stats/cast/ages", auth(http.HandlerFunc(statsHandler.CastAges), "user", "admin"))
+
+	r.Handle("GET /trash", auth(http.HandlerFunc(trashHandler.List), "admin"))
+	r.Handle("GET /audit", auth(http.HandlerFunc(auditHandler.List), "admin"))
+
+	r.Handle("GET /events/stream", auth(http.HandlerFunc(eventHandler.Stream), "user", "admin"))
+	r.Handle("POST /webhooks", auth(http.HandlerFunc(webhookHandler.Create), "admin"))
+	r.Handle("GET /webhooks", auth(http.HandlerFunc(webhookHandler.List), "admin"))
+	r.Handle("DELETE /webhooks/{WEBHOOK_ID}", auth(http.HandlerFunc(webhookHandler.Delete), "admin"))
+	r.Handle("GET /webhooks/dead-letters", auth(http.HandlerFunc(webhookHandler.DeadLetters), "admin"))
+	r.Handle("POST /webhooks/dead-letters/{DELIVERY_ID}/retry", auth(http.HandlerFunc(webhookHandler.Retry), "admin"))
+
+	r.Handle("GET /debug/vars", auth(expvar.Handler(), "admin"))
 
-	r.Handle("GET /actors/{ACT_ID}", authManager.Auth(http.HandlerFunc(actorHandler.Get), "user", "admin"))
-	r.Handle("POST /actors", authManager.Auth(http.HandlerFunc(actorHandler.Create), "admin"))
-	r.Handle("PUT /actors/{ACT_ID}", authManager.Auth(http.HandlerFunc(actorHandler.Update), "admin"))
-	r.Handle("DELETE /actors/{ACT_ID}", authManager.Auth(http.HandlerFunc(actorHandler.Delete), "admin"))
-	r.Handle("GET /actors/{ACT_ID}/movies", authManager.Auth(http.HandlerFunc(actorHandler.GetMoviesByActor), "user", "admin"))
+	r.Handle("GET /search/movies", auth(listRoute(movieHandler.SearchMovies), "user", "admin"))
+	r.Handle("GET /autocomplete", auth(http.HandlerFunc(autocompleteHandler.Suggest), "user", "admin"))
 
-	r.Handle("GET /movies/{MOV_ID}", authManager.Auth(http.HandlerFunc(movieHandler.Get), "user", "admin"))
-	r.Handle("POST /movies", authManager.Auth(http.HandlerFunc(movieHandler.Create), "admin"))
-	r.Handle("PUT /movies/{MOV_ID}", authManager.Auth(http.HandlerFunc(movieHandler.Update), "admin"))
-	r.Handle("DELETE /movies/{MOV_ID}", authManager.Auth(http.HandlerFunc(movieHandler.Delete), "admin"))
-	r.Handle("GET /movies/{MOV_ID}/actors", authManager.Auth(http.HandlerFunc(movieHandler.GetActorsByMovie), "user", "admin"))
-	r.Handle("GET /movies/sorted", authManager.Auth(http.HandlerFunc(movieHandler.GetMoviesSorted), "user", "admin"))
-	r.Handle("GET /movies/title", authManager.Auth(http.HandlerFunc(movieHandler.GetMoviesByTitle), "user", "admin"))
+	r.Handle("POST /import/{KIND}", auth(http.HandlerFunc(importHandler.Import), "admin"))
+	r.Handle("GET /import/jobs/{JOB_ID}", auth(http.HandlerFunc(importHandler.GetJob), "admin"))
+	r.Handle("GET /export/{KIND}", auth(http.HandlerFunc(exportHandler.Export), "admin"))
 
 	router := middleware.AccessLog(logger, r)
 	router = middleware.Panic(logger, router)
 
-	s := server.NewServer(router)
+	s := server.NewServer(router, writeTimeout)
 	if err := s.Start(); err != nil {
 		logger.Fatal(err)
 	}
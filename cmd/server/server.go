@@ -10,14 +10,16 @@ type Server struct {
 	http.Server
 }
 
-func NewServer(myHandler http.Handler) *Server {
+// NewServer serves myHandler with writeTimeout to write each response;
+// streaming handlers move it on as they write.
+func NewServer(myHandler http.Handler, writeTimeout time.Duration) *Server {
 	return &Server{
 		http.Server{
 			Addr:              ":8080",
 			Handler:           myHandler,
 			ReadTimeout:       10 * time.Second,
 			ReadHeaderTimeout: 10 * time.Second,
-			WriteTimeout:      10 * time.Second,
+			WriteTimeout:      writeTimeout,
 		},
 	}
 }
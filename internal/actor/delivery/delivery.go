@@ -6,11 +6,15 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"intern/models"
 	"intern/pkg/logger"
+	"intern/pkg/pagination"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 type ActorHandler struct {
@@ -66,7 +70,7 @@ func (ah *ActorHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ah.ActorUseCase.Create(&actor)
+	err = ah.ActorUseCase.Create(r.Context(), &actor)
 	if err != nil {
 		ah.Logger.Infow("can`t create actor",
 			"err:", err.Error())
@@ -102,7 +106,12 @@ func (ah *ActorHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Produce  application/json
 // @Param    Authorization header string true "token"
 // @Param id path int true "ACT_ID"
+// @Param    If-None-Match header string false "ETag of the cached actor"
+// @Param    If-Modified-Since header string false "Last-Modified of the cached actor"
 // @Success 200 {object} models.Actor "success get actor"
+// @Header  200 {string} ETag "hash of the actor"
+// @Header  200 {string} Last-Modified "when the actor last changed"
+// @Success 304 {object} nil "not modified"
 // @Failure 401 {object} nil "no auth"
 // @Failure 403 {object} nil "forbidden"
 // @Failure 404 {object} nil "Actor not found"
@@ -141,6 +150,9 @@ func (ah *ActorHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !actor.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", actor.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
 	w.WriteHeader(http.StatusOK)
 
 	_, err = w.Write(resp)
@@ -217,7 +229,7 @@ func (ah *ActorHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	actor.ID = actorId
-	err = ah.ActorUseCase.Update(actor)
+	err = ah.ActorUseCase.Update(r.Context(), actor)
 	if err != nil {
 		ah.Logger.Infow("can`t update actor",
 			"err:", err.Error())
@@ -247,7 +259,7 @@ func (ah *ActorHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete godoc
 // @Summary      Delete actor
-// @Description  Delete info about an actor by id
+// @Description  Move an actor to the trash, from where it can be restored until it is purged
 // @Tags     actors
 // @Accept	 application/json
 // @Produce  application/json
@@ -275,7 +287,7 @@ func (ah *ActorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ah.ActorUseCase.Delete(actorId)
+	err = ah.ActorUseCase.Delete(r.Context(), actorId)
 	if err != nil {
 		ah.Logger.Infow("can`t delete actor",
 			"err:", err.Error())
@@ -286,6 +298,69 @@ func (ah *ActorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// Restore godoc
+// @Summary      Restore actor
+// @Description  Take an actor out of the trash
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Success 200 {object} models.Actor "Actor restored"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not in the trash"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/restore [post]
+func (ah *ActorHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	actorIdString := r.PathValue("ACT_ID")
+	if actorIdString == "" {
+		ah.Logger.Errorw("no ACT_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	actorId, err := strconv.Atoi(actorIdString)
+	if err != nil {
+		ah.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, err := ah.ActorUseCase.Restore(r.Context(), actorId)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t restore actor",
+			"err:", err.Error())
+		http.Error(w, "can`t restore actor", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t restore actor",
+			"err:", err.Error())
+		http.Error(w, "can`t restore actor", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(actor)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal actor",
+			"err:", err.Error())
+		http.Error(w, "can`t make actor", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetMoviesByActor godoc
 // @Summary      Get actor's movies
 // @Description  Get list of actor's movies by id
@@ -343,3 +418,576 @@ func (ah *ActorHandler) GetMoviesByActor(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// Costars godoc
+// @Summary      Get actor's costars
+// @Description  Actors who played in movies together with the actor, most shared movies first
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Costar "success get costars"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/costars [get]
+func (ah *ActorHandler) Costars(w http.ResponseWriter, r *http.Request) {
+	actorIdString := r.PathValue("ACT_ID")
+	if actorIdString == "" {
+		ah.Logger.Errorw("no ACT_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	actorId, err := strconv.Atoi(actorIdString)
+	if err != nil {
+		ah.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		ah.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	costars, err := ah.ActorUseCase.Costars(actorId, page.Limit, page.Offset)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t get costars",
+			"err:", err.Error())
+		http.Error(w, "can`t get actor", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t get costars",
+			"err:", err.Error())
+		http.Error(w, "can`t get costars", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(costars)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal costars",
+			"err:", err.Error())
+		http.Error(w, "can`t make costars", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// List godoc
+// @Summary      List actors
+// @Description  Get a page of actors filtered by name fragment, gender and birthday range
+// @Tags     actors
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param q query string false "fragment of first or last name"
+// @Param gender query string false "m or f"
+// @Param born_after query string false "YYYY-MM-DD"
+// @Param born_before query string false "YYYY-MM-DD"
+// @Param sort query string false "name, birthday or movies"
+// @Param order query string false "asc or desc"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.ActorListItem "success get actors"
+// @Failure 400 {object} nil "invalid filter"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors [get]
+func (ah *ActorHandler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := ActorFilterFromRequest(r)
+	if err != nil {
+		ah.Logger.Infow("can`t parse actor filter",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	actors, err := ah.ActorUseCase.List(filter)
+	if errors.Is(err, actorUseCase.ErrInvalidFilter) {
+		ah.Logger.Infow("invalid actor filter",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		ah.Logger.Errorw("can`t get actors",
+			"err:", err.Error())
+		http.Error(w, "can`t get actors", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(actors)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal actors",
+			"err:", err.Error())
+		http.Error(w, "can`t make actors", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ActorFilterFromRequest reads the listing query parameters shared by the
+// endpoints that return collections of actors.
+func ActorFilterFromRequest(r *http.Request) (models.ActorFilter, error) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		return models.ActorFilter{}, err
+	}
+
+	filter := models.ActorFilter{
+		Name:   r.FormValue("q"),
+		Gender: r.FormValue("gender"),
+		SortBy: r.FormValue("sort"),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	switch r.FormValue("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.Errorf("unknown order %q", r.FormValue("order"))
+	}
+
+	if value := r.FormValue("born_after"); value != "" {
+		bornAfter, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid born_after")
+		}
+		filter.BornAfter = &bornAfter
+	}
+
+	if value := r.FormValue("born_before"); value != "" {
+		bornBefore, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid born_before")
+		}
+		filter.BornBefore = &bornBefore
+	}
+
+	return filter, nil
+}
+
+// GetByExternalID godoc
+// @Summary      Get actor by external id
+// @Description  Resolve an id of another catalogue (imdb, tmdb or wikidata) to a actor
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param source path string true "imdb, tmdb or wikidata"
+// @Param id path string true "id in the source"
+// @Success 200 {object} models.Actor "success get actor"
+// @Failure 400 {object} nil "unknown source"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/by-external/{source}/{id} [get]
+func (ah *ActorHandler) GetByExternalID(w http.ResponseWriter, r *http.Request) {
+	ext := models.ExternalID{Source: r.PathValue("SOURCE"), ID: r.PathValue("EXT_ID")}
+
+	actor, err := ah.ActorUseCase.GetByExternalID(ext)
+	if errors.Is(err, actorUseCase.ErrInvalidExternalID) {
+		ah.Logger.Infow("invalid external id",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		ah.Logger.Infow("can`t get actor",
+			"err:", err.Error())
+		http.Error(w, "can`t get actor", http.StatusNotFound)
+		return
+	}
+
+	resp, err := json.Marshal(actor)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal actor",
+			"err:", err.Error())
+		http.Error(w, "can`t make actor", http.StatusInternalServerError)
+		return
+	}
+
+	if !actor.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", actor.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddExternalID godoc
+// @Summary      Link external id
+// @Description  Link a actor to its id in another catalogue. An id belongs to one actor per source.
+// @Tags     actors
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param externalId body models.ExternalID true "source (imdb, tmdb or wikidata) and id"
+// @Success 201 {object} models.ExternalID "external id linked"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not found"
+// @Failure 409 {object} nil "id linked to another actor"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/external-ids [post]
+func (ah *ActorHandler) AddExternalID(w http.ResponseWriter, r *http.Request) {
+	actorIdString := r.PathValue("ACT_ID")
+	if actorIdString == "" {
+		ah.Logger.Errorw("no ACT_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	actorId, err := strconv.Atoi(actorIdString)
+	if err != nil {
+		ah.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	ext := models.ExternalID{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		ah.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		ah.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return
+	}
+
+	err = json.Unmarshal(body, &ext)
+	if err != nil {
+		ah.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = ah.ActorUseCase.AddExternalID(r.Context(), actorId, ext)
+	switch {
+	case errors.Is(err, actorUseCase.ErrInvalidExternalID):
+		ah.Logger.Infow("invalid external id",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, actorUseCase.ErrExternalIDTaken):
+		ah.Logger.Infow("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "external id is taken", http.StatusConflict)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "can`t get actor", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "can`t add external id", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(ext)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal external id",
+			"err:", err.Error())
+		http.Error(w, "can`t make external id", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteExternalID godoc
+// @Summary      Unlink external id
+// @Description  Remove the link between a actor and its id in another catalogue
+// @Tags     actors
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param source path string true "imdb, tmdb or wikidata"
+// @Param externalId path string true "id in the source"
+// @Success 200 {object} nil "external id unlinked"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "link not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/external-ids/{source}/{externalId} [delete]
+func (ah *ActorHandler) DeleteExternalID(w http.ResponseWriter, r *http.Request) {
+	actorIdString := r.PathValue("ACT_ID")
+	if actorIdString == "" {
+		ah.Logger.Errorw("no ACT_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	actorId, err := strconv.Atoi(actorIdString)
+	if err != nil {
+		ah.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	ext := models.ExternalID{Source: r.PathValue("SOURCE"), ID: r.PathValue("EXT_ID")}
+
+	err = ah.ActorUseCase.DeleteExternalID(r.Context(), actorId, ext)
+	if err != nil {
+		ah.Logger.Infow("can`t delete external id",
+			"err:", err.Error())
+		http.Error(w, "can`t delete external id", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Revisions godoc
+// @Summary      Actor revisions
+// @Description  Stored versions of an actor, newest first; each create, update and revert adds one
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Revision "success get revisions"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/revisions [get]
+func (ah *ActorHandler) Revisions(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := ah.pathID(w, r, "ACT_ID")
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		ah.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := ah.ActorUseCase.Revisions(actorId, page.Limit, page.Offset)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t get revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t get revisions", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t get revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t get revisions", http.StatusInternalServerError)
+		return
+	}
+
+	ah.write(w, revisions)
+}
+
+// RevisionDiff godoc
+// @Summary      Diff of actor revisions
+// @Description  Fields that differ between two revisions of an actor, with their values in each
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param rev path int true "REV"
+// @Param against query int false "revision to compare with, the previous one by default; 0 is the state before the first"
+// @Success 200 {object} models.RevisionDiff "success get diff"
+// @Failure 400 {object} nil "invalid revision"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor or revision not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/revisions/{rev}/diff [get]
+func (ah *ActorHandler) RevisionDiff(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := ah.pathID(w, r, "ACT_ID")
+	if !ok {
+		return
+	}
+
+	rev, ok := ah.pathID(w, r, "REV")
+	if !ok {
+		return
+	}
+
+	against := rev - 1
+
+	if againstString := r.FormValue("against"); againstString != "" {
+		var err error
+		against, err = strconv.Atoi(againstString)
+		if err != nil {
+			ah.Logger.Infow("can`t parse against",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := ah.ActorUseCase.RevisionDiff(actorId, rev, against)
+	switch {
+	case errors.Is(err, actorUseCase.ErrInvalidRevision):
+		ah.Logger.Infow("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t diff revisions", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t diff revisions", http.StatusInternalServerError)
+		return
+	}
+
+	ah.write(w, diff)
+}
+
+// Revert godoc
+// @Summary      Revert actor
+// @Description  Update an actor to one of its revisions, which adds a new revision.
+// @Description  Fields that are empty in the revision are left as they are.
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param rev path int true "REV"
+// @Success 200 {object} models.Actor "Actor reverted"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor or revision not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /actors/{id}/revisions/{rev}/revert [post]
+func (ah *ActorHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := ah.pathID(w, r, "ACT_ID")
+	if !ok {
+		return
+	}
+
+	rev, ok := ah.pathID(w, r, "REV")
+	if !ok {
+		return
+	}
+
+	actor, err := ah.ActorUseCase.Revert(r.Context(), actorId, rev)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ah.Logger.Infow("can`t revert actor",
+			"err:", err.Error())
+		http.Error(w, "can`t revert actor", http.StatusNotFound)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t revert actor",
+			"err:", err.Error())
+		http.Error(w, "can`t revert actor", http.StatusInternalServerError)
+		return
+	}
+
+	ah.write(w, actor)
+}
+
+func (ah *ActorHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	idString := r.PathValue(name)
+	if idString == "" {
+		ah.Logger.Errorw("no " + name + " var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		ah.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (ah *ActorHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,191 @@
+package cached
+
+import (
+	"intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
+	"intern/models"
+	"intern/pkg/cache"
+	"intern/pkg/logger"
+)
+
+const (
+	kindActor       = "actor"
+	kindActorMovies = "actor_movies"
+)
+
+type cachedActorRepo struct {
+	repository.ActorRepositoryI
+	Logger logger.Logger
+	Cache  *cache.Cache
+}
+
+// New reads single actors and their movies through c, see the movie one.
+func New(logger logger.Logger, repo repository.ActorRepositoryI, c *cache.Cache) repository.ActorRepositoryI {
+	return &cachedActorRepo{
+		ActorRepositoryI: repo,
+		Logger:           logger,
+		Cache:            c,
+	}
+}
+
+func (cr *cachedActorRepo) Get(id int) (*models.Actor, error) {
+	return cache.Load(cr.Cache, kindActor, cache.ActorKey(id), func() (*models.Actor, error) {
+		return cr.ActorRepositoryI.Get(id)
+	})
+}
+
+func (cr *cachedActorRepo) GetMoviesByActor(id int) ([]models.Movie, error) {
+	movies, err := cache.Load(cr.Cache, kindActorMovies, cache.ActorMoviesKey(id), func() ([]models.Movie, error) {
+		return cr.ActorRepositoryI.GetMoviesByActor(id)
+	})
+	// Like the actors of a movie, an empty list must not become null.
+	if err == nil && movies == nil {
+		movies = []models.Movie{}
+	}
+
+	return movies, err
+}
+
+func (cr *cachedActorRepo) Create(a *models.Actor) error {
+	if err := cr.ActorRepositoryI.Create(a); err != nil {
+		return err
+	}
+	cr.invalidate(a.ID)
+
+	return nil
+}
+
+func (cr *cachedActorRepo) Update(a *models.Actor) error {
+	err := cr.ActorRepositoryI.Update(a)
+	cr.invalidate(a.ID)
+
+	return err
+}
+
+func (cr *cachedActorRepo) Replace(a *models.Actor) error {
+	err := cr.ActorRepositoryI.Replace(a)
+	cr.invalidate(a.ID)
+
+	return err
+}
+
+func (cr *cachedActorRepo) Delete(id int) error {
+	err := cr.ActorRepositoryI.Delete(id)
+	cr.invalidate(id)
+
+	return err
+}
+
+func (cr *cachedActorRepo) Restore(id int) error {
+	err := cr.ActorRepositoryI.Restore(id)
+	cr.invalidate(id)
+
+	return err
+}
+
+func (cr *cachedActorRepo) Upsert(a *models.Actor) (string, error) {
+	outcome, err := cr.ActorRepositoryI.Upsert(a)
+	if err != nil {
+		return outcome, err
+	}
+	cr.invalidate(a.ID)
+
+	return outcome, nil
+}
+
+// Atomic invalidates the actors fn writes once the transaction is over, as
+// a load while it runs still reads the rows it replaces.
+func (cr *cachedActorRepo) Atomic(fn func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	tx := &txActorRepo{}
+	err := cr.ActorRepositoryI.Atomic(func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		tx.ActorRepositoryI = actors
+		return fn(tx, audit)
+	})
+
+	for _, id := range tx.written {
+		cr.invalidate(id)
+	}
+
+	return err
+}
+
+// Bulk invalidates the actors fn writes once the transaction is over, like
+// Atomic.
+func (cr *cachedActorRepo) Bulk(source string, fn func(actors repository.ActorRepositoryI) ([]int, error)) error {
+	tx := &txActorRepo{}
+	err := cr.ActorRepositoryI.Bulk(source, func(actors repository.ActorRepositoryI) ([]int, error) {
+		tx.ActorRepositoryI = actors
+		return fn(tx)
+	})
+
+	for _, id := range tx.written {
+		cr.invalidate(id)
+	}
+
+	return err
+}
+
+// invalidate drops the actor. A failed write is invalidated too, it may
+// have been applied.
+func (cr *cachedActorRepo) invalidate(id int) {
+	Invalidate(cr.Logger, cr.ActorRepositoryI, cr.Cache, id)
+}
+
+// Invalidate drops actor id from c and, as they list it, the actors of its
+// movies, which are read from repo. It is for the writes made around the
+// repository of New.
+func Invalidate(logger logger.Logger, repo repository.ActorRepositoryI, c *cache.Cache, id int) {
+	keys := []string{cache.ActorKey(id), cache.ActorMoviesKey(id)}
+
+	movies, err := repo.GetMoviesByActor(id)
+	if err != nil {
+		logger.Errorw("can`t get movies to invalidate",
+			"err:", err.Error())
+	}
+	for _, m := range movies {
+		keys = append(keys, cache.MovieActorsKey(m.ID))
+	}
+
+	c.Delete(keys...)
+}
+
+// txActorRepo is the repository of an Atomic call: it reads around the
+// cache and keeps the ids of the actors written.
+type txActorRepo struct {
+	repository.ActorRepositoryI
+	written []int
+}
+
+func (tr *txActorRepo) Create(a *models.Actor) error {
+	err := tr.ActorRepositoryI.Create(a)
+	tr.written = append(tr.written, a.ID)
+
+	return err
+}
+
+func (tr *txActorRepo) Update(a *models.Actor) error {
+	tr.written = append(tr.written, a.ID)
+	return tr.ActorRepositoryI.Update(a)
+}
+
+func (tr *txActorRepo) Replace(a *models.Actor) error {
+	tr.written = append(tr.written, a.ID)
+	return tr.ActorRepositoryI.Replace(a)
+}
+
+func (tr *txActorRepo) Delete(id int) error {
+	tr.written = append(tr.written, id)
+	return tr.ActorRepositoryI.Delete(id)
+}
+
+func (tr *txActorRepo) Restore(id int) error {
+	tr.written = append(tr.written, id)
+	return tr.ActorRepositoryI.Restore(id)
+}
+
+func (tr *txActorRepo) Upsert(a *models.Actor) (string, error) {
+	outcome, err := tr.ActorRepositoryI.Upsert(a)
+	tr.written = append(tr.written, a.ID)
+
+	return outcome, err
+}
@@ -0,0 +1,324 @@
+package contract
+
+import (
+	"intern/internal/actor/repository"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Backend adapts an ActorRepositoryI implementation to the contract suite.
+// The Expect* hooks are called right before the matching repository call so
+// that scripted backends (sqlmock) can prepare the database conversation;
+// in-memory backends implement them as no-ops.
+type Backend interface {
+	Repo() repository.ActorRepositoryI
+	ExpectCreate(a models.Actor, id int)
+	ExpectGet(a models.Actor)
+	ExpectGetMissing(id int)
+	ExpectUpdate(a models.Actor)
+	ExpectReplace(a models.Actor)
+	ExpectDelete(id int)
+	// ExpectRestore expects id to be taken out of the trash, found tells
+	// whether it was there.
+	ExpectRestore(id int, found bool)
+	ExpectListByName(name string, limit int, actors []models.ActorListItem)
+	// SeedCredit stores a movie, trashed or not, and links the actor id to
+	// it, the repository has no call for it.
+	SeedCredit(id, movieID int, trashed bool)
+	ExpectList(filter models.ActorFilter, actors []models.ActorListItem)
+	ExpectUpsert(a models.Actor, id int, outcome string)
+	// ExpectBulkUpsert expects a Bulk of one Upsert creating a as id.
+	ExpectBulkUpsert(a models.Actor, id int)
+	ExpectEachByName(name string, actors []models.ActorListItem)
+	ExpectGetByNaturalKey(a models.Actor)
+	ExpectAddExternalID(id int, ext models.ExternalID)
+	ExpectGetExternalIDs(id int, ids []models.ExternalID)
+	// ExpectGetByExternalID expects a lookup of ext, a nil for a miss.
+	ExpectGetByExternalID(ext models.ExternalID, a *models.Actor)
+	ExpectDeleteExternalID(id int, ext models.ExternalID, found bool)
+	Verify() error
+}
+
+// Run checks the behaviour every ActorRepositoryI implementation must share.
+// newBackend is called once per case and must return an empty repository.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	cases := map[string]func(t *testing.T, b Backend){
+		"CreateAssignsID":     testCreateAssignsID,
+		"GetMissing":          testGetMissing,
+		"UpdateChangesFields": testUpdateChangesFields,
+		"ReplaceClearsFields": testReplaceClearsFields,
+		"RestoreUndoesDelete": testRestoreUndoesDelete,
+		"DeleteRemoves":       testDeleteRemoves,
+		"ListFiltersByName":   testListFiltersByName,
+		"ListFiltersAndPages": testListFiltersAndPages,
+		"ListCountsMovies":    testListCountsMovies,
+		"UpsertByNaturalKey":  testUpsertByNaturalKey,
+		"BulkUpsert":          testBulkUpsert,
+		"EachIgnoresPaging":   testEachIgnoresPaging,
+		"ExternalIDs":         testExternalIDs,
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+			test(t, b)
+			assert.NoError(t, b.Verify())
+		})
+	}
+}
+
+func actor() models.Actor {
+	return models.Actor{
+		FirstName: "Sigourney",
+		LastName:  "Weaver",
+		Gender:    'f',
+		Birthday:  time.Date(1949, 10, 8, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func create(t *testing.T, b Backend, a models.Actor) models.Actor {
+	return createID(t, b, a, 1)
+}
+
+// createID creates a, which the empty repository gives id.
+func createID(t *testing.T, b Backend, a models.Actor, id int) models.Actor {
+	b.ExpectCreate(a, id)
+	require.NoError(t, b.Repo().Create(&a))
+	require.Equal(t, id, a.ID)
+
+	return a
+}
+
+func testCreateAssignsID(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+
+	b.ExpectGet(a)
+	got, err := b.Repo().Get(a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, a, *got)
+}
+
+func testGetMissing(t *testing.T, b Backend) {
+	b.ExpectGetMissing(42)
+	_, err := b.Repo().Get(42)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testUpdateChangesFields(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+
+	a.LastName = "Weaver-Simpson"
+	b.ExpectUpdate(a)
+	require.NoError(t, b.Repo().Update(&a))
+
+	b.ExpectGet(a)
+	got, err := b.Repo().Get(a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, a, *got)
+}
+
+func testReplaceClearsFields(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+
+	a.LastName = ""
+	a.Gender = 0
+	b.ExpectReplace(a)
+	require.NoError(t, b.Repo().Replace(&a))
+
+	b.ExpectGet(a)
+	got, err := b.Repo().Get(a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, a, *got)
+}
+
+func testDeleteRemoves(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+
+	b.ExpectDelete(a.ID)
+	require.NoError(t, b.Repo().Delete(a.ID))
+
+	b.ExpectGetMissing(a.ID)
+	_, err := b.Repo().Get(a.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testRestoreUndoesDelete(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+
+	b.ExpectRestore(a.ID, false)
+	err := b.Repo().Restore(a.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound for a actor not in the trash, got %v", err)
+
+	b.ExpectDelete(a.ID)
+	require.NoError(t, b.Repo().Delete(a.ID))
+
+	b.ExpectRestore(a.ID, true)
+	require.NoError(t, b.Repo().Restore(a.ID))
+
+	b.ExpectGet(a)
+	got, err := b.Repo().Get(a.ID)
+	require.NoError(t, err)
+	// The restore is a change, it may move UpdatedAt.
+	assert.False(t, got.UpdatedAt.Before(a.UpdatedAt))
+	a.UpdatedAt = got.UpdatedAt
+	assert.Equal(t, a, *got)
+}
+
+func testListFiltersByName(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+	want := []models.ActorListItem{{Actor: a}}
+
+	b.ExpectListByName("WEAV", 10, want)
+	actors, err := b.Repo().List(models.ActorFilter{Name: "WEAV", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, want, actors)
+}
+
+func testUpsertByNaturalKey(t *testing.T, b Backend) {
+	a := actor()
+
+	b.ExpectUpsert(a, 1, models.UpsertCreated)
+	outcome, err := b.Repo().Upsert(&a)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertCreated, outcome)
+	assert.Equal(t, 1, a.ID)
+
+	again := actor()
+	again.Gender = 'm'
+	b.ExpectUpsert(again, 1, models.UpsertUpdated)
+	outcome, err = b.Repo().Upsert(&again)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertUpdated, outcome)
+	assert.Equal(t, 1, again.ID)
+
+	b.ExpectGetByNaturalKey(again)
+	got, err := b.Repo().GetByNaturalKey(again.FirstName, again.LastName, again.Birthday)
+	require.NoError(t, err)
+	assert.Equal(t, again, *got)
+
+	// A actor in the trash is found too, Upsert restores it.
+	b.ExpectDelete(again.ID)
+	require.NoError(t, b.Repo().Delete(again.ID))
+
+	trashed := again
+	trashed.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	b.ExpectGetByNaturalKey(trashed)
+	got, err = b.Repo().GetByNaturalKey(again.FirstName, again.LastName, again.Birthday)
+	require.NoError(t, err)
+	assert.Equal(t, again.ID, got.ID)
+	assert.True(t, got.DeletedAt.Valid)
+
+	b.ExpectUpsert(again, 1, models.UpsertRestored)
+	outcome, err = b.Repo().Upsert(&again)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertRestored, outcome)
+}
+
+func testBulkUpsert(t *testing.T, b Backend) {
+	a := actor()
+
+	b.ExpectBulkUpsert(a, 1)
+	err := b.Repo().Bulk(models.BulkSourceImport, func(actors repository.ActorRepositoryI) ([]int, error) {
+		outcome, err := actors.Upsert(&a)
+		assert.Equal(t, models.UpsertCreated, outcome)
+
+		return []int{a.ID}, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, a.ID)
+}
+
+func testEachIgnoresPaging(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+	want := []models.ActorListItem{{Actor: a}}
+
+	var got []models.ActorListItem
+	b.ExpectEachByName("weav", want)
+	err := b.Repo().Each(models.ActorFilter{Name: "weav", Limit: 1, Offset: 1}, func(a models.ActorListItem) error {
+		got = append(got, a)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func testExternalIDs(t *testing.T, b Backend) {
+	a := create(t, b, actor())
+	imdb := models.ExternalID{Source: models.ExternalSourceIMDb, ID: "tt0078748"}
+	tmdb := models.ExternalID{Source: models.ExternalSourceTMDB, ID: "348"}
+
+	b.ExpectAddExternalID(a.ID, tmdb)
+	require.NoError(t, b.Repo().AddExternalID(a.ID, tmdb))
+	b.ExpectAddExternalID(a.ID, imdb)
+	require.NoError(t, b.Repo().AddExternalID(a.ID, imdb))
+
+	b.ExpectGetExternalIDs(a.ID, []models.ExternalID{imdb, tmdb})
+	ids, err := b.Repo().GetExternalIDs(a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExternalID{imdb, tmdb}, ids)
+
+	b.ExpectGetByExternalID(imdb, &a)
+	got, err := b.Repo().GetByExternalID(imdb)
+	require.NoError(t, err)
+	// The external ids are part of the entity, adding them may move
+	// UpdatedAt.
+	assert.False(t, got.UpdatedAt.Before(a.UpdatedAt))
+	a.UpdatedAt = got.UpdatedAt
+	assert.Equal(t, a, *got)
+
+	b.ExpectDeleteExternalID(a.ID, imdb, true)
+	require.NoError(t, b.Repo().DeleteExternalID(a.ID, imdb))
+
+	b.ExpectGetByExternalID(imdb, nil)
+	_, err = b.Repo().GetByExternalID(imdb)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+
+	b.ExpectDeleteExternalID(a.ID, imdb, false)
+	err = b.Repo().DeleteExternalID(a.ID, imdb)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testListFiltersAndPages(t *testing.T, b Backend) {
+	weaver := createID(t, b, actor(), 1)
+	hurt := createID(t, b, models.Actor{FirstName: "John", LastName: "Hurt", Gender: 'm', Birthday: time.Date(1940, 1, 22, 0, 0, 0, 0, time.UTC)}, 2)
+	cartwright := createID(t, b, models.Actor{FirstName: "Veronica", LastName: "Cartwright", Gender: 'f', Birthday: time.Date(1949, 4, 20, 0, 0, 0, 0, time.UTC)}, 3)
+	createID(t, b, models.Actor{FirstName: "Sigourney", LastName: "Weaver", Gender: 'f', Birthday: time.Date(1920, 1, 1, 0, 0, 0, 0, time.UTC)}, 4)
+
+	bornAfter := time.Date(1930, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := models.ActorFilter{Gender: "f", BornAfter: &bornAfter, SortBy: models.ActorSortBirthday, Desc: true, Limit: 10}
+	want := []models.ActorListItem{{Actor: weaver}, {Actor: cartwright}}
+	b.ExpectList(filter, want)
+	actors, err := b.Repo().List(filter)
+	require.NoError(t, err)
+	assert.Equal(t, want, actors)
+
+	filter = models.ActorFilter{Limit: 1, Offset: 1}
+	want = []models.ActorListItem{{Actor: hurt}}
+	b.ExpectList(filter, want)
+	actors, err = b.Repo().List(filter)
+	require.NoError(t, err)
+	assert.Equal(t, want, actors)
+}
+
+func testListCountsMovies(t *testing.T, b Backend) {
+	weaver := createID(t, b, actor(), 1)
+	hurt := createID(t, b, models.Actor{FirstName: "John", LastName: "Hurt", Gender: 'm', Birthday: time.Date(1940, 1, 22, 0, 0, 0, 0, time.UTC)}, 2)
+	b.SeedCredit(weaver.ID, 1, false)
+	b.SeedCredit(weaver.ID, 2, false)
+	b.SeedCredit(hurt.ID, 1, false)
+	// Movies in the trash are not counted.
+	b.SeedCredit(hurt.ID, 3, true)
+
+	filter := models.ActorFilter{SortBy: models.ActorSortMovies, Desc: true, Limit: 10}
+	want := []models.ActorListItem{{Actor: weaver, MoviesCount: 2}, {Actor: hurt, MoviesCount: 1}}
+	b.ExpectList(filter, want)
+	actors, err := b.Repo().List(filter)
+	require.NoError(t, err)
+	assert.Equal(t, want, actors)
+}
@@ -0,0 +1,513 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
+	memAudit "intern/internal/audit/repository/memory"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memActorRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+	// bulk is set on the repository of a Bulk call, whose writes add no
+	// event each.
+	bulk bool
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.ActorRepositoryI {
+	return &memActorRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ar *memActorRepo) Create(a *models.Actor) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	if a.ID == 0 {
+		a.ID = ar.DB.NextID("actors")
+	} else if _, ok := ar.DB.Actors[a.ID]; ok {
+		return errors.Errorf("memActorRepo.Create error: duplicate id %d", a.ID)
+	} else if _, ok := ar.DB.TrashedActors[a.ID]; ok {
+		return errors.Errorf("memActorRepo.Create error: duplicate id %d", a.ID)
+	} else {
+		ar.DB.SeenID("actors", a.ID)
+	}
+
+	a.UpdatedAt = time.Now()
+	ar.DB.Actors[a.ID] = *a
+	ar.addEvent(models.EventActorCreated, a.ID)
+
+	return nil
+}
+
+func (ar *memActorRepo) Get(id int) (*models.Actor, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	a, ok := ar.DB.Actors[id]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.Get error")
+	}
+
+	return &a, nil
+}
+
+// Update mirrors gorm's Updates: only non-zero fields are written and a
+// missing row is not an error.
+func (ar *memActorRepo) Update(a *models.Actor) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	stored, ok := ar.DB.Actors[a.ID]
+	if !ok {
+		return nil
+	}
+	before := stored
+
+	if a.FirstName != "" {
+		stored.FirstName = a.FirstName
+	}
+	if a.LastName != "" {
+		stored.LastName = a.LastName
+	}
+	if a.Gender != 0 {
+		stored.Gender = a.Gender
+	}
+	if !a.Birthday.IsZero() {
+		stored.Birthday = a.Birthday
+	}
+
+	stored.UpdatedAt = time.Now()
+	ar.DB.Actors[a.ID] = stored
+	a.UpdatedAt = stored.UpdatedAt
+	if catalogueChanged(before, stored) {
+		ar.addEvent(models.EventActorUpdated, stored.ID)
+	}
+
+	return nil
+}
+
+func (ar *memActorRepo) Replace(a *models.Actor) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	stored, ok := ar.DB.Actors[a.ID]
+	if !ok {
+		return nil
+	}
+	before := stored
+
+	stored.FirstName, stored.LastName, stored.Gender, stored.Birthday = a.FirstName, a.LastName, a.Gender, a.Birthday
+
+	stored.UpdatedAt = time.Now()
+	ar.DB.Actors[a.ID] = stored
+	a.UpdatedAt = stored.UpdatedAt
+	if catalogueChanged(before, stored) {
+		ar.addEvent(models.EventActorUpdated, stored.ID)
+	}
+
+	return nil
+}
+
+// Delete moves the actor to the trash, the rows referring to it stay for
+// a Restore.
+func (ar *memActorRepo) Delete(id int) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	a, ok := ar.DB.Actors[id]
+	if !ok {
+		return nil
+	}
+
+	a.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	a.UpdatedAt = a.DeletedAt.Time
+	ar.DB.TrashedActors[id] = a
+	delete(ar.DB.Actors, id)
+	ar.DB.CastVersion++
+	ar.addEvent(models.EventActorDeleted, id)
+
+	return nil
+}
+
+func (ar *memActorRepo) Restore(id int) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	a, ok := ar.DB.TrashedActors[id]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.Restore error")
+	}
+
+	a.DeletedAt = gorm.DeletedAt{}
+	a.UpdatedAt = time.Now()
+	ar.DB.Actors[id] = a
+	delete(ar.DB.TrashedActors, id)
+	ar.DB.CastVersion++
+	ar.addEvent(models.EventActorRestored, id)
+
+	return nil
+}
+
+func (ar *memActorRepo) GetMoviesByActor(id int) ([]models.Movie, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	movies := make([]models.Movie, 0)
+	seen := make(map[int]bool)
+
+	for _, ma := range ar.DB.MoviesActors {
+		if ma.ActorID != id || seen[ma.MovieID] {
+			continue
+		}
+		seen[ma.MovieID] = true
+
+		if m, ok := ar.DB.Movies[ma.MovieID]; ok {
+			movies = append(movies, m)
+		}
+	}
+
+	slices.SortFunc(movies, func(a, b models.Movie) int { return cmp.Compare(a.ID, b.ID) })
+
+	return movies, nil
+}
+
+func (ar *memActorRepo) Costars(id, limit, offset int) ([]models.Costar, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	mine := make(map[int]bool)
+	for _, ma := range ar.DB.MoviesActors {
+		if _, ok := ar.DB.Movies[ma.MovieID]; ok && ma.ActorID == id {
+			mine[ma.MovieID] = true
+		}
+	}
+
+	shared := make(map[int]map[int]bool)
+	for _, ma := range ar.DB.MoviesActors {
+		if ma.ActorID == id || !mine[ma.MovieID] {
+			continue
+		}
+		if shared[ma.ActorID] == nil {
+			shared[ma.ActorID] = make(map[int]bool)
+		}
+		shared[ma.ActorID][ma.MovieID] = true
+	}
+
+	costars := []models.Costar{}
+	for actorID, movies := range shared {
+		if a, ok := ar.DB.Actors[actorID]; ok {
+			costars = append(costars, models.Costar{Actor: a, SharedMovies: len(movies)})
+		}
+	}
+
+	slices.SortFunc(costars, func(a, b models.Costar) int {
+		return cmp.Or(
+			cmp.Compare(b.SharedMovies, a.SharedMovies),
+			cmp.Compare(a.LastName, b.LastName),
+			cmp.Compare(a.FirstName, b.FirstName),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	return memdb.Page(costars, limit, offset), nil
+}
+
+func (ar *memActorRepo) List(filter models.ActorFilter) ([]models.ActorListItem, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	moviesCount := make(map[int]int)
+	for _, ma := range ar.DB.MoviesActors {
+		if _, ok := ar.DB.Movies[ma.MovieID]; ok {
+			moviesCount[ma.ActorID]++
+		}
+	}
+
+	name := strings.ToLower(filter.Name)
+	actors := make([]models.ActorListItem, 0)
+
+	for _, a := range ar.DB.Actors {
+		fullName := strings.ToLower(a.FirstName + " " + a.LastName)
+		if name != "" && !strings.Contains(fullName, name) {
+			continue
+		}
+		if filter.Gender != "" && string(a.Gender) != filter.Gender {
+			continue
+		}
+		if filter.BornAfter != nil && a.Birthday.Before(*filter.BornAfter) {
+			continue
+		}
+		if filter.BornBefore != nil && a.Birthday.After(*filter.BornBefore) {
+			continue
+		}
+
+		actors = append(actors, models.ActorListItem{Actor: a, MoviesCount: moviesCount[a.ID]})
+	}
+
+	compare := func(a, b models.ActorListItem) int {
+		return cmp.Or(strings.Compare(a.LastName, b.LastName), strings.Compare(a.FirstName, b.FirstName))
+	}
+
+	switch filter.SortBy {
+	case models.ActorSortBirthday:
+		compare = func(a, b models.ActorListItem) int { return a.Birthday.Compare(b.Birthday) }
+	case models.ActorSortMovies:
+		compare = func(a, b models.ActorListItem) int { return cmp.Compare(a.MoviesCount, b.MoviesCount) }
+	}
+
+	slices.SortFunc(actors, func(a, b models.ActorListItem) int {
+		c := compare(a, b)
+		if filter.Desc {
+			c = -c
+		}
+
+		return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(actors, filter.Limit, filter.Offset), nil
+}
+
+func (ar *memActorRepo) GetByNaturalKey(firstName, lastName string, birthday time.Time) (*models.Actor, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	if a, ok := ar.findByNaturalKey(ar.DB.Actors, firstName, lastName, birthday); ok {
+		return &a, nil
+	}
+	if a, ok := ar.findByNaturalKey(ar.DB.TrashedActors, firstName, lastName, birthday); ok {
+		return &a, nil
+	}
+
+	return nil, errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.GetByNaturalKey error")
+}
+
+// Upsert mirrors upsertActorQuery: the oldest actor with the full name and
+// birthday keeps its id and gets the new gender, one in the trash is
+// restored if no live actor matches.
+func (ar *memActorRepo) Upsert(a *models.Actor) (string, error) {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	stored, ok := ar.findByNaturalKey(ar.DB.Actors, a.FirstName, a.LastName, a.Birthday)
+	restored := false
+	if !ok {
+		stored, ok = ar.findByNaturalKey(ar.DB.TrashedActors, a.FirstName, a.LastName, a.Birthday)
+		if ok {
+			stored.DeletedAt = gorm.DeletedAt{}
+			delete(ar.DB.TrashedActors, stored.ID)
+			ar.DB.CastVersion++
+			restored = true
+		}
+	}
+
+	if ok {
+		a.ID = stored.ID
+		before := stored
+		stored.Gender = a.Gender
+		stored.UpdatedAt = time.Now()
+		ar.DB.Actors[a.ID] = stored
+		a.UpdatedAt = stored.UpdatedAt
+
+		switch {
+		case restored:
+			ar.addEvent(models.EventActorRestored, stored.ID)
+
+			return models.UpsertRestored, nil
+		case catalogueChanged(before, stored):
+			ar.addEvent(models.EventActorUpdated, stored.ID)
+		}
+
+		return models.UpsertUpdated, nil
+	}
+
+	a.ID = ar.DB.NextID("actors")
+	a.UpdatedAt = time.Now()
+	ar.DB.Actors[a.ID] = *a
+	ar.addEvent(models.EventActorCreated, a.ID)
+
+	return models.UpsertCreated, nil
+}
+
+// findByNaturalKey looks in actors, Actors or TrashedActors, and returns the
+// actor with the lowest id of several. Must be called with the lock held.
+func (ar *memActorRepo) findByNaturalKey(actors map[int]models.Actor, firstName, lastName string, birthday time.Time) (models.Actor, bool) {
+	found := models.Actor{}
+	for _, a := range actors {
+		if a.FirstName == firstName && a.LastName == lastName && a.Birthday.Equal(birthday) && (found.ID == 0 || a.ID < found.ID) {
+			found = a
+		}
+	}
+
+	return found, found.ID != 0
+}
+
+// Each walks a snapshot of the matching actors, ignoring Limit and Offset;
+// fn runs without the lock held.
+func (ar *memActorRepo) Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error {
+	filter.Limit, filter.Offset = 0, 0
+
+	actors, err := ar.List(filter)
+	if err != nil {
+		return errors.Wrap(err, "memActorRepo.Each error")
+	}
+
+	for _, a := range actors {
+		if err := fn(a); err != nil {
+			return errors.Wrap(err, "memActorRepo.Each error")
+		}
+	}
+
+	return nil
+}
+
+func (ar *memActorRepo) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	ids := []models.ExternalID{}
+	for ext, owner := range ar.DB.ActorExternalIDs {
+		if owner == id {
+			ids = append(ids, ext)
+		}
+	}
+
+	slices.SortFunc(ids, func(a, b models.ExternalID) int {
+		return cmp.Or(strings.Compare(a.Source, b.Source), strings.Compare(a.ID, b.ID))
+	})
+
+	return ids, nil
+}
+
+func (ar *memActorRepo) GetByExternalID(ext models.ExternalID) (*models.Actor, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	id, ok := ar.DB.ActorExternalIDs[ext]
+	v, found := ar.DB.Actors[id]
+	if !ok || !found {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.GetByExternalID error")
+	}
+
+	return &v, nil
+}
+
+// AddExternalID enforces the primary key (source, external_id) and the
+// foreign key of actor_external_ids.
+func (ar *memActorRepo) AddExternalID(id int, ext models.ExternalID) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	if _, ok := ar.DB.Actors[id]; !ok {
+		return errors.Errorf("memActorRepo.AddExternalID error: actor %d does not exist", id)
+	}
+
+	if _, ok := ar.DB.ActorExternalIDs[ext]; ok {
+		return errors.Errorf("memActorRepo.AddExternalID error: %s id %q is already taken", ext.Source, ext.ID)
+	}
+
+	ar.DB.ActorExternalIDs[ext] = id
+	ar.DB.TouchActor(id)
+
+	return nil
+}
+
+func (ar *memActorRepo) DeleteExternalID(id int, ext models.ExternalID) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	owner, ok := ar.DB.ActorExternalIDs[ext]
+	if !ok || owner != id {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.DeleteExternalID error")
+	}
+
+	delete(ar.DB.ActorExternalIDs, ext)
+	ar.DB.TouchActor(id)
+
+	return nil
+}
+
+// AddRevision enforces the foreign key of actor_revisions.
+func (ar *memActorRepo) AddRevision(id int, r *models.Revision) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	if _, ok := ar.DB.Actors[id]; !ok {
+		return errors.Errorf("memActorRepo.AddRevision error: actor %d does not exist", id)
+	}
+
+	r.Rev = len(ar.DB.ActorRevisions[id]) + 1
+	r.CreatedAt = time.Now()
+	ar.DB.ActorRevisions[id] = append(ar.DB.ActorRevisions[id], *r)
+
+	return nil
+}
+
+func (ar *memActorRepo) GetRevisions(id, limit, offset int) ([]models.Revision, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	stored := ar.DB.ActorRevisions[id]
+	revisions := make([]models.Revision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+
+	return memdb.Page(revisions, limit, offset), nil
+}
+
+func (ar *memActorRepo) GetRevision(id, rev int) (*models.Revision, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	stored := ar.DB.ActorRevisions[id]
+	if rev < 1 || rev > len(stored) {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memActorRepo.GetRevision error")
+	}
+
+	r := stored[rev-1]
+
+	return &r, nil
+}
+
+// Atomic runs fn on the repository itself: the store has no transactions,
+// and its writes fail, if at all, before they change anything.
+func (ar *memActorRepo) Atomic(fn func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	return fn(ar, memAudit.New(ar.Logger, ar.DB))
+}
+
+// Bulk runs fn on a repository that adds no event, then adds the one of fn.
+func (ar *memActorRepo) Bulk(source string, fn func(actors repository.ActorRepositoryI) ([]int, error)) error {
+	ids, err := fn(&memActorRepo{Logger: ar.Logger, DB: ar.DB, bulk: true})
+
+	ar.DB.Lock()
+	ar.DB.AddBulkEvent(models.BulkEvent{Source: source, Actors: ids})
+	ar.DB.Unlock()
+
+	return err
+}
+
+// addEvent is DB.AddEntityEvent but in a Bulk call.
+func (ar *memActorRepo) addEvent(eventType string, id int) {
+	if !ar.bulk {
+		ar.DB.AddEntityEvent(eventType, id)
+	}
+}
+
+// catalogueChanged mirrors the actors_outbox_update trigger.
+func catalogueChanged(before, after models.Actor) bool {
+	return before.FirstName != after.FirstName || before.LastName != after.LastName ||
+		before.Gender != after.Gender || !before.Birthday.Equal(after.Birthday)
+}
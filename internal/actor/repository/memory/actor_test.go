@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"intern/internal/actor/repository"
+	"intern/internal/actor/repository/contract"
+	"intern/internal/memdb"
+	"intern/models"
+	"testing"
+)
+
+type backend struct {
+	repo repository.ActorRepositoryI
+	db   *memdb.DB
+}
+
+func (b backend) Repo() repository.ActorRepositoryI                      { return b.repo }
+func (b backend) ExpectCreate(models.Actor, int)                         {}
+func (b backend) ExpectGet(models.Actor)                                 {}
+func (b backend) ExpectGetMissing(int)                                   {}
+func (b backend) ExpectUpdate(models.Actor)                              {}
+func (b backend) ExpectReplace(models.Actor)                             {}
+func (b backend) ExpectRestore(int, bool)                                {}
+func (b backend) ExpectDelete(int)                                       {}
+func (b backend) ExpectListByName(string, int, []models.ActorListItem)   {}
+func (b backend) ExpectList(models.ActorFilter, []models.ActorListItem)  {}
+func (b backend) ExpectUpsert(models.Actor, int, string)                 {}
+func (b backend) ExpectBulkUpsert(models.Actor, int)                     {}
+func (b backend) ExpectGetByNaturalKey(models.Actor)                     {}
+func (b backend) ExpectEachByName(string, []models.ActorListItem)        {}
+func (b backend) ExpectAddExternalID(int, models.ExternalID)             {}
+func (b backend) ExpectGetExternalIDs(int, []models.ExternalID)          {}
+func (b backend) ExpectGetByExternalID(models.ExternalID, *models.Actor) {}
+func (b backend) ExpectDeleteExternalID(int, models.ExternalID, bool)    {}
+func (b backend) Verify() error                                          { return nil }
+
+func (b backend) SeedCredit(id, movieID int, trashed bool) {
+	movies := b.db.Movies
+	if trashed {
+		movies = b.db.TrashedMovies
+	}
+	movies[movieID] = models.Movie{ID: movieID}
+
+	linkID := b.db.NextID("movies_actors")
+	b.db.MoviesActors[linkID] = models.MovieActor{ID: linkID, MovieID: movieID, ActorID: id}
+}
+
+func TestActorRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db := memdb.New()
+		return backend{repo: New(nil, db), db: db}
+	})
+}
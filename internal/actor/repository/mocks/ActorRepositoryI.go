@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	repository "intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
 	models "intern/models"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,6 +16,88 @@ type ActorRepositoryI struct {
 	mock.Mock
 }
 
+// AddExternalID provides a mock function with given fields: id, ext
+func (_m *ActorRepositoryI) AddExternalID(id int, ext models.ExternalID) error {
+	ret := _m.Called(id, ext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, models.ExternalID) error); ok {
+		r0 = rf(id, ext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddRevision provides a mock function with given fields: id, r
+func (_m *ActorRepositoryI) AddRevision(id int, r *models.Revision) error {
+	ret := _m.Called(id, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, *models.Revision) error); ok {
+		r0 = rf(id, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Atomic provides a mock function with given fields: fn
+func (_m *ActorRepositoryI) Atomic(fn func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	ret := _m.Called(fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error); ok {
+		r0 = rf(fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Bulk provides a mock function with given fields: source, fn
+func (_m *ActorRepositoryI) Bulk(source string, fn func(actors repository.ActorRepositoryI) ([]int, error)) error {
+	ret := _m.Called(source, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func(actors repository.ActorRepositoryI) ([]int, error)) error); ok {
+		r0 = rf(source, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Costars provides a mock function with given fields: id, limit, offset
+func (_m *ActorRepositoryI) Costars(id int, limit int, offset int) ([]models.Costar, error) {
+	ret := _m.Called(id, limit, offset)
+
+	var r0 []models.Costar
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int, int) ([]models.Costar, error)); ok {
+		return rf(id, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, int) []models.Costar); ok {
+		r0 = rf(id, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Costar)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, int) error); ok {
+		r1 = rf(id, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: a
 func (_m *ActorRepositoryI) Create(a *models.Actor) error {
 	ret := _m.Called(a)
@@ -41,6 +126,34 @@ func (_m *ActorRepositoryI) Delete(id int) error {
 	return r0
 }
 
+// DeleteExternalID provides a mock function with given fields: id, ext
+func (_m *ActorRepositoryI) DeleteExternalID(id int, ext models.ExternalID) error {
+	ret := _m.Called(id, ext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, models.ExternalID) error); ok {
+		r0 = rf(id, ext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Each provides a mock function with given fields: filter, fn
+func (_m *ActorRepositoryI) Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error {
+	ret := _m.Called(filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.ActorFilter, func(a models.ActorListItem) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: id
 func (_m *ActorRepositoryI) Get(id int) (*models.Actor, error) {
 	ret := _m.Called(id)
@@ -67,6 +180,84 @@ func (_m *ActorRepositoryI) Get(id int) (*models.Actor, error) {
 	return r0, r1
 }
 
+// GetByExternalID provides a mock function with given fields: ext
+func (_m *ActorRepositoryI) GetByExternalID(ext models.ExternalID) (*models.Actor, error) {
+	ret := _m.Called(ext)
+
+	var r0 *models.Actor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.ExternalID) (*models.Actor, error)); ok {
+		return rf(ext)
+	}
+	if rf, ok := ret.Get(0).(func(models.ExternalID) *models.Actor); ok {
+		r0 = rf(ext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Actor)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.ExternalID) error); ok {
+		r1 = rf(ext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByNaturalKey provides a mock function with given fields: firstName, lastName, birthday
+func (_m *ActorRepositoryI) GetByNaturalKey(firstName string, lastName string, birthday time.Time) (*models.Actor, error) {
+	ret := _m.Called(firstName, lastName, birthday)
+
+	var r0 *models.Actor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) (*models.Actor, error)); ok {
+		return rf(firstName, lastName, birthday)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) *models.Actor); ok {
+		r0 = rf(firstName, lastName, birthday)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Actor)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(firstName, lastName, birthday)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExternalIDs provides a mock function with given fields: id
+func (_m *ActorRepositoryI) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	ret := _m.Called(id)
+
+	var r0 []models.ExternalID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]models.ExternalID, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int) []models.ExternalID); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ExternalID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMoviesByActor provides a mock function with given fields: id
 func (_m *ActorRepositoryI) GetMoviesByActor(id int) ([]models.Movie, error) {
 	ret := _m.Called(id)
@@ -93,6 +284,112 @@ func (_m *ActorRepositoryI) GetMoviesByActor(id int) ([]models.Movie, error) {
 	return r0, r1
 }
 
+// GetRevision provides a mock function with given fields: id, rev
+func (_m *ActorRepositoryI) GetRevision(id int, rev int) (*models.Revision, error) {
+	ret := _m.Called(id, rev)
+
+	var r0 *models.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int) (*models.Revision, error)); ok {
+		return rf(id, rev)
+	}
+	if rf, ok := ret.Get(0).(func(int, int) *models.Revision); ok {
+		r0 = rf(id, rev)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(id, rev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevisions provides a mock function with given fields: id, limit, offset
+func (_m *ActorRepositoryI) GetRevisions(id int, limit int, offset int) ([]models.Revision, error) {
+	ret := _m.Called(id, limit, offset)
+
+	var r0 []models.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int, int) ([]models.Revision, error)); ok {
+		return rf(id, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, int) []models.Revision); ok {
+		r0 = rf(id, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, int) error); ok {
+		r1 = rf(id, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: filter
+func (_m *ActorRepositoryI) List(filter models.ActorFilter) ([]models.ActorListItem, error) {
+	ret := _m.Called(filter)
+
+	var r0 []models.ActorListItem
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.ActorFilter) ([]models.ActorListItem, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(models.ActorFilter) []models.ActorListItem); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ActorListItem)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.ActorFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: a
+func (_m *ActorRepositoryI) Replace(a *models.Actor) error {
+	ret := _m.Called(a)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Actor) error); ok {
+		r0 = rf(a)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: id
+func (_m *ActorRepositoryI) Restore(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: a
 func (_m *ActorRepositoryI) Update(a *models.Actor) error {
 	ret := _m.Called(a)
@@ -107,6 +404,30 @@ func (_m *ActorRepositoryI) Update(a *models.Actor) error {
 	return r0
 }
 
+// Upsert provides a mock function with given fields: a
+func (_m *ActorRepositoryI) Upsert(a *models.Actor) (string, error) {
+	ret := _m.Called(a)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*models.Actor) (string, error)); ok {
+		return rf(a)
+	}
+	if rf, ok := ret.Get(0).(func(*models.Actor) string); ok {
+		r0 = rf(a)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*models.Actor) error); ok {
+		r1 = rf(a)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewActorRepositoryI creates a new instance of ActorRepositoryI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewActorRepositoryI(t interface {
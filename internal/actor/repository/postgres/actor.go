@@ -1,13 +1,53 @@
 package postgres
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 	"intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
+	pgAudit "intern/internal/audit/repository/postgres"
+	pgEvent "intern/internal/event/repository/postgres"
 	"intern/models"
 	"intern/pkg/logger"
+	"intern/pkg/sqlutil"
+	"time"
 )
 
+var actorSortColumns = map[string]string{
+	models.ActorSortName:     "a.last_name %[1]s, a.first_name %[1]s",
+	models.ActorSortBirthday: "a.birthday %[1]s",
+	models.ActorSortMovies:   "movies_count %[1]s",
+}
+
+// upsertActorQuery picks the actor to update the way upsertMovieQuery picks
+// the movie: the full name and birthday are not unique either.
+const upsertActorQuery = `WITH target AS (
+	SELECT id, deleted_at IS NOT NULL AS trashed FROM actors WHERE first_name = ? AND last_name = ? AND birthday = ?
+	ORDER BY deleted_at IS NOT NULL, id
+	LIMIT 1
+), updated AS (
+	UPDATE actors SET gender = ?, deleted_at = NULL
+	WHERE id = (SELECT id FROM target)
+	RETURNING id, CASE WHEN (SELECT trashed FROM target) THEN 'restored' ELSE 'updated' END AS outcome
+), inserted AS (
+	INSERT INTO actors (first_name, last_name, gender, birthday)
+	SELECT ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM target)
+	RETURNING id, 'created' AS outcome
+)
+SELECT id, outcome FROM updated UNION ALL SELECT id, outcome FROM inserted`
+
+// costarsQuery counts distinct movies, a cast link may be stored twice.
+const costarsQuery = `SELECT a.*, count(DISTINCT mine.movie_id) AS shared_movies
+FROM movies_actors mine
+JOIN movies m ON m.id = mine.movie_id AND m.deleted_at IS NULL
+JOIN movies_actors theirs ON theirs.movie_id = mine.movie_id AND theirs.actor_id <> mine.actor_id
+JOIN actors a ON a.id = theirs.actor_id AND a.deleted_at IS NULL
+WHERE mine.actor_id = ?
+GROUP BY a.id
+ORDER BY shared_movies DESC, a.last_name, a.first_name, a.id
+LIMIT ? OFFSET ?`
+
 type pgActorRepo struct {
 	Logger logger.Logger
 	DB     *gorm.DB
@@ -51,6 +91,17 @@ func (ar *pgActorRepo) Update(a *models.Actor) error {
 	return nil
 }
 
+func (ar *pgActorRepo) Replace(a *models.Actor) error {
+	tx := ar.DB.Model(a).Select("first_name", "last_name", "gender", "birthday").Updates(a)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgActorRepo.Replace error")
+	}
+
+	return nil
+}
+
+// Delete moves the actor to the trash, see models.Actor.DeletedAt.
 func (ar *pgActorRepo) Delete(id int) error {
 	tx := ar.DB.Delete(&models.Actor{}, id)
 
@@ -61,6 +112,20 @@ func (ar *pgActorRepo) Delete(id int) error {
 	return nil
 }
 
+func (ar *pgActorRepo) Restore(id int) error {
+	tx := ar.DB.Unscoped().Model(&models.Actor{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgActorRepo.Restore error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgActorRepo.Restore error")
+	}
+
+	return nil
+}
+
 func (ar *pgActorRepo) GetMoviesByActor(id int) ([]models.Movie, error) {
 	var movieIDs []int
 
@@ -80,3 +145,262 @@ func (ar *pgActorRepo) GetMoviesByActor(id int) ([]models.Movie, error) {
 
 	return movies, nil
 }
+
+func (ar *pgActorRepo) Costars(id, limit, offset int) ([]models.Costar, error) {
+	costars := []models.Costar{}
+	tx := ar.DB.Raw(costarsQuery, id, limit, offset).Scan(&costars)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.Costars error")
+	}
+
+	return costars, nil
+}
+
+func (ar *pgActorRepo) List(filter models.ActorFilter) ([]models.ActorListItem, error) {
+	var actors []models.ActorListItem
+
+	tx := ar.listQuery(filter).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Scan(&actors)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.List error")
+	}
+
+	return actors, nil
+}
+
+// Each streams every actor matching filter to fn, ignoring Limit and
+// Offset. Rows are read from the cursor one at a time; an error returned by
+// fn stops the iteration.
+func (ar *pgActorRepo) Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error {
+	rows, err := ar.listQuery(filter).Rows()
+	if err != nil {
+		return errors.Wrap(err, "pgActorRepo.Each error")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ActorListItem
+		if err := ar.DB.ScanRows(rows, &a); err != nil {
+			return errors.Wrap(err, "pgActorRepo.Each error")
+		}
+
+		if err := fn(a); err != nil {
+			return errors.Wrap(err, "pgActorRepo.Each error")
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "pgActorRepo.Each error")
+	}
+
+	return nil
+}
+
+// listQuery reads the actors table under an alias, so gorm does not leave
+// out the trash by itself; the movies in the trash are not counted either.
+func (ar *pgActorRepo) listQuery(filter models.ActorFilter) *gorm.DB {
+	tx := ar.DB.Table("actors a").
+		Select("a.id, a.first_name, a.last_name, a.gender, a.birthday, COUNT(m.id) AS movies_count").
+		Joins("LEFT JOIN movies_actors ma ON ma.actor_id = a.id").
+		Joins("LEFT JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL").
+		Group("a.id")
+
+	if filter.Name != "" {
+		pattern := "%" + sqlutil.EscapeLike(filter.Name) + "%"
+		tx = tx.Where("a.first_name ILIKE ? OR a.last_name ILIKE ? OR (a.first_name || ' ' || a.last_name) ILIKE ?",
+			pattern, pattern, pattern)
+	}
+
+	if filter.Gender != "" {
+		tx = tx.Where("a.gender = ?", filter.Gender)
+	}
+
+	if filter.BornAfter != nil {
+		tx = tx.Where("a.birthday >= ?", *filter.BornAfter)
+	}
+
+	if filter.BornBefore != nil {
+		tx = tx.Where("a.birthday <= ?", *filter.BornBefore)
+	}
+
+	order, ok := actorSortColumns[filter.SortBy]
+	if !ok {
+		order = actorSortColumns[models.ActorSortName]
+	}
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	return tx.Order(fmt.Sprintf(order, direction) + ", a.id")
+}
+
+func (ar *pgActorRepo) GetByNaturalKey(firstName, lastName string, birthday time.Time) (*models.Actor, error) {
+	var a models.Actor
+	tx := ar.DB.Unscoped().Where("first_name = ? AND last_name = ? AND birthday = ?", firstName, lastName, birthday).
+		Order("deleted_at IS NOT NULL, id").Take(&a)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.GetByNaturalKey error")
+	}
+
+	return &a, nil
+}
+
+func (ar *pgActorRepo) Upsert(a *models.Actor) (string, error) {
+	var res struct {
+		ID      int
+		Outcome string
+	}
+
+	tx := ar.DB.Raw(upsertActorQuery, a.FirstName, a.LastName, a.Birthday, string(a.Gender),
+		a.FirstName, a.LastName, string(a.Gender), a.Birthday).Scan(&res)
+
+	if tx.Error != nil {
+		return "", errors.Wrap(tx.Error, "pgActorRepo.Upsert error")
+	}
+
+	a.ID = res.ID
+
+	return res.Outcome, nil
+}
+
+func (ar *pgActorRepo) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	ids := []models.ExternalID{}
+	tx := ar.DB.Table("actor_external_ids").Select("source, external_id").Where("actor_id = ?", id).Order("source, external_id").Find(&ids)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.GetExternalIDs error")
+	}
+
+	return ids, nil
+}
+
+func (ar *pgActorRepo) GetByExternalID(ext models.ExternalID) (*models.Actor, error) {
+	var v models.Actor
+	tx := ar.DB.Where("id = (SELECT actor_id FROM actor_external_ids WHERE source = ? AND external_id = ?)", ext.Source, ext.ID).Take(&v)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.GetByExternalID error")
+	}
+
+	return &v, nil
+}
+
+func (ar *pgActorRepo) AddExternalID(id int, ext models.ExternalID) error {
+	tx := ar.DB.Exec("INSERT INTO actor_external_ids (source, external_id, actor_id) VALUES (?, ?, ?)", ext.Source, ext.ID, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgActorRepo.AddExternalID error")
+	}
+
+	return nil
+}
+
+func (ar *pgActorRepo) DeleteExternalID(id int, ext models.ExternalID) error {
+	tx := ar.DB.Exec("DELETE FROM actor_external_ids WHERE source = ? AND external_id = ? AND actor_id = ?", ext.Source, ext.ID, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgActorRepo.DeleteExternalID error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgActorRepo.DeleteExternalID error")
+	}
+
+	return nil
+}
+
+// addActorRevisionQuery numbers the revision after the last one of the actor; a
+// concurrent insert of the same number fails on the primary key.
+const addActorRevisionQuery = `INSERT INTO actor_revisions (actor_id, rev, user_id, data)
+SELECT ?, COALESCE(MAX(rev), 0) + 1, ?, ?::jsonb FROM actor_revisions WHERE actor_id = ?
+RETURNING rev, created_at`
+
+func (ar *pgActorRepo) AddRevision(id int, r *models.Revision) error {
+	tx := ar.DB.Raw(addActorRevisionQuery, id, r.UserID, string(r.Data), id).Scan(r)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgActorRepo.AddRevision error")
+	}
+
+	return nil
+}
+
+func (ar *pgActorRepo) GetRevisions(id, limit, offset int) ([]models.Revision, error) {
+	revisions := []models.Revision{}
+	tx := ar.DB.Table("actor_revisions").Select("rev, user_id, data, created_at").Where("actor_id = ?", id).
+		Order("rev DESC").Limit(limit).Offset(offset).Find(&revisions)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.GetRevisions error")
+	}
+
+	return revisions, nil
+}
+
+func (ar *pgActorRepo) GetRevision(id, rev int) (*models.Revision, error) {
+	var r models.Revision
+	tx := ar.DB.Table("actor_revisions").Select("rev, user_id, data, created_at").Where("actor_id = ? AND rev = ?", id, rev).Take(&r)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgActorRepo.GetRevision error")
+	}
+
+	return &r, nil
+}
+
+// Atomic runs fn in a transaction, the audit log writes to the same one.
+func (ar *pgActorRepo) Atomic(fn func(actors repository.ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	err := ar.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(New(ar.Logger, tx), pgAudit.New(ar.Logger, tx))
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgActorRepo.Atomic error")
+	}
+
+	return nil
+}
+
+// Bulk runs fn in a transaction with the outbox triggers off, see
+// pgEvent.BulkLoad. Each Upsert of fn has a savepoint, so that one failing
+// leaves the transaction usable for the next.
+func (ar *pgActorRepo) Bulk(source string, fn func(actors repository.ActorRepositoryI) ([]int, error)) error {
+	err := pgEvent.BulkLoad(ar.DB, source, func(tx *gorm.DB, e *models.BulkEvent) error {
+		ids, err := fn(bulkActorRepo{&pgActorRepo{Logger: ar.Logger, DB: tx}})
+		e.Actors = append(e.Actors, ids...)
+
+		return err
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgActorRepo.Bulk error")
+	}
+
+	return nil
+}
+
+// bulkActorRepo is the repository of a Bulk call.
+type bulkActorRepo struct {
+	*pgActorRepo
+}
+
+// Upsert runs in a nested transaction, which gorm makes a savepoint.
+func (br bulkActorRepo) Upsert(a *models.Actor) (string, error) {
+	var outcome string
+	err := br.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		outcome, err = New(br.Logger, tx).Upsert(a)
+
+		return err
+	})
+
+	return outcome, err
+}
@@ -9,6 +9,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	actorRep "intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
 	"intern/internal/testBuilders"
 	"intern/models"
 	"intern/pkg/logger"
@@ -75,8 +76,8 @@ func (s *ActorRepoTestSuite) TestCreateActor(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(
-		`INSERT INTO "actors" ("first_name","last_name","gender","birthday","id") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
-		WithArgs(actor.FirstName, actor.LastName, actor.Gender, actor.Birthday, actor.ID).
+		`INSERT INTO "actors" ("first_name","last_name","gender","birthday","deleted_at","id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+		WithArgs(actor.FirstName, actor.LastName, actor.Gender, actor.Birthday, nil, actor.ID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	s.mock.ExpectCommit()
@@ -106,7 +107,7 @@ func (s *ActorRepoTestSuite) TestGetActor(t provider.T) {
 		)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT * FROM "actors" WHERE id = $1 LIMIT $2`)).
+		`SELECT * FROM "actors" WHERE id = $1 AND "actors"."deleted_at" IS NULL LIMIT $2`)).
 		WithArgs(actor.ID, 1).
 		WillReturnRows(rows)
 
@@ -128,7 +129,7 @@ func (s *ActorRepoTestSuite) TestUpdateActor(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectExec(regexp.QuoteMeta(
-		`UPDATE "actors" SET "first_name"=$1,"last_name"=$2,"gender"=$3,"birthday"=$4 WHERE "id" = $5`)).
+		`UPDATE "actors" SET "first_name"=$1,"last_name"=$2,"gender"=$3,"birthday"=$4 WHERE "actors"."deleted_at" IS NULL AND "id" = $5`)).
 		WithArgs(actor.FirstName, actor.LastName, actor.Gender, actor.Birthday, actor.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	s.mock.ExpectCommit()
@@ -150,8 +151,8 @@ func (s *ActorRepoTestSuite) TestDeleteActor(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectExec(regexp.QuoteMeta(
-		`DELETE FROM "actors" WHERE "actors"."id" = $1`)).
-		WithArgs(actor.ID).WillReturnResult(sqlmock.NewResult(int64(actor.ID), 1))
+		`UPDATE "actors" SET "deleted_at"=$1 WHERE "actors"."id" = $2 AND "actors"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), actor.ID).WillReturnResult(sqlmock.NewResult(int64(actor.ID), 1))
 
 	s.mock.ExpectCommit()
 
@@ -159,6 +160,24 @@ func (s *ActorRepoTestSuite) TestDeleteActor(t provider.T) {
 	t.Assert().NoError(err)
 }
 
+func (s *ActorRepoTestSuite) TestRestoreActor(t provider.T) {
+	restore := regexp.QuoteMeta(`UPDATE "actors" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(restore).WithArgs(nil, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Restore(1)
+	t.Assert().NoError(err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(restore).WithArgs(nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err = s.repo.Restore(2)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
 func (s *ActorRepoTestSuite) TestGetMoviesByActor(t provider.T) {
 	movies := make([]models.Movie, 10)
 	err := faker.FakeData(&movies)
@@ -204,3 +223,160 @@ func (s *ActorRepoTestSuite) TestGetMoviesByActor(t provider.T) {
 	t.Assert().NoError(err)
 	t.Assert().Equal(movies, resMovies)
 }
+
+func (s *ActorRepoTestSuite) TestCostars(t provider.T) {
+	actor := s.actorBuilder.
+		WithID(2).
+		WithFirstName("Laurence").
+		WithLastName("Fishburne").
+		WithGender('m').
+		WithBirthday(time.Date(1961, 7, 30, 0, 0, 0, 0, time.UTC)).
+		Build()
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "shared_movies"}).
+		AddRow(actor.ID, actor.FirstName, actor.LastName, actor.Gender, actor.Birthday, 4)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT a.*, count(DISTINCT mine.movie_id) AS shared_movies
+FROM movies_actors mine
+JOIN movies m ON m.id = mine.movie_id AND m.deleted_at IS NULL
+JOIN movies_actors theirs ON theirs.movie_id = mine.movie_id AND theirs.actor_id <> mine.actor_id
+JOIN actors a ON a.id = theirs.actor_id AND a.deleted_at IS NULL
+WHERE mine.actor_id = $1
+GROUP BY a.id
+ORDER BY shared_movies DESC, a.last_name, a.first_name, a.id
+LIMIT $2 OFFSET $3`)).
+		WithArgs(1, 10, 0).
+		WillReturnRows(rows)
+
+	costars, err := s.repo.Costars(1, 10, 0)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.Costar{{Actor: actor, SharedMovies: 4}}, costars)
+}
+
+func (s *ActorRepoTestSuite) TestListActors(t provider.T) {
+	actor := s.actorBuilder.
+		WithID(1).
+		WithFirstName("Keanu").
+		WithLastName("Reeves").
+		WithGender('m').
+		WithBirthday(time.Date(1964, 9, 2, 0, 0, 0, 0, time.UTC)).
+		Build()
+
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "movies_count"}).
+		AddRow(actor.ID, actor.FirstName, actor.LastName, actor.Gender, actor.Birthday, 3)
+
+	bornAfter := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT a.id, a.first_name, a.last_name, a.gender, a.birthday, COUNT(m.id) AS movies_count FROM actors a `+
+			`LEFT JOIN movies_actors ma ON ma.actor_id = a.id `+
+			`LEFT JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL `+
+			`WHERE a.deleted_at IS NULL AND (a.first_name ILIKE $1 OR a.last_name ILIKE $2 OR (a.first_name || ' ' || a.last_name) ILIKE $3) `+
+			`AND a.gender = $4 AND a.birthday >= $5 `+
+			`GROUP BY "a"."id" ORDER BY movies_count DESC, a.id LIMIT $6 OFFSET $7`)).
+		WithArgs("%ree%", "%ree%", "%ree%", "m", bornAfter, 10, 20).
+		WillReturnRows(rows)
+
+	actors, err := s.repo.List(models.ActorFilter{
+		Name:      "ree",
+		Gender:    "m",
+		BornAfter: &bornAfter,
+		SortBy:    models.ActorSortMovies,
+		Desc:      true,
+		Limit:     10,
+		Offset:    20,
+	})
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.ActorListItem{{Actor: actor, MoviesCount: 3}}, actors)
+}
+
+func (s *ActorRepoTestSuite) TestListActorsDefaultSort(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "movies_count"})
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`GROUP BY "a"."id" ORDER BY a.last_name ASC, a.first_name ASC, a.id LIMIT $1`)).
+		WithArgs(20).
+		WillReturnRows(rows)
+
+	actors, err := s.repo.List(models.ActorFilter{Limit: 20})
+	t.Assert().NoError(err)
+	t.Assert().Empty(actors)
+}
+
+func (s *ActorRepoTestSuite) TestAddRevision(t provider.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := models.Revision{UserID: 3, Data: []byte(`{"id":1}`)}
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO actor_revisions (actor_id, rev, user_id, data) SELECT $1, COALESCE(MAX(rev), 0) + 1, $2, $3::jsonb FROM actor_revisions WHERE actor_id = $4 RETURNING rev, created_at`)).
+		WithArgs(1, 3, `{"id":1}`, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rev", "created_at"}).AddRow(2, created))
+
+	err := s.repo.AddRevision(1, &r)
+	t.Assert().NoError(err)
+	t.Assert().Equal(2, r.Rev)
+	t.Assert().Equal(created, r.CreatedAt)
+}
+
+func (s *ActorRepoTestSuite) TestGetRevisions(t provider.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"rev", "user_id", "data", "created_at"}).
+		AddRow(2, 3, []byte(`{"id":1}`), created).
+		AddRow(1, 0, []byte(`{"id":1}`), created)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT rev, user_id, data, created_at FROM "actor_revisions" WHERE actor_id = $1 ORDER BY rev DESC LIMIT $2`)).
+		WithArgs(1, 10).
+		WillReturnRows(rows)
+
+	revisions, err := s.repo.GetRevisions(1, 10, 0)
+	t.Assert().NoError(err)
+	t.Assert().Len(revisions, 2)
+	t.Assert().Equal(2, revisions[0].Rev)
+	t.Assert().Equal(3, revisions[0].UserID)
+	t.Assert().JSONEq(`{"id":1}`, string(revisions[1].Data))
+}
+
+func (s *ActorRepoTestSuite) TestAtomic(t provider.T) {
+	e := &models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditActor, EntityID: 1}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "actors" SET "deleted_at"=$1 WHERE "actors"."id" = $2 AND "actors"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "audit_entries" ("user_id","action","entity","entity_id","before","after","created_at") VALUES ($1,$2,$3,$4,(NULL),(NULL),$5) RETURNING "id"`)).
+		WithArgs(0, models.AuditDelete, models.AuditActor, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		if err := actors.Delete(1); err != nil {
+			return err
+		}
+
+		return audit.Append(e)
+	})
+	t.Assert().NoError(err)
+	t.Assert().Equal(1, e.ID)
+}
+
+func (s *ActorRepoTestSuite) TestAtomicRollsBack(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "actors" SET "deleted_at"=$1 WHERE "actors"."id" = $2 AND "actors"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_entries"`)).
+		WillReturnError(sql.ErrConnDone)
+	s.mock.ExpectRollback()
+
+	err := s.repo.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		if err := actors.Delete(1); err != nil {
+			return err
+		}
+
+		return audit.Append(&models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditActor, EntityID: 1})
+	})
+	t.Assert().ErrorIs(err, sql.ErrConnDone)
+}
@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"intern/internal/actor/repository"
+	"intern/internal/actor/repository/contract"
+	"intern/models"
+	"regexp"
+	"testing"
+)
+
+type sqlmockBackend struct {
+	repo repository.ActorRepositoryI
+	mock sqlmock.Sqlmock
+}
+
+func (b *sqlmockBackend) Repo() repository.ActorRepositoryI { return b.repo }
+
+func (b *sqlmockBackend) ExpectCreate(a models.Actor, id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "actors" ("first_name","last_name","gender","birthday","deleted_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(a.FirstName, a.LastName, a.Gender, a.Birthday, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectGet(a models.Actor) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actors" WHERE id = $1 AND "actors"."deleted_at" IS NULL LIMIT $2`)).
+		WithArgs(a.ID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday"}).
+			AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday))
+}
+
+func (b *sqlmockBackend) ExpectGetMissing(id int) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actors" WHERE id = $1 AND "actors"."deleted_at" IS NULL LIMIT $2`)).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday"}))
+}
+
+func (b *sqlmockBackend) ExpectUpdate(a models.Actor) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "actors" SET "first_name"=$1,"last_name"=$2,"gender"=$3,"birthday"=$4 WHERE "actors"."deleted_at" IS NULL AND "id" = $5`)).
+		WithArgs(a.FirstName, a.LastName, a.Gender, a.Birthday, a.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectReplace(a models.Actor) {
+	b.ExpectUpdate(a)
+}
+
+func (b *sqlmockBackend) ExpectDelete(id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "actors" SET "deleted_at"=$1 WHERE "actors"."id" = $2 AND "actors"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectRestore(id int, found bool) {
+	affected := int64(0)
+	if found {
+		affected = 1
+	}
+
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "actors" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`)).
+		WithArgs(nil, id).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectListByName(name string, limit int, actors []models.ActorListItem) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "movies_count"})
+	for _, a := range actors {
+		rows.AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday, a.MoviesCount)
+	}
+
+	pattern := "%" + name + "%"
+	b.mock.ExpectQuery(regexp.QuoteMeta(`FROM actors a`)).
+		WithArgs(pattern, pattern, pattern, limit).
+		WillReturnRows(rows)
+}
+
+// SeedCredit has nothing to store, the movies are counted by the query
+// ExpectList checks.
+func (b *sqlmockBackend) SeedCredit(int, int, bool) {}
+
+func (b *sqlmockBackend) ExpectList(filter models.ActorFilter, actors []models.ActorListItem) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "movies_count"})
+	for _, a := range actors {
+		rows.AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday, a.MoviesCount)
+	}
+
+	query := `SELECT a.id, a.first_name, a.last_name, a.gender, a.birthday, COUNT(m.id) AS movies_count FROM actors a ` +
+		`LEFT JOIN movies_actors ma ON ma.actor_id = a.id LEFT JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL ` +
+		`WHERE a.deleted_at IS NULL`
+	var args []driver.Value
+	if filter.Gender != "" {
+		query += ` AND a.gender = $1`
+		args = append(args, filter.Gender)
+	}
+	if filter.BornAfter != nil {
+		query += fmt.Sprintf(` AND a.birthday >= $%d`, len(args)+1)
+		args = append(args, *filter.BornAfter)
+	}
+
+	order := "a.last_name %[1]s, a.first_name %[1]s"
+	switch filter.SortBy {
+	case models.ActorSortBirthday:
+		order = "a.birthday %[1]s"
+	case models.ActorSortMovies:
+		order = "movies_count %[1]s"
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+	query += ` GROUP BY "a"."id" ORDER BY ` + fmt.Sprintf(order, direction) + fmt.Sprintf(`, a.id LIMIT $%d`, len(args)+1)
+	args = append(args, filter.Limit)
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(` OFFSET $%d`, len(args)+1)
+		args = append(args, filter.Offset)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(query) + `$`).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectEachByName(name string, actors []models.ActorListItem) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "movies_count"})
+	for _, a := range actors {
+		rows.AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday, a.MoviesCount)
+	}
+
+	pattern := "%" + name + "%"
+	b.mock.ExpectQuery(regexp.QuoteMeta(`GROUP BY "a"."id" ORDER BY a.last_name ASC, a.first_name ASC, a.id`)+`$`).
+		WithArgs(pattern, pattern, pattern).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectUpsert(a models.Actor, id int, outcome string) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`WITH target AS (
+		SELECT id, deleted_at IS NOT NULL AS trashed FROM actors WHERE first_name = $1 AND last_name = $2 AND birthday = $3 ORDER BY deleted_at IS NOT NULL, id LIMIT 1
+		), updated AS (
+		UPDATE actors SET gender = $4, deleted_at = NULL WHERE id = (SELECT id FROM target)
+		RETURNING id, CASE WHEN (SELECT trashed FROM target) THEN 'restored' ELSE 'updated' END AS outcome
+		), inserted AS (
+		INSERT INTO actors (first_name, last_name, gender, birthday)
+		SELECT $5, $6, $7, $8 WHERE NOT EXISTS (SELECT 1 FROM target)
+		RETURNING id, 'created' AS outcome
+		)
+		SELECT id, outcome FROM updated UNION ALL SELECT id, outcome FROM inserted`)).
+		WithArgs(a.FirstName, a.LastName, a.Birthday, string(a.Gender), a.FirstName, a.LastName, string(a.Gender), a.Birthday).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "outcome"}).AddRow(id, outcome))
+}
+
+func (b *sqlmockBackend) ExpectBulkUpsert(a models.Actor, id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	b.mock.ExpectExec(`SAVEPOINT sp`).WillReturnResult(sqlmock.NewResult(0, 0))
+	b.ExpectUpsert(a, id, models.UpsertCreated)
+	b.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox_events (type, payload) VALUES ($1, $2)`)).
+		WithArgs(models.EventCatalogueLoaded, fmt.Sprintf(`{"source":"import","movies":[],"actors":[%d]}`, id)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectGetByNaturalKey(a models.Actor) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actors" WHERE first_name = $1 AND last_name = $2 AND birthday = $3 ORDER BY deleted_at IS NOT NULL, id LIMIT $4`)).
+		WithArgs(a.FirstName, a.LastName, a.Birthday, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday", "deleted_at"}).
+			AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday, deletedAt(a.DeletedAt)))
+}
+
+func (b *sqlmockBackend) ExpectAddExternalID(id int, ext models.ExternalID) {
+	b.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO actor_external_ids (source, external_id, actor_id) VALUES ($1, $2, $3)`)).
+		WithArgs(ext.Source, ext.ID, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func (b *sqlmockBackend) ExpectGetExternalIDs(id int, ids []models.ExternalID) {
+	rows := sqlmock.NewRows([]string{"source", "external_id"})
+	for _, ext := range ids {
+		rows.AddRow(ext.Source, ext.ID)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT source, external_id FROM "actor_external_ids" WHERE actor_id = $1 ORDER BY source, external_id`)).
+		WithArgs(id).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectGetByExternalID(ext models.ExternalID, a *models.Actor) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday"})
+	if a != nil {
+		rows.AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actors" WHERE (id = (SELECT actor_id FROM actor_external_ids WHERE source = $1 AND external_id = $2)) AND "actors"."deleted_at" IS NULL LIMIT $3`)).
+		WithArgs(ext.Source, ext.ID, 1).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectDeleteExternalID(id int, ext models.ExternalID, found bool) {
+	affected := int64(0)
+	if found {
+		affected = 1
+	}
+
+	b.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM actor_external_ids WHERE source = $1 AND external_id = $2 AND actor_id = $3`)).
+		WithArgs(ext.Source, ext.ID, id).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+func (b *sqlmockBackend) Verify() error { return b.mock.ExpectationsWereMet() }
+
+// deletedAt is the deleted_at column of a row.
+func deletedAt(d gorm.DeletedAt) driver.Value {
+	if !d.Valid {
+		return nil
+	}
+
+	return d.Time
+}
+
+func TestActorRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal("error while creating sql mock")
+		}
+		t.Cleanup(func() { db.Close() })
+
+		gormDB, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_0",
+			DriverName:           "postgres",
+			Conn:                 db,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{})
+		if err != nil {
+			t.Fatal("error gorm open")
+		}
+
+		return &sqlmockBackend{repo: New(nil, gormDB), mock: mock}
+	})
+}
@@ -1,11 +1,44 @@
 package repository
 
-import "intern/models"
+import (
+	auditRep "intern/internal/audit/repository"
+	"intern/models"
+	"time"
+)
 
 type ActorRepositoryI interface {
 	Create(a *models.Actor) error
 	Get(id int) (*models.Actor, error)
+	// Update writes the fields of a that are set, Replace all of them.
 	Update(a *models.Actor) error
+	Replace(a *models.Actor) error
+	// Delete moves the actor to the trash, Restore takes it back out.
 	Delete(id int) error
+	Restore(id int) error
 	GetMoviesByActor(id int) ([]models.Movie, error)
+	// Costars returns the actors who share movies with the actor, most
+	// shared movies first.
+	Costars(id, limit, offset int) ([]models.Costar, error)
+	List(filter models.ActorFilter) ([]models.ActorListItem, error)
+	// GetByNaturalKey returns the actor Upsert would write, see movies.
+	GetByNaturalKey(firstName, lastName string, birthday time.Time) (*models.Actor, error)
+	// Upsert returns one of the models.Upsert* outcomes.
+	Upsert(a *models.Actor) (outcome string, err error)
+	Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error
+	GetExternalIDs(id int) ([]models.ExternalID, error)
+	GetByExternalID(ext models.ExternalID) (*models.Actor, error)
+	AddExternalID(id int, ext models.ExternalID) error
+	DeleteExternalID(id int, ext models.ExternalID) error
+	// AddRevision stores r as the next revision of the actor, setting its
+	// Rev and CreatedAt. GetRevisions returns the newest first.
+	AddRevision(id int, r *models.Revision) error
+	GetRevisions(id, limit, offset int) ([]models.Revision, error)
+	GetRevision(id, rev int) (*models.Revision, error)
+	// Atomic runs fn with a repository and an audit log whose writes are
+	// committed together if fn returns nil, and not at all otherwise.
+	Atomic(fn func(actors ActorRepositoryI, audit auditRep.AuditRepositoryI) error) error
+	// Bulk runs fn like Atomic, but as a bulk load of source: the writes of
+	// fn add no event each, the one event of them all lists the actors fn
+	// returns. An Upsert that fails is undone alone.
+	Bulk(source string, fn func(actors ActorRepositoryI) ([]int, error)) error
 }
@@ -1,31 +1,84 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 	actorRep "intern/internal/actor/repository"
+	auditRep "intern/internal/audit/repository"
+	auditUseCase "intern/internal/audit/usecase"
 	"intern/models"
+	"intern/pkg/jsondiff"
 )
 
+// ActorUseCaseI records every change in the audit log, as made by the
+// signed in user of the ctx passed along, and every version of the actor as
+// a revision.
 type ActorUseCaseI interface {
-	Create(a *models.Actor) error
+	Create(ctx context.Context, a *models.Actor) error
 	Get(id int) (*models.Actor, error)
-	Update(a *models.Actor) error
-	Delete(id int) error
+	Update(ctx context.Context, a *models.Actor) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (*models.Actor, error)
 	GetMoviesByActor(id int) ([]models.Movie, error)
+	Costars(id, limit, offset int) ([]models.Costar, error)
+	List(filter models.ActorFilter) ([]models.ActorListItem, error)
+	Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error
+	GetByExternalID(ext models.ExternalID) (*models.Actor, error)
+	AddExternalID(ctx context.Context, id int, ext models.ExternalID) error
+	DeleteExternalID(ctx context.Context, id int, ext models.ExternalID) error
+	Revisions(id, limit, offset int) ([]models.Revision, error)
+	// RevisionDiff compares revision rev with revision against, 0 being
+	// the state before the first revision.
+	RevisionDiff(id, rev, against int) (*models.RevisionDiff, error)
+	// Revert updates the actor to revision rev, which makes a new revision.
+	Revert(ctx context.Context, id, rev int) (*models.Actor, error)
 }
 
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+var ErrInvalidFilter = errors.New("invalid actor filter")
+
+var (
+	ErrInvalidExternalID = errors.New("invalid external id")
+	ErrExternalIDTaken   = errors.New("external id belongs to another actor")
+)
+
+var ErrInvalidRevision = errors.New("invalid revision")
+
 type actorUseCase struct {
 	actorRepository actorRep.ActorRepositoryI
+	audit           auditUseCase.AuditUseCaseI
+	context         ContextManager
 }
 
-func New(aRep actorRep.ActorRepositoryI) ActorUseCaseI {
+func New(aRep actorRep.ActorRepositoryI, audit auditUseCase.AuditUseCaseI, cm ContextManager) ActorUseCaseI {
 	return &actorUseCase{
 		actorRepository: aRep,
+		audit:           audit,
+		context:         cm,
 	}
 }
 
-func (aUC *actorUseCase) Create(a *models.Actor) error {
-	err := aUC.actorRepository.Create(a)
+func (aUC *actorUseCase) Create(ctx context.Context, a *models.Actor) error {
+	err := aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := actors.Create(a)
+
+		if err != nil {
+			return err
+		}
+
+		err = aUC.record(ctx, audit, models.AuditCreate, a.ID, nil, a)
+
+		if err != nil {
+			return err
+		}
+
+		return aUC.addRevision(ctx, actors, a)
+	})
 
 	if err != nil {
 		return errors.Wrap(err, "actorUseCase.Create error")
@@ -41,41 +94,176 @@ func (aUC *actorUseCase) Get(id int) (*models.Actor, error) {
 		return nil, errors.Wrap(err, "actorUseCase.Get error")
 	}
 
+	resActor.ExternalIDs, err = aUC.actorRepository.GetExternalIDs(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Get error: can't get external ids")
+	}
+
 	return resActor, nil
 }
 
-func (aUC *actorUseCase) Update(a *models.Actor) error {
-	_, err := aUC.actorRepository.Get(a.ID)
+func (aUC *actorUseCase) Update(ctx context.Context, a *models.Actor) error {
+	_, err := aUC.update(ctx, a, models.AuditUpdate, actorRep.ActorRepositoryI.Update)
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.Update error")
+	}
+
+	return nil
+}
+
+// update writes a with write and records it as action, all in one
+// transaction, and returns the updated actor.
+func (aUC *actorUseCase) update(ctx context.Context, a *models.Actor, action string,
+	write func(actorRep.ActorRepositoryI, *models.Actor) error) (*models.Actor, error) {
+	var after *models.Actor
+
+	err := aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		before, err := actors.Get(a.ID)
+
+		if err != nil {
+			return errors.Wrap(err, "Actor not found")
+		}
+
+		// A actor stored before revisions were kept, or by an import, gets
+		// its current state as the first revision, by an unknown user.
+		revisions, err := actors.GetRevisions(a.ID, 1, 0)
+
+		if err != nil {
+			return errors.Wrap(err, "can't get revisions")
+		}
+
+		if len(revisions) == 0 {
+			if err = aUC.addRevision(context.Background(), actors, before); err != nil {
+				return err
+			}
+		}
+
+		err = write(actors, a)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't update in repo")
+		}
+
+		// The stored row, as a carries only the fields that can be updated.
+		after, err = actors.Get(a.ID)
+
+		if err != nil {
+			return errors.Wrap(err, "Actor not found")
+		}
+
+		err = aUC.record(ctx, audit, action, a.ID, before, after)
+
+		if err != nil {
+			return err
+		}
+
+		return aUC.addRevision(ctx, actors, after)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// record appends the entry of a change made by the user of ctx to audit,
+// the log of the transaction making it.
+func (aUC *actorUseCase) record(ctx context.Context, audit auditRep.AuditRepositoryI, action string, id int, before, after interface{}) error {
+	e, err := aUC.audit.Entry(ctx, action, models.AuditActor, id, before, after)
 
 	if err != nil {
-		return errors.Wrap(err, "actorUseCase.Update error: Actor not found")
+		return errors.Wrap(err, "can't record audit")
 	}
 
-	err = aUC.actorRepository.Update(a)
+	err = audit.Append(e)
 
 	if err != nil {
-		return errors.Wrap(err, "actorUseCase.Update error: Can't update in repo")
+		return errors.Wrap(err, "can't record audit")
 	}
 
 	return nil
 }
 
-func (aUC *actorUseCase) Delete(id int) error {
-	_, err := aUC.actorRepository.Get(id)
+// addRevision stores a in actors as the next revision, by the user of ctx.
+func (aUC *actorUseCase) addRevision(ctx context.Context, actors actorRep.ActorRepositoryI, a *models.Actor) error {
+	snapshot := *a
+	snapshot.ExternalIDs = nil
+
+	data, err := json.Marshal(snapshot)
+
+	if err != nil {
+		return errors.Wrap(err, "can't marshal revision")
+	}
+
+	userID, err := aUC.context.UserIDFromContext(ctx)
 
 	if err != nil {
-		return errors.Wrap(err, "actorUseCase.Delete error: Actor not found")
+		userID = 0
 	}
 
-	err = aUC.actorRepository.Delete(id)
+	err = actors.AddRevision(a.ID, &models.Revision{UserID: userID, Data: data})
 
 	if err != nil {
-		return errors.Wrap(err, "actorUseCase.Delete error: Can't delete in repo")
+		return errors.Wrap(err, "can't add revision")
 	}
 
 	return nil
 }
 
+func (aUC *actorUseCase) Delete(ctx context.Context, id int) error {
+	err := aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		before, err := actors.Get(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Actor not found")
+		}
+
+		err = actors.Delete(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't delete in repo")
+		}
+
+		return aUC.record(ctx, audit, models.AuditDelete, id, before, nil)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.Delete error")
+	}
+
+	return nil
+}
+
+// Restore takes the actor out of the trash and returns it.
+func (aUC *actorUseCase) Restore(ctx context.Context, id int) (*models.Actor, error) {
+	var resActor *models.Actor
+
+	err := aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := actors.Restore(id)
+
+		if err != nil {
+			return err
+		}
+
+		resActor, err = actors.Get(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Actor not found")
+		}
+
+		return aUC.record(ctx, audit, models.AuditRestore, id, nil, resActor)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Restore error")
+	}
+
+	return resActor, nil
+}
+
 func (aUC *actorUseCase) GetMoviesByActor(id int) ([]models.Movie, error) {
 	movies, err := aUC.actorRepository.GetMoviesByActor(id)
 
@@ -85,3 +273,231 @@ func (aUC *actorUseCase) GetMoviesByActor(id int) ([]models.Movie, error) {
 
 	return movies, nil
 }
+
+func (aUC *actorUseCase) Costars(id, limit, offset int) ([]models.Costar, error) {
+	_, err := aUC.actorRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Costars error: Actor not found")
+	}
+
+	costars, err := aUC.actorRepository.Costars(id, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Costars error")
+	}
+
+	return costars, nil
+}
+
+func (aUC *actorUseCase) List(filter models.ActorFilter) ([]models.ActorListItem, error) {
+	err := validateFilter(&filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.List error")
+	}
+
+	actors, err := aUC.actorRepository.List(filter)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.List error")
+	}
+
+	return actors, nil
+}
+
+// Each streams all actors matching filter to fn; pagination is ignored.
+func (aUC *actorUseCase) Each(filter models.ActorFilter, fn func(a models.ActorListItem) error) error {
+	err := validateFilter(&filter)
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.Each error")
+	}
+
+	err = aUC.actorRepository.Each(filter, fn)
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.Each error")
+	}
+
+	return nil
+}
+
+func validateFilter(filter *models.ActorFilter) error {
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = models.ActorSortName
+	case models.ActorSortName, models.ActorSortBirthday, models.ActorSortMovies:
+	default:
+		return errors.Wrapf(ErrInvalidFilter, "unknown sort %q", filter.SortBy)
+	}
+
+	if filter.Gender != "" && filter.Gender != "m" && filter.Gender != "f" {
+		return errors.Wrapf(ErrInvalidFilter, "unknown gender %q", filter.Gender)
+	}
+
+	if filter.BornAfter != nil && filter.BornBefore != nil && filter.BornAfter.After(*filter.BornBefore) {
+		return errors.Wrap(ErrInvalidFilter, "empty birthday range")
+	}
+
+	return nil
+}
+
+func (aUC *actorUseCase) GetByExternalID(ext models.ExternalID) (*models.Actor, error) {
+	if !ext.Valid() {
+		return nil, errors.Wrapf(ErrInvalidExternalID, "actorUseCase.GetByExternalID error: %s %q", ext.Source, ext.ID)
+	}
+
+	resActor, err := aUC.actorRepository.GetByExternalID(ext)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.GetByExternalID error")
+	}
+
+	resActor.ExternalIDs, err = aUC.actorRepository.GetExternalIDs(resActor.ID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.GetByExternalID error: can't get external ids")
+	}
+
+	return resActor, nil
+}
+
+// AddExternalID links ext to the actor. Adding a link that already exists is
+// a no-op, an id of the source can not point to two actors.
+func (aUC *actorUseCase) AddExternalID(ctx context.Context, id int, ext models.ExternalID) error {
+	if !ext.Valid() {
+		return errors.Wrapf(ErrInvalidExternalID, "actorUseCase.AddExternalID error: %s %q", ext.Source, ext.ID)
+	}
+
+	_, err := aUC.actorRepository.Get(id)
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.AddExternalID error: Actor not found")
+	}
+
+	owner, err := aUC.actorRepository.GetByExternalID(ext)
+
+	switch {
+	case err == nil && owner.ID == id:
+		return nil
+	case err == nil:
+		return errors.Wrapf(ErrExternalIDTaken, "actorUseCase.AddExternalID error: %s %q is linked to actor %d", ext.Source, ext.ID, owner.ID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.Wrap(err, "actorUseCase.AddExternalID error")
+	}
+
+	err = aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := actors.AddExternalID(id, ext)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't add in repo")
+		}
+
+		return aUC.record(ctx, audit, models.AuditAddExternalID, id, nil, ext)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.AddExternalID error")
+	}
+
+	return nil
+}
+
+func (aUC *actorUseCase) DeleteExternalID(ctx context.Context, id int, ext models.ExternalID) error {
+	err := aUC.actorRepository.Atomic(func(actors actorRep.ActorRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := actors.DeleteExternalID(id, ext)
+
+		if err != nil {
+			return err
+		}
+
+		return aUC.record(ctx, audit, models.AuditDeleteExternalID, id, ext, nil)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "actorUseCase.DeleteExternalID error")
+	}
+
+	return nil
+}
+
+func (aUC *actorUseCase) Revisions(id, limit, offset int) ([]models.Revision, error) {
+	_, err := aUC.actorRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Revisions error: Actor not found")
+	}
+
+	revisions, err := aUC.actorRepository.GetRevisions(id, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Revisions error")
+	}
+
+	return revisions, nil
+}
+
+func (aUC *actorUseCase) RevisionDiff(id, rev, against int) (*models.RevisionDiff, error) {
+	if against < 0 {
+		return nil, errors.Wrapf(ErrInvalidRevision, "actorUseCase.RevisionDiff error: against %d", against)
+	}
+
+	_, err := aUC.actorRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.RevisionDiff error: Actor not found")
+	}
+
+	to, err := aUC.actorRepository.GetRevision(id, rev)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "actorUseCase.RevisionDiff error: revision %d", rev)
+	}
+
+	var before interface{}
+
+	if against > 0 {
+		from, err := aUC.actorRepository.GetRevision(id, against)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "actorUseCase.RevisionDiff error: revision %d", against)
+		}
+
+		before = from.Data
+	}
+
+	diff := &models.RevisionDiff{From: against, To: rev}
+	diff.Before, diff.After, err = jsondiff.Diff(before, to.Data)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.RevisionDiff error")
+	}
+
+	return diff, nil
+}
+
+// Revert writes every field of the revision, empty ones included.
+func (aUC *actorUseCase) Revert(ctx context.Context, id, rev int) (*models.Actor, error) {
+	r, err := aUC.actorRepository.GetRevision(id, rev)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "actorUseCase.Revert error: revision %d", rev)
+	}
+
+	var a models.Actor
+
+	err = json.Unmarshal(r.Data, &a)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "actorUseCase.Revert error: can't unmarshal revision %d", rev)
+	}
+
+	a.ID = id
+
+	res, err := aUC.update(ctx, &a, models.AuditRevert, actorRep.ActorRepositoryI.Replace)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "actorUseCase.Revert error")
+	}
+
+	return res, nil
+}
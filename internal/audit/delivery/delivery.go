@@ -0,0 +1,95 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	auditUseCase "intern/internal/audit/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+)
+
+type AuditHandler struct {
+	AuditUseCase auditUseCase.AuditUseCaseI
+	Logger       logger.Logger
+}
+
+// List godoc
+// @Summary      Audit log
+// @Description  Changes of movies and actors, newest first: who made them, the action and
+// @Description  the changed fields before and after.
+// @Tags     audit
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param entity query string false "movie or actor, both if empty"
+// @Param id query int false "id of the movie or actor, needs entity"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.AuditEntry "success get audit log"
+// @Failure 400 {object} nil "invalid filter or pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /audit [get]
+func (ah *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		ah.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.AuditFilter{
+		Entity: r.FormValue("entity"),
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+
+	if idString := r.FormValue("id"); idString != "" {
+		filter.EntityID, err = strconv.Atoi(idString)
+		if err != nil {
+			ah.Logger.Infow("can`t parse id",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := ah.AuditUseCase.List(filter)
+	switch {
+	case errors.Is(err, auditUseCase.ErrInvalidFilter):
+		ah.Logger.Infow("can`t get audit log",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case err != nil:
+		ah.Logger.Errorw("can`t get audit log",
+			"err:", err.Error())
+		http.Error(w, "can`t get audit log", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(entries)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal audit log",
+			"err:", err.Error())
+		http.Error(w, "can`t make audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"intern/internal/audit/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+type memAuditRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.AuditRepositoryI {
+	return &memAuditRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ar *memAuditRepo) Append(e *models.AuditEntry) error {
+	ar.DB.Lock()
+	defer ar.DB.Unlock()
+
+	e.ID = ar.DB.NextID("audit_entries")
+	e.CreatedAt = time.Now()
+	ar.DB.AuditEntries = append(ar.DB.AuditEntries, *e)
+
+	return nil
+}
+
+func (ar *memAuditRepo) List(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	ar.DB.RLock()
+	defer ar.DB.RUnlock()
+
+	// The entries are in the order they were appended, newest last.
+	entries := []models.AuditEntry{}
+	for i := len(ar.DB.AuditEntries) - 1; i >= 0; i-- {
+		e := ar.DB.AuditEntries[i]
+		if filter.Entity != "" && e.Entity != filter.Entity {
+			continue
+		}
+		if filter.EntityID > 0 && e.EntityID != filter.EntityID {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	return memdb.Page(entries, filter.Limit, filter.Offset), nil
+}
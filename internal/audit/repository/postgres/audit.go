@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/audit/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+type pgAuditRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.AuditRepositoryI {
+	return &pgAuditRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ar *pgAuditRepo) Append(e *models.AuditEntry) error {
+	tx := ar.DB.Create(e)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgAuditRepo.Append error")
+	}
+
+	return nil
+}
+
+func (ar *pgAuditRepo) List(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	tx := ar.DB.Model(&models.AuditEntry{})
+
+	if filter.Entity != "" {
+		tx = tx.Where("entity = ?", filter.Entity)
+	}
+
+	if filter.EntityID > 0 {
+		tx = tx.Where("entity_id = ?", filter.EntityID)
+	}
+
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	tx = tx.Order("id DESC").Offset(filter.Offset).Find(&entries)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgAuditRepo.List error")
+	}
+
+	return entries, nil
+}
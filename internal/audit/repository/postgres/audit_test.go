@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	auditRep "intern/internal/audit/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type AuditRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   auditRep.AuditRepositoryI
+}
+
+func TestAuditRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(AuditRepoTestSuite))
+}
+
+func (s *AuditRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *AuditRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *AuditRepoTestSuite) TestAppend(t provider.T) {
+	e := &models.AuditEntry{
+		UserID:   7,
+		Action:   models.AuditUpdate,
+		Entity:   models.AuditMovie,
+		EntityID: 3,
+		Before:   json.RawMessage(`{"rating":8}`),
+		After:    json.RawMessage(`{"rating":9}`),
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "audit_entries" ("user_id","action","entity","entity_id","before","after","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`)).
+		WithArgs(7, models.AuditUpdate, models.AuditMovie, 3, e.Before, e.After, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Append(e)
+	t.Assert().NoError(err)
+	t.Assert().Equal(1, e.ID)
+}
+
+func (s *AuditRepoTestSuite) TestList(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_entries" WHERE entity = $1 AND entity_id = $2 ORDER BY id DESC LIMIT $3`)).
+		WithArgs(models.AuditMovie, 3, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "action", "entity", "entity_id", "before", "after", "created_at"}).
+			AddRow(1, 7, models.AuditDelete, models.AuditMovie, 3, []byte(`{"id":3}`), nil, created))
+
+	entries, err := s.repo.List(models.AuditFilter{Entity: models.AuditMovie, EntityID: 3, Limit: 20})
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.AuditEntry{{
+		ID: 1, UserID: 7, Action: models.AuditDelete, Entity: models.AuditMovie, EntityID: 3,
+		Before: json.RawMessage(`{"id":3}`), CreatedAt: created,
+	}}, entries)
+}
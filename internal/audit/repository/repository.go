@@ -0,0 +1,11 @@
+package repository
+
+import "intern/models"
+
+// AuditRepositoryI stores the audit log. It is append-only: entries are
+// never changed or deleted.
+type AuditRepositoryI interface {
+	// Append stores the entry and sets its ID and CreatedAt.
+	Append(e *models.AuditEntry) error
+	List(filter models.AuditFilter) ([]models.AuditEntry, error)
+}
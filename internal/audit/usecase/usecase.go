@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	auditRep "intern/internal/audit/repository"
+	"intern/models"
+	"intern/pkg/jsondiff"
+)
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type AuditUseCaseI interface {
+	// Record appends an entry for a change made by the user of ctx. before
+	// and after are the entity around the change, nil on the side that
+	// does not exist; the entry keeps the fields that differ.
+	Record(ctx context.Context, action, entity string, entityID int, before, after interface{}) error
+	// Entry builds the entry Record appends, for a caller that appends it
+	// in the transaction of the change.
+	Entry(ctx context.Context, action, entity string, entityID int, before, after interface{}) (*models.AuditEntry, error)
+	List(filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+var ErrInvalidFilter = errors.New("invalid audit filter")
+
+type auditUseCase struct {
+	auditRepository auditRep.AuditRepositoryI
+	context         ContextManager
+}
+
+func New(aRep auditRep.AuditRepositoryI, cm ContextManager) AuditUseCaseI {
+	return &auditUseCase{
+		auditRepository: aRep,
+		context:         cm,
+	}
+}
+
+func (aUC *auditUseCase) Record(ctx context.Context, action, entity string, entityID int, before, after interface{}) error {
+	e, err := aUC.Entry(ctx, action, entity, entityID, before, after)
+	if err != nil {
+		return errors.Wrap(err, "auditUseCase.Record error")
+	}
+
+	err = aUC.auditRepository.Append(e)
+	if err != nil {
+		return errors.Wrap(err, "auditUseCase.Record error")
+	}
+
+	return nil
+}
+
+func (aUC *auditUseCase) Entry(ctx context.Context, action, entity string, entityID int, before, after interface{}) (*models.AuditEntry, error) {
+	// Changes made outside a request, without a signed in user, are
+	// recorded as user 0.
+	userID, err := aUC.context.UserIDFromContext(ctx)
+	if err != nil {
+		userID = 0
+	}
+
+	e := &models.AuditEntry{
+		UserID:   userID,
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+	}
+
+	e.Before, e.After, err = jsondiff.Diff(before, after)
+	if err != nil {
+		return nil, errors.Wrap(err, "auditUseCase.Entry error: can't diff")
+	}
+
+	return e, nil
+}
+
+func (aUC *auditUseCase) List(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	switch filter.Entity {
+	case "":
+		if filter.EntityID != 0 {
+			return nil, errors.Wrap(ErrInvalidFilter, "auditUseCase.List error: id without entity")
+		}
+	case models.AuditMovie, models.AuditActor:
+		if filter.EntityID < 0 {
+			return nil, errors.Wrapf(ErrInvalidFilter, "auditUseCase.List error: id %d", filter.EntityID)
+		}
+	default:
+		return nil, errors.Wrapf(ErrInvalidFilter, "auditUseCase.List error: unknown entity %q", filter.Entity)
+	}
+
+	entries, err := aUC.auditRepository.List(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "auditUseCase.List error")
+	}
+
+	return entries, nil
+}
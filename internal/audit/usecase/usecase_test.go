@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	memAudit "intern/internal/audit/repository/memory"
+	"intern/internal/audit/repository/mocks"
+	"intern/internal/memdb"
+	"intern/models"
+	ctxManager "intern/pkg/context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	uc := New(memAudit.New(nil, memdb.New()), ctxManager.Manager{})
+	ctx := ctxManager.Manager{}.ContextWithUserID(context.Background(), 7)
+
+	before := models.Actor{ID: 1, FirstName: "Sigourney", LastName: "Weaver"}
+	after := before
+	after.FirstName = "Susan"
+
+	require.NoError(t, uc.Record(ctx, models.AuditUpdate, models.AuditActor, 1, before, after))
+	require.NoError(t, uc.Record(ctx, models.AuditUpdate, models.AuditActor, 1, after, after))
+	require.NoError(t, uc.Record(ctx, models.AuditDeleteExternalID, models.AuditActor, 1,
+		models.ExternalID{Source: models.ExternalSourceIMDb, ID: "nm0000244"}, nil))
+	require.NoError(t, uc.Record(ctx, models.AuditCreate, models.AuditMovie, 2, nil, models.Movie{ID: 2}))
+
+	entries, err := uc.List(models.AuditFilter{Entity: models.AuditActor, EntityID: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, 7, entries[2].UserID)
+	assert.JSONEq(t, `{"firstName":"Sigourney"}`, string(entries[2].Before))
+	assert.JSONEq(t, `{"firstName":"Susan"}`, string(entries[2].After))
+
+	// An update that changed nothing leaves both sides empty.
+	assert.JSONEq(t, `{}`, string(entries[1].Before))
+	assert.JSONEq(t, `{}`, string(entries[1].After))
+
+	assert.JSONEq(t, `{"source":"imdb","id":"nm0000244"}`, string(entries[0].Before))
+	assert.Nil(t, entries[0].After)
+
+	entries, err = uc.List(models.AuditFilter{Limit: 2, Offset: 0})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.AuditMovie, entries[0].Entity)
+}
+
+func TestListInvalidFilter(t *testing.T) {
+	uc := New(memAudit.New(nil, memdb.New()), ctxManager.Manager{})
+
+	for _, filter := range []models.AuditFilter{
+		{EntityID: 1},
+		{Entity: "review"},
+		{Entity: models.AuditMovie, EntityID: -1},
+	} {
+		_, err := uc.List(filter)
+		assert.True(t, errors.Is(err, ErrInvalidFilter), "%+v: %v", filter, err)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	repo := &mocks.AuditRepositoryI{}
+	repo.On("Append", mock.Anything).Return(errors.New("append failed")).Once()
+
+	err := New(repo, ctxManager.Manager{}).Record(context.Background(), models.AuditDelete, models.AuditMovie, 1, models.Movie{ID: 1}, nil)
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+package delivery
+
+import (
+	"encoding/json"
+	autocompleteUseCase "intern/internal/autocomplete/usecase"
+	"net/http"
+	"strconv"
+
+	"intern/pkg/logger"
+
+	"github.com/pkg/errors"
+)
+
+type AutocompleteHandler struct {
+	AutocompleteUseCase autocompleteUseCase.AutocompleteUseCaseI
+	Logger              logger.Logger
+}
+
+// Suggest godoc
+// @Summary      Autocomplete
+// @Description  Typo-tolerant type-ahead suggestions for movie titles and actor names
+// @Tags     autocomplete
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param q query string true "typed text"
+// @Param type query string false "movie or actor, both if omitted"
+// @Param limit query int false "number of suggestions"
+// @Success 200 {object} []models.Suggestion "success get suggestions"
+// @Failure 400 {object} nil "invalid query"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /autocomplete [get]
+func (ah *AutocompleteHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitString := r.FormValue("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil {
+			ah.Logger.Infow("fail to convert limit to int",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	suggestions, err := ah.AutocompleteUseCase.Suggest(r.FormValue("q"), r.FormValue("type"), limit)
+	if errors.Is(err, autocompleteUseCase.ErrUnknownType) {
+		ah.Logger.Infow("unknown suggestion type",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		ah.Logger.Errorw("can`t get suggestions",
+			"err:", err.Error())
+		http.Error(w, "can`t get suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(suggestions)
+
+	if err != nil {
+		ah.Logger.Errorw("can`t marshal suggestions",
+			"err:", err.Error())
+		http.Error(w, "can`t make suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ah.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
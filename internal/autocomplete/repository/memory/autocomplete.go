@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/autocomplete/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// similarityThreshold is the default pg_trgm.similarity_threshold.
+const similarityThreshold = 0.3
+
+type memAutocompleteRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.AutocompleteRepositoryI {
+	return &memAutocompleteRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+// The match classes of a candidate, in the order they are ranked.
+const (
+	matchExact = iota
+	matchPrefix
+	matchFuzzy
+)
+
+type candidate struct {
+	suggestion models.Suggestion
+	class      int
+}
+
+func (ar *memAutocompleteRepo) SuggestMovies(query string, limit int) ([]models.Suggestion, error) {
+	ar.DB.RLock()
+	candidates := make([]candidate, 0)
+	for _, m := range ar.DB.Movies {
+		class := classify(strings.EqualFold(m.Title, query), hasPrefix(m.Title, query))
+		if c, ok := match(m.ID, models.SuggestionMovie, m.Title, query, class); ok {
+			candidates = append(candidates, c)
+		}
+	}
+	ar.DB.RUnlock()
+
+	return top(candidates, limit), nil
+}
+
+func (ar *memAutocompleteRepo) SuggestActors(query string, limit int) ([]models.Suggestion, error) {
+	ar.DB.RLock()
+	candidates := make([]candidate, 0)
+	for _, a := range ar.DB.Actors {
+		name := a.FirstName + " " + a.LastName
+		class := classify(strings.EqualFold(name, query) || strings.EqualFold(a.FirstName, query) || strings.EqualFold(a.LastName, query),
+			hasPrefix(a.FirstName, query) || hasPrefix(a.LastName, query))
+		if c, ok := match(a.ID, models.SuggestionActor, name, query, class); ok {
+			candidates = append(candidates, c)
+		}
+	}
+	ar.DB.RUnlock()
+
+	return top(candidates, limit), nil
+}
+
+func classify(exact, prefix bool) int {
+	switch {
+	case exact:
+		return matchExact
+	case prefix:
+		return matchPrefix
+	default:
+		return matchFuzzy
+	}
+}
+
+func match(id int, suggestionType, text, query string, class int) (candidate, bool) {
+	score := similarity(text, query)
+	if class == matchFuzzy && score < similarityThreshold {
+		return candidate{}, false
+	}
+
+	return candidate{
+		suggestion: models.Suggestion{ID: id, Type: suggestionType, Text: text, Score: score},
+		class:      class,
+	}, true
+}
+
+// top mirrors the ORDER BY of the Postgres queries.
+func top(candidates []candidate, limit int) []models.Suggestion {
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.class, b.class), cmp.Compare(b.suggestion.Score, a.suggestion.Score),
+			cmp.Compare(a.suggestion.ID, b.suggestion.ID))
+	})
+
+	candidates = memdb.Page(candidates, limit, 0)
+
+	suggestions := make([]models.Suggestion, len(candidates))
+	for i := range candidates {
+		suggestions[i] = candidates[i].suggestion
+	}
+
+	return suggestions
+}
+
+func hasPrefix(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// similarity follows pg_trgm: the share of trigrams the two strings have in
+// common, where each lower-cased word is padded with two spaces in front and
+// one behind.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+
+	return set
+}
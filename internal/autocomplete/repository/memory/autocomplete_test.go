@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"intern/internal/memdb"
+	"intern/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, similarity("word", "WORD"))
+	assert.InDelta(t, 0.363636, similarity("word", "two words"), 1e-6)
+	assert.Equal(t, 0.0, similarity("abc", "xyz"))
+}
+
+func TestSuggestActorsToleratesTypos(t *testing.T) {
+	db := memdb.New()
+	db.Actors[1] = models.Actor{ID: 1, FirstName: "Arnold", LastName: "Schwarzenegger"}
+	db.Actors[2] = models.Actor{ID: 2, FirstName: "Sylvester", LastName: "Stallone"}
+	db.Actors[3] = models.Actor{ID: 3, FirstName: "Schwan", LastName: "Lee"}
+
+	repo := New(nil, db)
+
+	suggestions, err := repo.SuggestActors("Arnold Schwarzeneger", 5)
+	assert.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+	assert.Equal(t, 1, suggestions[0].ID)
+
+	suggestions, err = repo.SuggestActors("schw", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 1}, []int{suggestions[0].ID, suggestions[1].ID})
+}
+
+func TestSuggestMoviesRanksByMatchClass(t *testing.T) {
+	db := memdb.New()
+	db.Movies[1] = models.Movie{ID: 1, Title: "An Alien"}
+	db.Movies[2] = models.Movie{ID: 2, Title: "Alien Resurrection"}
+	db.Movies[3] = models.Movie{ID: 3, Title: "Aliens"}
+	db.Movies[4] = models.Movie{ID: 4, Title: "ALIEN"}
+
+	suggestions, err := New(nil, db).SuggestMovies("alien", 5)
+	assert.NoError(t, err)
+
+	// Exact, then prefix, then fuzzy, even though the fuzzy match is more
+	// similar than the prefix ones.
+	ids := make([]int, len(suggestions))
+	for i, s := range suggestions {
+		ids[i] = s.ID
+	}
+	assert.Equal(t, []int{4, 3, 2, 1}, ids)
+	assert.Greater(t, suggestions[3].Score, suggestions[1].Score)
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/autocomplete/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"intern/pkg/sqlutil"
+)
+
+// Suggestions are ranked by how they match, exact (ignoring case) first,
+// then prefix, then fuzzy (trigram), and by similarity within each class.
+// Both WHERE branches are served by the gin_trgm_ops indexes from migration
+// 0003; an exact match is a prefix one too.
+const suggestMoviesQuery = `SELECT id, 'movie' AS type, title AS text, similarity(title, @query) AS score
+FROM movies
+WHERE (title ILIKE @prefix OR title % @query) AND deleted_at IS NULL
+ORDER BY CASE WHEN lower(title) = lower(@query) THEN 0 WHEN title ILIKE @prefix THEN 1 ELSE 2 END, score DESC, id
+LIMIT @limit`
+
+// An actor matches exactly by the full name or either name alone.
+const suggestActorsQuery = `SELECT id, 'actor' AS type, first_name || ' ' || last_name AS text,
+	similarity(first_name || ' ' || last_name, @query) AS score
+FROM actors
+WHERE (first_name ILIKE @prefix OR last_name ILIKE @prefix OR (first_name || ' ' || last_name) % @query)
+	AND deleted_at IS NULL
+ORDER BY CASE
+		WHEN lower(@query) IN (lower(first_name || ' ' || last_name), lower(first_name), lower(last_name)) THEN 0
+		WHEN first_name ILIKE @prefix OR last_name ILIKE @prefix THEN 1
+		ELSE 2
+	END, score DESC, id
+LIMIT @limit`
+
+type pgAutocompleteRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.AutocompleteRepositoryI {
+	return &pgAutocompleteRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ar *pgAutocompleteRepo) SuggestMovies(query string, limit int) ([]models.Suggestion, error) {
+	suggestions, err := ar.suggest(suggestMoviesQuery, query, limit)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgAutocompleteRepo.SuggestMovies error")
+	}
+
+	return suggestions, nil
+}
+
+func (ar *pgAutocompleteRepo) SuggestActors(query string, limit int) ([]models.Suggestion, error) {
+	suggestions, err := ar.suggest(suggestActorsQuery, query, limit)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgAutocompleteRepo.SuggestActors error")
+	}
+
+	return suggestions, nil
+}
+
+func (ar *pgAutocompleteRepo) suggest(sql, query string, limit int) ([]models.Suggestion, error) {
+	var suggestions []models.Suggestion
+
+	tx := ar.DB.Raw(sql, map[string]interface{}{
+		"query":  query,
+		"prefix": sqlutil.EscapeLike(query) + "%",
+		"limit":  limit,
+	}).Scan(&suggestions)
+
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return suggestions, nil
+}
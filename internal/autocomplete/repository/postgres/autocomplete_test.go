@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	autocompleteRep "intern/internal/autocomplete/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+)
+
+type AutocompleteRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   autocompleteRep.AutocompleteRepositoryI
+}
+
+func TestAutocompleteRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(AutocompleteRepoTestSuite))
+}
+
+func (s *AutocompleteRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *AutocompleteRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *AutocompleteRepoTestSuite) TestSuggestMovies(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "type", "text", "score"}).
+		AddRow(1, "movie", "The Godfather", 0.5)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`WHERE (title ILIKE $2 OR title % $3) AND deleted_at IS NULL
+ORDER BY CASE WHEN lower(title) = lower($4) THEN 0 WHEN title ILIKE $5 THEN 1 ELSE 2 END, score DESC, id
+LIMIT $6`)).
+		WithArgs("godfater", "godfater%", "godfater", "godfater", "godfater%", 5).
+		WillReturnRows(rows)
+
+	suggestions, err := s.repo.SuggestMovies("godfater", 5)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.Suggestion{{ID: 1, Type: "movie", Text: "The Godfather", Score: 0.5}}, suggestions)
+}
+
+func (s *AutocompleteRepoTestSuite) TestSuggestActorsEscapesPrefix(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "type", "text", "score"})
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`FROM actors`)).
+		WithArgs("50%_", `50\%\_%`, `50\%\_%`, "50%_", "50%_", `50\%\_%`, `50\%\_%`, 10).
+		WillReturnRows(rows)
+
+	suggestions, err := s.repo.SuggestActors("50%_", 10)
+	t.Assert().NoError(err)
+	t.Assert().Empty(suggestions)
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "intern/models"
+
+type AutocompleteRepositoryI interface {
+	SuggestMovies(query string, limit int) ([]models.Suggestion, error)
+	SuggestActors(query string, limit int) ([]models.Suggestion, error)
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	autocompleteRep "intern/internal/autocomplete/repository"
+	"intern/models"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultLimit = 10
+	MaxLimit     = 25
+)
+
+var ErrUnknownType = errors.New("unknown suggestion type")
+
+type AutocompleteUseCaseI interface {
+	Suggest(query, suggestionType string, limit int) ([]models.Suggestion, error)
+}
+
+type autocompleteUseCase struct {
+	autocompleteRepository autocompleteRep.AutocompleteRepositoryI
+}
+
+func New(aRep autocompleteRep.AutocompleteRepositoryI) AutocompleteUseCaseI {
+	return &autocompleteUseCase{
+		autocompleteRepository: aRep,
+	}
+}
+
+// Suggest returns up to limit suggestions of the given type, or of both
+// types merged by score when suggestionType is empty.
+func (aUC *autocompleteUseCase) Suggest(query, suggestionType string, limit int) ([]models.Suggestion, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []models.Suggestion{}, nil
+	}
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	switch suggestionType {
+	case models.SuggestionMovie:
+		suggestions, err := aUC.autocompleteRepository.SuggestMovies(query, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "autocompleteUseCase.Suggest error")
+		}
+
+		return suggestions, nil
+	case models.SuggestionActor:
+		suggestions, err := aUC.autocompleteRepository.SuggestActors(query, limit)
+		if err != nil {
+			return nil, errors.Wrap(err, "autocompleteUseCase.Suggest error")
+		}
+
+		return suggestions, nil
+	case "":
+	default:
+		return nil, errors.Wrapf(ErrUnknownType, "autocompleteUseCase.Suggest error: %q", suggestionType)
+	}
+
+	movies, err := aUC.autocompleteRepository.SuggestMovies(query, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "autocompleteUseCase.Suggest error while suggesting movies")
+	}
+
+	actors, err := aUC.autocompleteRepository.SuggestActors(query, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "autocompleteUseCase.Suggest error while suggesting actors")
+	}
+
+	suggestions := append(movies, actors...)
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
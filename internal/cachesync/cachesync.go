@@ -0,0 +1,114 @@
+// Package cachesync follows the outbox to invalidate the cached movies and
+// actors that were written around the cached repositories: by the imdb
+// import, the trash purge or another instance sharing the database.
+package cachesync
+
+import (
+	"encoding/json"
+	actorRep "intern/internal/actor/repository"
+	cachedActor "intern/internal/actor/repository/cached"
+	eventRep "intern/internal/event/repository"
+	movieRep "intern/internal/movie/repository"
+	cachedMovie "intern/internal/movie/repository/cached"
+	"intern/models"
+	"intern/pkg/cache"
+	"intern/pkg/logger"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// batchSize is how many events Sync reads at a time.
+const batchSize = 500
+
+type Syncer struct {
+	events eventRep.EventRepositoryI
+	movies movieRep.MovieRepositoryI
+	actors actorRep.ActorRepositoryI
+	cache  *cache.Cache
+	logger logger.Logger
+	// lastID is the last event handled. After holds back the events that
+	// one not committed yet may precede, following the ids skips none.
+	lastID int
+}
+
+// New starts after the latest event, what was cached before expires. The
+// casts of the movies and actors invalidated are read from movies and
+// actors, which must not be cached.
+func New(events eventRep.EventRepositoryI, movies movieRep.MovieRepositoryI, actors actorRep.ActorRepositoryI,
+	c *cache.Cache, logger logger.Logger) (*Syncer, error) {
+	lastID, err := events.LastID()
+	if err != nil {
+		return nil, errors.Wrap(err, "cachesync.New error")
+	}
+
+	return &Syncer{
+		events: events,
+		movies: movies,
+		actors: actors,
+		cache:  c,
+		logger: logger,
+		lastID: lastID,
+	}, nil
+}
+
+// Sync invalidates what the events since the previous call changed and
+// returns how many there were. It must not run concurrently.
+func (s *Syncer) Sync() (int, error) {
+	n := 0
+
+	for {
+		events, err := s.events.After(s.lastID, nil, batchSize)
+		if err != nil {
+			return n, errors.Wrap(err, "Syncer.Sync error")
+		}
+
+		for _, e := range events {
+			s.invalidate(e)
+			s.lastID = e.ID
+		}
+		n += len(events)
+
+		if len(events) < batchSize {
+			return n, nil
+		}
+	}
+}
+
+func (s *Syncer) invalidate(e models.Event) {
+	var err error
+
+	switch entity, _, _ := strings.Cut(e.Type, "."); entity {
+	case models.EventEntityMovie:
+		var p models.EntityEvent
+		if err = json.Unmarshal(e.Payload, &p); err == nil {
+			cachedMovie.Invalidate(s.logger, s.movies, s.cache, p.ID)
+		}
+	case models.EventEntityActor:
+		var p models.EntityEvent
+		if err = json.Unmarshal(e.Payload, &p); err == nil {
+			cachedActor.Invalidate(s.logger, s.actors, s.cache, p.ID)
+		}
+	case models.EventEntityCast:
+		var p models.CastEvent
+		if err = json.Unmarshal(e.Payload, &p); err == nil {
+			s.cache.Delete(cache.MovieActorsKey(p.MovieID), cache.ActorMoviesKey(p.ActorID))
+		}
+	case models.EventEntityCatalogue:
+		var p models.BulkEvent
+		if err = json.Unmarshal(e.Payload, &p); err == nil {
+			for _, id := range p.Movies {
+				cachedMovie.Invalidate(s.logger, s.movies, s.cache, id)
+			}
+			for _, id := range p.Actors {
+				cachedActor.Invalidate(s.logger, s.actors, s.cache, id)
+			}
+		}
+	}
+
+	if err != nil {
+		s.logger.Errorw("can`t read event to invalidate",
+			"event", e.ID,
+			"err:", err.Error())
+	}
+}
@@ -0,0 +1,155 @@
+package cachesync
+
+import (
+	memActor "intern/internal/actor/repository/memory"
+	eventRep "intern/internal/event/repository"
+	memEvent "intern/internal/event/repository/memory"
+	"intern/internal/memdb"
+	movieRep "intern/internal/movie/repository"
+	cachedMovie "intern/internal/movie/repository/cached"
+	memMovie "intern/internal/movie/repository/memory"
+	"intern/models"
+	"intern/pkg/cache"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSync(t *testing.T) {
+	db := memdb.New()
+	c := cache.New(cache.NewLRU(100), time.Minute, nil)
+	movies, actors := memMovie.New(nil, db), memActor.New(nil, db)
+	cached := cachedMovie.New(nil, movies, c)
+
+	movie := &models.Movie{Title: "Alien"}
+	require.NoError(t, movies.Create(movie))
+	actor := &models.Actor{FirstName: "Sigourney"}
+	require.NoError(t, actors.Create(actor))
+
+	syncer, err := New(memEvent.New(nil, db), movies, actors, c, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	_, err = cached.Get(movie.ID)
+	require.NoError(t, err)
+	_, err = cached.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+
+	// Written around the cache, as by another instance.
+	require.NoError(t, movies.Update(&models.Movie{ID: movie.ID, Title: "Aliens"}))
+	db.MoviesActors[1] = models.MovieActor{ID: 1, MovieID: movie.ID, ActorID: actor.ID}
+	db.AddCastEvent(movie.ID, actor.ID, models.CastAdded)
+
+	m, err := cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alien", m.Title)
+
+	// The creates came before the syncer.
+	n, err := syncer.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	m, err = cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aliens", m.Title)
+	cast, err := cached.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	assert.Len(t, cast, 1)
+
+	n, err = syncer.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestSyncBulkLoad(t *testing.T) {
+	db := memdb.New()
+	c := cache.New(cache.NewLRU(100), time.Minute, nil)
+	movies, actors := memMovie.New(nil, db), memActor.New(nil, db)
+	cached := cachedMovie.New(nil, movies, c)
+
+	movie := &models.Movie{Title: "Alien"}
+	require.NoError(t, movies.Create(movie))
+
+	syncer, err := New(memEvent.New(nil, db), movies, actors, c, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	_, err = cached.Get(movie.ID)
+	require.NoError(t, err)
+
+	// An import around the cache, with one event for its rows.
+	err = movies.Bulk(models.BulkSourceImport, func(movies movieRep.MovieRepositoryI) ([]int, error) {
+		_, err := movies.Upsert(&models.Movie{Title: "Alien", Description: "Director's cut"})
+		return []int{movie.ID}, err
+	})
+	require.NoError(t, err)
+
+	n, err := syncer.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	m, err := cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Director's cut", m.Description)
+}
+
+// inProgress is an outbox in which the events from id on belong to a
+// transaction in progress: After holds them back, with the ones after.
+type inProgress struct {
+	eventRep.EventRepositoryI
+	id int
+}
+
+func (r *inProgress) After(afterID int, entities []string, limit int) ([]models.Event, error) {
+	events, err := r.EventRepositoryI.After(afterID, entities, limit)
+	for i, e := range events {
+		if r.id != 0 && e.ID >= r.id {
+			return events[:i], err
+		}
+	}
+
+	return events, err
+}
+
+func TestSyncInterleavedTransactions(t *testing.T) {
+	db := memdb.New()
+	c := cache.New(cache.NewLRU(100), time.Minute, nil)
+	movies, actors := memMovie.New(nil, db), memActor.New(nil, db)
+	cached := cachedMovie.New(nil, movies, c)
+
+	alien, aliens := &models.Movie{Title: "Alien"}, &models.Movie{Title: "Aliens"}
+	require.NoError(t, movies.Create(alien))
+	require.NoError(t, movies.Create(aliens))
+
+	events := &inProgress{EventRepositoryI: memEvent.New(nil, db)}
+	syncer, err := New(events, movies, actors, c, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	for _, m := range []*models.Movie{alien, aliens} {
+		_, err = cached.Get(m.ID)
+		require.NoError(t, err)
+	}
+
+	// A writes the first event, B the second and commits first.
+	events.id = len(db.Events) + 1
+	require.NoError(t, movies.Update(&models.Movie{ID: alien.ID, Rating: 8}))
+	require.NoError(t, movies.Update(&models.Movie{ID: aliens.ID, Rating: 9}))
+
+	n, err := syncer.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// A commits.
+	events.id = 0
+	n, err = syncer.Sync()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	m, err := cached.Get(alien.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, m.Rating)
+	m, err = cached.Get(aliens.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 9, m.Rating)
+}
@@ -0,0 +1,94 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	castGraphUseCase "intern/internal/castgraph/usecase"
+	"intern/pkg/logger"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type CastGraphHandler struct {
+	CastGraphUseCase castGraphUseCase.CastGraphUseCaseI
+	Logger           logger.Logger
+}
+
+// Path godoc
+// @Summary      Degrees of separation
+// @Description  A shortest chain of actors linking the two actors, each pair of neighbours playing in a movie together.
+// @Description  Movies[i] is the movie shared by Actors[i] and Actors[i+1]; degrees is the number of movies.
+// @Tags     actors
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "ACT_ID"
+// @Param other path int true "OTHER_ID"
+// @Success 200 {object} models.CastPath "success get path"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Actor not found or actors are not connected"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /graph/path/{id}/{other} [get]
+func (gh *CastGraphHandler) Path(w http.ResponseWriter, r *http.Request) {
+	var ids [2]int
+
+	for i, name := range []string{"ACT_ID", "OTHER_ID"} {
+		idString := r.PathValue(name)
+		if idString == "" {
+			gh.Logger.Errorw("no " + name + " var")
+			http.Error(w, "unknown error", http.StatusInternalServerError)
+			return
+		}
+
+		id, err := strconv.Atoi(idString)
+		if err != nil {
+			gh.Logger.Errorw("fail to convert id to int",
+				"err:", err.Error())
+			http.Error(w, "unknown error", http.StatusInternalServerError)
+			return
+		}
+
+		ids[i] = id
+	}
+
+	path, err := gh.CastGraphUseCase.Path(ids[0], ids[1])
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		gh.Logger.Infow("can`t get path",
+			"err:", err.Error())
+		http.Error(w, "can`t get actor", http.StatusNotFound)
+		return
+	case errors.Is(err, castGraphUseCase.ErrNoPath):
+		gh.Logger.Infow("can`t get path",
+			"err:", err.Error())
+		http.Error(w, "actors are not connected", http.StatusNotFound)
+		return
+	case err != nil:
+		gh.Logger.Errorw("can`t get path",
+			"err:", err.Error())
+		http.Error(w, "can`t get path", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(path)
+
+	if err != nil {
+		gh.Logger.Errorw("can`t marshal path",
+			"err:", err.Error())
+		http.Error(w, "can`t make path", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		gh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"intern/internal/castgraph/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+type memCastGraphRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.CastGraphRepositoryI {
+	return &memCastGraphRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (gr *memCastGraphRepo) Version() (int64, error) {
+	gr.DB.RLock()
+	defer gr.DB.RUnlock()
+
+	return gr.DB.CastVersion, nil
+}
+
+func (gr *memCastGraphRepo) Credits() (int64, []models.MovieActor, error) {
+	gr.DB.RLock()
+	defer gr.DB.RUnlock()
+
+	credits := make([]models.MovieActor, 0, len(gr.DB.MoviesActors))
+	for _, ma := range gr.DB.MoviesActors {
+		_, okMovie := gr.DB.Movies[ma.MovieID]
+		_, okActor := gr.DB.Actors[ma.ActorID]
+		if !okMovie || !okActor {
+			continue
+		}
+
+		credits = append(credits, models.MovieActor{MovieID: ma.MovieID, ActorID: ma.ActorID})
+	}
+
+	return gr.DB.CastVersion, credits, nil
+}
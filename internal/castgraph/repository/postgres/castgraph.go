@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/castgraph/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+type pgCastGraphRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.CastGraphRepositoryI {
+	return &pgCastGraphRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+// Version reads the counter the trigger of migration 0012 bumps on every
+// statement that changes movies_actors.
+func (gr *pgCastGraphRepo) Version() (int64, error) {
+	var version int64
+	tx := gr.DB.Raw("SELECT version FROM cast_version").Scan(&version)
+
+	if tx.Error != nil {
+		return 0, errors.Wrap(tx.Error, "pgCastGraphRepo.Version error")
+	}
+
+	return version, nil
+}
+
+// Credits reads the version and the links from one snapshot, so a change
+// committed in between can not be missed. Links of the trash are left out,
+// moving a row in or out of it bumps the version too (migration 0014).
+func (gr *pgCastGraphRepo) Credits() (int64, []models.MovieActor, error) {
+	var version int64
+	credits := []models.MovieActor{}
+
+	err := gr.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT version FROM cast_version").Scan(&version).Error; err != nil {
+			return err
+		}
+
+		return tx.Table("movies_actors ma").Select("ma.movie_id, ma.actor_id").
+			Joins("JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL").
+			Joins("JOIN actors a ON a.id = ma.actor_id AND a.deleted_at IS NULL").
+			Find(&credits).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "pgCastGraphRepo.Credits error")
+	}
+
+	return version, credits, nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	castGraphRep "intern/internal/castgraph/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+)
+
+type CastGraphRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   castGraphRep.CastGraphRepositoryI
+}
+
+func TestCastGraphRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(CastGraphRepoTestSuite))
+}
+
+func (s *CastGraphRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *CastGraphRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *CastGraphRepoTestSuite) TestVersion(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM cast_version`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(7))
+
+	version, err := s.repo.Version()
+	t.Assert().NoError(err)
+	t.Assert().Equal(int64(7), version)
+}
+
+func (s *CastGraphRepoTestSuite) TestCredits(t provider.T) {
+	s.mock.ExpectBegin()
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT version FROM cast_version`)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(7))
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT ma.movie_id, ma.actor_id FROM movies_actors ma JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL JOIN actors a ON a.id = ma.actor_id AND a.deleted_at IS NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"movie_id", "actor_id"}).
+			AddRow(1, 2).
+			AddRow(1, 3))
+
+	s.mock.ExpectCommit()
+
+	version, credits, err := s.repo.Credits()
+	t.Assert().NoError(err)
+	t.Assert().Equal(int64(7), version)
+	t.Assert().Equal([]models.MovieActor{{MovieID: 1, ActorID: 2}, {MovieID: 1, ActorID: 3}}, credits)
+}
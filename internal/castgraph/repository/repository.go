@@ -0,0 +1,11 @@
+package repository
+
+import "intern/models"
+
+// CastGraphRepositoryI reads the cast links the actor graph is built from.
+type CastGraphRepositoryI interface {
+	// Version changes whenever the cast links do.
+	Version() (int64, error)
+	// Credits returns every cast link with the version they belong to.
+	Credits() (int64, []models.MovieActor, error)
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"cmp"
+	actorRep "intern/internal/actor/repository"
+	castGraphRep "intern/internal/castgraph/repository"
+	movieRep "intern/internal/movie/repository"
+	"intern/models"
+	"slices"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type CastGraphUseCaseI interface {
+	// Path returns a shortest chain of actor→movie→actor links between two
+	// actors, the "Bacon number" being its Degrees.
+	Path(fromID, toID int) (*models.CastPath, error)
+}
+
+var ErrNoPath = errors.New("actors are not connected")
+
+type castGraphUseCase struct {
+	castGraphRepository castGraphRep.CastGraphRepositoryI
+	actorRepository     actorRep.ActorRepositoryI
+	movieRepository     movieRep.MovieRepositoryI
+
+	// mu guards index; a built index is never changed, only replaced.
+	mu    sync.Mutex
+	index *index
+}
+
+func New(gRep castGraphRep.CastGraphRepositoryI, aRep actorRep.ActorRepositoryI, mRep movieRep.MovieRepositoryI) CastGraphUseCaseI {
+	return &castGraphUseCase{
+		castGraphRepository: gRep,
+		actorRepository:     aRep,
+		movieRepository:     mRep,
+	}
+}
+
+func (gUC *castGraphUseCase) Path(fromID, toID int) (*models.CastPath, error) {
+	for _, id := range []int{fromID, toID} {
+		_, err := gUC.actorRepository.Get(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "castGraphUseCase.Path error: Actor %d not found", id)
+		}
+	}
+
+	idx, err := gUC.current()
+	if err != nil {
+		return nil, errors.Wrap(err, "castGraphUseCase.Path error")
+	}
+
+	actorIDs, movieIDs, ok := idx.search(fromID, toID)
+	if !ok {
+		return nil, errors.Wrapf(ErrNoPath, "castGraphUseCase.Path error: %d and %d", fromID, toID)
+	}
+
+	path := &models.CastPath{
+		Degrees: len(movieIDs),
+		Actors:  make([]models.Actor, 0, len(actorIDs)),
+		Movies:  make([]models.Movie, 0, len(movieIDs)),
+	}
+
+	for _, id := range actorIDs {
+		a, err := gUC.actorRepository.Get(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "castGraphUseCase.Path error: can't get actor %d", id)
+		}
+		path.Actors = append(path.Actors, *a)
+	}
+
+	for _, id := range movieIDs {
+		m, err := gUC.movieRepository.Get(id)
+		if err != nil {
+			return nil, errors.Wrapf(err, "castGraphUseCase.Path error: can't get movie %d", id)
+		}
+		path.Movies = append(path.Movies, *m)
+	}
+
+	return path, nil
+}
+
+// current returns the index of the stored cast links, rebuilding it when
+// they changed since the last build. Concurrent callers wait for a single
+// rebuild.
+func (gUC *castGraphUseCase) current() (*index, error) {
+	version, err := gUC.castGraphRepository.Version()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get cast version")
+	}
+
+	gUC.mu.Lock()
+	defer gUC.mu.Unlock()
+
+	if gUC.index != nil && gUC.index.version == version {
+		return gUC.index, nil
+	}
+
+	version, credits, err := gUC.castGraphRepository.Credits()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get credits")
+	}
+
+	gUC.index = newIndex(version, credits)
+
+	return gUC.index, nil
+}
+
+// index is the bipartite actor-movie graph as adjacency lists both ways.
+// The lists are sorted, so searches return the same path on every build.
+type index struct {
+	version  int64
+	moviesOf map[int][]int
+	castOf   map[int][]int
+}
+
+func newIndex(version int64, credits []models.MovieActor) *index {
+	idx := &index{
+		version:  version,
+		moviesOf: make(map[int][]int),
+		castOf:   make(map[int][]int),
+	}
+
+	for _, ma := range credits {
+		idx.moviesOf[ma.ActorID] = append(idx.moviesOf[ma.ActorID], ma.MovieID)
+		idx.castOf[ma.MovieID] = append(idx.castOf[ma.MovieID], ma.ActorID)
+	}
+
+	for _, lists := range []map[int][]int{idx.moviesOf, idx.castOf} {
+		for id, list := range lists {
+			slices.Sort(list)
+			lists[id] = slices.Compact(list)
+		}
+	}
+
+	return idx
+}
+
+// side is the state of the search from one end: how far every reached
+// actor is, and the actor and movie it was reached through.
+type side struct {
+	dist     map[int]int
+	prev     map[int][2]int
+	frontier []int
+}
+
+func newSide(start int) *side {
+	return &side{
+		dist:     map[int]int{start: 0},
+		prev:     map[int][2]int{},
+		frontier: []int{start},
+	}
+}
+
+// search is a bidirectional breadth-first search over actors, two actors
+// being adjacent when they share a movie. It grows the smaller frontier
+// one level at a time; the first level that reaches the other side holds
+// a shortest path, the best meeting point of that level is taken.
+func (idx *index) search(fromID, toID int) ([]int, []int, bool) {
+	if fromID == toID {
+		return []int{fromID}, nil, true
+	}
+
+	forward, backward := newSide(fromID), newSide(toID)
+
+	for len(forward.frontier) > 0 && len(backward.frontier) > 0 {
+		grow, other := forward, backward
+		if len(backward.frontier) < len(forward.frontier) {
+			grow, other = backward, forward
+		}
+
+		meet, best := 0, -1
+		next := []int{}
+
+		for _, actorID := range grow.frontier {
+			for _, movieID := range idx.moviesOf[actorID] {
+				for _, costarID := range idx.castOf[movieID] {
+					if _, ok := grow.dist[costarID]; ok {
+						continue
+					}
+
+					grow.dist[costarID] = grow.dist[actorID] + 1
+					grow.prev[costarID] = [2]int{actorID, movieID}
+					next = append(next, costarID)
+
+					if d, ok := other.dist[costarID]; ok && (best < 0 || grow.dist[costarID]+d < best) {
+						meet, best = costarID, grow.dist[costarID]+d
+					}
+				}
+			}
+		}
+
+		if best >= 0 {
+			actorIDs, movieIDs := forward.walk(meet)
+			slices.Reverse(actorIDs)
+			slices.Reverse(movieIDs)
+
+			tailActors, tailMovies := backward.walk(meet)
+			return append(actorIDs, tailActors[1:]...), append(movieIDs, tailMovies...), true
+		}
+
+		slices.SortFunc(next, cmp.Compare[int])
+		grow.frontier = next
+	}
+
+	return nil, nil, false
+}
+
+// walk follows the links from actorID back to the start of the side and
+// returns the actors and movies on the way, actorID first.
+func (s *side) walk(actorID int) ([]int, []int) {
+	actorIDs := []int{actorID}
+	movieIDs := []int{}
+
+	for {
+		link, ok := s.prev[actorID]
+		if !ok {
+			return actorIDs, movieIDs
+		}
+
+		actorID = link[0]
+		actorIDs = append(actorIDs, actorID)
+		movieIDs = append(movieIDs, link[1])
+	}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	memActor "intern/internal/actor/repository/memory"
+	memCastGraph "intern/internal/castgraph/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	"intern/models"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newUseCase stores a chain of actors 1 - 2 - 3 - 4 linked by movies 1, 2
+// and 3, a shortcut from 1 to 3 through movie 4 and actor 5 who shares
+// no movie with anyone.
+func newUseCase() (CastGraphUseCaseI, *memdb.DB) {
+	db := memdb.New()
+
+	for id := 1; id <= 5; id++ {
+		db.Actors[id] = models.Actor{ID: id, FirstName: "Actor", LastName: string(rune('A' + id - 1))}
+		db.SeenID("actors", id)
+	}
+
+	for id := 1; id <= 5; id++ {
+		db.Movies[id] = models.Movie{ID: id, Title: "Movie " + string(rune('A'+id-1))}
+		db.SeenID("movies", id)
+	}
+
+	links := [][2]int{{1, 1}, {1, 2}, {2, 2}, {2, 3}, {3, 3}, {3, 4}, {4, 1}, {4, 3}, {5, 5}}
+	for i, link := range links {
+		db.MoviesActors[i+1] = models.MovieActor{ID: i + 1, MovieID: link[0], ActorID: link[1]}
+	}
+
+	return New(memCastGraph.New(nil, db), memActor.New(nil, db), memMovie.New(nil, db)), db
+}
+
+func actorIDs(path *models.CastPath) []int {
+	ids := make([]int, len(path.Actors))
+	for i, a := range path.Actors {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func movieIDs(path *models.CastPath) []int {
+	ids := make([]int, len(path.Movies))
+	for i, m := range path.Movies {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestPath(t *testing.T) {
+	uc, _ := newUseCase()
+
+	path, err := uc.Path(1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 2, path.Degrees)
+	assert.Equal(t, []int{1, 3, 4}, actorIDs(path))
+	assert.Equal(t, []int{4, 3}, movieIDs(path))
+	assert.Equal(t, "Movie D", path.Movies[0].Title)
+
+	path, err = uc.Path(4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, path.Degrees)
+	assert.Equal(t, []int{4, 3, 2}, actorIDs(path))
+	assert.Equal(t, []int{3, 2}, movieIDs(path))
+
+	path, err = uc.Path(2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 0, path.Degrees)
+	assert.Equal(t, []int{2}, actorIDs(path))
+	assert.Empty(t, path.Movies)
+}
+
+func TestPathNotFound(t *testing.T) {
+	uc, _ := newUseCase()
+
+	_, err := uc.Path(1, 5)
+	assert.True(t, errors.Is(err, ErrNoPath), err)
+
+	_, err = uc.Path(1, 42)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	_, err = uc.Path(42, 1)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+func TestPathRefresh(t *testing.T) {
+	uc, db := newUseCase()
+
+	_, err := uc.Path(1, 5)
+	require.True(t, errors.Is(err, ErrNoPath), err)
+
+	// Links stored without a new version are not seen.
+	db.MoviesActors[10] = models.MovieActor{ID: 10, MovieID: 5, ActorID: 4}
+
+	_, err = uc.Path(1, 5)
+	require.True(t, errors.Is(err, ErrNoPath), err)
+
+	db.CastVersion++
+
+	path, err := uc.Path(1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 3, path.Degrees)
+	assert.Equal(t, []int{1, 3, 4, 5}, actorIDs(path))
+	assert.Equal(t, []int{4, 3, 5}, movieIDs(path))
+}
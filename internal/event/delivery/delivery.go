@@ -0,0 +1,172 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	eventUseCase "intern/internal/event/usecase"
+	"intern/pkg/logger"
+
+	"github.com/pkg/errors"
+)
+
+// streamBatch is the most events a stream reads at once.
+const streamBatch = 100
+
+type EventHandler struct {
+	EventUseCase eventUseCase.EventUseCaseI
+	Logger       logger.Logger
+	// PollInterval is how often a stream looks for new events. Heartbeat is
+	// how often it sends a comment, so that proxies keep it open.
+	PollInterval time.Duration
+	Heartbeat    time.Duration
+	// WriteTimeout bounds each write of a stream. The WriteTimeout of the
+	// server would end the stream itself, so every write moves it on.
+	WriteTimeout time.Duration
+}
+
+// Stream godoc
+// @Summary      Stream of catalogue changes
+// @Description  Server-Sent Events of the changes of movies, actors and casts, as webhooks receive them: the event
+// @Description  name is the type and the data the models.Event. The stream starts with the changes made after it is
+// @Description  opened, or after Last-Event-ID when reconnecting. Comments are sent as heartbeats. A bulk load (seed,
+// @Description  IMDb, import) sends one catalogue event for all its rows.
+// @Tags     events
+// @Produce  text/event-stream
+// @Param    Authorization header string true "token"
+// @Param    Last-Event-ID header int false "id of the last event received"
+// @Param entity query string false "comma-separated movie, actor, cast and catalogue, every entity if empty"
+// @Success 200 {object} models.Event "stream of events"
+// @Failure 400 {object} nil "invalid entity or Last-Event-ID"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /events/stream [get]
+func (eh *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var entities []string
+	if entity := r.FormValue("entity"); entity != "" {
+		entities = strings.Split(entity, ",")
+	}
+
+	var lastID int
+	var err error
+
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		lastID, err = strconv.Atoi(lastEventID)
+		if err != nil {
+			eh.Logger.Infow("can`t parse Last-Event-ID",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	} else {
+		lastID, err = eh.EventUseCase.LastID()
+		if err != nil {
+			eh.Logger.Errorw("can`t get last event",
+				"err:", err.Error())
+			http.Error(w, "can`t get events", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	events, err := eh.EventUseCase.After(lastID, entities, streamBatch)
+	switch {
+	case errors.Is(err, eventUseCase.ErrInvalidFilter):
+		eh.Logger.Infow("can`t get events",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case err != nil:
+		eh.Logger.Errorw("can`t get events",
+			"err:", err.Error())
+		http.Error(w, "can`t get events", http.StatusInternalServerError)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keeps nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err = eh.write(w, rc, ": connected\n\n")
+	if err != nil {
+		eh.Logger.Infow("can`t write event stream",
+			"err:", err.Error())
+		return
+	}
+
+	poll := time.NewTicker(eh.PollInterval)
+	defer poll.Stop()
+
+	heartbeat := time.NewTicker(eh.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				eh.Logger.Errorw("can`t marshal event",
+					"err:", err.Error())
+				return
+			}
+
+			err = eh.write(w, rc, fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data))
+			if err != nil {
+				eh.Logger.Infow("can`t write event stream",
+					"err:", err.Error())
+				return
+			}
+
+			lastID = e.ID
+		}
+
+		// A full batch may have more behind it.
+		if len(events) < streamBatch {
+			events = nil
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				err = eh.write(w, rc, ": heartbeat\n\n")
+				if err != nil {
+					eh.Logger.Infow("can`t write event stream",
+						"err:", err.Error())
+					return
+				}
+				continue
+			case <-poll.C:
+			}
+		}
+
+		events, err = eh.EventUseCase.After(lastID, entities, streamBatch)
+		if err != nil {
+			// The client reconnects with the last id it got.
+			eh.Logger.Errorw("can`t get events",
+				"err:", err.Error())
+			return
+		}
+	}
+}
+
+func (eh *EventHandler) write(w http.ResponseWriter, rc *http.ResponseController, chunk string) error {
+	err := rc.SetWriteDeadline(time.Now().Add(eh.WriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	_, err = io.WriteString(w, chunk)
+	if err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
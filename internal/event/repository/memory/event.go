@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"intern/internal/event/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type memEventRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.EventRepositoryI {
+	return &memEventRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (er *memEventRepo) After(afterID int, entities []string, limit int) ([]models.Event, error) {
+	er.DB.RLock()
+	defer er.DB.RUnlock()
+
+	// The outbox is in id order.
+	i := sort.Search(len(er.DB.Events), func(i int) bool {
+		return er.DB.Events[i].ID > afterID
+	})
+
+	events := []models.Event{}
+	for _, e := range er.DB.Events[i:] {
+		if limit > 0 && len(events) == limit {
+			break
+		}
+
+		entity, _, _ := strings.Cut(e.Type, ".")
+		if len(entities) > 0 && !slices.Contains(entities, entity) {
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+func (er *memEventRepo) LastID() (int, error) {
+	er.DB.RLock()
+	defer er.DB.RUnlock()
+
+	if len(er.DB.Events) == 0 {
+		return 0, nil
+	}
+
+	return er.DB.Events[len(er.DB.Events)-1].ID, nil
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/models"
+	"slices"
+)
+
+// bulkLoadQuery turns the outbox triggers off for the rest of the
+// transaction, see migrations/0023_outbox_bulk_load.
+const bulkLoadQuery = `SET LOCAL intern.bulk_load = on`
+
+const addEventQuery = `INSERT INTO outbox_events (type, payload) VALUES (?, ?)`
+
+// BulkLoad runs fn in a transaction of db whose writes add no event each to
+// the outbox. fn lists the movies and actors it writes in e instead, which
+// is written as the one event of them all unless it lists none.
+func BulkLoad(db *gorm.DB, source string, fn func(tx *gorm.DB, e *models.BulkEvent) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(bulkLoadQuery).Error; err != nil {
+			return errors.Wrap(err, "can`t start bulk load")
+		}
+
+		e := models.BulkEvent{Source: source, Movies: []int{}, Actors: []int{}}
+		if err := fn(tx, &e); err != nil {
+			return err
+		}
+
+		if len(e.Movies) == 0 && len(e.Actors) == 0 {
+			return nil
+		}
+
+		slices.Sort(e.Movies)
+		e.Movies = slices.Compact(e.Movies)
+		slices.Sort(e.Actors)
+		e.Actors = slices.Compact(e.Actors)
+
+		// The payload is a plain struct, marshalling it does not fail.
+		payload, _ := json.Marshal(e)
+
+		if err := tx.Exec(addEventQuery, models.EventCatalogueLoaded, string(payload)).Error; err != nil {
+			return errors.Wrap(err, "can`t add bulk load event")
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/event/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+// unsettledQuery finds the first event after an id that a transaction in
+// progress may still get ahead of, see the horizon of outbox_events.
+const unsettledQuery = `SELECT MIN(id) FROM outbox_events WHERE id > ? AND horizon > pg_snapshot_xmin(pg_current_snapshot())`
+
+const lastIDQuery = `SELECT COALESCE(
+	(SELECT MIN(id) - 1 FROM outbox_events WHERE horizon > pg_snapshot_xmin(pg_current_snapshot())),
+	(SELECT MAX(id) FROM outbox_events),
+	0)`
+
+type pgEventRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.EventRepositoryI {
+	return &pgEventRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+// After reads up to the first unsettled event. Settled events stay so, the
+// events are read in a snapshot of their own.
+func (er *pgEventRepo) After(afterID int, entities []string, limit int) ([]models.Event, error) {
+	var unsettled sql.NullInt64
+	tx := er.DB.Raw(unsettledQuery, afterID).Scan(&unsettled)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgEventRepo.After error")
+	}
+
+	events := []models.Event{}
+	tx = er.DB.Table("outbox_events").Select("id, type, payload, created_at").Where("id > ?", afterID)
+
+	if unsettled.Valid {
+		tx = tx.Where("id < ?", unsettled.Int64)
+	}
+
+	if len(entities) > 0 {
+		tx = tx.Where("split_part(type, '.', 1) IN ?", entities)
+	}
+
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+
+	tx = tx.Order("id").Find(&events)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgEventRepo.After error")
+	}
+
+	return events, nil
+}
+
+func (er *pgEventRepo) LastID() (int, error) {
+	var id int
+	tx := er.DB.Raw(lastIDQuery).Scan(&id)
+
+	if tx.Error != nil {
+		return 0, errors.Wrap(tx.Error, "pgEventRepo.LastID error")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	eventRep "intern/internal/event/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type EventRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   eventRep.EventRepositoryI
+}
+
+func TestEventRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(EventRepoTestSuite))
+}
+
+func (s *EventRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *EventRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *EventRepoTestSuite) expectUnsettled(afterID int, unsettled interface{}) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT MIN(id) FROM outbox_events WHERE id > $1 AND horizon > pg_snapshot_xmin(pg_current_snapshot())`)).
+		WithArgs(afterID).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(unsettled))
+}
+
+func (s *EventRepoTestSuite) TestAfter(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.expectUnsettled(7, nil)
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT id, type, payload, created_at FROM "outbox_events" WHERE id > $1 AND split_part(type, '.', 1) IN ($2,$3) ORDER BY id LIMIT $4`)).
+		WithArgs(7, models.EventEntityMovie, models.EventEntityCast, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "payload", "created_at"}).
+			AddRow(8, models.EventMovieCreated, []byte(`{"id":1}`), created))
+
+	events, err := s.repo.After(7, []string{models.EventEntityMovie, models.EventEntityCast}, 100)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.Event{{ID: 8, Type: models.EventMovieCreated, Payload: []byte(`{"id":1}`), CreatedAt: created}}, events)
+}
+
+// Transaction A writes event 8, then B writes event 9 and commits first.
+// While A is in progress, 9 is held back with it.
+func (s *EventRepoTestSuite) TestAfterInterleavedTransactions(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta(`SELECT id, type, payload, created_at FROM "outbox_events" WHERE id > $1 AND id < $2 ORDER BY id LIMIT $3`)
+	rows := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"id", "type", "payload", "created_at"}) }
+
+	// 9 is visible, its horizon is above A.
+	s.expectUnsettled(7, 9)
+	s.mock.ExpectQuery(query).WithArgs(7, 9, 100).WillReturnRows(rows())
+
+	events, err := s.repo.After(7, nil, 100)
+	t.Assert().NoError(err)
+	t.Assert().Empty(events)
+
+	// A has committed.
+	s.expectUnsettled(7, nil)
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, type, payload, created_at FROM "outbox_events" WHERE id > $1 ORDER BY id LIMIT $2`)).
+		WithArgs(7, 100).
+		WillReturnRows(rows().
+			AddRow(8, models.EventMovieUpdated, []byte(`{"id":1}`), created).
+			AddRow(9, models.EventActorUpdated, []byte(`{"id":2}`), created))
+
+	events, err = s.repo.After(7, nil, 100)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]int{8, 9}, []int{events[0].ID, events[1].ID})
+}
+
+func (s *EventRepoTestSuite) TestLastID(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(lastIDQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(42))
+
+	id, err := s.repo.LastID()
+	t.Assert().NoError(err)
+	t.Assert().Equal(42, id)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "intern/models"
+
+// EventRepositoryI reads the outbox, see models.Event.
+type EventRepositoryI interface {
+	// After returns up to limit events with an id above afterID, oldest
+	// first, of the given entities unless empty. It stops before an event
+	// that may still be preceded by one not committed yet, so that a reader
+	// following the ids misses none.
+	After(afterID int, entities []string, limit int) ([]models.Event, error)
+	// LastID returns the id of the latest event After would return, 0 if
+	// there is none.
+	LastID() (int, error)
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	eventRep "intern/internal/event/repository"
+	"intern/models"
+	"slices"
+)
+
+type EventUseCaseI interface {
+	// After returns up to limit events after the given id, oldest first, of
+	// the given entities unless empty. No event committed later can come
+	// before the ones returned.
+	After(afterID int, entities []string, limit int) ([]models.Event, error)
+	// LastID returns the id of the latest event After would return, 0 if
+	// there is none.
+	LastID() (int, error)
+}
+
+var ErrInvalidFilter = errors.New("invalid event filter")
+
+var entities = []string{models.EventEntityMovie, models.EventEntityActor, models.EventEntityCast, models.EventEntityCatalogue}
+
+type eventUseCase struct {
+	eventRepository eventRep.EventRepositoryI
+}
+
+func New(eRep eventRep.EventRepositoryI) EventUseCaseI {
+	return &eventUseCase{
+		eventRepository: eRep,
+	}
+}
+
+func (eUC *eventUseCase) After(afterID int, filter []string, limit int) ([]models.Event, error) {
+	if afterID < 0 {
+		return nil, errors.Wrapf(ErrInvalidFilter, "eventUseCase.After error: id %d", afterID)
+	}
+
+	for _, entity := range filter {
+		if !slices.Contains(entities, entity) {
+			return nil, errors.Wrapf(ErrInvalidFilter, "eventUseCase.After error: unknown entity %q", entity)
+		}
+	}
+
+	events, err := eUC.eventRepository.After(afterID, filter, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "eventUseCase.After error")
+	}
+
+	return events, nil
+}
+
+func (eUC *eventUseCase) LastID() (int, error) {
+	id, err := eUC.eventRepository.LastID()
+	if err != nil {
+		return 0, errors.Wrap(err, "eventUseCase.LastID error")
+	}
+
+	return id, nil
+}
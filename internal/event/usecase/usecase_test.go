@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	memEvent "intern/internal/event/repository/memory"
+	"intern/internal/memdb"
+	"intern/models"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAfter(t *testing.T) {
+	db := memdb.New()
+	uc := New(memEvent.New(nil, db))
+
+	last, err := uc.LastID()
+	require.NoError(t, err)
+	assert.Equal(t, 0, last)
+
+	db.AddEntityEvent(models.EventMovieCreated, 1)
+	db.AddEntityEvent(models.EventActorCreated, 2)
+	db.AddCastEvent(1, 2, models.CastAdded)
+	db.AddEntityEvent(models.EventMovieUpdated, 1)
+
+	last, err = uc.LastID()
+	require.NoError(t, err)
+	assert.Equal(t, 4, last)
+
+	events, err := uc.After(0, nil, 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+
+	events, err = uc.After(1, []string{models.EventEntityMovie, models.EventEntityCast}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.EventCastChanged, events[0].Type)
+	assert.Equal(t, models.EventMovieUpdated, events[1].Type)
+
+	events, err = uc.After(0, []string{models.EventEntityMovie}, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, 1, events[0].ID)
+
+	events, err = uc.After(4, nil, 0)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = uc.After(0, []string{"review"}, 0)
+	assert.True(t, errors.Is(err, ErrInvalidFilter), err)
+	_, err = uc.After(-1, nil, 0)
+	assert.True(t, errors.Is(err, ErrInvalidFilter), err)
+}
@@ -0,0 +1,199 @@
+package delivery
+
+import (
+	actorDel "intern/internal/actor/delivery"
+	actorUseCase "intern/internal/actor/usecase"
+	movieDel "intern/internal/movie/delivery"
+	movieUseCase "intern/internal/movie/usecase"
+	"intern/models"
+	"intern/pkg/export"
+	"intern/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type ExportHandler struct {
+	MovieUseCase movieUseCase.MovieUseCaseI
+	ActorUseCase actorUseCase.ActorUseCaseI
+	Logger       logger.Logger
+	// WriteTimeout bounds each write of an export. The WriteTimeout of the
+	// server would cut a large export short, so every write moves it on.
+	WriteTimeout time.Duration
+}
+
+// Export godoc
+// @Summary      Export the catalogue
+// @Description  Stream all movies, actors or credits (cast links) matching the listing filters.
+// @Description  CSV uses the ';'-separated build/data layout, so an export can be loaded back as seed data or imported.
+// @Description  Movies accept title, sort (id, title, release_date, rating, weighted_rating) and order; actors accept the GET /actors filters;
+// @Description  credits accept movie_id and actor_id. limit and offset are ignored.
+// @Tags     export
+// @Produce  text/csv,application/x-ndjson,application/json
+// @Param    Authorization header string true "token"
+// @Param kind path string true "movies, actors or credits"
+// @Param format query string false "csv (default), ndjson or json"
+// @Success 200 {object} nil "export stream"
+// @Failure 400 {object} nil "invalid format or filter"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "unknown kind"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /export/{kind} [get]
+func (eh *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.FormValue("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	contentType, err := export.ContentType(format)
+	if err != nil {
+		eh.Logger.Infow("can`t export",
+			"err:", err.Error())
+		http.Error(w, "bad format", http.StatusBadRequest)
+		return
+	}
+
+	kind := r.PathValue("KIND")
+
+	var stream func(enc export.Encoder) error
+
+	switch kind {
+	case "movies":
+		stream, err = eh.movies(r)
+	case "actors":
+		stream, err = eh.actors(r)
+	case "credits":
+		stream, err = eh.credits(r)
+	default:
+		http.Error(w, "unknown export", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		eh.Logger.Infow("can`t parse export filter",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	out := &trackingWriter{w: w, rc: http.NewResponseController(w), timeout: eh.WriteTimeout}
+
+	enc, err := export.NewEncoder(format, out)
+	if err != nil {
+		eh.Logger.Errorw("can`t create encoder",
+			"err:", err.Error())
+		http.Error(w, "can`t export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+kind+"."+format)
+
+	err = stream(enc)
+	if err == nil {
+		err = enc.Close()
+	}
+
+	if err == nil {
+		return
+	}
+
+	// Once rows went out the status is sent, all we can do is cut the
+	// stream short so the client sees a broken document.
+	if out.written {
+		eh.Logger.Errorw("export interrupted",
+			"kind", kind, "err:", err.Error())
+		return
+	}
+
+	w.Header().Del("Content-Disposition")
+
+	if errors.Is(err, movieUseCase.ErrInvalidFilter) || errors.Is(err, actorUseCase.ErrInvalidFilter) {
+		eh.Logger.Infow("invalid export filter",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	eh.Logger.Errorw("can`t export",
+		"kind", kind, "err:", err.Error())
+	http.Error(w, "can`t export", http.StatusInternalServerError)
+}
+
+func (eh *ExportHandler) movies(r *http.Request) (func(enc export.Encoder) error, error) {
+	filter, err := movieDel.MovieFilterFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(enc export.Encoder) error {
+		return eh.MovieUseCase.Each(filter, func(m models.Movie) error {
+			return enc.Encode([]string{
+				strconv.Itoa(m.ID), m.Title, m.Description, m.ReleaseDate.Format(time.DateOnly), strconv.Itoa(m.Rating),
+			}, m)
+		})
+	}, nil
+}
+
+func (eh *ExportHandler) actors(r *http.Request) (func(enc export.Encoder) error, error) {
+	filter, err := actorDel.ActorFilterFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(enc export.Encoder) error {
+		return eh.ActorUseCase.Each(filter, func(a models.ActorListItem) error {
+			return enc.Encode([]string{
+				strconv.Itoa(a.ID), a.FirstName, a.LastName, string(a.Gender), a.Birthday.Format(time.DateOnly),
+			}, a)
+		})
+	}, nil
+}
+
+func (eh *ExportHandler) credits(r *http.Request) (func(enc export.Encoder) error, error) {
+	var filter models.CreditFilter
+
+	for param, id := range map[string]*int{"movie_id": &filter.MovieID, "actor_id": &filter.ActorID} {
+		if value := r.FormValue(param); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s", param)
+			}
+			*id = parsed
+		}
+	}
+
+	return func(enc export.Encoder) error {
+		return eh.MovieUseCase.EachCredit(filter, func(ma models.MovieActor) error {
+			return enc.Encode([]string{
+				strconv.Itoa(ma.ID), strconv.Itoa(ma.MovieID), strconv.Itoa(ma.ActorID),
+			}, ma)
+		})
+	}, nil
+}
+
+// trackingWriter remembers whether the response body has been started, up
+// to then an error can still be reported with a proper status. Each write
+// extends the write deadline of the connection by timeout.
+type trackingWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+	written bool
+}
+
+func (tw *trackingWriter) Write(p []byte) (int, error) {
+	if tw.timeout > 0 {
+		err := tw.rc.SetWriteDeadline(time.Now().Add(tw.timeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return 0, err
+		}
+	}
+
+	tw.written = tw.written || len(p) > 0
+	return tw.w.Write(p)
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"intern/internal/imdb/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+type memImdbRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.ImdbRepositoryI {
+	return &memImdbRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ir *memImdbRepo) GetProgress(file string) (*models.ImdbProgress, error) {
+	ir.DB.RLock()
+	defer ir.DB.RUnlock()
+
+	progress, ok := ir.DB.ImdbProgress[file]
+	if !ok {
+		progress = models.ImdbProgress{File: file}
+	}
+
+	return &progress, nil
+}
+
+// SaveTitles mirrors the Postgres batch: every IMDb id that is not linked
+// yet gets a new movie, whatever else has the same title and year. Like
+// there, a batch adds one event of all its rows.
+func (ir *memImdbRepo) SaveTitles(titles []models.ImdbTitle, progress models.ImdbProgress) error {
+	ir.DB.Lock()
+	defer ir.DB.Unlock()
+
+	e := models.BulkEvent{Source: models.BulkSourceImdb}
+
+	for _, t := range titles {
+		key := models.ExternalID{Source: models.ExternalSourceIMDb, ID: t.Tconst}
+		if _, ok := ir.DB.MovieExternalIDs[key]; ok {
+			continue
+		}
+
+		m := t.Movie
+		m.ID = ir.DB.NextID("movies")
+		m.UpdatedAt = time.Now()
+		ir.DB.Movies[m.ID] = m
+		e.Movies = append(e.Movies, m.ID)
+
+		ir.DB.MovieExternalIDs[key] = m.ID
+	}
+
+	ir.DB.AddBulkEvent(e)
+	ir.DB.ImdbProgress[progress.File] = progress
+
+	return nil
+}
+
+func (ir *memImdbRepo) SaveNames(names []models.ImdbName, progress models.ImdbProgress) error {
+	ir.DB.Lock()
+	defer ir.DB.Unlock()
+
+	e := models.BulkEvent{Source: models.BulkSourceImdb}
+
+	for _, n := range names {
+		key := models.ExternalID{Source: models.ExternalSourceIMDb, ID: n.Nconst}
+		if _, ok := ir.DB.ActorExternalIDs[key]; ok {
+			continue
+		}
+
+		a := n.Actor
+		a.ID = ir.DB.NextID("actors")
+		a.UpdatedAt = time.Now()
+		ir.DB.Actors[a.ID] = a
+		e.Actors = append(e.Actors, a.ID)
+
+		ir.DB.ActorExternalIDs[key] = a.ID
+	}
+
+	ir.DB.AddBulkEvent(e)
+	ir.DB.ImdbProgress[progress.File] = progress
+
+	return nil
+}
+
+func (ir *memImdbRepo) SaveCredits(credits []models.ImdbPrincipal, progress models.ImdbProgress) error {
+	ir.DB.Lock()
+	defer ir.DB.Unlock()
+
+	e := models.BulkEvent{Source: models.BulkSourceImdb}
+
+	linked := make(map[[2]int]bool, len(ir.DB.MoviesActors))
+	for _, ma := range ir.DB.MoviesActors {
+		linked[[2]int{ma.MovieID, ma.ActorID}] = true
+	}
+
+	for _, c := range credits {
+		movieID, okMovie := ir.DB.MovieExternalIDs[models.ExternalID{Source: models.ExternalSourceIMDb, ID: c.Tconst}]
+		actorID, okActor := ir.DB.ActorExternalIDs[models.ExternalID{Source: models.ExternalSourceIMDb, ID: c.Nconst}]
+
+		if !okMovie || !okActor || linked[[2]int{movieID, actorID}] {
+			continue
+		}
+		linked[[2]int{movieID, actorID}] = true
+
+		id := ir.DB.NextID("movies_actors")
+		ir.DB.MoviesActors[id] = models.MovieActor{ID: id, MovieID: movieID, ActorID: actorID}
+		e.Movies = append(e.Movies, movieID)
+		e.Actors = append(e.Actors, actorID)
+	}
+	ir.DB.CastVersion++
+
+	ir.DB.AddBulkEvent(e)
+	ir.DB.ImdbProgress[progress.File] = progress
+
+	return nil
+}
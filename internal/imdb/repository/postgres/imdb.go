@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	pgEvent "intern/internal/event/repository/postgres"
+	"intern/internal/imdb/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+// The batches are passed as parallel arrays and unnested, so one statement
+// stores a whole batch. IMDb dates are only years, so titles and people are
+// told apart by their IMDb id alone: each id not linked yet gets a new row,
+// even if a row with the same name and date is already stored. The ids are
+// drawn from the sequence up front to link the rows in the same statement.
+//
+// The rows go around the movie and actor repositories on purpose, like the
+// seed does, so that a batch costs one statement. The use case audits each
+// file as one entry, not the rows. No revision is stored: the first edit
+// through the API stores the imported row as revision 1. The editorial
+// rating is 0, the dumps carry none, and the audience rating starts empty
+// as for every new movie. A batch is a bulk load, the outbox gets one event
+// of it, see save.
+const saveTitlesQuery = `WITH input AS (
+	SELECT DISTINCT ON (tconst) *, nextval(pg_get_serial_sequence('movies', 'id')) AS id
+	FROM unnest(?::text[], ?::text[], ?::text[], ?::date[]) AS i(tconst, title, description, release_date)
+	WHERE NOT EXISTS (SELECT 1 FROM movie_external_ids e WHERE e.source = 'imdb' AND e.external_id = i.tconst)
+	ORDER BY tconst
+), inserted AS (
+	INSERT INTO movies (id, title, description, release_date, rating) OVERRIDING SYSTEM VALUE
+	SELECT id, title, description, release_date, 0 FROM input
+)
+INSERT INTO movie_external_ids (source, external_id, movie_id)
+SELECT 'imdb', tconst, id FROM input
+RETURNING movie_id`
+
+const saveNamesQuery = `WITH input AS (
+	SELECT DISTINCT ON (nconst) *, nextval(pg_get_serial_sequence('actors', 'id')) AS id
+	FROM unnest(?::text[], ?::text[], ?::text[], ?::text[], ?::date[]) AS i(nconst, first_name, last_name, gender, birthday)
+	WHERE NOT EXISTS (SELECT 1 FROM actor_external_ids e WHERE e.source = 'imdb' AND e.external_id = i.nconst)
+	ORDER BY nconst
+), inserted AS (
+	INSERT INTO actors (id, first_name, last_name, gender, birthday) OVERRIDING SYSTEM VALUE
+	SELECT id, first_name, last_name, gender, birthday FROM input
+)
+INSERT INTO actor_external_ids (source, external_id, actor_id)
+SELECT 'imdb', nconst, id FROM input
+RETURNING actor_id`
+
+// Principals of titles or people that were not imported are dropped by the
+// joins.
+const saveCreditsQuery = `INSERT INTO movies_actors (movie_id, actor_id)
+SELECT DISTINCT t.movie_id, n.actor_id
+FROM unnest(?::text[], ?::text[]) AS p(tconst, nconst)
+JOIN movie_external_ids t ON t.source = 'imdb' AND t.external_id = p.tconst
+JOIN actor_external_ids n ON n.source = 'imdb' AND n.external_id = p.nconst
+WHERE NOT EXISTS (SELECT 1 FROM movies_actors ma WHERE ma.movie_id = t.movie_id AND ma.actor_id = n.actor_id)
+RETURNING movie_id, actor_id`
+
+const saveProgressQuery = `INSERT INTO imdb_import_progress (file, line, done) VALUES (?, ?, ?)
+ON CONFLICT (file) DO UPDATE SET line = EXCLUDED.line, done = EXCLUDED.done, updated_at = now()`
+
+type pgImdbRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.ImdbRepositoryI {
+	return &pgImdbRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ir *pgImdbRepo) GetProgress(file string) (*models.ImdbProgress, error) {
+	progress := models.ImdbProgress{File: file}
+
+	tx := ir.DB.Table("imdb_import_progress").Select("file, line, done").Where("file = ?", file).Limit(1).Find(&progress)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgImdbRepo.GetProgress error")
+	}
+
+	return &progress, nil
+}
+
+func (ir *pgImdbRepo) SaveTitles(titles []models.ImdbTitle, progress models.ImdbProgress) error {
+	tconsts := make(pq.StringArray, len(titles))
+	names := make(pq.StringArray, len(titles))
+	descriptions := make(pq.StringArray, len(titles))
+	dates := make(pq.StringArray, len(titles))
+
+	for i, t := range titles {
+		tconsts[i] = t.Tconst
+		names[i] = t.Movie.Title
+		descriptions[i] = t.Movie.Description
+		dates[i] = t.Movie.ReleaseDate.Format(time.DateOnly)
+	}
+
+	err := ir.save(progress, len(titles), saveTitlesQuery, tconsts, names, descriptions, dates)
+
+	if err != nil {
+		return errors.Wrap(err, "pgImdbRepo.SaveTitles error")
+	}
+
+	return nil
+}
+
+func (ir *pgImdbRepo) SaveNames(names []models.ImdbName, progress models.ImdbProgress) error {
+	nconsts := make(pq.StringArray, len(names))
+	firstNames := make(pq.StringArray, len(names))
+	lastNames := make(pq.StringArray, len(names))
+	genders := make(pq.StringArray, len(names))
+	birthdays := make(pq.StringArray, len(names))
+
+	for i, n := range names {
+		nconsts[i] = n.Nconst
+		firstNames[i] = n.Actor.FirstName
+		lastNames[i] = n.Actor.LastName
+		genders[i] = string(n.Actor.Gender)
+		birthdays[i] = n.Actor.Birthday.Format(time.DateOnly)
+	}
+
+	err := ir.save(progress, len(names), saveNamesQuery, nconsts, firstNames, lastNames, genders, birthdays)
+
+	if err != nil {
+		return errors.Wrap(err, "pgImdbRepo.SaveNames error")
+	}
+
+	return nil
+}
+
+func (ir *pgImdbRepo) SaveCredits(credits []models.ImdbPrincipal, progress models.ImdbProgress) error {
+	tconsts := make(pq.StringArray, len(credits))
+	nconsts := make(pq.StringArray, len(credits))
+
+	for i, c := range credits {
+		tconsts[i] = c.Tconst
+		nconsts[i] = c.Nconst
+	}
+
+	err := ir.save(progress, len(credits), saveCreditsQuery, tconsts, nconsts)
+
+	if err != nil {
+		return errors.Wrap(err, "pgImdbRepo.SaveCredits error")
+	}
+
+	return nil
+}
+
+// save runs the batch statement (skipped for an empty batch) and records
+// progress in one transaction. A batch is a bulk load: the statement
+// returns the movie_id and actor_id of the rows it writes, which make the
+// one event of the batch.
+func (ir *pgImdbRepo) save(progress models.ImdbProgress, n int, query string, args ...interface{}) error {
+	return pgEvent.BulkLoad(ir.DB, models.BulkSourceImdb, func(tx *gorm.DB, e *models.BulkEvent) error {
+		if n > 0 {
+			var written []models.MovieActor
+			if err := tx.Raw(query, args...).Scan(&written).Error; err != nil {
+				return err
+			}
+
+			for _, w := range written {
+				if w.MovieID != 0 {
+					e.Movies = append(e.Movies, w.MovieID)
+				}
+				if w.ActorID != 0 {
+					e.Actors = append(e.Actors, w.ActorID)
+				}
+			}
+		}
+
+		return tx.Exec(saveProgressQuery, progress.File, progress.Line, progress.Done).Error
+	})
+}
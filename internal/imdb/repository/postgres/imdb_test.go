@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	imdbRep "intern/internal/imdb/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type ImdbRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   imdbRep.ImdbRepositoryI
+}
+
+func TestImdbRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(ImdbRepoTestSuite))
+}
+
+func (s *ImdbRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *ImdbRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *ImdbRepoTestSuite) TestGetProgress(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT file, line, done FROM "imdb_import_progress" WHERE file = $1 LIMIT $2`)).
+		WithArgs("title.basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"file", "line", "done"}).AddRow("title.basics", 4000, false))
+
+	progress, err := s.repo.GetProgress("title.basics")
+	t.Assert().NoError(err)
+	t.Assert().Equal(&models.ImdbProgress{File: "title.basics", Line: 4000}, progress)
+}
+
+func (s *ImdbRepoTestSuite) TestGetProgressNotStarted(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT file, line, done FROM "imdb_import_progress" WHERE file = $1 LIMIT $2`)).
+		WithArgs("name.basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"file", "line", "done"}))
+
+	progress, err := s.repo.GetProgress("name.basics")
+	t.Assert().NoError(err)
+	t.Assert().Equal(&models.ImdbProgress{File: "name.basics"}, progress)
+}
+
+func (s *ImdbRepoTestSuite) TestSaveTitles(t provider.T) {
+	titles := []models.ImdbTitle{
+		{Tconst: "tt0078748", Movie: models.Movie{Title: "Alien", Description: "Horror, Sci-Fi. 117 min", ReleaseDate: time.Date(1979, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{Tconst: "tt0090605", Movie: models.Movie{Title: "Aliens", Description: "137 min", ReleaseDate: time.Date(1986, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`FROM unnest($1::text[], $2::text[], $3::text[], $4::date[]) AS i(tconst, title, description, release_date)`)).
+		WithArgs(`{"tt0078748","tt0090605"}`, `{"Alien","Aliens"}`, `{"Horror, Sci-Fi. 117 min","137 min"}`, `{"1979-01-01","1986-01-01"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"movie_id"}).AddRow(8).AddRow(7))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO imdb_import_progress (file, line, done) VALUES ($1, $2, $3)`)).
+		WithArgs("title.basics", 2, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox_events (type, payload) VALUES ($1, $2)`)).
+		WithArgs("catalogue.loaded", `{"source":"imdb","movies":[7,8],"actors":[]}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SaveTitles(titles, models.ImdbProgress{File: "title.basics", Line: 2})
+	t.Assert().NoError(err)
+}
+
+func (s *ImdbRepoTestSuite) TestSaveNamesRollsBack(t provider.T) {
+	names := []models.ImdbName{
+		{Nconst: "nm0000244", Actor: models.Actor{FirstName: "Sigourney", LastName: "Weaver", Gender: 'f', Birthday: time.Date(1949, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::date[]) AS i(nconst, first_name, last_name, gender, birthday)`)).
+		WithArgs(`{"nm0000244"}`, `{"Sigourney"}`, `{"Weaver"}`, `{"f"}`, `{"1949-01-01"}`).
+		WillReturnError(sql.ErrConnDone)
+	s.mock.ExpectRollback()
+
+	err := s.repo.SaveNames(names, models.ImdbProgress{File: "name.basics", Line: 1})
+	t.Assert().ErrorIs(err, sql.ErrConnDone)
+}
+
+func (s *ImdbRepoTestSuite) TestSaveLastEmptyBatch(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO imdb_import_progress (file, line, done) VALUES ($1, $2, $3)`)).
+		WithArgs("title.principals", 11, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SaveCredits(nil, models.ImdbProgress{File: "title.principals", Line: 11, Done: true})
+	t.Assert().NoError(err)
+}
+
+func (s *ImdbRepoTestSuite) TestSaveCredits(t provider.T) {
+	credits := []models.ImdbPrincipal{
+		{Tconst: "tt0078748", Nconst: "nm0000244"},
+		{Tconst: "tt0090605", Nconst: "nm0000244"},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`FROM unnest($1::text[], $2::text[]) AS p(tconst, nconst)`)).
+		WithArgs(`{"tt0078748","tt0090605"}`, `{"nm0000244","nm0000244"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"movie_id", "actor_id"}).AddRow(7, 3).AddRow(8, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO imdb_import_progress (file, line, done) VALUES ($1, $2, $3)`)).
+		WithArgs("title.principals", 2, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox_events (type, payload) VALUES ($1, $2)`)).
+		WithArgs("catalogue.loaded", `{"source":"imdb","movies":[7,8],"actors":[3]}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SaveCredits(credits, models.ImdbProgress{File: "title.principals", Line: 2})
+	t.Assert().NoError(err)
+}
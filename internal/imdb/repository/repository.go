@@ -0,0 +1,15 @@
+package repository
+
+import "intern/models"
+
+// ImdbRepositoryI stores batches of an IMDb dump. Every Save call writes the
+// batch and moves the progress of its file in one transaction, so an
+// interrupted import can resume right after the last stored batch. The rows
+// are written without audit entries or revisions, with one outbox event
+// per batch; see the Postgres implementation for why.
+type ImdbRepositoryI interface {
+	GetProgress(file string) (*models.ImdbProgress, error)
+	SaveTitles(titles []models.ImdbTitle, progress models.ImdbProgress) error
+	SaveNames(names []models.ImdbName, progress models.ImdbProgress) error
+	SaveCredits(credits []models.ImdbPrincipal, progress models.ImdbProgress) error
+}
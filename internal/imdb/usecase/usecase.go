@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	auditUseCase "intern/internal/audit/usecase"
+	imdbRep "intern/internal/imdb/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// The dump files, in the order they are imported: cast links need both the
+// movies and the actors to be stored.
+const (
+	FileTitles     = "title.basics"
+	FileNames      = "name.basics"
+	FilePrincipals = "title.principals"
+)
+
+// fileEntities are the entities whose audit log records the import of a
+// file, cast links are changes of the movies.
+var fileEntities = map[string]string{
+	FileTitles:     models.AuditMovie,
+	FileNames:      models.AuditActor,
+	FilePrincipals: models.AuditMovie,
+}
+
+const (
+	DefaultBatchSize = 1000
+
+	maxLineSize = 16 << 20
+	null        = `\N`
+)
+
+// Lengths of the columns the dump values end up in (migration 0001).
+const (
+	maxTitle       = 150
+	maxDescription = 1000
+	maxName        = 35
+)
+
+var DefaultTitleTypes = []string{"movie"}
+
+type Options struct {
+	// Dir holds <file>.tsv.gz or the unpacked <file>.tsv for every file.
+	Dir       string
+	BatchSize int
+	// TitleTypes are the titleType values imported as movies.
+	TitleTypes   []string
+	IncludeAdult bool
+}
+
+type ImdbUseCaseI interface {
+	Run(opts Options) error
+}
+
+type imdbUseCase struct {
+	imdbRepository imdbRep.ImdbRepositoryI
+	audit          auditUseCase.AuditUseCaseI
+	logger         logger.Logger
+}
+
+// New takes a logger unlike the other use cases: an import of the full
+// dumps runs for a long time and reports how far it got.
+func New(rep imdbRep.ImdbRepositoryI, audit auditUseCase.AuditUseCaseI, logger logger.Logger) ImdbUseCaseI {
+	return &imdbUseCase{
+		imdbRepository: rep,
+		audit:          audit,
+		logger:         logger,
+	}
+}
+
+// Run imports the dumps of opts.Dir. Every file resumes after the last line
+// stored by a previous run, files already finished are skipped. Each file a
+// run imports rows of is recorded as one audit entry, by no user.
+func (iUC *imdbUseCase) Run(opts Options) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if len(opts.TitleTypes) == 0 {
+		opts.TitleTypes = DefaultTitleTypes
+	}
+
+	err := importFile(iUC, opts, FileTitles, []string{"tconst", "titleType", "primaryTitle", "isAdult", "startYear", "runtimeMinutes", "genres"},
+		func(r row) (models.ImdbTitle, bool) { return parseTitle(r, opts) }, iUC.imdbRepository.SaveTitles)
+	if err != nil {
+		return errors.Wrap(err, "imdbUseCase.Run error")
+	}
+
+	err = importFile(iUC, opts, FileNames, []string{"nconst", "primaryName", "birthYear", "primaryProfession"},
+		parseName, iUC.imdbRepository.SaveNames)
+	if err != nil {
+		return errors.Wrap(err, "imdbUseCase.Run error")
+	}
+
+	err = importFile(iUC, opts, FilePrincipals, []string{"tconst", "nconst", "category"},
+		parsePrincipal, iUC.imdbRepository.SaveCredits)
+	if err != nil {
+		return errors.Wrap(err, "imdbUseCase.Run error")
+	}
+
+	return nil
+}
+
+// importFile streams file and stores the rows parse keeps in batches. The
+// progress saved with a batch is the line it ends at; skipped lines after
+// it are read again on resume, which is harmless.
+func importFile[T any](iUC *imdbUseCase, opts Options, file string, columns []string,
+	parse func(r row) (T, bool), save func(batch []T, progress models.ImdbProgress) error) error {
+	progress, err := iUC.imdbRepository.GetProgress(file)
+	if err != nil {
+		return errors.Wrapf(err, "%s: can't get progress", file)
+	}
+
+	if progress.Done {
+		iUC.logger.Infow("imdb file already imported", "file", file)
+		return nil
+	}
+
+	in, err := openDump(opts.Dir, file)
+	if err != nil {
+		return errors.Wrap(err, file)
+	}
+	defer in.Close()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	if !scanner.Scan() {
+		return errors.Errorf("%s: missing header", file)
+	}
+
+	header := strings.Split(scanner.Text(), "\t")
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range columns {
+		if _, ok := index[name]; !ok {
+			return errors.Errorf("%s: missing column %q", file, name)
+		}
+	}
+
+	if progress.Line > 0 {
+		iUC.logger.Infow("resuming imdb file", "file", file, "line", progress.Line)
+	}
+
+	after := map[string]interface{}{"source": models.ExternalSourceIMDb, "file": file, "afterLine": progress.Line}
+
+	err = iUC.audit.Record(context.Background(), models.AuditImport, fileEntities[file], 0, nil, after)
+	if err != nil {
+		return errors.Wrapf(err, "%s: can't record audit", file)
+	}
+
+	batch := make([]T, 0, opts.BatchSize)
+	line, stored, malformed := 0, 0, 0
+
+	for scanner.Scan() {
+		line++
+		if line <= progress.Line {
+			continue
+		}
+
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != len(header) {
+			malformed++
+			continue
+		}
+
+		item, ok := parse(row{fields: fields, index: index})
+		if !ok {
+			continue
+		}
+
+		batch = append(batch, item)
+		if len(batch) < opts.BatchSize {
+			continue
+		}
+
+		err = save(batch, models.ImdbProgress{File: file, Line: line})
+		if err != nil {
+			return errors.Wrapf(err, "%s: can't save batch ending at line %d", file, line)
+		}
+		stored += len(batch)
+		batch = batch[:0]
+	}
+
+	if err = scanner.Err(); err != nil {
+		return errors.Wrapf(err, "%s: read error after line %d", file, line)
+	}
+
+	err = save(batch, models.ImdbProgress{File: file, Line: line, Done: true})
+	if err != nil {
+		return errors.Wrapf(err, "%s: can't save last batch", file)
+	}
+	stored += len(batch)
+
+	iUC.logger.Infow("imdb file imported",
+		"file", file, "lines", line, "stored", stored, "malformed", malformed)
+
+	return nil
+}
+
+type dump struct {
+	io.Reader
+	file *os.File
+}
+
+func (d *dump) Close() error {
+	return d.file.Close()
+}
+
+// openDump prefers the gzip file as published by IMDb and falls back to an
+// unpacked one.
+func openDump(dir, file string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(dir, file+".tsv.gz"))
+	if err == nil {
+		gz, err := gzip.NewReader(bufio.NewReader(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		return &dump{Reader: gz, file: f}, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err = os.Open(filepath.Join(dir, file+".tsv"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dump{Reader: f, file: f}, nil
+}
+
+type row struct {
+	fields []string
+	index  map[string]int
+}
+
+// get returns the value of column, "" for a null.
+func (r row) get(column string) string {
+	value := r.fields[r.index[column]]
+	if value == null {
+		return ""
+	}
+
+	return value
+}
+
+func (r row) year(column string) (time.Time, bool) {
+	year, err := strconv.Atoi(r.get(column))
+	if err != nil || year <= 0 {
+		return time.Time{}, false
+	}
+
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), true
+}
+
+// parseTitle keeps the titles of the wanted types that have a start year,
+// which becomes the release date. The dumps have no plot, so the genres and
+// runtime make up the description.
+func parseTitle(r row, opts Options) (models.ImdbTitle, bool) {
+	if !slices.Contains(opts.TitleTypes, r.get("titleType")) {
+		return models.ImdbTitle{}, false
+	}
+	if r.get("isAdult") == "1" && !opts.IncludeAdult {
+		return models.ImdbTitle{}, false
+	}
+
+	released, ok := r.year("startYear")
+	title := truncate(r.get("primaryTitle"), maxTitle)
+	if !ok || title == "" {
+		return models.ImdbTitle{}, false
+	}
+
+	var description []string
+	if genres := r.get("genres"); genres != "" {
+		description = append(description, strings.ReplaceAll(genres, ",", ", "))
+	}
+	if runtime := r.get("runtimeMinutes"); runtime != "" {
+		description = append(description, runtime+" min")
+	}
+
+	return models.ImdbTitle{
+		Tconst: r.get("tconst"),
+		Movie: models.Movie{
+			Title:       title,
+			Description: truncate(strings.Join(description, ". "), maxDescription),
+			ReleaseDate: released,
+		},
+	}, true
+}
+
+// parseName keeps actors and actresses with a birth year. The profession
+// listed first decides the gender; the name is split at its last space.
+func parseName(r row) (models.ImdbName, bool) {
+	var gender byte
+	for _, profession := range strings.Split(r.get("primaryProfession"), ",") {
+		if profession == "actor" {
+			gender = 'm'
+			break
+		}
+		if profession == "actress" {
+			gender = 'f'
+			break
+		}
+	}
+
+	birthday, ok := r.year("birthYear")
+	name := strings.TrimSpace(r.get("primaryName"))
+	if gender == 0 || !ok || name == "" {
+		return models.ImdbName{}, false
+	}
+
+	firstName, lastName := name, ""
+	if i := strings.LastIndexByte(name, ' '); i > 0 {
+		firstName, lastName = name[:i], name[i+1:]
+	}
+
+	return models.ImdbName{
+		Nconst: r.get("nconst"),
+		Actor: models.Actor{
+			FirstName: truncate(firstName, maxName),
+			LastName:  truncate(lastName, maxName),
+			Gender:    gender,
+			Birthday:  birthday,
+		},
+	}, true
+}
+
+func parsePrincipal(r row) (models.ImdbPrincipal, bool) {
+	switch r.get("category") {
+	case "actor", "actress":
+	default:
+		return models.ImdbPrincipal{}, false
+	}
+
+	return models.ImdbPrincipal{
+		Tconst: r.get("tconst"),
+		Nconst: r.get("nconst"),
+	}, true
+}
+
+// truncate cuts s to n characters, VARCHAR lengths count characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
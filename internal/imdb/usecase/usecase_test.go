@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	memAudit "intern/internal/audit/repository/memory"
+	auditUseCase "intern/internal/audit/usecase"
+	imdbRep "intern/internal/imdb/repository"
+	memImdb "intern/internal/imdb/repository/memory"
+	"intern/internal/memdb"
+	"intern/models"
+	ctxManager "intern/pkg/context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newUseCase(rep imdbRep.ImdbRepositoryI) ImdbUseCaseI {
+	return newAuditedUseCase(rep, memdb.New())
+}
+
+// newAuditedUseCase keeps the audit log in db.
+func newAuditedUseCase(rep imdbRep.ImdbRepositoryI, db *memdb.DB) ImdbUseCaseI {
+	return New(rep, auditUseCase.New(memAudit.New(nil, db), ctxManager.Manager{}), zap.NewNop().Sugar())
+}
+
+// fixtures copies testdata to a temporary directory with title.basics
+// gzipped, the way IMDb publishes it.
+func fixtures(t *testing.T) string {
+	dir := t.TempDir()
+
+	for _, file := range []string{FileTitles, FileNames, FilePrincipals} {
+		in, err := os.Open(filepath.Join("testdata", file+".tsv"))
+		require.NoError(t, err)
+		defer in.Close()
+
+		if file != FileTitles {
+			out, err := os.Create(filepath.Join(dir, file+".tsv"))
+			require.NoError(t, err)
+			_, err = io.Copy(out, in)
+			require.NoError(t, err)
+			require.NoError(t, out.Close())
+			continue
+		}
+
+		out, err := os.Create(filepath.Join(dir, file+".tsv.gz"))
+		require.NoError(t, err)
+		gz := gzip.NewWriter(out)
+		_, err = io.Copy(gz, in)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		require.NoError(t, out.Close())
+	}
+
+	return dir
+}
+
+func year(y int) time.Time {
+	return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func imdb(id string) models.ExternalID {
+	return models.ExternalID{Source: models.ExternalSourceIMDb, ID: id}
+}
+
+func credits(db *memdb.DB) [][2]int {
+	links := make([][2]int, 0, len(db.MoviesActors))
+	for _, ma := range db.MoviesActors {
+		links = append(links, [2]int{ma.MovieID, ma.ActorID})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i][0] < links[j][0] || links[i][0] == links[j][0] && links[i][1] < links[j][1]
+	})
+
+	return links
+}
+
+// movies returns the movies of db without the UpdatedAt set by the run.
+func movies(db *memdb.DB) map[int]models.Movie {
+	res := make(map[int]models.Movie, len(db.Movies))
+	for id, m := range db.Movies {
+		m.UpdatedAt = time.Time{}
+		res[id] = m
+	}
+
+	return res
+}
+
+// actors is movies for the actors.
+func actors(db *memdb.DB) map[int]models.Actor {
+	res := make(map[int]models.Actor, len(db.Actors))
+	for id, a := range db.Actors {
+		a.UpdatedAt = time.Time{}
+		res[id] = a
+	}
+
+	return res
+}
+
+func TestRun(t *testing.T) {
+	db := memdb.New()
+	curated := models.Movie{ID: 1, Title: "Alien", Description: "In space no one can hear you scream", ReleaseDate: year(1979), Rating: 8}
+	db.Movies[curated.ID] = curated
+	db.SeenID("movies", curated.ID)
+
+	err := newUseCase(memImdb.New(nil, db)).Run(Options{Dir: fixtures(t), BatchSize: 2})
+	require.NoError(t, err)
+
+	// A year is not enough to tell the curated movie and the IMDb one apart.
+	assert.Equal(t, map[int]models.Movie{
+		1: curated,
+		2: {ID: 2, Title: "Alien", Description: "Horror, Sci-Fi. 117 min", ReleaseDate: year(1979)},
+		3: {ID: 3, Title: "Aliens", Description: "Action, Adventure, Sci-Fi. 137 min", ReleaseDate: year(1986)},
+		4: {ID: 4, Title: "Alien³", Description: "114 min", ReleaseDate: year(1992)},
+	}, movies(db))
+	assert.Equal(t, map[models.ExternalID]int{imdb("tt0078748"): 2, imdb("tt0090605"): 3, imdb("tt0103644"): 4}, db.MovieExternalIDs)
+
+	assert.Equal(t, map[int]models.Actor{
+		1: {ID: 1, FirstName: "Sigourney", LastName: "Weaver", Gender: 'f', Birthday: year(1949)},
+		2: {ID: 2, FirstName: "Tom", LastName: "Skerritt", Gender: 'm', Birthday: year(1933)},
+		3: {ID: 3, FirstName: "Lance", LastName: "Henriksen", Gender: 'm', Birthday: year(1940)},
+		4: {ID: 4, FirstName: "Ridley", LastName: "Scott", Gender: 'm', Birthday: year(1937)},
+		5: {ID: 5, FirstName: "Cher", Gender: 'f', Birthday: year(1946)},
+	}, actors(db))
+	assert.Equal(t, map[models.ExternalID]int{imdb("nm0000244"): 1, imdb("nm0000642"): 2, imdb("nm0001416"): 3, imdb("nm0000631"): 4, imdb("nm9999991"): 5}, db.ActorExternalIDs)
+
+	assert.Equal(t, [][2]int{{2, 1}, {2, 2}, {3, 1}, {3, 3}, {4, 1}, {4, 3}}, credits(db))
+
+	assert.Equal(t, map[string]models.ImdbProgress{
+		FileTitles:     {File: FileTitles, Line: 8, Done: true},
+		FileNames:      {File: FileNames, Line: 7, Done: true},
+		FilePrincipals: {File: FilePrincipals, Line: 11, Done: true},
+	}, db.ImdbProgress)
+}
+
+func TestRunRecordsAudit(t *testing.T) {
+	db := memdb.New()
+	uc := newAuditedUseCase(memImdb.New(nil, db), db)
+
+	require.NoError(t, uc.Run(Options{Dir: fixtures(t)}))
+
+	// One entry per file, none for the rows.
+	require.Len(t, db.AuditEntries, 3)
+	for i, entity := range []string{models.AuditMovie, models.AuditActor, models.AuditMovie} {
+		e := db.AuditEntries[i]
+		assert.Equal(t, models.AuditImport, e.Action)
+		assert.Equal(t, entity, e.Entity)
+		assert.Equal(t, 0, e.EntityID)
+		assert.Equal(t, 0, e.UserID)
+	}
+	assert.JSONEq(t, `{"source":"imdb","file":"name.basics","afterLine":0}`, string(db.AuditEntries[1].After))
+
+	// The files are done, a second run writes nothing.
+	require.NoError(t, uc.Run(Options{Dir: fixtures(t)}))
+	assert.Len(t, db.AuditEntries, 3)
+}
+
+func TestRunAddsBulkEvents(t *testing.T) {
+	db := memdb.New()
+
+	require.NoError(t, newUseCase(memImdb.New(nil, db)).Run(Options{Dir: fixtures(t), BatchSize: 2}))
+
+	// No event per row, one per batch that wrote any.
+	movieIDs, actorIDs := map[int]bool{}, map[int]bool{}
+	for _, e := range db.Events {
+		require.Equal(t, models.EventCatalogueLoaded, e.Type)
+
+		var p models.BulkEvent
+		require.NoError(t, json.Unmarshal(e.Payload, &p))
+		assert.Equal(t, models.BulkSourceImdb, p.Source)
+		for _, id := range p.Movies {
+			movieIDs[id] = true
+		}
+		for _, id := range p.Actors {
+			actorIDs[id] = true
+		}
+	}
+
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true}, movieIDs)
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true, 5: true}, actorIDs)
+}
+
+func TestRunSameNameAndYear(t *testing.T) {
+	db := memdb.New()
+	dir := fixtures(t)
+	names := "nconst\tprimaryName\tbirthYear\tdeathYear\tprimaryProfession\tknownForTitles\n" +
+		"nm0000001\tJohn Smith\t1970\t\\N\tactor\ttt0078748\n" +
+		"nm0000002\tJohn Smith\t1970\t\\N\tactor\ttt0090605\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileNames+".tsv"), []byte(names), 0o644))
+
+	err := newUseCase(memImdb.New(nil, db)).Run(Options{Dir: dir})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]models.Actor{
+		1: {ID: 1, FirstName: "John", LastName: "Smith", Gender: 'm', Birthday: year(1970)},
+		2: {ID: 2, FirstName: "John", LastName: "Smith", Gender: 'm', Birthday: year(1970)},
+	}, actors(db))
+	assert.Equal(t, map[models.ExternalID]int{imdb("nm0000001"): 1, imdb("nm0000002"): 2}, db.ActorExternalIDs)
+}
+
+func TestRunTitleTypes(t *testing.T) {
+	db := memdb.New()
+
+	err := newUseCase(memImdb.New(nil, db)).Run(Options{Dir: fixtures(t), TitleTypes: []string{"tvSeries", "tvMovie"}, IncludeAdult: true})
+	require.NoError(t, err)
+
+	titles := make([]string, 0, len(db.Movies))
+	for _, m := range db.Movies {
+		titles = append(titles, m.Title)
+	}
+	assert.ElementsMatch(t, []string{"The Simpsons", "Alien: The Making Of"}, titles)
+	assert.Equal(t, [][2]int{{db.MovieExternalIDs[imdb("tt0096697")], db.ActorExternalIDs[imdb("nm9999991")]}}, credits(db))
+}
+
+func TestRunMissingFile(t *testing.T) {
+	db := memdb.New()
+	dir := fixtures(t)
+	require.NoError(t, os.Remove(filepath.Join(dir, FilePrincipals+".tsv")))
+
+	err := newUseCase(memImdb.New(nil, db)).Run(Options{Dir: dir})
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Len(t, db.Movies, 3)
+	assert.Empty(t, db.MoviesActors)
+}
+
+// failingRepo stops storing after a number of saves, like a killed import.
+type failingRepo struct {
+	imdbRep.ImdbRepositoryI
+	left int
+}
+
+var errKilled = errors.New("killed")
+
+func (fr *failingRepo) allow() error {
+	if fr.left == 0 {
+		return errKilled
+	}
+	fr.left--
+
+	return nil
+}
+
+func (fr *failingRepo) SaveTitles(titles []models.ImdbTitle, progress models.ImdbProgress) error {
+	if err := fr.allow(); err != nil {
+		return err
+	}
+	return fr.ImdbRepositoryI.SaveTitles(titles, progress)
+}
+
+func (fr *failingRepo) SaveNames(names []models.ImdbName, progress models.ImdbProgress) error {
+	if err := fr.allow(); err != nil {
+		return err
+	}
+	return fr.ImdbRepositoryI.SaveNames(names, progress)
+}
+
+func (fr *failingRepo) SaveCredits(credits []models.ImdbPrincipal, progress models.ImdbProgress) error {
+	if err := fr.allow(); err != nil {
+		return err
+	}
+	return fr.ImdbRepositoryI.SaveCredits(credits, progress)
+}
+
+func TestRunResumes(t *testing.T) {
+	dir := fixtures(t)
+	opts := Options{Dir: dir, BatchSize: 2}
+
+	want := memdb.New()
+	require.NoError(t, newUseCase(memImdb.New(nil, want)).Run(opts))
+
+	db := memdb.New()
+	rep := memImdb.New(nil, db)
+
+	runs := 0
+	for ; runs < 20; runs++ {
+		err := newUseCase(&failingRepo{ImdbRepositoryI: rep, left: 1}).Run(opts)
+		if err == nil {
+			break
+		}
+		require.True(t, errors.Is(err, errKilled), err)
+	}
+
+	assert.Greater(t, runs, 3)
+	assert.Equal(t, movies(want), movies(db))
+	assert.Equal(t, actors(want), actors(db))
+	assert.Equal(t, credits(want), credits(db))
+	assert.Equal(t, want.MovieExternalIDs, db.MovieExternalIDs)
+	assert.Equal(t, want.ActorExternalIDs, db.ActorExternalIDs)
+	assert.Equal(t, want.ImdbProgress, db.ImdbProgress)
+
+	// Finished files are not read again.
+	require.NoError(t, newUseCase(&failingRepo{ImdbRepositoryI: rep}).Run(opts))
+}
@@ -0,0 +1,168 @@
+package delivery
+
+import (
+	"encoding/json"
+	importUseCase "intern/internal/importer/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// MaxBodySize limits the size of an uploaded file.
+const MaxBodySize = 64 << 20
+
+type ImportHandler struct {
+	ImportUseCase importUseCase.ImportUseCaseI
+	Logger        logger.Logger
+}
+
+// Import godoc
+// @Summary      Bulk import movies or actors
+// @Description  Upsert movies (by title and release date) or actors (by name and birthday) from a ';'-separated CSV in the build/data layout or from NDJSON.
+// @Description  Uploads of up to 500 rows are processed at once (200), larger ones return a running job to poll (202).
+// @Tags     import
+// @Accept	 text/csv,application/x-ndjson
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param kind path string true "movies or actors"
+// @Param format query string false "csv or ndjson, taken from Content-Type by default"
+// @Param dry_run query bool false "only validate and count"
+// @Success 200 {object} models.ImportJob "import finished"
+// @Success 202 {object} models.ImportJob "import started"
+// @Failure 400 {object} nil "invalid file"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /import/{kind} [post]
+func (ih *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatFromContentType(r.Header.Get("Content-Type"))
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			ih.Logger.Infow("can`t parse dry_run",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, MaxBodySize)
+	defer body.Close()
+
+	job, err := ih.ImportUseCase.Import(r.Context(), r.PathValue("KIND"), format, dryRun, body)
+	if errors.Is(err, importUseCase.ErrInvalidImport) {
+		ih.Logger.Infow("invalid import",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		ih.Logger.Errorw("can`t import",
+			"err:", err.Error())
+		http.Error(w, "can`t import", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(job)
+
+	if err != nil {
+		ih.Logger.Errorw("can`t marshal import job",
+			"err:", err.Error())
+		http.Error(w, "can`t make import job", http.StatusInternalServerError)
+		return
+	}
+
+	if job.Status == models.ImportStatusDone {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.Header().Set("Location", "/import/jobs/"+strconv.Itoa(job.ID))
+		w.WriteHeader(http.StatusAccepted)
+	}
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ih.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetJob godoc
+// @Summary      Get import job
+// @Description  Get progress, counters and row errors of an import
+// @Tags     import
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "JOB_ID"
+// @Success 200 {object} models.ImportJob "success get import job"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Import job not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /import/jobs/{id} [get]
+func (ih *ImportHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobIdString := r.PathValue("JOB_ID")
+	if jobIdString == "" {
+		ih.Logger.Errorw("no JOB_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	jobId, err := strconv.Atoi(jobIdString)
+	if err != nil {
+		ih.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := ih.ImportUseCase.GetJob(jobId)
+	if err != nil {
+		ih.Logger.Infow("can`t get import job",
+			"err:", err.Error())
+		http.Error(w, "can`t get import job", http.StatusNotFound)
+		return
+	}
+
+	resp, err := json.Marshal(job)
+
+	if err != nil {
+		ih.Logger.Errorw("can`t marshal import job",
+			"err:", err.Error())
+		http.Error(w, "can`t make import job", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		ih.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func formatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return models.ImportFormatNDJSON
+	default:
+		return models.ImportFormatCSV
+	}
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"intern/internal/importer/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memJobRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.JobRepositoryI {
+	return &memJobRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (jr *memJobRepo) Create(j *models.ImportJob) error {
+	jr.DB.Lock()
+	defer jr.DB.Unlock()
+
+	j.ID = jr.DB.NextID("import_jobs")
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = time.Now()
+	}
+	if j.UpdatedAt.IsZero() {
+		j.UpdatedAt = j.CreatedAt
+	}
+
+	jr.DB.ImportJobs[j.ID] = clone(*j)
+
+	return nil
+}
+
+func (jr *memJobRepo) Get(id int) (*models.ImportJob, error) {
+	jr.DB.RLock()
+	defer jr.DB.RUnlock()
+
+	j, ok := jr.DB.ImportJobs[id]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memJobRepo.Get error")
+	}
+
+	j = clone(j)
+
+	return &j, nil
+}
+
+func (jr *memJobRepo) Update(j *models.ImportJob) error {
+	jr.DB.Lock()
+	defer jr.DB.Unlock()
+
+	j.UpdatedAt = time.Now()
+	jr.DB.ImportJobs[j.ID] = clone(*j)
+
+	return nil
+}
+
+func (jr *memJobRepo) FailStale(before time.Time, message string) (int, error) {
+	jr.DB.Lock()
+	defer jr.DB.Unlock()
+
+	n := 0
+	now := time.Now()
+	for id, j := range jr.DB.ImportJobs {
+		if j.Status != models.ImportStatusRunning || !j.UpdatedAt.Before(before) {
+			continue
+		}
+
+		finished := now
+		j.Status, j.Error, j.FinishedAt, j.UpdatedAt = models.ImportStatusFailed, message, &finished, now
+		jr.DB.ImportJobs[id] = j
+		n++
+	}
+
+	return n, nil
+}
+
+// clone keeps the stored job independent of the caller's copy, the import
+// keeps appending row errors while the job is being polled.
+func clone(j models.ImportJob) models.ImportJob {
+	j.Errors = slices.Clone(j.Errors)
+	if j.FinishedAt != nil {
+		finished := *j.FinishedAt
+		j.FinishedAt = &finished
+	}
+
+	return j
+}
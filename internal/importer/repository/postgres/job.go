@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/importer/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+type pgJobRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.JobRepositoryI {
+	return &pgJobRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (jr *pgJobRepo) Create(j *models.ImportJob) error {
+	tx := jr.DB.Create(j)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgJobRepo.Create error")
+	}
+
+	return nil
+}
+
+func (jr *pgJobRepo) Get(id int) (*models.ImportJob, error) {
+	var j models.ImportJob
+	tx := jr.DB.Where("id = ?", id).Take(&j)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgJobRepo.Get error")
+	}
+
+	return &j, nil
+}
+
+// Update writes every column, progress counters may legitimately be zero.
+func (jr *pgJobRepo) Update(j *models.ImportJob) error {
+	tx := jr.DB.Save(j)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgJobRepo.Update error")
+	}
+
+	return nil
+}
+
+func (jr *pgJobRepo) FailStale(before time.Time, message string) (int, error) {
+	tx := jr.DB.Model(&models.ImportJob{}).
+		Where("status = ? AND updated_at < ?", models.ImportStatusRunning, before).
+		Updates(map[string]interface{}{
+			"status":      models.ImportStatusFailed,
+			"error":       message,
+			"finished_at": time.Now(),
+		})
+
+	if tx.Error != nil {
+		return 0, errors.Wrap(tx.Error, "pgJobRepo.FailStale error")
+	}
+
+	return int(tx.RowsAffected), nil
+}
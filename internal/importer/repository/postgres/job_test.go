@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	importRep "intern/internal/importer/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type JobRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   importRep.JobRepositoryI
+}
+
+func TestJobRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(JobRepoTestSuite))
+}
+
+func (s *JobRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *JobRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *JobRepoTestSuite) TestCreateJob(t provider.T) {
+	job := &models.ImportJob{
+		Kind:   models.ImportMovies,
+		Format: models.ImportFormatCSV,
+		Status: models.ImportStatusRunning,
+		Total:  3,
+		Errors: []models.ImportRowError{},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "import_jobs" ("kind","format","dry_run","status","total","processed","created","updated","restored","failed","errors","error","created_at","updated_at","finished_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) RETURNING "id"`)).
+		WithArgs(job.Kind, job.Format, false, job.Status, 3, 0, 0, 0, 0, 0, "[]", "", sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Create(job)
+	t.Assert().NoError(err)
+	t.Assert().Equal(5, job.ID)
+}
+
+func (s *JobRepoTestSuite) TestGetJob(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "kind", "format", "dry_run", "status", "total", "processed",
+		"created", "updated", "restored", "failed", "errors", "error", "created_at", "updated_at", "finished_at"}).
+		AddRow(5, "actors", "ndjson", true, "running", 10, 4, 2, 1, 1, 1,
+			`[{"row":3,"field":"gender","message":"must be m or f"}]`, "", created, created, nil)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "import_jobs" WHERE id = $1 LIMIT $2`)).
+		WithArgs(5, 1).
+		WillReturnRows(rows)
+
+	job, err := s.repo.Get(5)
+	t.Assert().NoError(err)
+	t.Assert().Equal(&models.ImportJob{
+		ID:        5,
+		Kind:      models.ImportActors,
+		Format:    models.ImportFormatNDJSON,
+		DryRun:    true,
+		Status:    models.ImportStatusRunning,
+		Total:     10,
+		Processed: 4,
+		Created:   2,
+		Updated:   1,
+		Restored:  1,
+		Failed:    1,
+		Errors:    []models.ImportRowError{{Row: 3, Field: "gender", Message: "must be m or f"}},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}, job)
+}
+
+func (s *JobRepoTestSuite) TestUpdateJob(t provider.T) {
+	finished := time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC)
+	job := &models.ImportJob{
+		ID:         5,
+		Kind:       models.ImportMovies,
+		Format:     models.ImportFormatCSV,
+		Status:     models.ImportStatusDone,
+		Total:      2,
+		Processed:  2,
+		Updated:    2,
+		Errors:     []models.ImportRowError{},
+		CreatedAt:  finished.Add(-time.Minute),
+		FinishedAt: &finished,
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "import_jobs" SET "kind"=$1,"format"=$2,"dry_run"=$3,"status"=$4,"total"=$5,"processed"=$6,"created"=$7,"updated"=$8,"restored"=$9,"failed"=$10,"errors"=$11,"error"=$12,"created_at"=$13,"updated_at"=$14,"finished_at"=$15 WHERE "id" = $16`)).
+		WithArgs(job.Kind, job.Format, false, job.Status, 2, 2, 0, 2, 0, 0, "[]", "", job.CreatedAt, sqlmock.AnyArg(), finished, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Update(job)
+	t.Assert().NoError(err)
+}
+
+func (s *JobRepoTestSuite) TestFailStale(t provider.T) {
+	before := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "import_jobs" SET "error"=$1,"finished_at"=$2,"status"=$3,"updated_at"=$4 WHERE status = $5 AND updated_at < $6`)).
+		WithArgs("interrupted", sqlmock.AnyArg(), models.ImportStatusFailed, sqlmock.AnyArg(), models.ImportStatusRunning, before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	n, err := s.repo.FailStale(before, "interrupted")
+	t.Assert().NoError(err)
+	t.Assert().Equal(2, n)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"intern/models"
+	"time"
+)
+
+type JobRepositoryI interface {
+	Create(j *models.ImportJob) error
+	Get(id int) (*models.ImportJob, error)
+	Update(j *models.ImportJob) error
+	// FailStale marks the running jobs last updated before before as failed
+	// with message and returns how many there were.
+	FailStale(before time.Time, message string) (int, error)
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"intern/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// record is one row of an upload keyed by column name. CSV files use the
+// build/data layout (optionally without the leading id column), NDJSON
+// lines use the JSON names of models.Movie and models.Actor.
+type record struct {
+	line   int
+	values map[string]string
+}
+
+type columns struct {
+	csv  []string
+	json map[string]string
+}
+
+var kindColumns = map[string]columns{
+	models.ImportMovies: {
+		csv: []string{"id", "title", "description", "release_date", "rating"},
+		json: map[string]string{
+			"id": "id", "title": "title", "description": "description", "releaseDate": "release_date", "rating": "rating",
+		},
+	},
+	models.ImportActors: {
+		csv: []string{"id", "first_name", "last_name", "gender", "birthday"},
+		json: map[string]string{
+			"id": "id", "firstName": "first_name", "lastName": "last_name", "gender": "gender", "birthday": "birthday",
+		},
+	},
+}
+
+func readRecords(kind, format string, r io.Reader) ([]record, error) {
+	cols := kindColumns[kind]
+
+	switch format {
+	case models.ImportFormatCSV:
+		return readCSV(cols.csv, r)
+	case models.ImportFormatNDJSON:
+		return readNDJSON(cols.json, r)
+	default:
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+}
+
+func readCSV(names []string, r io.Reader) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+	reader.FieldsPerRecord = -1
+
+	var records []record
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		rec := record{line: line, values: make(map[string]string, len(names))}
+
+		switch len(fields) {
+		case len(names):
+		case len(names) - 1:
+			fields = append([]string{""}, fields...)
+		default:
+			rec.values = nil
+			records = append(records, rec)
+			continue
+		}
+
+		for i, name := range names {
+			rec.values[name] = strings.TrimSpace(fields[i])
+		}
+
+		records = append(records, rec)
+	}
+}
+
+func readNDJSON(names map[string]string, r io.Reader) ([]record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []record
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		rec := record{line: line}
+
+		var obj map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+
+		if decoder.Decode(&obj) == nil {
+			rec.values = make(map[string]string, len(obj))
+			for key, value := range obj {
+				if name, ok := names[key]; ok && value != nil {
+					rec.values[name] = strings.TrimSpace(fmt.Sprint(value))
+				}
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+type rowErrors []models.ImportRowError
+
+func (re *rowErrors) add(line int, field, message string) {
+	*re = append(*re, models.ImportRowError{Row: line, Field: field, Message: message})
+}
+
+func (rec record) malformed() rowErrors {
+	if rec.values != nil {
+		return nil
+	}
+
+	return rowErrors{{Row: rec.line, Message: "malformed row"}}
+}
+
+func (rec record) text(errs *rowErrors, field string, maxLen int, required bool) string {
+	value := rec.values[field]
+
+	if required && value == "" {
+		errs.add(rec.line, field, "is required")
+	} else if utf8.RuneCountInString(value) > maxLen {
+		errs.add(rec.line, field, fmt.Sprintf("is longer than %d characters", maxLen))
+	}
+
+	return value
+}
+
+// date accepts both plain dates and RFC 3339 timestamps, the latter is how
+// the API itself renders dates.
+func (rec record) date(errs *rowErrors, field string) time.Time {
+	value := rec.values[field]
+
+	if d, err := time.Parse(time.DateOnly, value); err == nil {
+		return d
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	errs.add(rec.line, field, "must be a YYYY-MM-DD date")
+
+	return time.Time{}
+}
+
+func parseMovie(rec record) (models.Movie, rowErrors) {
+	if errs := rec.malformed(); errs != nil {
+		return models.Movie{}, errs
+	}
+
+	var errs rowErrors
+
+	m := models.Movie{
+		Title:       rec.text(&errs, "title", 150, true),
+		Description: rec.text(&errs, "description", 1000, false),
+		ReleaseDate: rec.date(&errs, "release_date"),
+	}
+
+	rating, err := strconv.Atoi(rec.values["rating"])
+	if err != nil || rating < 0 || rating > 10 {
+		errs.add(rec.line, "rating", "must be an integer from 0 to 10")
+	}
+	m.Rating = rating
+
+	return m, errs
+}
+
+func parseActor(rec record) (models.Actor, rowErrors) {
+	if errs := rec.malformed(); errs != nil {
+		return models.Actor{}, errs
+	}
+
+	var errs rowErrors
+
+	a := models.Actor{
+		FirstName: rec.text(&errs, "first_name", 35, true),
+		LastName:  rec.text(&errs, "last_name", 35, true),
+		Birthday:  rec.date(&errs, "birthday"),
+	}
+
+	// models.Actor renders gender as a byte, so NDJSON exported from the API
+	// carries 102/109 instead of f/m.
+	switch strings.ToLower(rec.values["gender"]) {
+	case "m", "109":
+		a.Gender = 'm'
+	case "f", "102":
+		a.Gender = 'f'
+	default:
+		errs.add(rec.line, "gender", "must be m or f")
+	}
+
+	if a.Birthday.After(time.Now()) {
+		errs.add(rec.line, "birthday", "is in the future")
+	}
+
+	return a, errs
+}
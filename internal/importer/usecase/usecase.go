@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	actorRep "intern/internal/actor/repository"
+	auditUseCase "intern/internal/audit/usecase"
+	importRep "intern/internal/importer/repository"
+	movieRep "intern/internal/movie/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// SyncRows is the largest upload processed within the request; bigger
+	// ones run in the background and are polled through the job.
+	SyncRows = 500
+	// MaxRowErrors caps the errors stored on a job, Failed keeps counting.
+	MaxRowErrors = 1000
+	// StaleAfter is how long a running job may go without saving progress
+	// before it is taken for the job of a stopped server. Progress is saved
+	// after every batch of batchSize rows, far more often.
+	StaleAfter = 10 * time.Minute
+
+	batchSize = 100
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+type ImportUseCaseI interface {
+	Import(ctx context.Context, kind, format string, dryRun bool, r io.Reader) (*models.ImportJob, error)
+	GetJob(id int) (*models.ImportJob, error)
+	FailStale() (int, error)
+}
+
+type importUseCase struct {
+	movieRepository movieRep.MovieRepositoryI
+	actorRepository actorRep.ActorRepositoryI
+	jobRepository   importRep.JobRepositoryI
+	audit           auditUseCase.AuditUseCaseI
+	logger          logger.Logger
+}
+
+// New takes a logger unlike the other use cases: large imports finish in
+// the background, where there is no caller left to return errors to.
+func New(mRep movieRep.MovieRepositoryI, aRep actorRep.ActorRepositoryI, jRep importRep.JobRepositoryI,
+	audit auditUseCase.AuditUseCaseI, logger logger.Logger) ImportUseCaseI {
+	return &importUseCase{
+		movieRepository: mRep,
+		actorRepository: aRep,
+		jobRepository:   jRep,
+		audit:           audit,
+		logger:          logger,
+	}
+}
+
+// Import upserts the movies or actors of r by their natural key (title and
+// release date, or full name and birthday). Invalid rows are skipped and
+// reported on the job. A dry run only validates and tells how many rows
+// would be created or updated.
+//
+// The rows are not audited one by one: before any is written, the import
+// is recorded as one entry, by the user of ctx, that points at the job.
+// Nor do they add an event each: every batch is a bulk load, with one
+// event of its rows.
+func (iUC *importUseCase) Import(ctx context.Context, kind, format string, dryRun bool, r io.Reader) (*models.ImportJob, error) {
+	if _, ok := kindColumns[kind]; !ok {
+		return nil, errors.Wrapf(ErrInvalidImport, "importUseCase.Import error: unknown kind %q", kind)
+	}
+
+	records, err := readRecords(kind, format, r)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidImport, "importUseCase.Import error: %v", err)
+	}
+
+	job := &models.ImportJob{
+		Kind:   kind,
+		Format: format,
+		DryRun: dryRun,
+		Status: models.ImportStatusRunning,
+		Total:  len(records),
+		Errors: []models.ImportRowError{},
+	}
+
+	err = iUC.jobRepository.Create(job)
+	if err != nil {
+		return nil, errors.Wrap(err, "importUseCase.Import error: can't create job")
+	}
+
+	if !dryRun {
+		err = iUC.record(ctx, job)
+		if err != nil {
+			iUC.fail(job, err)
+			return nil, errors.Wrap(err, "importUseCase.Import error")
+		}
+	}
+
+	if len(records) <= SyncRows {
+		err = iUC.run(job, records)
+		if err != nil {
+			iUC.fail(job, err)
+			return nil, errors.Wrap(err, "importUseCase.Import error")
+		}
+
+		return job, nil
+	}
+
+	started := *job
+	started.Errors = []models.ImportRowError{}
+
+	go func() {
+		if err := iUC.run(job, records); err != nil {
+			iUC.logger.Errorw("import job failed", "job", job.ID, "err:", err.Error())
+			iUC.fail(job, err)
+		}
+	}()
+
+	return &started, nil
+}
+
+func (iUC *importUseCase) GetJob(id int) (*models.ImportJob, error) {
+	job, err := iUC.jobRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "importUseCase.GetJob error")
+	}
+
+	return job, nil
+}
+
+// FailStale fails the jobs a stopped server left running. The running jobs
+// of the other servers keep saving progress and are not touched.
+func (iUC *importUseCase) FailStale() (int, error) {
+	n, err := iUC.jobRepository.FailStale(time.Now().Add(-StaleAfter), "the import was interrupted")
+
+	if err != nil {
+		return 0, errors.Wrap(err, "importUseCase.FailStale error")
+	}
+
+	return n, nil
+}
+
+// record appends the audit entry of job, with the id 0 of no single movie
+// or actor.
+func (iUC *importUseCase) record(ctx context.Context, job *models.ImportJob) error {
+	entity := models.AuditMovie
+	if job.Kind == models.ImportActors {
+		entity = models.AuditActor
+	}
+
+	after := map[string]interface{}{"job": job.ID, "format": job.Format, "rows": job.Total}
+
+	err := iUC.audit.Record(ctx, models.AuditImport, entity, 0, nil, after)
+	if err != nil {
+		return errors.Wrap(err, "can't record audit")
+	}
+
+	return nil
+}
+
+// fail records why job stopped, so that it is not polled as running forever.
+func (iUC *importUseCase) fail(job *models.ImportJob, cause error) {
+	finished := time.Now()
+	job.Status = models.ImportStatusFailed
+	job.Error = cause.Error()
+	job.FinishedAt = &finished
+
+	if err := iUC.jobRepository.Update(job); err != nil {
+		iUC.logger.Errorw("can`t fail import job", "job", job.ID, "err:", err.Error())
+	}
+}
+
+func (iUC *importUseCase) run(job *models.ImportJob, records []record) error {
+	// Natural keys met earlier in the file, so that a dry run reports a
+	// repeated row as an update like the real import would do.
+	seen := make(map[string]bool)
+
+	for start := 0; start < len(records); start += batchSize {
+		if err := iUC.importBatch(job, records[start:min(start+batchSize, len(records))], seen); err != nil {
+			return errors.Wrap(err, "can't save rows")
+		}
+
+		if job.Processed < job.Total {
+			if err := iUC.jobRepository.Update(job); err != nil {
+				return errors.Wrap(err, "can't save job progress")
+			}
+		}
+	}
+
+	finished := time.Now()
+	job.Status = models.ImportStatusDone
+	job.FinishedAt = &finished
+
+	if err := iUC.jobRepository.Update(job); err != nil {
+		return errors.Wrap(err, "can't finish job")
+	}
+
+	return nil
+}
+
+// importBatch imports the rows of batch as a bulk load, or looks them up
+// for a dry run.
+func (iUC *importUseCase) importBatch(job *models.ImportJob, batch []record, seen map[string]bool) error {
+	switch {
+	case job.DryRun:
+		iUC.importRows(job, batch, seen, iUC.movieRepository, iUC.actorRepository)
+		return nil
+	case job.Kind == models.ImportMovies:
+		return iUC.movieRepository.Bulk(models.BulkSourceImport, func(movies movieRep.MovieRepositoryI) ([]int, error) {
+			return iUC.importRows(job, batch, seen, movies, iUC.actorRepository), nil
+		})
+	default:
+		return iUC.actorRepository.Bulk(models.BulkSourceImport, func(actors actorRep.ActorRepositoryI) ([]int, error) {
+			return iUC.importRows(job, batch, seen, iUC.movieRepository, actors), nil
+		})
+	}
+}
+
+// importRows imports the rows of batch into movies or actors, as the kind
+// of job says, and returns the ids written.
+func (iUC *importUseCase) importRows(job *models.ImportJob, batch []record, seen map[string]bool,
+	movies movieRep.MovieRepositoryI, actors actorRep.ActorRepositoryI) []int {
+	written := []int{}
+
+	for _, rec := range batch {
+		var id int
+		var errs rowErrors
+		if job.Kind == models.ImportMovies {
+			id, errs = iUC.importMovie(movies, job, rec, seen)
+		} else {
+			id, errs = iUC.importActor(actors, job, rec, seen)
+		}
+
+		if id != 0 {
+			written = append(written, id)
+		}
+
+		if len(errs) > 0 {
+			job.Failed++
+			if room := MaxRowErrors - len(job.Errors); room > 0 {
+				job.Errors = append(job.Errors, errs[:min(len(errs), room)]...)
+			}
+		}
+
+		job.Processed++
+	}
+
+	return written
+}
+
+// importMovie returns the id of the movie written, 0 for a dry run.
+func (iUC *importUseCase) importMovie(movies movieRep.MovieRepositoryI, job *models.ImportJob, rec record,
+	seen map[string]bool) (int, rowErrors) {
+	m, errs := parseMovie(rec)
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	if job.DryRun {
+		key := fmt.Sprintf("%s\x00%s", m.Title, m.ReleaseDate.Format(time.DateOnly))
+
+		return 0, iUC.count(job, rec, seen, key, func() (bool, error) {
+			stored, err := movies.GetByNaturalKey(m.Title, m.ReleaseDate)
+			if err != nil {
+				return false, err
+			}
+
+			return stored.DeletedAt.Valid, nil
+		})
+	}
+
+	outcome, err := movies.Upsert(&m)
+	if err != nil {
+		return 0, rowErrors{{Row: rec.line, Message: "can't save movie"}}
+	}
+
+	iUC.tally(job, outcome)
+
+	return m.ID, nil
+}
+
+// importActor is importMovie for an actor.
+func (iUC *importUseCase) importActor(actors actorRep.ActorRepositoryI, job *models.ImportJob, rec record,
+	seen map[string]bool) (int, rowErrors) {
+	a, errs := parseActor(rec)
+	if len(errs) > 0 {
+		return 0, errs
+	}
+
+	if job.DryRun {
+		key := fmt.Sprintf("%s\x00%s\x00%s", a.FirstName, a.LastName, a.Birthday.Format(time.DateOnly))
+
+		return 0, iUC.count(job, rec, seen, key, func() (bool, error) {
+			stored, err := actors.GetByNaturalKey(a.FirstName, a.LastName, a.Birthday)
+			if err != nil {
+				return false, err
+			}
+
+			return stored.DeletedAt.Valid, nil
+		})
+	}
+
+	outcome, err := actors.Upsert(&a)
+	if err != nil {
+		return 0, rowErrors{{Row: rec.line, Message: "can't save actor"}}
+	}
+
+	iUC.tally(job, outcome)
+
+	return a.ID, nil
+}
+
+// count classifies a dry-run row as Upsert would; lookup must return
+// gorm.ErrRecordNotFound for a key that is not stored yet, and whether the
+// row stored is in the trash otherwise.
+func (iUC *importUseCase) count(job *models.ImportJob, rec record, seen map[string]bool, key string, lookup func() (trashed bool, err error)) rowErrors {
+	outcome := models.UpsertUpdated
+
+	if !seen[key] {
+		trashed, err := lookup()
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			outcome = models.UpsertCreated
+		case err != nil:
+			return rowErrors{{Row: rec.line, Message: "can't look up existing row"}}
+		case trashed:
+			outcome = models.UpsertRestored
+		}
+	}
+
+	seen[key] = true
+	iUC.tally(job, outcome)
+
+	return nil
+}
+
+func (iUC *importUseCase) tally(job *models.ImportJob, outcome string) {
+	switch outcome {
+	case models.UpsertCreated:
+		job.Created++
+	case models.UpsertRestored:
+		job.Restored++
+	default:
+		job.Updated++
+	}
+}
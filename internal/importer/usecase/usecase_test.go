@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	memActor "intern/internal/actor/repository/memory"
+	memAudit "intern/internal/audit/repository/memory"
+	auditUseCase "intern/internal/audit/usecase"
+	importRep "intern/internal/importer/repository"
+	memImport "intern/internal/importer/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	"intern/models"
+	ctxManager "intern/pkg/context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newUseCase(db *memdb.DB) ImportUseCaseI {
+	logger := zap.NewNop().Sugar()
+	return New(memMovie.New(logger, db), memActor.New(logger, db), memImport.New(logger, db), newAudit(db), logger)
+}
+
+func newAudit(db *memdb.DB) auditUseCase.AuditUseCaseI {
+	return auditUseCase.New(memAudit.New(nil, db), ctxManager.Manager{})
+}
+
+func seedMovie(db *memdb.DB) models.Movie {
+	m := models.Movie{
+		ID:          1,
+		Title:       "Alien",
+		Description: "In space no one can hear you scream",
+		ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC),
+		Rating:      8,
+	}
+	db.Movies[m.ID] = m
+	db.SeenID("movies", m.ID)
+
+	return m
+}
+
+const moviesCSV = `1;Alien;Director's cut;1979-05-25;9
+Aliens;Back again;1986-07-18;8
+Aliens;Back again, twice;1986-07-18;7
+;no title;1990-01-01;3
+Alien 3;bad date and rating;1992-05-32;12
+too;few
+`
+
+func TestImportMoviesCSV(t *testing.T) {
+	db := memdb.New()
+	seedMovie(db)
+
+	job, err := newUseCase(db).Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(moviesCSV))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ImportStatusDone, job.Status)
+	assert.Equal(t, 6, job.Total)
+	assert.Equal(t, 6, job.Processed)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 2, job.Updated)
+	assert.Equal(t, 3, job.Failed)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 4, Field: "title", Message: "is required"},
+		{Row: 5, Field: "release_date", Message: "must be a YYYY-MM-DD date"},
+		{Row: 5, Field: "rating", Message: "must be an integer from 0 to 10"},
+		{Row: 6, Message: "malformed row"},
+	}, job.Errors)
+
+	assert.Len(t, db.Movies, 2)
+	assert.Equal(t, "Director's cut", db.Movies[1].Description)
+	assert.Equal(t, "Back again, twice", db.Movies[2].Description)
+
+	stored, err := newUseCase(db).GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, job, stored)
+}
+
+func TestImportDryRunDoesNotWrite(t *testing.T) {
+	db := memdb.New()
+	seedMovie(db)
+
+	job, err := newUseCase(db).Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, true, strings.NewReader(moviesCSV))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 2, job.Updated)
+	assert.Equal(t, 3, job.Failed)
+	assert.Len(t, db.Movies, 1)
+	assert.Equal(t, "In space no one can hear you scream", db.Movies[1].Description)
+	assert.Empty(t, db.AuditEntries)
+}
+
+func TestImportAddsBulkEvent(t *testing.T) {
+	db := memdb.New()
+	seedMovie(db)
+
+	_, err := newUseCase(db).Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(moviesCSV))
+	require.NoError(t, err)
+
+	// One event for the batch, none for the rows.
+	require.Len(t, db.Events, 1)
+	assert.Equal(t, models.EventCatalogueLoaded, db.Events[0].Type)
+	assert.JSONEq(t, `{"source":"import","movies":[1,2],"actors":[]}`, string(db.Events[0].Payload))
+}
+
+func TestImportRestoresTrashed(t *testing.T) {
+	db := memdb.New()
+	m := seedMovie(db)
+	delete(db.Movies, m.ID)
+	m.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	db.TrashedMovies[m.ID] = m
+
+	const csv = "Alien;Back from the trash;1979-05-25;8\n"
+
+	job, err := newUseCase(db).Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, true, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.Created)
+	assert.Equal(t, 0, job.Updated)
+	assert.Equal(t, 1, job.Restored)
+	assert.Empty(t, db.Movies)
+
+	job, err = newUseCase(db).Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.Created)
+	assert.Equal(t, 0, job.Updated)
+	assert.Equal(t, 1, job.Restored)
+	assert.Empty(t, db.TrashedMovies)
+	assert.Equal(t, "Back from the trash", db.Movies[m.ID].Description)
+}
+
+func TestImportRecordsAudit(t *testing.T) {
+	db := memdb.New()
+	ctx := ctxManager.Manager{}.ContextWithUserID(context.Background(), 3)
+
+	job, err := newUseCase(db).Import(ctx, models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(moviesCSV))
+	require.NoError(t, err)
+
+	// One entry for the whole import, none for the rows.
+	require.Len(t, db.AuditEntries, 1)
+	e := db.AuditEntries[0]
+	assert.Equal(t, 3, e.UserID)
+	assert.Equal(t, models.AuditImport, e.Action)
+	assert.Equal(t, models.AuditMovie, e.Entity)
+	assert.Equal(t, 0, e.EntityID)
+	assert.Nil(t, e.Before)
+	assert.JSONEq(t, fmt.Sprintf(`{"job":%d,"format":"csv","rows":6}`, job.ID), string(e.After))
+}
+
+func TestImportActorsNDJSON(t *testing.T) {
+	db := memdb.New()
+
+	body := `{"firstName":"Sigourney","lastName":"Weaver","gender":"f","birthday":"1949-10-08"}
+
+{"firstName":"Tom","lastName":"Skerritt","gender":109,"birthday":"1933-08-25T00:00:00Z"}
+{"firstName":"Ian","lastName":"Holm","gender":"x","birthday":"1931-09-12"}
+{broken
+`
+
+	job, err := newUseCase(db).Import(context.Background(), models.ImportActors, models.ImportFormatNDJSON, false, strings.NewReader(body))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, job.Total)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 2, job.Failed)
+	assert.Equal(t, []models.ImportRowError{
+		{Row: 4, Field: "gender", Message: "must be m or f"},
+		{Row: 5, Message: "malformed row"},
+	}, job.Errors)
+	assert.Equal(t, byte('m'), db.Actors[2].Gender)
+}
+
+func TestImportLargeFileRunsInBackground(t *testing.T) {
+	db := memdb.New()
+	uc := newUseCase(db)
+
+	var body strings.Builder
+	for i := 0; i < SyncRows+1; i++ {
+		fmt.Fprintf(&body, "Movie %d;desc;2001-01-01;5\n", i)
+	}
+
+	job, err := uc.Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(body.String()))
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportStatusRunning, job.Status)
+
+	require.Eventually(t, func() bool {
+		stored, err := uc.GetJob(job.ID)
+		return err == nil && stored.Status == models.ImportStatusDone
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stored, err := uc.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, SyncRows+1, stored.Created)
+}
+
+// failingJobs cannot save a job unless it is failing it.
+type failingJobs struct {
+	importRep.JobRepositoryI
+}
+
+var errSave = errors.New("save failed")
+
+func (fj failingJobs) Update(j *models.ImportJob) error {
+	if j.Status != models.ImportStatusFailed {
+		return errSave
+	}
+	return fj.JobRepositoryI.Update(j)
+}
+
+func newFailingUseCase(db *memdb.DB) ImportUseCaseI {
+	logger := zap.NewNop().Sugar()
+	return New(memMovie.New(logger, db), memActor.New(logger, db), failingJobs{memImport.New(logger, db)}, newAudit(db), logger)
+}
+
+func TestImportFailureFailsJob(t *testing.T) {
+	db := memdb.New()
+	uc := newFailingUseCase(db)
+
+	_, err := uc.Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(moviesCSV))
+	assert.True(t, errors.Is(err, errSave))
+
+	stored, err := uc.GetJob(1)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportStatusFailed, stored.Status)
+	assert.Contains(t, stored.Error, errSave.Error())
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestImportBackgroundFailureFailsJob(t *testing.T) {
+	db := memdb.New()
+	uc := newFailingUseCase(db)
+
+	var body strings.Builder
+	for i := 0; i < SyncRows+1; i++ {
+		fmt.Fprintf(&body, "Movie %d;desc;2001-01-01;5\n", i)
+	}
+
+	job, err := uc.Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader(body.String()))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		stored, err := uc.GetJob(job.ID)
+		return err == nil && stored.Status == models.ImportStatusFailed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stored, err := uc.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Contains(t, stored.Error, errSave.Error())
+	assert.NotNil(t, stored.FinishedAt)
+}
+
+func TestFailStale(t *testing.T) {
+	db := memdb.New()
+	now := time.Now()
+	db.ImportJobs[1] = models.ImportJob{ID: 1, Status: models.ImportStatusRunning, UpdatedAt: now.Add(-StaleAfter - time.Minute)}
+	db.ImportJobs[2] = models.ImportJob{ID: 2, Status: models.ImportStatusRunning, UpdatedAt: now}
+	db.ImportJobs[3] = models.ImportJob{ID: 3, Status: models.ImportStatusDone, UpdatedAt: now.Add(-time.Hour)}
+
+	n, err := newUseCase(db).FailStale()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, models.ImportStatusFailed, db.ImportJobs[1].Status)
+	assert.NotEmpty(t, db.ImportJobs[1].Error)
+	assert.NotNil(t, db.ImportJobs[1].FinishedAt)
+	assert.Equal(t, models.ImportStatusRunning, db.ImportJobs[2].Status)
+	assert.Equal(t, models.ImportStatusDone, db.ImportJobs[3].Status)
+}
+
+func TestImportInvalid(t *testing.T) {
+	uc := newUseCase(memdb.New())
+
+	_, err := uc.Import(context.Background(), "users", models.ImportFormatCSV, false, strings.NewReader(""))
+	assert.True(t, errors.Is(err, ErrInvalidImport))
+
+	_, err = uc.Import(context.Background(), models.ImportMovies, "xml", false, strings.NewReader(""))
+	assert.True(t, errors.Is(err, ErrInvalidImport))
+
+	_, err = uc.Import(context.Background(), models.ImportMovies, models.ImportFormatCSV, false, strings.NewReader("\"unterminated;x;2000-01-01;1\n"))
+	assert.True(t, errors.Is(err, ErrInvalidImport))
+}
@@ -0,0 +1,557 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	listUseCase "intern/internal/list/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ListForm struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Visibility is public, unlisted or private (the default on create).
+	Visibility string `json:"visibility"`
+}
+
+type EntryForm struct {
+	MovieID int `json:"movieId"`
+	// Position counts from 1, zero appends.
+	Position int    `json:"position"`
+	Note     string `json:"note"`
+}
+
+type NoteForm struct {
+	Note string `json:"note"`
+}
+
+type OrderForm struct {
+	MovieIDs []int `json:"movieIds"`
+}
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type ListHandler struct {
+	ListUseCase listUseCase.ListUseCaseI
+	Logger      logger.Logger
+	Context     ContextManager
+}
+
+// Create godoc
+// @Summary      Create list
+// @Description  Create a named movie list of the signed in user. Lists are private unless visibility says otherwise.
+// @Tags     lists
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param list body ListForm true "list to create"
+// @Success 201 {object} models.List "list created"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists [post]
+func (lh *ListHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	form := ListForm{}
+	if !lh.readForm(w, r, &form) {
+		return
+	}
+
+	l := &models.List{UserID: userID, Name: form.Name, Description: form.Description, Visibility: form.Visibility}
+
+	err := lh.ListUseCase.Create(l)
+	if err != nil {
+		lh.fail(w, err, "create list")
+		return
+	}
+
+	lh.write(w, http.StatusCreated, l)
+}
+
+// Mine godoc
+// @Summary      My lists
+// @Description  Lists of the signed in user without their entries, most recently changed first
+// @Tags     lists
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.List "success get lists"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/lists [get]
+func (lh *ListHandler) Mine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		lh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	lists, err := lh.ListUseCase.Mine(userID, page.Limit, page.Offset)
+	if err != nil {
+		lh.fail(w, err, "get lists")
+		return
+	}
+
+	lh.write(w, http.StatusOK, lists)
+}
+
+// Get godoc
+// @Summary      Get list
+// @Description  Get a list with its entries in order. Works for own lists and public lists of others.
+// @Tags     lists
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Success 200 {object} models.List "success get list"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id} [get]
+func (lh *ListHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	l, err := lh.ListUseCase.Get(listID, userID)
+	if err != nil {
+		lh.fail(w, err, "get list")
+		return
+	}
+
+	lh.write(w, http.StatusOK, l)
+}
+
+// GetShared godoc
+// @Summary      Get shared list
+// @Description  Get a public or unlisted list with its entries by its share token. No sign in needed.
+// @Tags     lists
+// @Produce  application/json
+// @Param token path string true "share token"
+// @Success 200 {object} models.List "success get list"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /shared/lists/{token} [get]
+func (lh *ListHandler) GetShared(w http.ResponseWriter, r *http.Request) {
+	l, err := lh.ListUseCase.GetShared(r.PathValue("TOKEN"))
+	if err != nil {
+		lh.fail(w, err, "get shared list")
+		return
+	}
+
+	lh.write(w, http.StatusOK, l)
+}
+
+// Update godoc
+// @Summary      Update list
+// @Description  Replace the name, description and visibility of an own list
+// @Tags     lists
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Param list body ListForm true "new list fields"
+// @Success 200 {object} models.List "list updated"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id} [put]
+func (lh *ListHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	form := ListForm{}
+	if !lh.readForm(w, r, &form) {
+		return
+	}
+
+	l := &models.List{ID: listID, Name: form.Name, Description: form.Description, Visibility: form.Visibility}
+
+	err := lh.ListUseCase.Update(l, userID)
+	if err != nil {
+		lh.fail(w, err, "update list")
+		return
+	}
+
+	lh.write(w, http.StatusOK, l)
+}
+
+// Delete godoc
+// @Summary      Delete list
+// @Description  Delete an own list with its entries
+// @Tags     lists
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Success 200 {object} nil "list deleted"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id} [delete]
+func (lh *ListHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	err := lh.ListUseCase.Delete(listID, userID)
+	if err != nil {
+		lh.fail(w, err, "delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RotateToken godoc
+// @Summary      New share link
+// @Description  Replace the share token of an own list; links handed out before stop working
+// @Tags     lists
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Success 200 {object} models.List "token replaced"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id}/share-token [post]
+func (lh *ListHandler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	l, err := lh.ListUseCase.RotateToken(listID, userID)
+	if err != nil {
+		lh.fail(w, err, "rotate share token")
+		return
+	}
+
+	lh.write(w, http.StatusOK, l)
+}
+
+// AddEntry godoc
+// @Summary      Add movie to list
+// @Description  Put a movie with an optional note into an own list, at position (from 1) or at the end
+// @Tags     lists
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Param entry body EntryForm true "movie, position and note"
+// @Success 201 {object} models.ListEntry "entry added"
+// @Failure 400 {object} nil "invalid body or position"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List or movie not found"
+// @Failure 409 {object} nil "movie already in the list"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id}/entries [post]
+func (lh *ListHandler) AddEntry(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	form := EntryForm{}
+	if !lh.readForm(w, r, &form) {
+		return
+	}
+
+	e := &models.ListEntry{ListID: listID, MovieID: form.MovieID, Position: form.Position, Note: form.Note}
+
+	err := lh.ListUseCase.AddEntry(e, userID)
+	if err != nil {
+		lh.fail(w, err, "add list entry")
+		return
+	}
+
+	lh.write(w, http.StatusCreated, e)
+}
+
+// UpdateEntry godoc
+// @Summary      Update list entry
+// @Description  Replace the note of a movie in an own list
+// @Tags     lists
+// @Accept	 application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Param movie path int true "MOV_ID"
+// @Param note body NoteForm true "new note"
+// @Success 200 {object} nil "entry updated"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List or entry not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id}/entries/{movie} [put]
+func (lh *ListHandler) UpdateEntry(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	movieID, ok := lh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	form := NoteForm{}
+	if !lh.readForm(w, r, &form) {
+		return
+	}
+
+	err := lh.ListUseCase.UpdateEntry(&models.ListEntry{ListID: listID, MovieID: movieID, Note: form.Note}, userID)
+	if err != nil {
+		lh.fail(w, err, "update list entry")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveEntry godoc
+// @Summary      Remove movie from list
+// @Description  Remove a movie from an own list, the entries after it move up
+// @Tags     lists
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Param movie path int true "MOV_ID"
+// @Success 200 {object} nil "entry removed"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List or entry not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id}/entries/{movie} [delete]
+func (lh *ListHandler) RemoveEntry(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	movieID, ok := lh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	err := lh.ListUseCase.RemoveEntry(listID, movieID, userID)
+	if err != nil {
+		lh.fail(w, err, "remove list entry")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reorder godoc
+// @Summary      Reorder list
+// @Description  Put the entries of an own list in the given order; movieIds must name every movie of the list once
+// @Tags     lists
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "LIST_ID"
+// @Param order body OrderForm true "movie ids in the new order"
+// @Success 200 {object} models.List "list reordered"
+// @Failure 400 {object} nil "invalid order"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the owner"
+// @Failure 404 {object} nil "List not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /lists/{id}/order [put]
+func (lh *ListHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := lh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	listID, ok := lh.pathID(w, r, "LIST_ID")
+	if !ok {
+		return
+	}
+
+	form := OrderForm{}
+	if !lh.readForm(w, r, &form) {
+		return
+	}
+
+	l, err := lh.ListUseCase.Reorder(listID, form.MovieIDs, userID)
+	if err != nil {
+		lh.fail(w, err, "reorder list")
+		return
+	}
+
+	lh.write(w, http.StatusOK, l)
+}
+
+func (lh *ListHandler) userID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := lh.Context.UserIDFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Errorw("can`t get user",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func (lh *ListHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	idString := r.PathValue(name)
+	if idString == "" {
+		lh.Logger.Errorw("no " + name + " var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		lh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (lh *ListHandler) readForm(w http.ResponseWriter, r *http.Request, form interface{}) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		lh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		lh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return false
+	}
+
+	err = json.Unmarshal(body, form)
+	if err != nil {
+		lh.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// fail maps the errors of the use case to a status.
+func (lh *ListHandler) fail(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, listUseCase.ErrInvalidList):
+		lh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+	case errors.Is(err, listUseCase.ErrNotOwner):
+		lh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case errors.Is(err, listUseCase.ErrEntryExists):
+		lh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "movie already in the list", http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		lh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		lh.Logger.Errorw("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "can`t "+action, http.StatusInternalServerError)
+	}
+}
+
+func (lh *ListHandler) write(w http.ResponseWriter, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		lh.Logger.Errorw("can`t marshal list",
+			"err:", err.Error())
+		http.Error(w, "can`t make list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		lh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
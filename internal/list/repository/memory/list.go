@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/list/repository"
+	"intern/internal/memdb"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memListRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.ListRepositoryI {
+	return &memListRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (lr *memListRepo) Create(l *models.List) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	for _, other := range lr.DB.Lists {
+		if other.ShareToken == l.ShareToken {
+			return errors.New("memListRepo.Create error: share token taken")
+		}
+	}
+
+	now := time.Now()
+	l.ID = lr.DB.NextID("lists")
+	l.CreatedAt, l.UpdatedAt = now, now
+
+	stored := *l
+	stored.Entries = nil
+	lr.DB.Lists[l.ID] = stored
+
+	return nil
+}
+
+func (lr *memListRepo) Get(id int) (*models.List, error) {
+	lr.DB.RLock()
+	defer lr.DB.RUnlock()
+
+	l, ok := lr.DB.Lists[id]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.Get error")
+	}
+
+	return &l, nil
+}
+
+func (lr *memListRepo) GetByToken(token string) (*models.List, error) {
+	lr.DB.RLock()
+	defer lr.DB.RUnlock()
+
+	for _, l := range lr.DB.Lists {
+		if l.ShareToken == token {
+			return &l, nil
+		}
+	}
+
+	return nil, errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.GetByToken error")
+}
+
+func (lr *memListRepo) ListByUser(userID, limit, offset int) ([]models.List, error) {
+	lr.DB.RLock()
+	defer lr.DB.RUnlock()
+
+	lists := []models.List{}
+	for _, l := range lr.DB.Lists {
+		if l.UserID == userID {
+			lists = append(lists, l)
+		}
+	}
+
+	slices.SortFunc(lists, func(a, b models.List) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return memdb.Page(lists, limit, offset), nil
+}
+
+func (lr *memListRepo) Update(l *models.List) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	stored, ok := lr.DB.Lists[l.ID]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.Update error")
+	}
+
+	stored.Name = l.Name
+	stored.Description = l.Description
+	stored.Visibility = l.Visibility
+	stored.ShareToken = l.ShareToken
+	stored.UpdatedAt = l.UpdatedAt
+	lr.DB.Lists[l.ID] = stored
+
+	return nil
+}
+
+func (lr *memListRepo) Delete(id int) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	if _, ok := lr.DB.Lists[id]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.Delete error")
+	}
+
+	delete(lr.DB.Lists, id)
+	for key := range lr.DB.ListEntries {
+		if key.ListID == id {
+			delete(lr.DB.ListEntries, key)
+		}
+	}
+
+	return nil
+}
+
+func (lr *memListRepo) Entries(listID int) ([]models.ListEntry, error) {
+	lr.DB.RLock()
+	defer lr.DB.RUnlock()
+
+	// A movie in the trash keeps its entry, without the movie.
+	entries := lr.entries(listID)
+	for i := range entries {
+		if m, ok := lr.DB.Movies[entries[i].MovieID]; ok {
+			entries[i].Movie = &m
+		}
+	}
+
+	return entries, nil
+}
+
+// entries returns the entries of the list in order. Must be called with the
+// lock held.
+func (lr *memListRepo) entries(listID int) []models.ListEntry {
+	entries := []models.ListEntry{}
+	for key, e := range lr.DB.ListEntries {
+		if key.ListID == listID {
+			entries = append(entries, e)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b models.ListEntry) int { return cmp.Compare(a.Position, b.Position) })
+
+	return entries
+}
+
+func (lr *memListRepo) AddEntry(e *models.ListEntry) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	l, ok := lr.DB.Lists[e.ListID]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.AddEntry error")
+	}
+
+	if _, ok := lr.DB.Movies[e.MovieID]; !ok {
+		return errors.Errorf("memListRepo.AddEntry error: movie %d does not exist", e.MovieID)
+	}
+
+	key := memdb.ListEntryKey{ListID: e.ListID, MovieID: e.MovieID}
+	if _, ok := lr.DB.ListEntries[key]; ok {
+		return errors.Errorf("memListRepo.AddEntry error: movie %d already in list %d", e.MovieID, e.ListID)
+	}
+
+	entries := lr.entries(e.ListID)
+	if e.Position == 0 {
+		e.Position = 1
+		if len(entries) > 0 {
+			e.Position = entries[len(entries)-1].Position + 1
+		}
+	} else {
+		for _, other := range entries {
+			if other.Position >= e.Position {
+				other.Position++
+				lr.DB.ListEntries[memdb.ListEntryKey{ListID: other.ListID, MovieID: other.MovieID}] = other
+			}
+		}
+	}
+
+	e.AddedAt = time.Now()
+	stored := *e
+	stored.Movie = nil
+	lr.DB.ListEntries[key] = stored
+
+	l.UpdatedAt = e.AddedAt
+	lr.DB.Lists[l.ID] = l
+
+	return nil
+}
+
+func (lr *memListRepo) UpdateEntry(e *models.ListEntry) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	key := memdb.ListEntryKey{ListID: e.ListID, MovieID: e.MovieID}
+	stored, ok := lr.DB.ListEntries[key]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.UpdateEntry error")
+	}
+
+	stored.Note = e.Note
+	lr.DB.ListEntries[key] = stored
+	lr.touch(e.ListID)
+
+	return nil
+}
+
+func (lr *memListRepo) RemoveEntry(listID, movieID int) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	key := memdb.ListEntryKey{ListID: listID, MovieID: movieID}
+	removed, ok := lr.DB.ListEntries[key]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.RemoveEntry error")
+	}
+
+	delete(lr.DB.ListEntries, key)
+	for other, e := range lr.DB.ListEntries {
+		if other.ListID == listID && e.Position > removed.Position {
+			e.Position--
+			lr.DB.ListEntries[other] = e
+		}
+	}
+	lr.touch(listID)
+
+	return nil
+}
+
+func (lr *memListRepo) Reorder(listID int, movieIDs []int) error {
+	lr.DB.Lock()
+	defer lr.DB.Unlock()
+
+	if _, ok := lr.DB.Lists[listID]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memListRepo.Reorder error")
+	}
+
+	for i, movieID := range movieIDs {
+		key := memdb.ListEntryKey{ListID: listID, MovieID: movieID}
+		if e, ok := lr.DB.ListEntries[key]; ok {
+			e.Position = i + 1
+			lr.DB.ListEntries[key] = e
+		}
+	}
+	lr.touch(listID)
+
+	return nil
+}
+
+// touch moves the update time of the list. Must be called with the write
+// lock held.
+func (lr *memListRepo) touch(listID int) {
+	if l, ok := lr.DB.Lists[listID]; ok {
+		l.UpdatedAt = time.Now()
+		lr.DB.Lists[listID] = l
+	}
+}
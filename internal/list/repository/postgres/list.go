@@ -0,0 +1,259 @@
+package postgres
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/list/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+const reorderQuery = `UPDATE list_entries e SET position = o.position
+FROM unnest(?::int[]) WITH ORDINALITY AS o(movie_id, position)
+WHERE e.list_id = ? AND e.movie_id = o.movie_id`
+
+type pgListRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.ListRepositoryI {
+	return &pgListRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (lr *pgListRepo) Create(l *models.List) error {
+	tx := lr.DB.Create(l)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgListRepo.Create error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) Get(id int) (*models.List, error) {
+	var l models.List
+	tx := lr.DB.Where("id = ?", id).Take(&l)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgListRepo.Get error")
+	}
+
+	return &l, nil
+}
+
+func (lr *pgListRepo) GetByToken(token string) (*models.List, error) {
+	var l models.List
+	tx := lr.DB.Where("share_token = ?", token).Take(&l)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgListRepo.GetByToken error")
+	}
+
+	return &l, nil
+}
+
+func (lr *pgListRepo) ListByUser(userID, limit, offset int) ([]models.List, error) {
+	lists := []models.List{}
+	tx := lr.DB.Where("user_id = ?", userID).Order("updated_at DESC, id DESC").Limit(limit).Offset(offset).Find(&lists)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgListRepo.ListByUser error")
+	}
+
+	return lists, nil
+}
+
+func (lr *pgListRepo) Update(l *models.List) error {
+	tx := lr.DB.Model(l).Select("name", "description", "visibility", "share_token", "updated_at").Updates(l)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgListRepo.Update error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgListRepo.Update error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) Delete(id int) error {
+	tx := lr.DB.Delete(&models.List{}, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgListRepo.Delete error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgListRepo.Delete error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) Entries(listID int) ([]models.ListEntry, error) {
+	entries := []models.ListEntry{}
+	tx := lr.DB.Where("list_id = ?", listID).Order("position").Find(&entries)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgListRepo.Entries error")
+	}
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.MovieID
+	}
+
+	var movies []models.Movie
+	tx = lr.DB.Where("id IN ?", ids).Find(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgListRepo.Entries error: can't get movies")
+	}
+
+	// A movie in the trash keeps its entry, without the movie.
+	byID := make(map[int]*models.Movie, len(movies))
+	for i := range movies {
+		byID[movies[i].ID] = &movies[i]
+	}
+	for i := range entries {
+		entries[i].Movie = byID[entries[i].MovieID]
+	}
+
+	return entries, nil
+}
+
+func (lr *pgListRepo) AddEntry(e *models.ListEntry) error {
+	err := lr.DB.Transaction(func(tx *gorm.DB) error {
+		err := lockList(tx, e.ListID)
+		if err != nil {
+			return err
+		}
+
+		if e.Position == 0 {
+			err = tx.Raw("SELECT COALESCE(MAX(position), 0) + 1 FROM list_entries WHERE list_id = ?", e.ListID).Scan(&e.Position).Error
+		} else {
+			err = tx.Exec("UPDATE list_entries SET position = position + 1 WHERE list_id = ? AND position >= ?", e.ListID, e.Position).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Raw("INSERT INTO list_entries (list_id, movie_id, position, note) VALUES (?, ?, ?, ?) RETURNING added_at",
+			e.ListID, e.MovieID, e.Position, e.Note).Scan(&e.AddedAt).Error
+		if err != nil {
+			return err
+		}
+
+		return touch(tx, e.ListID)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgListRepo.AddEntry error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) UpdateEntry(e *models.ListEntry) error {
+	err := lr.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec("UPDATE list_entries SET note = ? WHERE list_id = ? AND movie_id = ?", e.Note, e.ListID, e.MovieID)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return touch(tx, e.ListID)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgListRepo.UpdateEntry error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) RemoveEntry(listID, movieID int) error {
+	err := lr.DB.Transaction(func(tx *gorm.DB) error {
+		err := lockList(tx, listID)
+		if err != nil {
+			return err
+		}
+
+		var removed []int
+		err = tx.Raw("DELETE FROM list_entries WHERE list_id = ? AND movie_id = ? RETURNING position", listID, movieID).Scan(&removed).Error
+		if err != nil {
+			return err
+		}
+
+		if len(removed) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err = tx.Exec("UPDATE list_entries SET position = position - 1 WHERE list_id = ? AND position > ?", listID, removed[0]).Error
+		if err != nil {
+			return err
+		}
+
+		return touch(tx, listID)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgListRepo.RemoveEntry error")
+	}
+
+	return nil
+}
+
+func (lr *pgListRepo) Reorder(listID int, movieIDs []int) error {
+	err := lr.DB.Transaction(func(tx *gorm.DB) error {
+		err := lockList(tx, listID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(reorderQuery, pq.Array(movieIDs), listID).Error
+		if err != nil {
+			return err
+		}
+
+		return touch(tx, listID)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgListRepo.Reorder error")
+	}
+
+	return nil
+}
+
+func lockList(tx *gorm.DB, id int) error {
+	var ids []int
+
+	err := tx.Raw("SELECT id FROM lists WHERE id = ? FOR UPDATE", id).Scan(&ids).Error
+	if err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func touch(tx *gorm.DB, id int) error {
+	return tx.Exec("UPDATE lists SET updated_at = now() WHERE id = ?", id).Error
+}
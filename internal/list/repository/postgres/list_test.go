@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	listRep "intern/internal/list/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type ListRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   listRep.ListRepositoryI
+}
+
+func TestListRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(ListRepoTestSuite))
+}
+
+func (s *ListRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *ListRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *ListRepoTestSuite) TestGetByToken(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "lists" WHERE share_token = $1 LIMIT $2`)).
+		WithArgs("abc", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "description", "visibility", "share_token", "created_at", "updated_at"}).
+			AddRow(4, 7, "Thrillers", "", models.ListUnlisted, "abc", created, created))
+
+	l, err := s.repo.GetByToken("abc")
+	t.Assert().NoError(err)
+	t.Assert().Equal(&models.List{ID: 4, UserID: 7, Name: "Thrillers", Visibility: models.ListUnlisted, ShareToken: "abc", CreatedAt: created, UpdatedAt: created}, l)
+}
+
+func (s *ListRepoTestSuite) TestAddEntryAtPosition(t provider.T) {
+	added := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := &models.ListEntry{ListID: 4, MovieID: 9, Position: 2, Note: "slow start"}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM lists WHERE id = $1 FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE list_entries SET position = position + 1 WHERE list_id = $1 AND position >= $2`)).
+		WithArgs(4, 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO list_entries (list_id, movie_id, position, note) VALUES ($1, $2, $3, $4) RETURNING added_at`)).
+		WithArgs(4, 9, 2, "slow start").
+		WillReturnRows(sqlmock.NewRows([]string{"added_at"}).AddRow(added))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE lists SET updated_at = now() WHERE id = $1`)).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.AddEntry(entry)
+	t.Assert().NoError(err)
+	t.Assert().Equal(added, entry.AddedAt)
+}
+
+func (s *ListRepoTestSuite) TestAddEntryAppends(t provider.T) {
+	entry := &models.ListEntry{ListID: 4, MovieID: 9}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM lists WHERE id = $1 FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(MAX(position), 0) + 1 FROM list_entries WHERE list_id = $1`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(6))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO list_entries (list_id, movie_id, position, note) VALUES ($1, $2, $3, $4) RETURNING added_at`)).
+		WithArgs(4, 9, 6, "").
+		WillReturnRows(sqlmock.NewRows([]string{"added_at"}).AddRow(time.Now()))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE lists SET updated_at = now() WHERE id = $1`)).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.AddEntry(entry)
+	t.Assert().NoError(err)
+	t.Assert().Equal(6, entry.Position)
+}
+
+func (s *ListRepoTestSuite) TestRemoveEntry(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM lists WHERE id = $1 FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM list_entries WHERE list_id = $1 AND movie_id = $2 RETURNING position`)).
+		WithArgs(4, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE list_entries SET position = position - 1 WHERE list_id = $1 AND position > $2`)).
+		WithArgs(4, 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE lists SET updated_at = now() WHERE id = $1`)).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.RemoveEntry(4, 9)
+	t.Assert().NoError(err)
+}
+
+func (s *ListRepoTestSuite) TestRemoveEntryMissingList(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM lists WHERE id = $1 FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	s.mock.ExpectRollback()
+
+	err := s.repo.RemoveEntry(4, 9)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (s *ListRepoTestSuite) TestReorder(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM lists WHERE id = $1 FOR UPDATE`)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE list_entries e SET position = o.position FROM unnest($1::int[]) WITH ORDINALITY AS o(movie_id, position) WHERE e.list_id = $2 AND e.movie_id = o.movie_id`)).
+		WithArgs(pq.Array([]int{3, 1, 2}), 4).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE lists SET updated_at = now() WHERE id = $1`)).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Reorder(4, []int{3, 1, 2})
+	t.Assert().NoError(err)
+}
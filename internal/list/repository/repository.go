@@ -0,0 +1,27 @@
+package repository
+
+import "intern/models"
+
+// ListRepositoryI stores user lists and their entries. Entry positions
+// order a list; gaps left by deleted movies are allowed, so callers only
+// rely on the order. The entry methods lock the list row, so concurrent
+// changes of one list apply in turn.
+type ListRepositoryI interface {
+	Create(l *models.List) error
+	Get(id int) (*models.List, error)
+	GetByToken(token string) (*models.List, error)
+	ListByUser(userID, limit, offset int) ([]models.List, error)
+	Update(l *models.List) error
+	Delete(id int) error
+	// Entries returns the entries in order with their movies.
+	Entries(listID int) ([]models.ListEntry, error)
+	// AddEntry inserts e before the entry at e.Position, shifting it and
+	// the ones after it; a zero position appends. e.Position is set to the
+	// stored position.
+	AddEntry(e *models.ListEntry) error
+	UpdateEntry(e *models.ListEntry) error
+	RemoveEntry(listID, movieID int) error
+	// Reorder numbers the entries 1..n in the order of movieIDs, which
+	// has to hold every movie of the list.
+	Reorder(listID int, movieIDs []int) error
+}
@@ -0,0 +1,380 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	listRep "intern/internal/list/repository"
+	movieRep "intern/internal/movie/repository"
+	"intern/models"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ListUseCaseI interface {
+	Create(l *models.List) error
+	Get(id, userID int) (*models.List, error)
+	GetShared(token string) (*models.List, error)
+	Mine(userID, limit, offset int) ([]models.List, error)
+	Update(l *models.List, userID int) error
+	Delete(id, userID int) error
+	RotateToken(id, userID int) (*models.List, error)
+	AddEntry(e *models.ListEntry, userID int) error
+	UpdateEntry(e *models.ListEntry, userID int) error
+	RemoveEntry(listID, movieID, userID int) error
+	Reorder(listID int, movieIDs []int, userID int) (*models.List, error)
+}
+
+var (
+	ErrInvalidList = errors.New("invalid list")
+	ErrEntryExists = errors.New("movie already in the list")
+	ErrNotOwner    = errors.New("list belongs to another user")
+)
+
+type listUseCase struct {
+	listRepository  listRep.ListRepositoryI
+	movieRepository movieRep.MovieRepositoryI
+}
+
+func New(lRep listRep.ListRepositoryI, mRep movieRep.MovieRepositoryI) ListUseCaseI {
+	return &listUseCase{
+		listRepository:  lRep,
+		movieRepository: mRep,
+	}
+}
+
+// Create stores a new list of l.UserID, private unless told otherwise.
+func (lUC *listUseCase) Create(l *models.List) error {
+	if l.Visibility == "" {
+		l.Visibility = models.ListPrivate
+	}
+
+	err := validate(l)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Create error")
+	}
+
+	l.ShareToken, err = newToken()
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Create error: can't make share token")
+	}
+
+	err = lUC.listRepository.Create(l)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Create error")
+	}
+
+	return nil
+}
+
+// Get returns a list with its entries to its owner, or to anyone if it is
+// public. Unlisted lists of others are only reachable by share token.
+func (lUC *listUseCase) Get(id, userID int) (*models.List, error) {
+	l, err := lUC.listRepository.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Get error")
+	}
+
+	if l.UserID != userID {
+		if l.Visibility != models.ListPublic {
+			return nil, errors.Wrapf(gorm.ErrRecordNotFound, "listUseCase.Get error: list %d is %s", id, l.Visibility)
+		}
+		l.ShareToken = ""
+	}
+
+	err = lUC.fillEntries(l)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Get error")
+	}
+
+	return l, nil
+}
+
+// GetShared returns a public or unlisted list by its share token.
+func (lUC *listUseCase) GetShared(token string) (*models.List, error) {
+	l, err := lUC.listRepository.GetByToken(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.GetShared error")
+	}
+
+	if l.Visibility == models.ListPrivate {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "listUseCase.GetShared error: list %d is private", l.ID)
+	}
+
+	l.ShareToken = ""
+
+	err = lUC.fillEntries(l)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.GetShared error")
+	}
+
+	return l, nil
+}
+
+// Mine pages through the lists of the user, most recently changed first.
+func (lUC *listUseCase) Mine(userID, limit, offset int) ([]models.List, error) {
+	lists, err := lUC.listRepository.ListByUser(userID, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Mine error")
+	}
+
+	return lists, nil
+}
+
+// Update replaces the name, description and visibility of a list.
+func (lUC *listUseCase) Update(l *models.List, userID int) error {
+	err := validate(l)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Update error")
+	}
+
+	stored, err := lUC.owned(l.ID, userID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Update error")
+	}
+
+	stored.Name = l.Name
+	stored.Description = l.Description
+	stored.Visibility = l.Visibility
+	stored.UpdatedAt = time.Now()
+
+	err = lUC.listRepository.Update(stored)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Update error: Can't update in repo")
+	}
+
+	*l = *stored
+
+	return nil
+}
+
+func (lUC *listUseCase) Delete(id, userID int) error {
+	_, err := lUC.owned(id, userID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Delete error")
+	}
+
+	err = lUC.listRepository.Delete(id)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.Delete error: Can't delete in repo")
+	}
+
+	return nil
+}
+
+// RotateToken replaces the share token, links handed out before stop
+// working.
+func (lUC *listUseCase) RotateToken(id, userID int) (*models.List, error) {
+	stored, err := lUC.owned(id, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.RotateToken error")
+	}
+
+	stored.ShareToken, err = newToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.RotateToken error: can't make share token")
+	}
+	stored.UpdatedAt = time.Now()
+
+	err = lUC.listRepository.Update(stored)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.RotateToken error: Can't update in repo")
+	}
+
+	return stored, nil
+}
+
+// AddEntry puts a movie at position e.Position (1 is the top) of the list,
+// at the end when the position is zero or one past the last entry.
+func (lUC *listUseCase) AddEntry(e *models.ListEntry, userID int) error {
+	e.Note = strings.TrimSpace(e.Note)
+	if utf8.RuneCountInString(e.Note) > models.MaxListNote {
+		return errors.Wrapf(ErrInvalidList, "listUseCase.AddEntry error: note longer than %d characters", models.MaxListNote)
+	}
+
+	_, err := lUC.owned(e.ListID, userID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.AddEntry error")
+	}
+
+	_, err = lUC.movieRepository.Get(e.MovieID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.AddEntry error: Movie not found")
+	}
+
+	entries, err := lUC.listRepository.Entries(e.ListID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.AddEntry error")
+	}
+
+	if slices.ContainsFunc(entries, func(other models.ListEntry) bool { return other.MovieID == e.MovieID }) {
+		return errors.Wrapf(ErrEntryExists, "listUseCase.AddEntry error: movie %d, list %d", e.MovieID, e.ListID)
+	}
+
+	rank := e.Position
+	if rank < 0 || rank > len(entries)+1 {
+		return errors.Wrapf(ErrInvalidList, "listUseCase.AddEntry error: position %d is not within 1..%d", rank, len(entries)+1)
+	}
+
+	// The repository works with stored positions, which may have gaps.
+	e.Position = 0
+	if rank > 0 && rank <= len(entries) {
+		e.Position = entries[rank-1].Position
+	}
+
+	err = lUC.listRepository.AddEntry(e)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.AddEntry error")
+	}
+
+	e.Position = rank
+	if rank == 0 {
+		e.Position = len(entries) + 1
+	}
+
+	return nil
+}
+
+// UpdateEntry replaces the note of an entry.
+func (lUC *listUseCase) UpdateEntry(e *models.ListEntry, userID int) error {
+	e.Note = strings.TrimSpace(e.Note)
+	if utf8.RuneCountInString(e.Note) > models.MaxListNote {
+		return errors.Wrapf(ErrInvalidList, "listUseCase.UpdateEntry error: note longer than %d characters", models.MaxListNote)
+	}
+
+	_, err := lUC.owned(e.ListID, userID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.UpdateEntry error")
+	}
+
+	err = lUC.listRepository.UpdateEntry(e)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.UpdateEntry error")
+	}
+
+	return nil
+}
+
+func (lUC *listUseCase) RemoveEntry(listID, movieID, userID int) error {
+	_, err := lUC.owned(listID, userID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.RemoveEntry error")
+	}
+
+	err = lUC.listRepository.RemoveEntry(listID, movieID)
+	if err != nil {
+		return errors.Wrap(err, "listUseCase.RemoveEntry error")
+	}
+
+	return nil
+}
+
+// Reorder puts the entries in the order of movieIDs, which must name every
+// movie of the list once. Returns the reordered list.
+func (lUC *listUseCase) Reorder(listID int, movieIDs []int, userID int) (*models.List, error) {
+	l, err := lUC.owned(listID, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Reorder error")
+	}
+
+	entries, err := lUC.listRepository.Entries(listID)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Reorder error")
+	}
+
+	current := make([]int, len(entries))
+	for i, e := range entries {
+		current[i] = e.MovieID
+	}
+
+	wanted := slices.Clone(movieIDs)
+	slices.Sort(current)
+	slices.Sort(wanted)
+
+	if !slices.Equal(current, wanted) {
+		return nil, errors.Wrapf(ErrInvalidList, "listUseCase.Reorder error: order must name each of the %d movies of the list once", len(entries))
+	}
+
+	err = lUC.listRepository.Reorder(listID, movieIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Reorder error")
+	}
+
+	err = lUC.fillEntries(l)
+	if err != nil {
+		return nil, errors.Wrap(err, "listUseCase.Reorder error")
+	}
+
+	return l, nil
+}
+
+// owned returns the list if userID owns it. Lists of others the user can't
+// see are reported as missing.
+func (lUC *listUseCase) owned(id, userID int) (*models.List, error) {
+	l, err := lUC.listRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.UserID == userID {
+		return l, nil
+	}
+
+	if l.Visibility == models.ListPublic {
+		return nil, errors.Wrapf(ErrNotOwner, "list %d", id)
+	}
+
+	return nil, errors.Wrapf(gorm.ErrRecordNotFound, "list %d is %s", id, l.Visibility)
+}
+
+// fillEntries loads the entries and numbers them from 1, hiding gaps in
+// the stored positions.
+func (lUC *listUseCase) fillEntries(l *models.List) error {
+	entries, err := lUC.listRepository.Entries(l.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entries[i].Position = i + 1
+	}
+	l.Entries = entries
+
+	return nil
+}
+
+func validate(l *models.List) error {
+	l.Name = strings.TrimSpace(l.Name)
+	l.Description = strings.TrimSpace(l.Description)
+
+	if l.Name == "" || utf8.RuneCountInString(l.Name) > models.MaxListName {
+		return errors.Wrapf(ErrInvalidList, "name must have 1 to %d characters", models.MaxListName)
+	}
+
+	if utf8.RuneCountInString(l.Description) > models.MaxListDescription {
+		return errors.Wrapf(ErrInvalidList, "description longer than %d characters", models.MaxListDescription)
+	}
+
+	switch l.Visibility {
+	case models.ListPublic, models.ListUnlisted, models.ListPrivate:
+	default:
+		return errors.Wrapf(ErrInvalidList, "unknown visibility %q", l.Visibility)
+	}
+
+	return nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	memList "intern/internal/list/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memTrash "intern/internal/trash/repository/memory"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const owner, other = 7, 8
+
+func newUseCase() (ListUseCaseI, *memdb.DB) {
+	db := memdb.New()
+	for id := 1; id <= 5; id++ {
+		db.Movies[id] = models.Movie{ID: id, Title: "Movie " + string(rune('A'+id-1)), ReleaseDate: time.Date(1990+id, time.May, 25, 0, 0, 0, 0, time.UTC)}
+		db.SeenID("movies", id)
+	}
+
+	return New(memList.New(nil, db), memMovie.New(nil, db)), db
+}
+
+func create(t *testing.T, uc ListUseCaseI, visibility string, movieIDs ...int) *models.List {
+	l := &models.List{UserID: owner, Name: " Best 90s thrillers ", Visibility: visibility}
+	require.NoError(t, uc.Create(l))
+
+	for _, id := range movieIDs {
+		require.NoError(t, uc.AddEntry(&models.ListEntry{ListID: l.ID, MovieID: id}, owner))
+	}
+
+	return l
+}
+
+func order(l *models.List) []int {
+	ids := make([]int, len(l.Entries))
+	for i, e := range l.Entries {
+		ids[i] = e.MovieID
+		if e.Position != i+1 {
+			return nil
+		}
+	}
+
+	return ids
+}
+
+func TestCreate(t *testing.T) {
+	uc, _ := newUseCase()
+
+	l := create(t, uc, "")
+	assert.Equal(t, "Best 90s thrillers", l.Name)
+	assert.Equal(t, models.ListPrivate, l.Visibility)
+	assert.Len(t, l.ShareToken, 32)
+
+	for _, bad := range []models.List{
+		{UserID: owner, Name: "  "},
+		{UserID: owner, Name: "Fine", Visibility: "friends"},
+	} {
+		err := uc.Create(&bad)
+		assert.True(t, errors.Is(err, ErrInvalidList), err)
+	}
+
+	lists, err := uc.Mine(owner, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, lists, 1)
+}
+
+func TestEntries(t *testing.T) {
+	uc, db := newUseCase()
+	l := create(t, uc, models.ListPrivate, 1, 2, 3)
+
+	e := &models.ListEntry{ListID: l.ID, MovieID: 4, Position: 1, Note: "  watch first "}
+	require.NoError(t, uc.AddEntry(e, owner))
+	assert.Equal(t, 1, e.Position)
+	assert.Equal(t, "watch first", e.Note)
+
+	got, err := uc.Get(l.ID, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 1, 2, 3}, order(got))
+	assert.Equal(t, "Movie D", got.Entries[0].Movie.Title)
+
+	err = uc.AddEntry(&models.ListEntry{ListID: l.ID, MovieID: 2}, owner)
+	assert.True(t, errors.Is(err, ErrEntryExists), err)
+	err = uc.AddEntry(&models.ListEntry{ListID: l.ID, MovieID: 5, Position: 6}, owner)
+	assert.True(t, errors.Is(err, ErrInvalidList), err)
+	err = uc.AddEntry(&models.ListEntry{ListID: l.ID, MovieID: 42}, owner)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	// A movie in the trash keeps its entry, without the movie.
+	require.NoError(t, memMovie.New(nil, db).Delete(1))
+
+	got, err = uc.Get(l.ID, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 1, 2, 3}, order(got))
+	assert.Nil(t, got.Entries[1].Movie)
+
+	// A purged movie leaves a gap in the stored positions; inserting by
+	// position still counts the remaining entries.
+	_, err = memTrash.New(nil, db).Purge(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.NoError(t, uc.AddEntry(&models.ListEntry{ListID: l.ID, MovieID: 5, Position: 2}, owner))
+
+	got, err = uc.Get(l.ID, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 2, 3}, order(got))
+
+	require.NoError(t, uc.RemoveEntry(l.ID, 5, owner))
+	err = uc.RemoveEntry(l.ID, 5, owner)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	require.NoError(t, uc.UpdateEntry(&models.ListEntry{ListID: l.ID, MovieID: 2, Note: "twist ending"}, owner))
+
+	got, err = uc.Reorder(l.ID, []int{3, 2, 4}, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 4}, order(got))
+	assert.Equal(t, "twist ending", got.Entries[1].Note)
+
+	for _, bad := range [][]int{{3, 2}, {3, 2, 4, 4}, {3, 2, 5}} {
+		_, err = uc.Reorder(l.ID, bad, owner)
+		assert.True(t, errors.Is(err, ErrInvalidList), "%v: %v", bad, err)
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	uc, _ := newUseCase()
+	private := create(t, uc, models.ListPrivate, 1)
+	unlisted := create(t, uc, models.ListUnlisted, 2)
+	public := create(t, uc, models.ListPublic, 3)
+
+	// Others see public lists by id, without the share token.
+	got, err := uc.Get(public.ID, other)
+	require.NoError(t, err)
+	assert.Empty(t, got.ShareToken)
+	assert.Equal(t, []int{3}, order(got))
+
+	for _, id := range []int{private.ID, unlisted.ID} {
+		_, err = uc.Get(id, other)
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+	}
+
+	// The share token opens public and unlisted lists.
+	got, err = uc.GetShared(unlisted.ShareToken)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, order(got))
+	assert.Empty(t, got.ShareToken)
+
+	_, err = uc.GetShared(private.ShareToken)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	rotated, err := uc.RotateToken(unlisted.ID, owner)
+	require.NoError(t, err)
+	assert.NotEqual(t, unlisted.ShareToken, rotated.ShareToken)
+	_, err = uc.GetShared(unlisted.ShareToken)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	// Only the owner changes a list.
+	err = uc.Update(&models.List{ID: public.ID, Name: "Mine now", Visibility: models.ListPublic}, other)
+	assert.True(t, errors.Is(err, ErrNotOwner), err)
+	err = uc.AddEntry(&models.ListEntry{ListID: public.ID, MovieID: 4}, other)
+	assert.True(t, errors.Is(err, ErrNotOwner), err)
+	err = uc.Delete(private.ID, other)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	updated := &models.List{ID: public.ID, Name: "Renamed", Visibility: models.ListPrivate}
+	require.NoError(t, uc.Update(updated, owner))
+	assert.Equal(t, public.ShareToken, updated.ShareToken)
+	_, err = uc.GetShared(public.ShareToken)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	require.NoError(t, uc.Delete(public.ID, owner))
+	_, err = uc.Get(public.ID, owner)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
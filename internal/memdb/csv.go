@@ -0,0 +1,170 @@
+package memdb
+
+import (
+	"encoding/csv"
+	"intern/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoadCSV fills the database from the ';'-separated files in dir that use
+// the build/data layout (actors.csv, movies.csv, moviesActors.csv,
+// users.csv). Missing files are skipped.
+func (db *DB) LoadCSV(dir string) error {
+	db.Lock()
+	defer db.Unlock()
+
+	loaders := []struct {
+		file string
+		load func([]string) error
+	}{
+		{"actors.csv", db.loadActor},
+		{"movies.csv", db.loadMovie},
+		{"moviesActors.csv", db.loadMovieActor},
+		{"users.csv", db.loadUser},
+	}
+
+	for _, l := range loaders {
+		err := readCSV(filepath.Join(dir, l.file), l.load)
+		if err != nil {
+			return errors.Wrapf(err, "memdb.LoadCSV error while loading %s", l.file)
+		}
+	}
+
+	return nil
+}
+
+func readCSV(path string, load func([]string) error) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = ';'
+	r.LazyQuotes = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if err := load(record); err != nil {
+			return errors.Wrapf(err, "line %d", i+1)
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) loadActor(record []string) error {
+	if len(record) != 5 {
+		return errors.Errorf("expected 5 fields, got %d", len(record))
+	}
+
+	id, err := strconv.Atoi(record[0])
+	if err != nil {
+		return err
+	}
+
+	birthday, err := time.Parse(time.DateOnly, record[4])
+	if err != nil {
+		return err
+	}
+
+	db.Actors[id] = models.Actor{
+		ID:        id,
+		FirstName: record[1],
+		LastName:  record[2],
+		Gender:    record[3][0],
+		Birthday:  birthday,
+		UpdatedAt: time.Now(),
+	}
+	db.SeenID("actors", id)
+
+	return nil
+}
+
+func (db *DB) loadMovie(record []string) error {
+	if len(record) != 5 {
+		return errors.Errorf("expected 5 fields, got %d", len(record))
+	}
+
+	id, err := strconv.Atoi(record[0])
+	if err != nil {
+		return err
+	}
+
+	releaseDate, err := time.Parse(time.DateOnly, record[3])
+	if err != nil {
+		return err
+	}
+
+	rating, err := strconv.Atoi(record[4])
+	if err != nil {
+		return err
+	}
+
+	db.Movies[id] = models.Movie{
+		ID:          id,
+		Title:       record[1],
+		Description: record[2],
+		ReleaseDate: releaseDate,
+		Rating:      rating,
+		UpdatedAt:   time.Now(),
+	}
+	db.SeenID("movies", id)
+
+	return nil
+}
+
+func (db *DB) loadMovieActor(record []string) error {
+	if len(record) != 3 {
+		return errors.Errorf("expected 3 fields, got %d", len(record))
+	}
+
+	ids := make([]int, len(record))
+	for i := range record {
+		id, err := strconv.Atoi(record[i])
+		if err != nil {
+			return err
+		}
+		ids[i] = id
+	}
+
+	db.MoviesActors[ids[0]] = models.MovieActor{ID: ids[0], MovieID: ids[1], ActorID: ids[2]}
+	db.SeenID("movies_actors", ids[0])
+	db.CastVersion++
+
+	return nil
+}
+
+func (db *DB) loadUser(record []string) error {
+	if len(record) != 4 {
+		return errors.Errorf("expected 4 fields, got %d", len(record))
+	}
+
+	id, err := strconv.Atoi(record[0])
+	if err != nil {
+		return err
+	}
+
+	db.Users[id] = models.User{
+		ID:       id,
+		Login:    record[1],
+		Password: record[2],
+		Role:     record[3],
+	}
+	db.SeenID("users", id)
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package memdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCSV(t *testing.T) {
+	db := New()
+
+	err := db.LoadCSV("../../build/data")
+	assert.NoError(t, err)
+	assert.Len(t, db.Actors, 1000)
+	assert.Len(t, db.Movies, 500)
+	assert.Len(t, db.MoviesActors, 5000)
+	assert.Len(t, db.Users, 1000)
+
+	db.Lock()
+	assert.Equal(t, 501, db.NextID("movies"))
+	db.Unlock()
+}
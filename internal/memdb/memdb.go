@@ -0,0 +1,238 @@
+package memdb
+
+import (
+	"encoding/json"
+	"intern/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DB is the shared state behind the in-memory repositories. Repositories
+// take the embedded lock themselves, so a single DB can back all of them
+// and cross-table reads (e.g. actors of a movie) stay consistent.
+type DB struct {
+	sync.RWMutex
+
+	Movies       map[int]models.Movie
+	Actors       map[int]models.Actor
+	MoviesActors map[int]models.MovieActor
+	// CastVersion is bumped by every change of MoviesActors, like the
+	// cast_version row of Postgres.
+	CastVersion int64
+
+	// Soft-deleted movies and actors with their DeletedAt set, out of
+	// Movies and Actors until restored or purged. Rows referring to them
+	// stay, readers skip them as the movie or actor is not found.
+	TrashedMovies map[int]models.Movie
+	TrashedActors map[int]models.Actor
+
+	Users      map[int]models.User
+	ImportJobs map[int]models.ImportJob
+	Ratings    map[UserMovieKey]models.Rating
+	Reviews    map[int]models.Review
+
+	// When a movie was put on the watchlist and when it was watched.
+	Watchlist map[UserMovieKey]time.Time
+	History   map[UserMovieKey]time.Time
+
+	// Helpful (true) or unhelpful votes on reviews.
+	ReviewVotes map[ReviewVoteKey]bool
+
+	Lists       map[int]models.List
+	ListEntries map[ListEntryKey]models.ListEntry
+
+	// Precomputed neighbours of the movies, rebuilt by the similarity job.
+	Similarities map[SimilarityKey]models.Similarity
+
+	// External ids point to the movie or actor they belong to.
+	MovieExternalIDs map[models.ExternalID]int
+	ActorExternalIDs map[models.ExternalID]int
+
+	// Revisions of the movies and actors, in order: revision n at n-1.
+	MovieRevisions map[int][]models.Revision
+	ActorRevisions map[int][]models.Revision
+
+	// Resume points of the IMDb dump files.
+	ImdbProgress map[string]models.ImdbProgress
+
+	// The audit log in the order it was written; only ever appended to.
+	AuditEntries []models.AuditEntry
+
+	// The outbox in the order it was written, see AddEntityEvent. The first
+	// DispatchedEvents of them have been turned into deliveries.
+	Events           []models.Event
+	DispatchedEvents int
+
+	Webhooks          map[int]models.Webhook
+	WebhookDeliveries map[int]models.WebhookDelivery
+
+	sequences map[string]int
+}
+
+// UserMovieKey is the primary key of per-user movie data: ratings,
+// watchlist and history hold one row per user and movie.
+type UserMovieKey struct {
+	UserID  int
+	MovieID int
+}
+
+// ReviewVoteKey is the primary key of a review vote: one per user and
+// review.
+type ReviewVoteKey struct {
+	ReviewID int
+	UserID   int
+}
+
+// ListEntryKey is the primary key of a list entry: a movie appears once
+// per list.
+type ListEntryKey struct {
+	ListID  int
+	MovieID int
+}
+
+// SimilarityKey is the primary key of a similarity: one per kind and pair
+// of movies.
+type SimilarityKey struct {
+	Kind      string
+	MovieID   int
+	SimilarID int
+}
+
+func New() *DB {
+	return &DB{
+		Movies:       make(map[int]models.Movie),
+		Actors:       make(map[int]models.Actor),
+		MoviesActors: make(map[int]models.MovieActor),
+
+		TrashedMovies: make(map[int]models.Movie),
+		TrashedActors: make(map[int]models.Actor),
+
+		Users:        make(map[int]models.User),
+		ImportJobs:   make(map[int]models.ImportJob),
+		Ratings:      make(map[UserMovieKey]models.Rating),
+		Reviews:      make(map[int]models.Review),
+		ReviewVotes:  make(map[ReviewVoteKey]bool),
+		Watchlist:    make(map[UserMovieKey]time.Time),
+		History:      make(map[UserMovieKey]time.Time),
+		Lists:        make(map[int]models.List),
+		ListEntries:  make(map[ListEntryKey]models.ListEntry),
+		Similarities: make(map[SimilarityKey]models.Similarity),
+
+		MovieExternalIDs: make(map[models.ExternalID]int),
+		ActorExternalIDs: make(map[models.ExternalID]int),
+
+		MovieRevisions: make(map[int][]models.Revision),
+		ActorRevisions: make(map[int][]models.Revision),
+
+		Webhooks:          make(map[int]models.Webhook),
+		WebhookDeliveries: make(map[int]models.WebhookDelivery),
+
+		ImdbProgress: make(map[string]models.ImdbProgress),
+		sequences:    make(map[string]int),
+	}
+}
+
+// NextID returns the next identity value of table. Must be called with the
+// write lock held.
+func (db *DB) NextID(table string) int {
+	db.sequences[table]++
+	return db.sequences[table]
+}
+
+// SeenID moves the identity of table past id, so that rows inserted with an
+// explicit id do not collide with generated ones. Must be called with the
+// write lock held.
+func (db *DB) SeenID(table string, id int) {
+	if id > db.sequences[table] {
+		db.sequences[table] = id
+	}
+}
+
+// TouchMovie sets the UpdatedAt of the movie, live or in the trash, to now
+// and returns it, like the updated_at triggers of Postgres. Must be called
+// with the write lock held.
+func (db *DB) TouchMovie(id int) time.Time {
+	now := time.Now()
+	if m, ok := db.Movies[id]; ok {
+		m.UpdatedAt = now
+		db.Movies[id] = m
+	} else if m, ok := db.TrashedMovies[id]; ok {
+		m.UpdatedAt = now
+		db.TrashedMovies[id] = m
+	}
+
+	return now
+}
+
+// TouchActor is TouchMovie for an actor.
+func (db *DB) TouchActor(id int) time.Time {
+	now := time.Now()
+	if a, ok := db.Actors[id]; ok {
+		a.UpdatedAt = now
+		db.Actors[id] = a
+	} else if a, ok := db.TrashedActors[id]; ok {
+		a.UpdatedAt = now
+		db.TrashedActors[id] = a
+	}
+
+	return now
+}
+
+// AddEntityEvent writes an event of the change being made to a movie or
+// an actor to the outbox, like the triggers of Postgres do. Must be called
+// with the write lock held.
+func (db *DB) AddEntityEvent(eventType string, id int) {
+	db.addEvent(eventType, models.EntityEvent{ID: id})
+}
+
+// AddCastEvent is AddEntityEvent for a cast link added or removed.
+func (db *DB) AddCastEvent(movieID, actorID int, change string) {
+	db.addEvent(models.EventCastChanged, models.CastEvent{MovieID: movieID, ActorID: actorID, Change: change})
+}
+
+// AddBulkEvent writes the one event of a bulk load, which adds no other,
+// unless it lists no movie or actor.
+func (db *DB) AddBulkEvent(e models.BulkEvent) {
+	if len(e.Movies) == 0 && len(e.Actors) == 0 {
+		return
+	}
+
+	e.Movies, e.Actors = distinct(e.Movies), distinct(e.Actors)
+	db.addEvent(models.EventCatalogueLoaded, e)
+}
+
+// distinct returns the ids sorted without repeats, never nil.
+func distinct(ids []int) []int {
+	ids = append([]int{}, ids...)
+	slices.Sort(ids)
+
+	return slices.Compact(ids)
+}
+
+func (db *DB) addEvent(eventType string, payload interface{}) {
+	// The payloads are plain structs, marshalling them does not fail.
+	data, _ := json.Marshal(payload)
+
+	db.Events = append(db.Events, models.Event{
+		ID:        db.NextID("outbox_events"),
+		Type:      eventType,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Page applies LIMIT/OFFSET semantics to an already ordered slice; a
+// non-positive limit means no limit.
+func Page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
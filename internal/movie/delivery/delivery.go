@@ -2,6 +2,7 @@ package delivery
 
 import (
 	"encoding/json"
+	"fmt"
 	movieUseCase "intern/internal/movie/usecase"
 	"io"
 	"net/http"
@@ -9,8 +10,11 @@ import (
 
 	"intern/models"
 	"intern/pkg/logger"
+	"intern/pkg/pagination"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 type MovieHandler struct {
@@ -66,7 +70,7 @@ func (mh *MovieHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = mh.MovieUseCase.Create(&movie)
+	err = mh.MovieUseCase.Create(r.Context(), &movie)
 	if err != nil {
 		mh.Logger.Infow("can`t create movie",
 			"err:", err.Error())
@@ -102,7 +106,12 @@ func (mh *MovieHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Produce  application/json
 // @Param    Authorization header string true "token"
 // @Param id path int true "MOV_ID"
+// @Param    If-None-Match header string false "ETag of the cached movie"
+// @Param    If-Modified-Since header string false "Last-Modified of the cached movie"
 // @Success 200 {object} models.Movie "success get movie"
+// @Header  200 {string} ETag "hash of the movie"
+// @Header  200 {string} Last-Modified "when the movie last changed"
+// @Success 304 {object} nil "not modified"
 // @Failure 401 {object} nil "no auth"
 // @Failure 403 {object} nil "forbidden"
 // @Failure 404 {object} nil "Movie not found"
@@ -141,6 +150,9 @@ func (mh *MovieHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !movie.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", movie.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
 	w.WriteHeader(http.StatusOK)
 
 	_, err = w.Write(resp)
@@ -217,7 +229,7 @@ func (mh *MovieHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	movie.ID = movieId
-	err = mh.MovieUseCase.Update(movie)
+	err = mh.MovieUseCase.Update(r.Context(), movie)
 	if err != nil {
 		mh.Logger.Infow("can`t update movie",
 			"err:", err.Error())
@@ -247,7 +259,7 @@ func (mh *MovieHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete godoc
 // @Summary      Delete movie
-// @Description  Delete info about a movie by id
+// @Description  Move a movie to the trash, from where it can be restored until it is purged
 // @Tags     movies
 // @Accept	 application/json
 // @Produce  application/json
@@ -275,7 +287,7 @@ func (mh *MovieHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = mh.MovieUseCase.Delete(movieId)
+	err = mh.MovieUseCase.Delete(r.Context(), movieId)
 	if err != nil {
 		mh.Logger.Infow("can`t delete movie",
 			"err:", err.Error())
@@ -286,6 +298,69 @@ func (mh *MovieHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// Restore godoc
+// @Summary      Restore movie
+// @Description  Take a movie out of the trash
+// @Tags     movies
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} models.Movie "Movie restored"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not in the trash"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/restore [post]
+func (mh *MovieHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		mh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		mh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	movie, err := mh.MovieUseCase.Restore(r.Context(), movieId)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mh.Logger.Infow("can`t restore movie",
+			"err:", err.Error())
+		http.Error(w, "can`t restore movie", http.StatusNotFound)
+		return
+	case err != nil:
+		mh.Logger.Errorw("can`t restore movie",
+			"err:", err.Error())
+		http.Error(w, "can`t restore movie", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(movie)
+
+	if err != nil {
+		mh.Logger.Errorw("can`t marshal movie",
+			"err:", err.Error())
+		http.Error(w, "can`t make movie", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		mh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetMoviesSorted godoc
 // @Summary      Get sorted movies
 // @Description  Get list of movies sorted by specified column
@@ -443,3 +518,475 @@ func (mh *MovieHandler) GetMoviesByTitle(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// SearchMovies godoc
+// @Summary      Search movies
+// @Description  Full-text search over movie titles and descriptions ranked by relevance
+// @Tags     movies
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param q query string true "search query"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.MovieSearchResult "success search movies"
+// @Failure 400 {object} nil "invalid query"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /search/movies [get]
+func (mh *MovieHandler) SearchMovies(w http.ResponseWriter, r *http.Request) {
+	query := r.FormValue("q")
+	if query == "" {
+		mh.Logger.Infow("no q key")
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		mh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	results, err := mh.MovieUseCase.SearchMovies(query, page.Limit, page.Offset)
+	if err != nil {
+		mh.Logger.Errorw("can`t search movies",
+			"err:", err.Error())
+		http.Error(w, "can`t search movies", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(results)
+
+	if err != nil {
+		mh.Logger.Errorw("can`t marshal movies",
+			"err:", err.Error())
+		http.Error(w, "can`t make movies", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		mh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// MovieFilterFromRequest reads the query parameters shared by the endpoints
+// that return whole collections of movies: title (fragment, as in
+// GET /movies/title), sort and order.
+func MovieFilterFromRequest(r *http.Request) (models.MovieFilter, error) {
+	filter := models.MovieFilter{
+		Title:  r.FormValue("title"),
+		SortBy: r.FormValue("sort"),
+	}
+
+	switch r.FormValue("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, fmt.Errorf("unknown order %q", r.FormValue("order"))
+	}
+
+	return filter, nil
+}
+
+// GetByExternalID godoc
+// @Summary      Get movie by external id
+// @Description  Resolve an id of another catalogue (imdb, tmdb or wikidata) to a movie
+// @Tags     movies
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param source path string true "imdb, tmdb or wikidata"
+// @Param id path string true "id in the source"
+// @Success 200 {object} models.Movie "success get movie"
+// @Failure 400 {object} nil "unknown source"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/by-external/{source}/{id} [get]
+func (mh *MovieHandler) GetByExternalID(w http.ResponseWriter, r *http.Request) {
+	ext := models.ExternalID{Source: r.PathValue("SOURCE"), ID: r.PathValue("EXT_ID")}
+
+	movie, err := mh.MovieUseCase.GetByExternalID(ext)
+	if errors.Is(err, movieUseCase.ErrInvalidExternalID) {
+		mh.Logger.Infow("invalid external id",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		mh.Logger.Infow("can`t get movie",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	}
+
+	resp, err := json.Marshal(movie)
+
+	if err != nil {
+		mh.Logger.Errorw("can`t marshal movie",
+			"err:", err.Error())
+		http.Error(w, "can`t make movie", http.StatusInternalServerError)
+		return
+	}
+
+	if !movie.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", movie.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		mh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddExternalID godoc
+// @Summary      Link external id
+// @Description  Link a movie to its id in another catalogue. An id belongs to one movie per source.
+// @Tags     movies
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param externalId body models.ExternalID true "source (imdb, tmdb or wikidata) and id"
+// @Success 201 {object} models.ExternalID "external id linked"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 409 {object} nil "id linked to another movie"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/external-ids [post]
+func (mh *MovieHandler) AddExternalID(w http.ResponseWriter, r *http.Request) {
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		mh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		mh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	ext := models.ExternalID{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		mh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		mh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return
+	}
+
+	err = json.Unmarshal(body, &ext)
+	if err != nil {
+		mh.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = mh.MovieUseCase.AddExternalID(r.Context(), movieId, ext)
+	switch {
+	case errors.Is(err, movieUseCase.ErrInvalidExternalID):
+		mh.Logger.Infow("invalid external id",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, movieUseCase.ErrExternalIDTaken):
+		mh.Logger.Infow("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "external id is taken", http.StatusConflict)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mh.Logger.Infow("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	case err != nil:
+		mh.Logger.Errorw("can`t add external id",
+			"err:", err.Error())
+		http.Error(w, "can`t add external id", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(ext)
+
+	if err != nil {
+		mh.Logger.Errorw("can`t marshal external id",
+			"err:", err.Error())
+		http.Error(w, "can`t make external id", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		mh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteExternalID godoc
+// @Summary      Unlink external id
+// @Description  Remove the link between a movie and its id in another catalogue
+// @Tags     movies
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param source path string true "imdb, tmdb or wikidata"
+// @Param externalId path string true "id in the source"
+// @Success 200 {object} nil "external id unlinked"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "link not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/external-ids/{source}/{externalId} [delete]
+func (mh *MovieHandler) DeleteExternalID(w http.ResponseWriter, r *http.Request) {
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		mh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		mh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	ext := models.ExternalID{Source: r.PathValue("SOURCE"), ID: r.PathValue("EXT_ID")}
+
+	err = mh.MovieUseCase.DeleteExternalID(r.Context(), movieId, ext)
+	if err != nil {
+		mh.Logger.Infow("can`t delete external id",
+			"err:", err.Error())
+		http.Error(w, "can`t delete external id", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Revisions godoc
+// @Summary      Movie revisions
+// @Description  Stored versions of a movie, newest first; each create, update and revert adds one
+// @Tags     movies
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Revision "success get revisions"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/revisions [get]
+func (mh *MovieHandler) Revisions(w http.ResponseWriter, r *http.Request) {
+	movieId, ok := mh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		mh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := mh.MovieUseCase.Revisions(movieId, page.Limit, page.Offset)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mh.Logger.Infow("can`t get revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t get revisions", http.StatusNotFound)
+		return
+	case err != nil:
+		mh.Logger.Errorw("can`t get revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t get revisions", http.StatusInternalServerError)
+		return
+	}
+
+	mh.write(w, revisions)
+}
+
+// RevisionDiff godoc
+// @Summary      Diff of movie revisions
+// @Description  Fields that differ between two revisions of a movie, with their values in each
+// @Tags     movies
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param rev path int true "REV"
+// @Param against query int false "revision to compare with, the previous one by default; 0 is the state before the first"
+// @Success 200 {object} models.RevisionDiff "success get diff"
+// @Failure 400 {object} nil "invalid revision"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie or revision not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/revisions/{rev}/diff [get]
+func (mh *MovieHandler) RevisionDiff(w http.ResponseWriter, r *http.Request) {
+	movieId, ok := mh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	rev, ok := mh.pathID(w, r, "REV")
+	if !ok {
+		return
+	}
+
+	against := rev - 1
+
+	if againstString := r.FormValue("against"); againstString != "" {
+		var err error
+		against, err = strconv.Atoi(againstString)
+		if err != nil {
+			mh.Logger.Infow("can`t parse against",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	diff, err := mh.MovieUseCase.RevisionDiff(movieId, rev, against)
+	switch {
+	case errors.Is(err, movieUseCase.ErrInvalidRevision):
+		mh.Logger.Infow("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mh.Logger.Infow("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t diff revisions", http.StatusNotFound)
+		return
+	case err != nil:
+		mh.Logger.Errorw("can`t diff revisions",
+			"err:", err.Error())
+		http.Error(w, "can`t diff revisions", http.StatusInternalServerError)
+		return
+	}
+
+	mh.write(w, diff)
+}
+
+// Revert godoc
+// @Summary      Revert movie
+// @Description  Update a movie to one of its revisions, which adds a new revision.
+// @Description  Fields that are empty in the revision are left as they are.
+// @Tags     movies
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param rev path int true "REV"
+// @Success 200 {object} models.Movie "Movie reverted"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie or revision not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/revisions/{rev}/revert [post]
+func (mh *MovieHandler) Revert(w http.ResponseWriter, r *http.Request) {
+	movieId, ok := mh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	rev, ok := mh.pathID(w, r, "REV")
+	if !ok {
+		return
+	}
+
+	movie, err := mh.MovieUseCase.Revert(r.Context(), movieId, rev)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		mh.Logger.Infow("can`t revert movie",
+			"err:", err.Error())
+		http.Error(w, "can`t revert movie", http.StatusNotFound)
+		return
+	case err != nil:
+		mh.Logger.Errorw("can`t revert movie",
+			"err:", err.Error())
+		http.Error(w, "can`t revert movie", http.StatusInternalServerError)
+		return
+	}
+
+	mh.write(w, movie)
+}
+
+func (mh *MovieHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	idString := r.PathValue(name)
+	if idString == "" {
+		mh.Logger.Errorw("no " + name + " var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		mh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (mh *MovieHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		mh.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		mh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
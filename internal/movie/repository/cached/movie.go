@@ -0,0 +1,194 @@
+package cached
+
+import (
+	auditRep "intern/internal/audit/repository"
+	"intern/internal/movie/repository"
+	"intern/models"
+	"intern/pkg/cache"
+	"intern/pkg/logger"
+)
+
+const (
+	kindMovie       = "movie"
+	kindMovieActors = "movie_actors"
+)
+
+type cachedMovieRepo struct {
+	repository.MovieRepositoryI
+	Logger logger.Logger
+	Cache  *cache.Cache
+}
+
+// New reads single movies and their actors through c, the writes made
+// through it invalidate them. Writes that bypass it, such as the imdb
+// import, the purge job, audience ratings or the other instances, are
+// invalidated with Invalidate.
+func New(logger logger.Logger, repo repository.MovieRepositoryI, c *cache.Cache) repository.MovieRepositoryI {
+	return &cachedMovieRepo{
+		MovieRepositoryI: repo,
+		Logger:           logger,
+		Cache:            c,
+	}
+}
+
+func (cr *cachedMovieRepo) Get(id int) (*models.Movie, error) {
+	return cache.Load(cr.Cache, kindMovie, cache.MovieKey(id), func() (*models.Movie, error) {
+		return cr.MovieRepositoryI.Get(id)
+	})
+}
+
+func (cr *cachedMovieRepo) GetActorsByMovie(id int) ([]models.Actor, error) {
+	actors, err := cache.Load(cr.Cache, kindMovieActors, cache.MovieActorsKey(id), func() ([]models.Actor, error) {
+		return cr.MovieRepositoryI.GetActorsByMovie(id)
+	})
+	// The cache decodes an empty list as nil, which is null in JSON.
+	if err == nil && actors == nil {
+		actors = []models.Actor{}
+	}
+
+	return actors, err
+}
+
+func (cr *cachedMovieRepo) Create(m *models.Movie) error {
+	if err := cr.MovieRepositoryI.Create(m); err != nil {
+		return err
+	}
+	cr.invalidate(m.ID)
+
+	return nil
+}
+
+func (cr *cachedMovieRepo) Update(m *models.Movie) error {
+	err := cr.MovieRepositoryI.Update(m)
+	cr.invalidate(m.ID)
+
+	return err
+}
+
+func (cr *cachedMovieRepo) Replace(m *models.Movie) error {
+	err := cr.MovieRepositoryI.Replace(m)
+	cr.invalidate(m.ID)
+
+	return err
+}
+
+func (cr *cachedMovieRepo) Delete(id int) error {
+	err := cr.MovieRepositoryI.Delete(id)
+	cr.invalidate(id)
+
+	return err
+}
+
+func (cr *cachedMovieRepo) Restore(id int) error {
+	err := cr.MovieRepositoryI.Restore(id)
+	cr.invalidate(id)
+
+	return err
+}
+
+func (cr *cachedMovieRepo) Upsert(m *models.Movie) (string, error) {
+	outcome, err := cr.MovieRepositoryI.Upsert(m)
+	if err != nil {
+		return outcome, err
+	}
+	cr.invalidate(m.ID)
+
+	return outcome, nil
+}
+
+// Atomic invalidates the movies fn writes once the transaction is over, as
+// a load while it runs still reads the rows it replaces.
+func (cr *cachedMovieRepo) Atomic(fn func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	tx := &txMovieRepo{}
+	err := cr.MovieRepositoryI.Atomic(func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		tx.MovieRepositoryI = movies
+		return fn(tx, audit)
+	})
+
+	for _, id := range tx.written {
+		cr.invalidate(id)
+	}
+
+	return err
+}
+
+// Bulk invalidates the movies fn writes once the transaction is over, like
+// Atomic.
+func (cr *cachedMovieRepo) Bulk(source string, fn func(movies repository.MovieRepositoryI) ([]int, error)) error {
+	tx := &txMovieRepo{}
+	err := cr.MovieRepositoryI.Bulk(source, func(movies repository.MovieRepositoryI) ([]int, error) {
+		tx.MovieRepositoryI = movies
+		return fn(tx)
+	})
+
+	for _, id := range tx.written {
+		cr.invalidate(id)
+	}
+
+	return err
+}
+
+// invalidate drops the movie. A failed write is invalidated too, it may
+// have been applied.
+func (cr *cachedMovieRepo) invalidate(id int) {
+	Invalidate(cr.Logger, cr.MovieRepositoryI, cr.Cache, id)
+}
+
+// Invalidate drops movie id from c and, as they list it, the movies of its
+// actors, who are read from repo. It is for the writes made around the
+// repository of New.
+func Invalidate(logger logger.Logger, repo repository.MovieRepositoryI, c *cache.Cache, id int) {
+	keys := []string{cache.MovieKey(id), cache.MovieActorsKey(id)}
+
+	actors, err := repo.GetActorsByMovie(id)
+	if err != nil {
+		logger.Errorw("can`t get actors to invalidate",
+			"err:", err.Error())
+	}
+	for _, a := range actors {
+		keys = append(keys, cache.ActorMoviesKey(a.ID))
+	}
+
+	c.Delete(keys...)
+}
+
+// txMovieRepo is the repository of an Atomic call: it reads around the
+// cache and keeps the ids of the movies written.
+type txMovieRepo struct {
+	repository.MovieRepositoryI
+	written []int
+}
+
+func (tr *txMovieRepo) Create(m *models.Movie) error {
+	err := tr.MovieRepositoryI.Create(m)
+	tr.written = append(tr.written, m.ID)
+
+	return err
+}
+
+func (tr *txMovieRepo) Update(m *models.Movie) error {
+	tr.written = append(tr.written, m.ID)
+	return tr.MovieRepositoryI.Update(m)
+}
+
+func (tr *txMovieRepo) Replace(m *models.Movie) error {
+	tr.written = append(tr.written, m.ID)
+	return tr.MovieRepositoryI.Replace(m)
+}
+
+func (tr *txMovieRepo) Delete(id int) error {
+	tr.written = append(tr.written, id)
+	return tr.MovieRepositoryI.Delete(id)
+}
+
+func (tr *txMovieRepo) Restore(id int) error {
+	tr.written = append(tr.written, id)
+	return tr.MovieRepositoryI.Restore(id)
+}
+
+func (tr *txMovieRepo) Upsert(m *models.Movie) (string, error) {
+	outcome, err := tr.MovieRepositoryI.Upsert(m)
+	tr.written = append(tr.written, m.ID)
+
+	return outcome, err
+}
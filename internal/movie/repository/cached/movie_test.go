@@ -0,0 +1,83 @@
+package cached
+
+import (
+	cachedActor "intern/internal/actor/repository/cached"
+	memActor "intern/internal/actor/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	"intern/models"
+	"intern/pkg/cache"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedMovies(t *testing.T) {
+	db := memdb.New()
+	c := cache.New(cache.NewLRU(100), time.Minute, nil)
+	movies := New(nil, memMovie.New(nil, db), c)
+	actors := cachedActor.New(nil, memActor.New(nil, db), c)
+
+	movie := &models.Movie{Title: "Alien"}
+	require.NoError(t, movies.Create(movie))
+	actor := &models.Actor{FirstName: "Sigourney"}
+	require.NoError(t, actors.Create(actor))
+
+	cast, err := movies.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Actor{}, cast)
+
+	db.MoviesActors[1] = models.MovieActor{ID: 1, MovieID: movie.ID, ActorID: actor.ID}
+
+	// Links made past the repositories show once the values expire.
+	cast, err = movies.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Actor{}, cast)
+	c.Delete(cache.MovieActorsKey(movie.ID))
+
+	cast, err = movies.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	require.Len(t, cast, 1)
+	filmography, err := actors.GetMoviesByActor(actor.ID)
+	require.NoError(t, err)
+	require.Len(t, filmography, 1)
+
+	m, err := movies.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alien", m.Title)
+	db.Movies[movie.ID] = models.Movie{ID: movie.ID, Title: "Alien 2"}
+	m, err = movies.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alien", m.Title)
+
+	// Updates invalidate the movie and the filmographies listing it.
+	require.NoError(t, movies.Update(&models.Movie{ID: movie.ID, Title: "Aliens"}))
+	m, err = movies.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aliens", m.Title)
+	filmography, err = actors.GetMoviesByActor(actor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Aliens", filmography[0].Title)
+
+	// And the other way round.
+	require.NoError(t, actors.Update(&models.Actor{ID: actor.ID, LastName: "Weaver"}))
+	cast, err = movies.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Weaver", cast[0].LastName)
+
+	require.NoError(t, actors.Delete(actor.ID))
+	cast, err = movies.GetActorsByMovie(movie.ID)
+	require.NoError(t, err)
+	assert.Empty(t, cast)
+
+	require.NoError(t, movies.Delete(movie.ID))
+	_, err = movies.Get(movie.ID)
+	assert.Error(t, err)
+
+	stats := c.Stats(kindMovie)
+	assert.EqualValues(t, 1, stats.Hits.Load())
+	assert.EqualValues(t, 3, stats.Misses.Load())
+	assert.EqualValues(t, 1, c.Stats(kindMovieActors).Hits.Load())
+}
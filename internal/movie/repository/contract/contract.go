@@ -0,0 +1,348 @@
+package contract
+
+import (
+	"intern/internal/movie/repository"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Backend adapts a MovieRepositoryI implementation to the contract suite.
+// The Expect* hooks are called right before the matching repository call so
+// that scripted backends (sqlmock) can prepare the database conversation;
+// in-memory backends implement them as no-ops.
+type Backend interface {
+	Repo() repository.MovieRepositoryI
+	ExpectCreate(m models.Movie, id int)
+	ExpectGet(m models.Movie)
+	ExpectGetMissing(id int)
+	ExpectUpdate(m models.Movie)
+	ExpectReplace(m models.Movie)
+	ExpectDelete(id int)
+	// ExpectRestore expects id to be taken out of the trash, found tells
+	// whether it was there.
+	ExpectRestore(id int, found bool)
+	ExpectGetMoviesByTitle(title string, movies []models.Movie)
+	ExpectGetMoviesSorted(sortingColumn string, movies []models.Movie)
+	// SeedCast stores actors and links them to the movie id, the
+	// repository has no call for it.
+	SeedCast(id int, actors ...models.Actor)
+	ExpectGetActorsByMovie(id int, actors []models.Actor)
+	ExpectSearchMovies(query string, limit, offset int, results []models.MovieSearchResult)
+	ExpectUpsert(m models.Movie, id int, outcome string)
+	// ExpectBulkUpsert expects a Bulk of one Upsert creating m as id.
+	ExpectBulkUpsert(m models.Movie, id int)
+	ExpectGetByNaturalKey(m models.Movie)
+	ExpectEachByTitle(title string, movies []models.Movie)
+	ExpectAddExternalID(id int, ext models.ExternalID)
+	ExpectGetExternalIDs(id int, ids []models.ExternalID)
+	// ExpectGetByExternalID expects a lookup of ext, m nil for a miss.
+	ExpectGetByExternalID(ext models.ExternalID, m *models.Movie)
+	ExpectDeleteExternalID(id int, ext models.ExternalID, found bool)
+	Verify() error
+}
+
+// Run checks the behaviour every MovieRepositoryI implementation must share.
+// newBackend is called once per case and must return an empty repository.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	cases := map[string]func(t *testing.T, b Backend){
+		"CreateAssignsID":       testCreateAssignsID,
+		"GetMissing":            testGetMissing,
+		"UpdateChangesFields":   testUpdateChangesFields,
+		"ReplaceClearsFields":   testReplaceClearsFields,
+		"RestoreUndoesDelete":   testRestoreUndoesDelete,
+		"DeleteRemoves":         testDeleteRemoves,
+		"GetMoviesByTitleMatch": testGetMoviesByTitle,
+		"GetMoviesSorted":       testGetMoviesSorted,
+		"GetActorsByMovie":      testGetActorsByMovie,
+		"SearchMovies":          testSearchMovies,
+		"UpsertByNaturalKey":    testUpsertByNaturalKey,
+		"BulkUpsert":            testBulkUpsert,
+		"EachFiltersByTitle":    testEachFiltersByTitle,
+		"ExternalIDs":           testExternalIDs,
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+			test(t, b)
+			assert.NoError(t, b.Verify())
+		})
+	}
+}
+
+func movie() models.Movie {
+	return models.Movie{
+		Title:       "Alien",
+		Description: "In space no one can hear you scream",
+		ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC),
+		Rating:      8,
+	}
+}
+
+func create(t *testing.T, b Backend, m models.Movie) models.Movie {
+	return createID(t, b, m, 1)
+}
+
+// createID creates m, which the empty repository gives id.
+func createID(t *testing.T, b Backend, m models.Movie, id int) models.Movie {
+	b.ExpectCreate(m, id)
+	require.NoError(t, b.Repo().Create(&m))
+	require.Equal(t, id, m.ID)
+
+	return m
+}
+
+func testCreateAssignsID(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	b.ExpectGet(m)
+	got, err := b.Repo().Get(m.ID)
+	require.NoError(t, err)
+	assert.Equal(t, m, *got)
+}
+
+func testGetMissing(t *testing.T, b Backend) {
+	b.ExpectGetMissing(42)
+	_, err := b.Repo().Get(42)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testUpdateChangesFields(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	m.Title = "Aliens"
+	m.Rating = 9
+	b.ExpectUpdate(m)
+	require.NoError(t, b.Repo().Update(&m))
+
+	b.ExpectGet(m)
+	got, err := b.Repo().Get(m.ID)
+	require.NoError(t, err)
+	assert.Equal(t, m, *got)
+}
+
+func testReplaceClearsFields(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	m.Description = ""
+	m.Rating = 0
+	b.ExpectReplace(m)
+	require.NoError(t, b.Repo().Replace(&m))
+
+	b.ExpectGet(m)
+	got, err := b.Repo().Get(m.ID)
+	require.NoError(t, err)
+	assert.Equal(t, m, *got)
+}
+
+func testDeleteRemoves(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	b.ExpectDelete(m.ID)
+	require.NoError(t, b.Repo().Delete(m.ID))
+
+	b.ExpectGetMissing(m.ID)
+	_, err := b.Repo().Get(m.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testRestoreUndoesDelete(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	b.ExpectRestore(m.ID, false)
+	err := b.Repo().Restore(m.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound for a movie not in the trash, got %v", err)
+
+	b.ExpectDelete(m.ID)
+	require.NoError(t, b.Repo().Delete(m.ID))
+
+	b.ExpectRestore(m.ID, true)
+	require.NoError(t, b.Repo().Restore(m.ID))
+
+	b.ExpectGet(m)
+	got, err := b.Repo().Get(m.ID)
+	require.NoError(t, err)
+	// The restore is a change, it may move UpdatedAt.
+	assert.False(t, got.UpdatedAt.Before(m.UpdatedAt))
+	m.UpdatedAt = got.UpdatedAt
+	assert.Equal(t, m, *got)
+}
+
+func testGetMoviesByTitle(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	b.ExpectGetMoviesByTitle("lie", []models.Movie{m})
+	movies, err := b.Repo().GetMoviesByTitle("lie")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Movie{m}, movies)
+}
+
+func testUpsertByNaturalKey(t *testing.T, b Backend) {
+	m := movie()
+
+	b.ExpectUpsert(m, 1, models.UpsertCreated)
+	outcome, err := b.Repo().Upsert(&m)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertCreated, outcome)
+	assert.Equal(t, 1, m.ID)
+
+	again := movie()
+	again.Rating = 9
+	b.ExpectUpsert(again, 1, models.UpsertUpdated)
+	outcome, err = b.Repo().Upsert(&again)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertUpdated, outcome)
+	assert.Equal(t, 1, again.ID)
+
+	b.ExpectGetByNaturalKey(again)
+	got, err := b.Repo().GetByNaturalKey(again.Title, again.ReleaseDate)
+	require.NoError(t, err)
+	assert.Equal(t, again, *got)
+
+	// A movie in the trash is found too, Upsert restores it.
+	b.ExpectDelete(again.ID)
+	require.NoError(t, b.Repo().Delete(again.ID))
+
+	trashed := again
+	trashed.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	b.ExpectGetByNaturalKey(trashed)
+	got, err = b.Repo().GetByNaturalKey(again.Title, again.ReleaseDate)
+	require.NoError(t, err)
+	assert.Equal(t, again.ID, got.ID)
+	assert.True(t, got.DeletedAt.Valid)
+
+	b.ExpectUpsert(again, 1, models.UpsertRestored)
+	outcome, err = b.Repo().Upsert(&again)
+	require.NoError(t, err)
+	assert.Equal(t, models.UpsertRestored, outcome)
+}
+
+func testBulkUpsert(t *testing.T, b Backend) {
+	m := movie()
+
+	b.ExpectBulkUpsert(m, 1)
+	err := b.Repo().Bulk(models.BulkSourceImport, func(movies repository.MovieRepositoryI) ([]int, error) {
+		outcome, err := movies.Upsert(&m)
+		assert.Equal(t, models.UpsertCreated, outcome)
+
+		return []int{m.ID}, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.ID)
+}
+
+func testEachFiltersByTitle(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+
+	var got []models.Movie
+	b.ExpectEachByTitle("lie", []models.Movie{m})
+	err := b.Repo().Each(models.MovieFilter{Title: "lie", SortBy: models.MovieSortRating, Desc: true}, func(m models.Movie) error {
+		got = append(got, m)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Movie{m}, got)
+
+	stop := errors.New("stop")
+	b.ExpectEachByTitle("lie", []models.Movie{m})
+	err = b.Repo().Each(models.MovieFilter{Title: "lie", SortBy: models.MovieSortRating, Desc: true}, func(models.Movie) error {
+		return stop
+	})
+	assert.True(t, errors.Is(err, stop), "want the callback error, got %v", err)
+}
+
+func testExternalIDs(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+	imdb := models.ExternalID{Source: models.ExternalSourceIMDb, ID: "tt0078748"}
+	tmdb := models.ExternalID{Source: models.ExternalSourceTMDB, ID: "348"}
+
+	b.ExpectAddExternalID(m.ID, tmdb)
+	require.NoError(t, b.Repo().AddExternalID(m.ID, tmdb))
+	b.ExpectAddExternalID(m.ID, imdb)
+	require.NoError(t, b.Repo().AddExternalID(m.ID, imdb))
+
+	b.ExpectGetExternalIDs(m.ID, []models.ExternalID{imdb, tmdb})
+	ids, err := b.Repo().GetExternalIDs(m.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExternalID{imdb, tmdb}, ids)
+
+	b.ExpectGetByExternalID(imdb, &m)
+	got, err := b.Repo().GetByExternalID(imdb)
+	require.NoError(t, err)
+	// The external ids are part of the entity, adding them may move
+	// UpdatedAt.
+	assert.False(t, got.UpdatedAt.Before(m.UpdatedAt))
+	m.UpdatedAt = got.UpdatedAt
+	assert.Equal(t, m, *got)
+
+	b.ExpectDeleteExternalID(m.ID, imdb, true)
+	require.NoError(t, b.Repo().DeleteExternalID(m.ID, imdb))
+
+	b.ExpectGetByExternalID(imdb, nil)
+	_, err = b.Repo().GetByExternalID(imdb)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+
+	b.ExpectDeleteExternalID(m.ID, imdb, false)
+	err = b.Repo().DeleteExternalID(m.ID, imdb)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
+
+func testGetMoviesSorted(t *testing.T, b Backend) {
+	alien := createID(t, b, movie(), 1)
+	aliens := movie()
+	aliens.Title, aliens.Rating = "Aliens", 9
+	aliens = createID(t, b, aliens, 2)
+	heat := movie()
+	heat.Title, heat.Description = "Heat", "A heist in Los Angeles"
+	heat = createID(t, b, heat, 3)
+
+	// Ties are broken by id.
+	want := []models.Movie{aliens, alien, heat}
+	b.ExpectGetMoviesSorted("rating desc", want)
+	movies, err := b.Repo().GetMoviesSorted("rating desc")
+	require.NoError(t, err)
+	assert.Equal(t, want, movies)
+}
+
+func testGetActorsByMovie(t *testing.T, b Backend) {
+	m := create(t, b, movie())
+	actors := []models.Actor{
+		{ID: 1, FirstName: "Sigourney", LastName: "Weaver", Gender: 'f', Birthday: time.Date(1949, 10, 8, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, FirstName: "John", LastName: "Hurt", Gender: 'm', Birthday: time.Date(1940, 1, 22, 0, 0, 0, 0, time.UTC)},
+	}
+	b.SeedCast(m.ID, actors...)
+
+	b.ExpectGetActorsByMovie(m.ID, actors)
+	got, err := b.Repo().GetActorsByMovie(m.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, actors, got)
+}
+
+func testSearchMovies(t *testing.T, b Backend) {
+	alien := create(t, b, movie())
+	heat := movie()
+	heat.Title, heat.Description = "Heat", "A heist in Los Angeles"
+	createID(t, b, heat, 2)
+
+	b.ExpectSearchMovies("space", 20, 0, []models.MovieSearchResult{{
+		Movie: alien, Rank: 0.1, TitleHighlight: "Alien", Snippet: "In <b>space</b> no one can hear you scream",
+	}})
+	results, err := b.Repo().SearchMovies("space", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, alien, results[0].Movie)
+	assert.Greater(t, results[0].Rank, 0.0)
+	assert.Equal(t, "Alien", results[0].TitleHighlight)
+	assert.Contains(t, results[0].Snippet, "<b>space</b>")
+
+	b.ExpectSearchMovies("space", 20, 1, nil)
+	results, err = b.Repo().SearchMovies("space", 20, 1)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,613 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	auditRep "intern/internal/audit/repository"
+	memAudit "intern/internal/audit/repository/memory"
+	"intern/internal/memdb"
+	"intern/internal/movie/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var movieColumns = map[string]func(a, b models.Movie) int{
+	"id":           func(a, b models.Movie) int { return cmp.Compare(a.ID, b.ID) },
+	"title":        func(a, b models.Movie) int { return strings.Compare(a.Title, b.Title) },
+	"description":  func(a, b models.Movie) int { return strings.Compare(a.Description, b.Description) },
+	"release_date": func(a, b models.Movie) int { return a.ReleaseDate.Compare(b.ReleaseDate) },
+	"rating":       func(a, b models.Movie) int { return cmp.Compare(a.Rating, b.Rating) },
+	"weighted_rating": func(a, b models.Movie) int {
+		return cmp.Compare(a.WeightedRating, b.WeightedRating)
+	},
+}
+
+type memMovieRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+	// bulk is set on the repository of a Bulk call, whose writes add no
+	// event each.
+	bulk bool
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.MovieRepositoryI {
+	return &memMovieRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (mr *memMovieRepo) Create(m *models.Movie) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	if m.ID == 0 {
+		m.ID = mr.DB.NextID("movies")
+	} else if _, ok := mr.DB.Movies[m.ID]; ok {
+		return errors.Errorf("memMovieRepo.Create error: duplicate id %d", m.ID)
+	} else if _, ok := mr.DB.TrashedMovies[m.ID]; ok {
+		return errors.Errorf("memMovieRepo.Create error: duplicate id %d", m.ID)
+	} else {
+		mr.DB.SeenID("movies", m.ID)
+	}
+
+	m.UpdatedAt = time.Now()
+	mr.DB.Movies[m.ID] = *m
+	mr.addEvent(models.EventMovieCreated, m.ID)
+
+	return nil
+}
+
+func (mr *memMovieRepo) Get(id int) (*models.Movie, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	m, ok := mr.DB.Movies[id]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.Get error")
+	}
+
+	return &m, nil
+}
+
+// Update mirrors gorm's Updates: only non-zero fields are written and a
+// missing row is not an error.
+func (mr *memMovieRepo) Update(m *models.Movie) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	stored, ok := mr.DB.Movies[m.ID]
+	if !ok {
+		return nil
+	}
+	before := stored
+
+	if m.Title != "" {
+		stored.Title = m.Title
+	}
+	if m.Description != "" {
+		stored.Description = m.Description
+	}
+	if !m.ReleaseDate.IsZero() {
+		stored.ReleaseDate = m.ReleaseDate
+	}
+	if m.Rating != 0 {
+		stored.Rating = m.Rating
+	}
+
+	stored.UpdatedAt = time.Now()
+	mr.DB.Movies[m.ID] = stored
+	m.UpdatedAt = stored.UpdatedAt
+	if catalogueChanged(before, stored) {
+		mr.addEvent(models.EventMovieUpdated, stored.ID)
+	}
+
+	return nil
+}
+
+func (mr *memMovieRepo) Replace(m *models.Movie) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	stored, ok := mr.DB.Movies[m.ID]
+	if !ok {
+		return nil
+	}
+	before := stored
+
+	stored.Title, stored.Description, stored.ReleaseDate, stored.Rating = m.Title, m.Description, m.ReleaseDate, m.Rating
+
+	stored.UpdatedAt = time.Now()
+	mr.DB.Movies[m.ID] = stored
+	m.UpdatedAt = stored.UpdatedAt
+	if catalogueChanged(before, stored) {
+		mr.addEvent(models.EventMovieUpdated, stored.ID)
+	}
+
+	return nil
+}
+
+// Delete moves the movie to the trash, the rows referring to it stay for
+// a Restore.
+func (mr *memMovieRepo) Delete(id int) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	m, ok := mr.DB.Movies[id]
+	if !ok {
+		return nil
+	}
+
+	m.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	m.UpdatedAt = m.DeletedAt.Time
+	mr.DB.TrashedMovies[id] = m
+	delete(mr.DB.Movies, id)
+	mr.DB.CastVersion++
+	mr.addEvent(models.EventMovieDeleted, id)
+
+	return nil
+}
+
+func (mr *memMovieRepo) Restore(id int) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	m, ok := mr.DB.TrashedMovies[id]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.Restore error")
+	}
+
+	m.DeletedAt = gorm.DeletedAt{}
+	m.UpdatedAt = time.Now()
+	mr.DB.Movies[id] = m
+	delete(mr.DB.TrashedMovies, id)
+	mr.DB.CastVersion++
+	mr.addEvent(models.EventMovieRestored, id)
+
+	return nil
+}
+
+func (mr *memMovieRepo) GetMoviesSorted(sortingColumn string) ([]models.Movie, error) {
+	column, direction, _ := strings.Cut(strings.ToLower(strings.TrimSpace(sortingColumn)), " ")
+
+	compare, ok := movieColumns[column]
+	if !ok {
+		return nil, errors.Errorf("memMovieRepo.GetMoviesSorted error: unknown column %q", column)
+	}
+
+	switch strings.TrimSpace(direction) {
+	case "", "asc":
+	case "desc":
+		asc := compare
+		compare = func(a, b models.Movie) int { return asc(b, a) }
+	default:
+		return nil, errors.Errorf("memMovieRepo.GetMoviesSorted error: unknown direction %q", direction)
+	}
+
+	mr.DB.RLock()
+	movies := make([]models.Movie, 0, len(mr.DB.Movies))
+	for _, m := range mr.DB.Movies {
+		movies = append(movies, m)
+	}
+	mr.DB.RUnlock()
+
+	slices.SortStableFunc(movies, func(a, b models.Movie) int {
+		return cmp.Or(compare(a, b), cmp.Compare(a.ID, b.ID))
+	})
+
+	return movies, nil
+}
+
+func (mr *memMovieRepo) GetActorsByMovie(id int) ([]models.Actor, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	actors := make([]models.Actor, 0)
+	seen := make(map[int]bool)
+
+	for _, ma := range mr.DB.MoviesActors {
+		if ma.MovieID != id || seen[ma.ActorID] {
+			continue
+		}
+		seen[ma.ActorID] = true
+
+		if a, ok := mr.DB.Actors[ma.ActorID]; ok {
+			actors = append(actors, a)
+		}
+	}
+
+	slices.SortFunc(actors, func(a, b models.Actor) int { return cmp.Compare(a.ID, b.ID) })
+
+	return actors, nil
+}
+
+func (mr *memMovieRepo) GetMoviesByTitle(title string) ([]models.Movie, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	movies := make([]models.Movie, 0)
+
+	for _, m := range mr.DB.Movies {
+		if strings.Contains(m.Title, title) {
+			movies = append(movies, m)
+		}
+	}
+
+	slices.SortFunc(movies, func(a, b models.Movie) int { return cmp.Compare(a.ID, b.ID) })
+
+	return movies, nil
+}
+
+// SearchMovies approximates the Postgres full-text search: every query word
+// must occur in the title or description ("-word" excludes), title hits
+// weigh more than description hits, and matched words are wrapped in <b>.
+func (mr *memMovieRepo) SearchMovies(query string, limit, offset int) ([]models.MovieSearchResult, error) {
+	var include, exclude []string
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		word = strings.Trim(word, `"`)
+		if strings.EqualFold(word, "or") || word == "" {
+			continue
+		}
+
+		if strings.HasPrefix(word, "-") {
+			exclude = append(exclude, word[1:])
+		} else {
+			include = append(include, word)
+		}
+	}
+
+	if len(include) == 0 {
+		return []models.MovieSearchResult{}, nil
+	}
+
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	results := make([]models.MovieSearchResult, 0)
+
+	for _, m := range mr.DB.Movies {
+		title, description := strings.ToLower(m.Title), strings.ToLower(m.Description)
+
+		rank := 0.0
+		matched := true
+
+		for _, word := range include {
+			inTitle, inDescription := strings.Contains(title, word), strings.Contains(description, word)
+			if !inTitle && !inDescription {
+				matched = false
+				break
+			}
+
+			if inTitle {
+				rank += 1
+			}
+			if inDescription {
+				rank += 0.4
+			}
+		}
+
+		for _, word := range exclude {
+			if word != "" && (strings.Contains(title, word) || strings.Contains(description, word)) {
+				matched = false
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		results = append(results, models.MovieSearchResult{
+			Movie:          m,
+			Rank:           rank / float64(len(include)),
+			TitleHighlight: highlight(m.Title, include),
+			Snippet:        highlight(m.Description, include),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b models.MovieSearchResult) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(results, limit, offset), nil
+}
+
+func highlight(text string, words []string) string {
+	fields := strings.Fields(text)
+
+	for i, field := range fields {
+		lower := strings.ToLower(field)
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				fields[i] = fmt.Sprintf("<b>%s</b>", field)
+				break
+			}
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+func (mr *memMovieRepo) GetByNaturalKey(title string, releaseDate time.Time) (*models.Movie, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	if m, ok := mr.findByNaturalKey(mr.DB.Movies, title, releaseDate); ok {
+		return &m, nil
+	}
+	if m, ok := mr.findByNaturalKey(mr.DB.TrashedMovies, title, releaseDate); ok {
+		return &m, nil
+	}
+
+	return nil, errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.GetByNaturalKey error")
+}
+
+// Upsert mirrors upsertMovieQuery: the oldest movie with the title and
+// release date keeps its id and gets the new description and rating, one in
+// the trash is restored if no live movie matches.
+func (mr *memMovieRepo) Upsert(m *models.Movie) (string, error) {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	stored, ok := mr.findByNaturalKey(mr.DB.Movies, m.Title, m.ReleaseDate)
+	restored := false
+	if !ok {
+		stored, ok = mr.findByNaturalKey(mr.DB.TrashedMovies, m.Title, m.ReleaseDate)
+		if ok {
+			stored.DeletedAt = gorm.DeletedAt{}
+			delete(mr.DB.TrashedMovies, stored.ID)
+			mr.DB.CastVersion++
+			restored = true
+		}
+	}
+
+	if ok {
+		m.ID = stored.ID
+		before := stored
+		stored.Description = m.Description
+		stored.Rating = m.Rating
+		stored.UpdatedAt = time.Now()
+		mr.DB.Movies[m.ID] = stored
+		m.UpdatedAt = stored.UpdatedAt
+
+		switch {
+		case restored:
+			mr.addEvent(models.EventMovieRestored, stored.ID)
+
+			return models.UpsertRestored, nil
+		case catalogueChanged(before, stored):
+			mr.addEvent(models.EventMovieUpdated, stored.ID)
+		}
+
+		return models.UpsertUpdated, nil
+	}
+
+	m.ID = mr.DB.NextID("movies")
+	m.UpdatedAt = time.Now()
+	mr.DB.Movies[m.ID] = *m
+	mr.addEvent(models.EventMovieCreated, m.ID)
+
+	return models.UpsertCreated, nil
+}
+
+// findByNaturalKey looks in movies, Movies or TrashedMovies, and returns the
+// movie with the lowest id of several. Must be called with the lock held.
+func (mr *memMovieRepo) findByNaturalKey(movies map[int]models.Movie, title string, releaseDate time.Time) (models.Movie, bool) {
+	found := models.Movie{}
+	for _, m := range movies {
+		if m.Title == title && m.ReleaseDate.Equal(releaseDate) && (found.ID == 0 || m.ID < found.ID) {
+			found = m
+		}
+	}
+
+	return found, found.ID != 0
+}
+
+// Each walks a snapshot of the matching movies, fn runs without the lock
+// held so it may call back into the repository.
+func (mr *memMovieRepo) Each(filter models.MovieFilter, fn func(m models.Movie) error) error {
+	compare, ok := movieColumns[filter.SortBy]
+	if !ok {
+		return errors.Errorf("memMovieRepo.Each error: unknown column %q", filter.SortBy)
+	}
+
+	mr.DB.RLock()
+	movies := make([]models.Movie, 0)
+	for _, m := range mr.DB.Movies {
+		if strings.Contains(m.Title, filter.Title) {
+			movies = append(movies, m)
+		}
+	}
+	mr.DB.RUnlock()
+
+	slices.SortFunc(movies, func(a, b models.Movie) int {
+		c := compare(a, b)
+		if filter.Desc {
+			c = -c
+		}
+
+		return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	})
+
+	for _, m := range movies {
+		if err := fn(m); err != nil {
+			return errors.Wrap(err, "memMovieRepo.Each error")
+		}
+	}
+
+	return nil
+}
+
+// EachCredit leaves out the links of movies and actors in the trash.
+func (mr *memMovieRepo) EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error {
+	mr.DB.RLock()
+	credits := make([]models.MovieActor, 0)
+	for _, ma := range mr.DB.MoviesActors {
+		_, okMovie := mr.DB.Movies[ma.MovieID]
+		_, okActor := mr.DB.Actors[ma.ActorID]
+		if !okMovie || !okActor {
+			continue
+		}
+
+		if (filter.MovieID == 0 || ma.MovieID == filter.MovieID) && (filter.ActorID == 0 || ma.ActorID == filter.ActorID) {
+			credits = append(credits, ma)
+		}
+	}
+	mr.DB.RUnlock()
+
+	slices.SortFunc(credits, func(a, b models.MovieActor) int { return cmp.Compare(a.ID, b.ID) })
+
+	for _, ma := range credits {
+		if err := fn(ma); err != nil {
+			return errors.Wrap(err, "memMovieRepo.EachCredit error")
+		}
+	}
+
+	return nil
+}
+
+func (mr *memMovieRepo) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	ids := []models.ExternalID{}
+	for ext, owner := range mr.DB.MovieExternalIDs {
+		if owner == id {
+			ids = append(ids, ext)
+		}
+	}
+
+	slices.SortFunc(ids, func(a, b models.ExternalID) int {
+		return cmp.Or(strings.Compare(a.Source, b.Source), strings.Compare(a.ID, b.ID))
+	})
+
+	return ids, nil
+}
+
+func (mr *memMovieRepo) GetByExternalID(ext models.ExternalID) (*models.Movie, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	id, ok := mr.DB.MovieExternalIDs[ext]
+	v, found := mr.DB.Movies[id]
+	if !ok || !found {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.GetByExternalID error")
+	}
+
+	return &v, nil
+}
+
+// AddExternalID enforces the primary key (source, external_id) and the
+// foreign key of movie_external_ids.
+func (mr *memMovieRepo) AddExternalID(id int, ext models.ExternalID) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	if _, ok := mr.DB.Movies[id]; !ok {
+		return errors.Errorf("memMovieRepo.AddExternalID error: movie %d does not exist", id)
+	}
+
+	if _, ok := mr.DB.MovieExternalIDs[ext]; ok {
+		return errors.Errorf("memMovieRepo.AddExternalID error: %s id %q is already taken", ext.Source, ext.ID)
+	}
+
+	mr.DB.MovieExternalIDs[ext] = id
+	mr.DB.TouchMovie(id)
+
+	return nil
+}
+
+func (mr *memMovieRepo) DeleteExternalID(id int, ext models.ExternalID) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	owner, ok := mr.DB.MovieExternalIDs[ext]
+	if !ok || owner != id {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.DeleteExternalID error")
+	}
+
+	delete(mr.DB.MovieExternalIDs, ext)
+	mr.DB.TouchMovie(id)
+
+	return nil
+}
+
+// AddRevision enforces the foreign key of movie_revisions.
+func (mr *memMovieRepo) AddRevision(id int, r *models.Revision) error {
+	mr.DB.Lock()
+	defer mr.DB.Unlock()
+
+	if _, ok := mr.DB.Movies[id]; !ok {
+		return errors.Errorf("memMovieRepo.AddRevision error: movie %d does not exist", id)
+	}
+
+	r.Rev = len(mr.DB.MovieRevisions[id]) + 1
+	r.CreatedAt = time.Now()
+	mr.DB.MovieRevisions[id] = append(mr.DB.MovieRevisions[id], *r)
+
+	return nil
+}
+
+func (mr *memMovieRepo) GetRevisions(id, limit, offset int) ([]models.Revision, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	stored := mr.DB.MovieRevisions[id]
+	revisions := make([]models.Revision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+
+	return memdb.Page(revisions, limit, offset), nil
+}
+
+func (mr *memMovieRepo) GetRevision(id, rev int) (*models.Revision, error) {
+	mr.DB.RLock()
+	defer mr.DB.RUnlock()
+
+	stored := mr.DB.MovieRevisions[id]
+	if rev < 1 || rev > len(stored) {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memMovieRepo.GetRevision error")
+	}
+
+	r := stored[rev-1]
+
+	return &r, nil
+}
+
+// Atomic runs fn on the repository itself: the store has no transactions,
+// and its writes fail, if at all, before they change anything.
+func (mr *memMovieRepo) Atomic(fn func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	return fn(mr, memAudit.New(mr.Logger, mr.DB))
+}
+
+// Bulk runs fn on a repository that adds no event, then adds the one of fn.
+func (mr *memMovieRepo) Bulk(source string, fn func(movies repository.MovieRepositoryI) ([]int, error)) error {
+	ids, err := fn(&memMovieRepo{Logger: mr.Logger, DB: mr.DB, bulk: true})
+
+	mr.DB.Lock()
+	mr.DB.AddBulkEvent(models.BulkEvent{Source: source, Movies: ids})
+	mr.DB.Unlock()
+
+	return err
+}
+
+// addEvent is DB.AddEntityEvent but in a Bulk call.
+func (mr *memMovieRepo) addEvent(eventType string, id int) {
+	if !mr.bulk {
+		mr.DB.AddEntityEvent(eventType, id)
+	}
+}
+
+// catalogueChanged mirrors the movies_outbox_update trigger: a change of
+// the RatingStats alone is not an event.
+func catalogueChanged(before, after models.Movie) bool {
+	return before.Title != after.Title || before.Description != after.Description ||
+		!before.ReleaseDate.Equal(after.ReleaseDate) || before.Rating != after.Rating
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"intern/internal/memdb"
+	"intern/internal/movie/repository"
+	"intern/internal/movie/repository/contract"
+	"intern/models"
+	"testing"
+)
+
+type backend struct {
+	repo repository.MovieRepositoryI
+	db   *memdb.DB
+}
+
+func (b backend) Repo() repository.MovieRepositoryI                               { return b.repo }
+func (b backend) ExpectCreate(models.Movie, int)                                  {}
+func (b backend) ExpectGet(models.Movie)                                          {}
+func (b backend) ExpectGetMissing(int)                                            {}
+func (b backend) ExpectUpdate(models.Movie)                                       {}
+func (b backend) ExpectReplace(models.Movie)                                      {}
+func (b backend) ExpectRestore(int, bool)                                         {}
+func (b backend) ExpectDelete(int)                                                {}
+func (b backend) ExpectGetMoviesByTitle(string, []models.Movie)                   {}
+func (b backend) ExpectGetMoviesSorted(string, []models.Movie)                    {}
+func (b backend) ExpectGetActorsByMovie(int, []models.Actor)                      {}
+func (b backend) ExpectSearchMovies(string, int, int, []models.MovieSearchResult) {}
+func (b backend) ExpectUpsert(models.Movie, int, string)                          {}
+func (b backend) ExpectBulkUpsert(models.Movie, int)                              {}
+func (b backend) ExpectGetByNaturalKey(models.Movie)                              {}
+func (b backend) ExpectEachByTitle(string, []models.Movie)                        {}
+func (b backend) ExpectAddExternalID(int, models.ExternalID)                      {}
+func (b backend) ExpectGetExternalIDs(int, []models.ExternalID)                   {}
+func (b backend) ExpectGetByExternalID(models.ExternalID, *models.Movie)          {}
+func (b backend) ExpectDeleteExternalID(int, models.ExternalID, bool)             {}
+func (b backend) Verify() error                                                   { return nil }
+
+func (b backend) SeedCast(id int, actors ...models.Actor) {
+	for _, a := range actors {
+		b.db.Actors[a.ID] = a
+		linkID := b.db.NextID("movies_actors")
+		b.db.MoviesActors[linkID] = models.MovieActor{ID: linkID, MovieID: id, ActorID: a.ID}
+	}
+}
+
+func TestMovieRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db := memdb.New()
+		return backend{repo: New(nil, db), db: db}
+	})
+}
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	auditRep "intern/internal/audit/repository"
+	repository "intern/internal/movie/repository"
 	models "intern/models"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,6 +16,62 @@ type MovieRepositoryI struct {
 	mock.Mock
 }
 
+// AddExternalID provides a mock function with given fields: id, ext
+func (_m *MovieRepositoryI) AddExternalID(id int, ext models.ExternalID) error {
+	ret := _m.Called(id, ext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, models.ExternalID) error); ok {
+		r0 = rf(id, ext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddRevision provides a mock function with given fields: id, r
+func (_m *MovieRepositoryI) AddRevision(id int, r *models.Revision) error {
+	ret := _m.Called(id, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, *models.Revision) error); ok {
+		r0 = rf(id, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Atomic provides a mock function with given fields: fn
+func (_m *MovieRepositoryI) Atomic(fn func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	ret := _m.Called(fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error); ok {
+		r0 = rf(fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Bulk provides a mock function with given fields: source, fn
+func (_m *MovieRepositoryI) Bulk(source string, fn func(movies repository.MovieRepositoryI) ([]int, error)) error {
+	ret := _m.Called(source, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, func(movies repository.MovieRepositoryI) ([]int, error)) error); ok {
+		r0 = rf(source, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: m
 func (_m *MovieRepositoryI) Create(m *models.Movie) error {
 	ret := _m.Called(m)
@@ -41,6 +100,48 @@ func (_m *MovieRepositoryI) Delete(id int) error {
 	return r0
 }
 
+// DeleteExternalID provides a mock function with given fields: id, ext
+func (_m *MovieRepositoryI) DeleteExternalID(id int, ext models.ExternalID) error {
+	ret := _m.Called(id, ext)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, models.ExternalID) error); ok {
+		r0 = rf(id, ext)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Each provides a mock function with given fields: filter, fn
+func (_m *MovieRepositoryI) Each(filter models.MovieFilter, fn func(m models.Movie) error) error {
+	ret := _m.Called(filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.MovieFilter, func(m models.Movie) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EachCredit provides a mock function with given fields: filter, fn
+func (_m *MovieRepositoryI) EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error {
+	ret := _m.Called(filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(models.CreditFilter, func(ma models.MovieActor) error) error); ok {
+		r0 = rf(filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: id
 func (_m *MovieRepositoryI) Get(id int) (*models.Movie, error) {
 	ret := _m.Called(id)
@@ -93,6 +194,84 @@ func (_m *MovieRepositoryI) GetActorsByMovie(id int) ([]models.Actor, error) {
 	return r0, r1
 }
 
+// GetByExternalID provides a mock function with given fields: ext
+func (_m *MovieRepositoryI) GetByExternalID(ext models.ExternalID) (*models.Movie, error) {
+	ret := _m.Called(ext)
+
+	var r0 *models.Movie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.ExternalID) (*models.Movie, error)); ok {
+		return rf(ext)
+	}
+	if rf, ok := ret.Get(0).(func(models.ExternalID) *models.Movie); ok {
+		r0 = rf(ext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Movie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.ExternalID) error); ok {
+		r1 = rf(ext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByNaturalKey provides a mock function with given fields: title, releaseDate
+func (_m *MovieRepositoryI) GetByNaturalKey(title string, releaseDate time.Time) (*models.Movie, error) {
+	ret := _m.Called(title, releaseDate)
+
+	var r0 *models.Movie
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, time.Time) (*models.Movie, error)); ok {
+		return rf(title, releaseDate)
+	}
+	if rf, ok := ret.Get(0).(func(string, time.Time) *models.Movie); ok {
+		r0 = rf(title, releaseDate)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Movie)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(title, releaseDate)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetExternalIDs provides a mock function with given fields: id
+func (_m *MovieRepositoryI) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	ret := _m.Called(id)
+
+	var r0 []models.ExternalID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]models.ExternalID, error)); ok {
+		return rf(id)
+	}
+	if rf, ok := ret.Get(0).(func(int) []models.ExternalID); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ExternalID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMoviesByTitle provides a mock function with given fields: title
 func (_m *MovieRepositoryI) GetMoviesByTitle(title string) ([]models.Movie, error) {
 	ret := _m.Called(title)
@@ -145,6 +324,112 @@ func (_m *MovieRepositoryI) GetMoviesSorted(sortingColumn string) ([]models.Movi
 	return r0, r1
 }
 
+// GetRevision provides a mock function with given fields: id, rev
+func (_m *MovieRepositoryI) GetRevision(id int, rev int) (*models.Revision, error) {
+	ret := _m.Called(id, rev)
+
+	var r0 *models.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int) (*models.Revision, error)); ok {
+		return rf(id, rev)
+	}
+	if rf, ok := ret.Get(0).(func(int, int) *models.Revision); ok {
+		r0 = rf(id, rev)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(id, rev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRevisions provides a mock function with given fields: id, limit, offset
+func (_m *MovieRepositoryI) GetRevisions(id int, limit int, offset int) ([]models.Revision, error) {
+	ret := _m.Called(id, limit, offset)
+
+	var r0 []models.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int, int) ([]models.Revision, error)); ok {
+		return rf(id, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(int, int, int) []models.Revision); ok {
+		r0 = rf(id, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int, int) error); ok {
+		r1 = rf(id, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Replace provides a mock function with given fields: m
+func (_m *MovieRepositoryI) Replace(m *models.Movie) error {
+	ret := _m.Called(m)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Movie) error); ok {
+		r0 = rf(m)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: id
+func (_m *MovieRepositoryI) Restore(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchMovies provides a mock function with given fields: query, limit, offset
+func (_m *MovieRepositoryI) SearchMovies(query string, limit int, offset int) ([]models.MovieSearchResult, error) {
+	ret := _m.Called(query, limit, offset)
+
+	var r0 []models.MovieSearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int, int) ([]models.MovieSearchResult, error)); ok {
+		return rf(query, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(string, int, int) []models.MovieSearchResult); ok {
+		r0 = rf(query, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.MovieSearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int, int) error); ok {
+		r1 = rf(query, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: m
 func (_m *MovieRepositoryI) Update(m *models.Movie) error {
 	ret := _m.Called(m)
@@ -159,6 +444,30 @@ func (_m *MovieRepositoryI) Update(m *models.Movie) error {
 	return r0
 }
 
+// Upsert provides a mock function with given fields: m
+func (_m *MovieRepositoryI) Upsert(m *models.Movie) (string, error) {
+	ret := _m.Called(m)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*models.Movie) (string, error)); ok {
+		return rf(m)
+	}
+	if rf, ok := ret.Get(0).(func(*models.Movie) string); ok {
+		r0 = rf(m)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*models.Movie) error); ok {
+		r1 = rf(m)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewMovieRepositoryI creates a new instance of MovieRepositoryI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMovieRepositoryI(t interface {
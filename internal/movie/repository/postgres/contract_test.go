@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"intern/internal/movie/repository"
+	"intern/internal/movie/repository/contract"
+	"intern/models"
+	"regexp"
+	"testing"
+)
+
+type sqlmockBackend struct {
+	repo repository.MovieRepositoryI
+	mock sqlmock.Sqlmock
+}
+
+func (b *sqlmockBackend) Repo() repository.MovieRepositoryI { return b.repo }
+
+func (b *sqlmockBackend) ExpectCreate(m models.Movie, id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "movies" ("title","description","release_date","rating","deleted_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(m.Title, m.Description, m.ReleaseDate, m.Rating, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectGet(m models.Movie) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE id = $1 AND "movies"."deleted_at" IS NULL LIMIT $2`)).
+		WithArgs(m.ID, 1).
+		WillReturnRows(movieRows(m))
+}
+
+func (b *sqlmockBackend) ExpectGetMissing(id int) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE id = $1 AND "movies"."deleted_at" IS NULL LIMIT $2`)).
+		WithArgs(id, 1).
+		WillReturnRows(movieRows())
+}
+
+func (b *sqlmockBackend) ExpectUpdate(m models.Movie) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(
+		`UPDATE "movies" SET "title"=$1,"description"=$2,"release_date"=$3,"rating"=$4 WHERE "movies"."deleted_at" IS NULL AND "id" = $5`)).
+		WithArgs(m.Title, m.Description, m.ReleaseDate, m.Rating, m.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectReplace(m models.Movie) {
+	b.ExpectUpdate(m)
+}
+
+func (b *sqlmockBackend) ExpectDelete(id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "movies" SET "deleted_at"=$1 WHERE "movies"."id" = $2 AND "movies"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectRestore(id int, found bool) {
+	affected := int64(0)
+	if found {
+		affected = 1
+	}
+
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "movies" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`)).
+		WithArgs(nil, id).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectGetMoviesByTitle(title string, movies []models.Movie) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE title LIKE $1`)).
+		WithArgs("%" + title + "%").
+		WillReturnRows(movieRows(movies...))
+}
+
+func (b *sqlmockBackend) ExpectGetMoviesSorted(sortingColumn string, movies []models.Movie) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE "movies"."deleted_at" IS NULL ORDER BY ` + sortingColumn + `,id`)).
+		WillReturnRows(movieRows(movies...))
+}
+
+// SeedCast has nothing to store, ExpectGetActorsByMovie scripts the links.
+func (b *sqlmockBackend) SeedCast(int, ...models.Actor) {}
+
+func (b *sqlmockBackend) ExpectGetActorsByMovie(id int, actors []models.Actor) {
+	links := sqlmock.NewRows([]string{"actor_id"})
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "birthday"})
+	args := make([]driver.Value, len(actors))
+	for i, a := range actors {
+		links.AddRow(a.ID)
+		rows.AddRow(a.ID, a.FirstName, a.LastName, a.Gender, a.Birthday)
+		args[i] = a.ID
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT actor_id FROM "movies_actors" WHERE movie_id = $1`)).
+		WithArgs(id).
+		WillReturnRows(links)
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "actors" WHERE "actors"."id" IN ($1,$2) AND "actors"."deleted_at" IS NULL`)).
+		WithArgs(args...).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectSearchMovies(query string, limit, offset int, results []models.MovieSearchResult) {
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "release_date", "rating",
+		"votes", "score_sum", "average_rating", "weighted_rating", "rank", "title_highlight", "snippet"})
+	for _, r := range results {
+		rows.AddRow(r.ID, r.Title, r.Description, r.ReleaseDate, r.Rating,
+			r.Votes, r.ScoreSum, r.AverageRating, r.WeightedRating, r.Rank, r.TitleHighlight, r.Snippet)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.id, m.title, m.description, m.release_date, m.rating,
+	m.votes, m.score_sum, m.average_rating, m.weighted_rating,`)).
+		WithArgs(query, limit, offset).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectUpsert(m models.Movie, id int, outcome string) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`WITH target AS (
+		SELECT id, deleted_at IS NOT NULL AS trashed FROM movies WHERE title = $1 AND release_date = $2 ORDER BY deleted_at IS NOT NULL, id LIMIT 1
+		), updated AS (
+		UPDATE movies SET description = $3, rating = $4, deleted_at = NULL WHERE id = (SELECT id FROM target)
+		RETURNING id, CASE WHEN (SELECT trashed FROM target) THEN 'restored' ELSE 'updated' END AS outcome
+		), inserted AS (
+		INSERT INTO movies (title, description, release_date, rating)
+		SELECT $5, $6, $7, $8 WHERE NOT EXISTS (SELECT 1 FROM target)
+		RETURNING id, 'created' AS outcome
+		)
+		SELECT id, outcome FROM updated UNION ALL SELECT id, outcome FROM inserted`)).
+		WithArgs(m.Title, m.ReleaseDate, m.Description, m.Rating, m.Title, m.Description, m.ReleaseDate, m.Rating).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "outcome"}).AddRow(id, outcome))
+}
+
+func (b *sqlmockBackend) ExpectBulkUpsert(m models.Movie, id int) {
+	b.mock.ExpectBegin()
+	b.mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	b.mock.ExpectExec(`SAVEPOINT sp`).WillReturnResult(sqlmock.NewResult(0, 0))
+	b.ExpectUpsert(m, id, models.UpsertCreated)
+	b.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox_events (type, payload) VALUES ($1, $2)`)).
+		WithArgs(models.EventCatalogueLoaded, fmt.Sprintf(`{"source":"import","movies":[%d],"actors":[]}`, id)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	b.mock.ExpectCommit()
+}
+
+func (b *sqlmockBackend) ExpectGetByNaturalKey(m models.Movie) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE title = $1 AND release_date = $2 ORDER BY deleted_at IS NOT NULL, id LIMIT $3`)).
+		WithArgs(m.Title, m.ReleaseDate, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "release_date", "rating", "deleted_at"}).
+			AddRow(m.ID, m.Title, m.Description, m.ReleaseDate, m.Rating, deletedAt(m.DeletedAt)))
+}
+
+func (b *sqlmockBackend) ExpectEachByTitle(title string, movies []models.Movie) {
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE title LIKE $1 AND "movies"."deleted_at" IS NULL ORDER BY rating DESC, id`)).
+		WithArgs("%" + title + "%").
+		WillReturnRows(movieRows(movies...))
+}
+
+func (b *sqlmockBackend) ExpectAddExternalID(id int, ext models.ExternalID) {
+	b.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO movie_external_ids (source, external_id, movie_id) VALUES ($1, $2, $3)`)).
+		WithArgs(ext.Source, ext.ID, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func (b *sqlmockBackend) ExpectGetExternalIDs(id int, ids []models.ExternalID) {
+	rows := sqlmock.NewRows([]string{"source", "external_id"})
+	for _, ext := range ids {
+		rows.AddRow(ext.Source, ext.ID)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT source, external_id FROM "movie_external_ids" WHERE movie_id = $1 ORDER BY source, external_id`)).
+		WithArgs(id).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectGetByExternalID(ext models.ExternalID, m *models.Movie) {
+	rows := movieRows()
+	if m != nil {
+		rows = movieRows(*m)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE (id = (SELECT movie_id FROM movie_external_ids WHERE source = $1 AND external_id = $2)) AND "movies"."deleted_at" IS NULL LIMIT $3`)).
+		WithArgs(ext.Source, ext.ID, 1).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) ExpectDeleteExternalID(id int, ext models.ExternalID, found bool) {
+	affected := int64(0)
+	if found {
+		affected = 1
+	}
+
+	b.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM movie_external_ids WHERE source = $1 AND external_id = $2 AND movie_id = $3`)).
+		WithArgs(ext.Source, ext.ID, id).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+func (b *sqlmockBackend) Verify() error { return b.mock.ExpectationsWereMet() }
+
+// deletedAt is the deleted_at column of a row.
+func deletedAt(d gorm.DeletedAt) driver.Value {
+	if !d.Valid {
+		return nil
+	}
+
+	return d.Time
+}
+
+func movieRows(movies ...models.Movie) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "release_date", "rating"})
+	for _, m := range movies {
+		rows.AddRow(m.ID, m.Title, m.Description, m.ReleaseDate, m.Rating)
+	}
+
+	return rows
+}
+
+func TestMovieRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal("error while creating sql mock")
+		}
+		t.Cleanup(func() { db.Close() })
+
+		gormDB, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_0",
+			DriverName:           "postgres",
+			Conn:                 db,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{})
+		if err != nil {
+			t.Fatal("error gorm open")
+		}
+
+		return &sqlmockBackend{repo: New(nil, gormDB), mock: mock}
+	})
+}
@@ -1,13 +1,58 @@
 package postgres
 
 import (
+	"database/sql"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
+	auditRep "intern/internal/audit/repository"
+	pgAudit "intern/internal/audit/repository/postgres"
+	pgEvent "intern/internal/event/repository/postgres"
 	"intern/internal/movie/repository"
 	"intern/models"
 	"intern/pkg/logger"
+	"intern/pkg/sqlutil"
+	"time"
 )
 
+// searchMoviesQuery ranks movies by the precomputed search_vector column
+// (title weighted above description) and highlights the matched words.
+const searchMoviesQuery = `SELECT m.id, m.title, m.description, m.release_date, m.rating,
+	m.votes, m.score_sum, m.average_rating, m.weighted_rating,
+	ts_rank(m.search_vector, q) AS rank,
+	ts_headline('english', m.title, q, 'HighlightAll=true') AS title_highlight,
+	ts_headline('english', m.description, q, 'MaxFragments=2, MinWords=5, MaxWords=20') AS snippet
+FROM movies m, websearch_to_tsquery('english', ?) q
+WHERE m.search_vector @@ q AND m.deleted_at IS NULL
+ORDER BY rank DESC, m.id
+LIMIT ? OFFSET ?`
+
+// upsertMovieQuery updates the movie with the title and release date, or
+// inserts one. The natural key is not unique: of several movies the oldest
+// live one is updated, and a movie in the trash is only taken out of it if
+// no live one matches. Concurrent upserts of a new key may both insert.
+const upsertMovieQuery = `WITH target AS (
+	SELECT id, deleted_at IS NOT NULL AS trashed FROM movies WHERE title = ? AND release_date = ?
+	ORDER BY deleted_at IS NOT NULL, id
+	LIMIT 1
+), updated AS (
+	UPDATE movies SET description = ?, rating = ?, deleted_at = NULL
+	WHERE id = (SELECT id FROM target)
+	RETURNING id, CASE WHEN (SELECT trashed FROM target) THEN 'restored' ELSE 'updated' END AS outcome
+), inserted AS (
+	INSERT INTO movies (title, description, release_date, rating)
+	SELECT ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM target)
+	RETURNING id, 'created' AS outcome
+)
+SELECT id, outcome FROM updated UNION ALL SELECT id, outcome FROM inserted`
+
+var movieSortColumns = map[string]bool{
+	models.MovieSortID:          true,
+	models.MovieSortTitle:       true,
+	models.MovieSortReleaseDate: true,
+	models.MovieSortRating:      true,
+	models.MovieSortWeighted:    true,
+}
+
 type pgMovieRepo struct {
 	Logger logger.Logger
 	DB     *gorm.DB
@@ -51,6 +96,17 @@ func (mr *pgMovieRepo) Update(m *models.Movie) error {
 	return nil
 }
 
+func (mr *pgMovieRepo) Replace(m *models.Movie) error {
+	tx := mr.DB.Model(m).Select("title", "description", "release_date", "rating").Updates(m)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgMovieRepo.Replace error")
+	}
+
+	return nil
+}
+
+// Delete moves the movie to the trash, see models.Movie.DeletedAt.
 func (mr *pgMovieRepo) Delete(id int) error {
 	tx := mr.DB.Delete(&models.Movie{}, id)
 
@@ -61,10 +117,24 @@ func (mr *pgMovieRepo) Delete(id int) error {
 	return nil
 }
 
+func (mr *pgMovieRepo) Restore(id int) error {
+	tx := mr.DB.Unscoped().Model(&models.Movie{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgMovieRepo.Restore error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgMovieRepo.Restore error")
+	}
+
+	return nil
+}
+
 func (mr *pgMovieRepo) GetMoviesSorted(sortingColumn string) ([]models.Movie, error) {
 	var movies []models.Movie
 
-	tx := mr.DB.Order(sortingColumn).Find(&movies)
+	tx := mr.DB.Order(sortingColumn).Order("id").Find(&movies)
 
 	if tx.Error != nil {
 		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetMoviesSorted error")
@@ -106,3 +176,263 @@ func (mr *pgMovieRepo) GetMoviesByTitle(title string) ([]models.Movie, error) {
 
 	return movies, nil
 }
+
+func (mr *pgMovieRepo) SearchMovies(query string, limit, offset int) ([]models.MovieSearchResult, error) {
+	var results []models.MovieSearchResult
+
+	tx := mr.DB.Raw(searchMoviesQuery, query, limit, offset).Scan(&results)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.SearchMovies error")
+	}
+
+	return results, nil
+}
+
+func (mr *pgMovieRepo) GetByNaturalKey(title string, releaseDate time.Time) (*models.Movie, error) {
+	var m models.Movie
+	tx := mr.DB.Unscoped().Where("title = ? AND release_date = ?", title, releaseDate).Order("deleted_at IS NOT NULL, id").Take(&m)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetByNaturalKey error")
+	}
+
+	return &m, nil
+}
+
+func (mr *pgMovieRepo) Upsert(m *models.Movie) (string, error) {
+	var res struct {
+		ID      int
+		Outcome string
+	}
+
+	tx := mr.DB.Raw(upsertMovieQuery, m.Title, m.ReleaseDate, m.Description, m.Rating,
+		m.Title, m.Description, m.ReleaseDate, m.Rating).Scan(&res)
+
+	if tx.Error != nil {
+		return "", errors.Wrap(tx.Error, "pgMovieRepo.Upsert error")
+	}
+
+	m.ID = res.ID
+
+	return res.Outcome, nil
+}
+
+// Each streams the movies matching filter to fn. Rows are read from the
+// cursor one at a time, so memory use does not grow with the table; an
+// error returned by fn stops the iteration.
+func (mr *pgMovieRepo) Each(filter models.MovieFilter, fn func(m models.Movie) error) error {
+	if !movieSortColumns[filter.SortBy] {
+		return errors.Errorf("pgMovieRepo.Each error: unknown column %q", filter.SortBy)
+	}
+
+	tx := mr.DB.Model(&models.Movie{})
+
+	if filter.Title != "" {
+		tx = tx.Where("title LIKE ?", "%"+sqlutil.EscapeLike(filter.Title)+"%")
+	}
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	rows, err := tx.Order(filter.SortBy + " " + direction + ", id").Rows()
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.Each error")
+	}
+
+	err = mr.scanEach(rows, func() error {
+		var m models.Movie
+		if err := mr.DB.ScanRows(rows, &m); err != nil {
+			return err
+		}
+
+		return fn(m)
+	})
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.Each error")
+	}
+
+	return nil
+}
+
+// EachCredit leaves out the links of movies and actors in the trash.
+func (mr *pgMovieRepo) EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error {
+	tx := mr.DB.Table("movies_actors ma").Select("ma.id, ma.movie_id, ma.actor_id").
+		Joins("JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL").
+		Joins("JOIN actors a ON a.id = ma.actor_id AND a.deleted_at IS NULL")
+
+	if filter.MovieID != 0 {
+		tx = tx.Where("ma.movie_id = ?", filter.MovieID)
+	}
+
+	if filter.ActorID != 0 {
+		tx = tx.Where("ma.actor_id = ?", filter.ActorID)
+	}
+
+	rows, err := tx.Order("ma.id").Rows()
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.EachCredit error")
+	}
+
+	err = mr.scanEach(rows, func() error {
+		var ma models.MovieActor
+		if err := rows.Scan(&ma.ID, &ma.MovieID, &ma.ActorID); err != nil {
+			return err
+		}
+
+		return fn(ma)
+	})
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.EachCredit error")
+	}
+
+	return nil
+}
+
+func (mr *pgMovieRepo) scanEach(rows *sql.Rows, next func() error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := next(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (mr *pgMovieRepo) GetExternalIDs(id int) ([]models.ExternalID, error) {
+	ids := []models.ExternalID{}
+	tx := mr.DB.Table("movie_external_ids").Select("source, external_id").Where("movie_id = ?", id).Order("source, external_id").Find(&ids)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetExternalIDs error")
+	}
+
+	return ids, nil
+}
+
+func (mr *pgMovieRepo) GetByExternalID(ext models.ExternalID) (*models.Movie, error) {
+	var v models.Movie
+	tx := mr.DB.Where("id = (SELECT movie_id FROM movie_external_ids WHERE source = ? AND external_id = ?)", ext.Source, ext.ID).Take(&v)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetByExternalID error")
+	}
+
+	return &v, nil
+}
+
+func (mr *pgMovieRepo) AddExternalID(id int, ext models.ExternalID) error {
+	tx := mr.DB.Exec("INSERT INTO movie_external_ids (source, external_id, movie_id) VALUES (?, ?, ?)", ext.Source, ext.ID, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgMovieRepo.AddExternalID error")
+	}
+
+	return nil
+}
+
+func (mr *pgMovieRepo) DeleteExternalID(id int, ext models.ExternalID) error {
+	tx := mr.DB.Exec("DELETE FROM movie_external_ids WHERE source = ? AND external_id = ? AND movie_id = ?", ext.Source, ext.ID, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgMovieRepo.DeleteExternalID error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgMovieRepo.DeleteExternalID error")
+	}
+
+	return nil
+}
+
+// addMovieRevisionQuery numbers the revision after the last one of the movie; a
+// concurrent insert of the same number fails on the primary key.
+const addMovieRevisionQuery = `INSERT INTO movie_revisions (movie_id, rev, user_id, data)
+SELECT ?, COALESCE(MAX(rev), 0) + 1, ?, ?::jsonb FROM movie_revisions WHERE movie_id = ?
+RETURNING rev, created_at`
+
+func (mr *pgMovieRepo) AddRevision(id int, r *models.Revision) error {
+	tx := mr.DB.Raw(addMovieRevisionQuery, id, r.UserID, string(r.Data), id).Scan(r)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgMovieRepo.AddRevision error")
+	}
+
+	return nil
+}
+
+func (mr *pgMovieRepo) GetRevisions(id, limit, offset int) ([]models.Revision, error) {
+	revisions := []models.Revision{}
+	tx := mr.DB.Table("movie_revisions").Select("rev, user_id, data, created_at").Where("movie_id = ?", id).
+		Order("rev DESC").Limit(limit).Offset(offset).Find(&revisions)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetRevisions error")
+	}
+
+	return revisions, nil
+}
+
+func (mr *pgMovieRepo) GetRevision(id, rev int) (*models.Revision, error) {
+	var r models.Revision
+	tx := mr.DB.Table("movie_revisions").Select("rev, user_id, data, created_at").Where("movie_id = ? AND rev = ?", id, rev).Take(&r)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgMovieRepo.GetRevision error")
+	}
+
+	return &r, nil
+}
+
+// Atomic runs fn in a transaction, the audit log writes to the same one.
+func (mr *pgMovieRepo) Atomic(fn func(movies repository.MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error {
+	err := mr.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(New(mr.Logger, tx), pgAudit.New(mr.Logger, tx))
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.Atomic error")
+	}
+
+	return nil
+}
+
+// Bulk runs fn in a transaction with the outbox triggers off, see
+// pgEvent.BulkLoad. Each Upsert of fn has a savepoint, so that one failing
+// leaves the transaction usable for the next.
+func (mr *pgMovieRepo) Bulk(source string, fn func(movies repository.MovieRepositoryI) ([]int, error)) error {
+	err := pgEvent.BulkLoad(mr.DB, source, func(tx *gorm.DB, e *models.BulkEvent) error {
+		ids, err := fn(bulkMovieRepo{&pgMovieRepo{Logger: mr.Logger, DB: tx}})
+		e.Movies = append(e.Movies, ids...)
+
+		return err
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgMovieRepo.Bulk error")
+	}
+
+	return nil
+}
+
+// bulkMovieRepo is the repository of a Bulk call.
+type bulkMovieRepo struct {
+	*pgMovieRepo
+}
+
+// Upsert runs in a nested transaction, which gorm makes a savepoint.
+func (br bulkMovieRepo) Upsert(m *models.Movie) (string, error) {
+	var outcome string
+	err := br.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		outcome, err = New(br.Logger, tx).Upsert(m)
+
+		return err
+	})
+
+	return outcome, err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ozontech/allure-go/pkg/framework/suite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	auditRep "intern/internal/audit/repository"
 	movieRep "intern/internal/movie/repository"
 	"intern/internal/testBuilders"
 	"intern/models"
@@ -75,8 +76,8 @@ func (s *MovieRepoTestSuite) TestCreateMovie(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(
-		`INSERT INTO "movies" ("title","description","release_date","rating","id") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
-		WithArgs(movie.Title, movie.Description, movie.ReleaseDate, movie.Rating, movie.ID).
+		`INSERT INTO "movies" ("title","description","release_date","rating","deleted_at","id") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+		WithArgs(movie.Title, movie.Description, movie.ReleaseDate, movie.Rating, nil, movie.ID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 	s.mock.ExpectCommit()
@@ -105,7 +106,7 @@ func (s *MovieRepoTestSuite) TestGetMovie(t provider.T) {
 		)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(
-		`SELECT * FROM "movies" WHERE id = $1 LIMIT $2`)).
+		`SELECT * FROM "movies" WHERE id = $1 AND "movies"."deleted_at" IS NULL LIMIT $2`)).
 		WithArgs(movie.ID, 1).
 		WillReturnRows(rows)
 
@@ -126,7 +127,7 @@ func (s *MovieRepoTestSuite) TestUpdateMovie(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectExec(regexp.QuoteMeta(
-		`UPDATE "movies" SET "title"=$1,"description"=$2,"release_date"=$3,"rating"=$4 WHERE "id" = $5`)).
+		`UPDATE "movies" SET "title"=$1,"description"=$2,"release_date"=$3,"rating"=$4 WHERE "movies"."deleted_at" IS NULL AND "id" = $5`)).
 		WithArgs(movie.Title, movie.Description, movie.ReleaseDate, movie.Rating, movie.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	s.mock.ExpectCommit()
@@ -147,8 +148,8 @@ func (s *MovieRepoTestSuite) TestDeleteMovie(t provider.T) {
 	s.mock.ExpectBegin()
 
 	s.mock.ExpectExec(regexp.QuoteMeta(
-		`DELETE FROM "movies" WHERE "movies"."id" = $1`)).
-		WithArgs(movie.ID).WillReturnResult(sqlmock.NewResult(int64(movie.ID), 1))
+		`UPDATE "movies" SET "deleted_at"=$1 WHERE "movies"."id" = $2 AND "movies"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), movie.ID).WillReturnResult(sqlmock.NewResult(int64(movie.ID), 1))
 
 	s.mock.ExpectCommit()
 
@@ -156,6 +157,24 @@ func (s *MovieRepoTestSuite) TestDeleteMovie(t provider.T) {
 	t.Assert().NoError(err)
 }
 
+func (s *MovieRepoTestSuite) TestRestoreMovie(t provider.T) {
+	restore := regexp.QuoteMeta(`UPDATE "movies" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(restore).WithArgs(nil, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Restore(1)
+	t.Assert().NoError(err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(restore).WithArgs(nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err = s.repo.Restore(2)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
 func (s *MovieRepoTestSuite) TestGetActorsByMovie(t provider.T) {
 	actors := make([]models.Actor, 10)
 	err := faker.FakeData(&actors)
@@ -201,3 +220,144 @@ func (s *MovieRepoTestSuite) TestGetActorsByMovie(t provider.T) {
 	t.Assert().NoError(err)
 	t.Assert().Equal(actors, resActors)
 }
+
+func (s *MovieRepoTestSuite) TestSearchMovies(t provider.T) {
+	movie := s.movieBuilder.
+		WithID(1).
+		WithTitle("Dark Knight").
+		WithDesc("Batman fights the Joker").
+		WithRating(9).
+		WithRelease(time.Date(2008, 7, 18, 0, 0, 0, 0, time.UTC)).
+		Build()
+
+	movie.RatingStats = models.RatingStats{Votes: 2, ScoreSum: 17, AverageRating: 8.5, WeightedRating: 7.9}
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "release_date", "rating",
+		"votes", "score_sum", "average_rating", "weighted_rating", "rank", "title_highlight", "snippet"}).
+		AddRow(
+			movie.ID,
+			movie.Title,
+			movie.Description,
+			movie.ReleaseDate,
+			movie.Rating,
+			2, 17, 8.5, 7.9,
+			0.6,
+			"<b>Dark</b> Knight",
+			"Batman fights the Joker",
+		)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`m.votes, m.score_sum, m.average_rating, m.weighted_rating, ts_rank(m.search_vector, q) AS rank`)+".*"+regexp.QuoteMeta(
+		`FROM movies m, websearch_to_tsquery('english', $1) q WHERE m.search_vector @@ q AND m.deleted_at IS NULL ORDER BY rank DESC, m.id LIMIT $2 OFFSET $3`)).
+		WithArgs("dark", 20, 0).
+		WillReturnRows(rows)
+
+	results, err := s.repo.SearchMovies("dark", 20, 0)
+	t.Assert().NoError(err)
+	t.Assert().Len(results, 1)
+	t.Assert().Equal(movie, results[0].Movie)
+	t.Assert().Equal("<b>Dark</b> Knight", results[0].TitleHighlight)
+}
+
+func (s *MovieRepoTestSuite) TestEachCredit(t provider.T) {
+	credits := []models.MovieActor{
+		{ID: 3, MovieID: 1, ActorID: 7},
+		{ID: 9, MovieID: 1, ActorID: 2},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "movie_id", "actor_id"})
+	for _, ma := range credits {
+		rows.AddRow(ma.ID, ma.MovieID, ma.ActorID)
+	}
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT ma.id, ma.movie_id, ma.actor_id FROM movies_actors ma ` +
+		`JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL JOIN actors a ON a.id = ma.actor_id AND a.deleted_at IS NULL ` +
+		`WHERE ma.movie_id = $1 ORDER BY ma.id`)).
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	var got []models.MovieActor
+	err := s.repo.EachCredit(models.CreditFilter{MovieID: 1}, func(ma models.MovieActor) error {
+		got = append(got, ma)
+		return nil
+	})
+	t.Assert().NoError(err)
+	t.Assert().Equal(credits, got)
+}
+
+func (s *MovieRepoTestSuite) TestAddRevision(t provider.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := models.Revision{UserID: 3, Data: []byte(`{"id":1}`)}
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO movie_revisions (movie_id, rev, user_id, data) SELECT $1, COALESCE(MAX(rev), 0) + 1, $2, $3::jsonb FROM movie_revisions WHERE movie_id = $4 RETURNING rev, created_at`)).
+		WithArgs(1, 3, `{"id":1}`, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"rev", "created_at"}).AddRow(2, created))
+
+	err := s.repo.AddRevision(1, &r)
+	t.Assert().NoError(err)
+	t.Assert().Equal(2, r.Rev)
+	t.Assert().Equal(created, r.CreatedAt)
+}
+
+func (s *MovieRepoTestSuite) TestGetRevisions(t provider.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"rev", "user_id", "data", "created_at"}).
+		AddRow(2, 3, []byte(`{"id":1}`), created).
+		AddRow(1, 0, []byte(`{"id":1}`), created)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`SELECT rev, user_id, data, created_at FROM "movie_revisions" WHERE movie_id = $1 ORDER BY rev DESC LIMIT $2`)).
+		WithArgs(1, 10).
+		WillReturnRows(rows)
+
+	revisions, err := s.repo.GetRevisions(1, 10, 0)
+	t.Assert().NoError(err)
+	t.Assert().Len(revisions, 2)
+	t.Assert().Equal(2, revisions[0].Rev)
+	t.Assert().Equal(3, revisions[0].UserID)
+	t.Assert().JSONEq(`{"id":1}`, string(revisions[1].Data))
+}
+
+func (s *MovieRepoTestSuite) TestAtomic(t provider.T) {
+	e := &models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditMovie, EntityID: 1}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "movies" SET "deleted_at"=$1 WHERE "movies"."id" = $2 AND "movies"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(
+		`INSERT INTO "audit_entries" ("user_id","action","entity","entity_id","before","after","created_at") VALUES ($1,$2,$3,$4,(NULL),(NULL),$5) RETURNING "id"`)).
+		WithArgs(0, models.AuditDelete, models.AuditMovie, 1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		if err := movies.Delete(1); err != nil {
+			return err
+		}
+
+		return audit.Append(e)
+	})
+	t.Assert().NoError(err)
+	t.Assert().Equal(1, e.ID)
+}
+
+func (s *MovieRepoTestSuite) TestAtomicRollsBack(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "movies" SET "deleted_at"=$1 WHERE "movies"."id" = $2 AND "movies"."deleted_at" IS NULL`)).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_entries"`)).
+		WillReturnError(sql.ErrConnDone)
+	s.mock.ExpectRollback()
+
+	err := s.repo.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		if err := movies.Delete(1); err != nil {
+			return err
+		}
+
+		return audit.Append(&models.AuditEntry{Action: models.AuditDelete, Entity: models.AuditMovie, EntityID: 1})
+	})
+	t.Assert().ErrorIs(err, sql.ErrConnDone)
+}
@@ -1,13 +1,45 @@
 package repository
 
-import "intern/models"
+import (
+	auditRep "intern/internal/audit/repository"
+	"intern/models"
+	"time"
+)
 
 type MovieRepositoryI interface {
 	Create(m *models.Movie) error
 	Get(id int) (*models.Movie, error)
+	// Update writes the fields of m that are set, Replace all of them.
 	Update(m *models.Movie) error
+	Replace(m *models.Movie) error
+	// Delete moves the movie to the trash, Restore takes it back out.
 	Delete(id int) error
+	Restore(id int) error
 	GetMoviesSorted(sortingColumn string) ([]models.Movie, error)
 	GetActorsByMovie(id int) ([]models.Actor, error)
 	GetMoviesByTitle(title string) ([]models.Movie, error)
+	SearchMovies(query string, limit, offset int) ([]models.MovieSearchResult, error)
+	// GetByNaturalKey returns the movie Upsert would write: the oldest live
+	// one with the title and release date, else one from the trash.
+	GetByNaturalKey(title string, releaseDate time.Time) (*models.Movie, error)
+	// Upsert returns one of the models.Upsert* outcomes.
+	Upsert(m *models.Movie) (outcome string, err error)
+	Each(filter models.MovieFilter, fn func(m models.Movie) error) error
+	EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error
+	GetExternalIDs(id int) ([]models.ExternalID, error)
+	GetByExternalID(ext models.ExternalID) (*models.Movie, error)
+	AddExternalID(id int, ext models.ExternalID) error
+	DeleteExternalID(id int, ext models.ExternalID) error
+	// AddRevision stores r as the next revision of the movie, setting its
+	// Rev and CreatedAt. GetRevisions returns the newest first.
+	AddRevision(id int, r *models.Revision) error
+	GetRevisions(id, limit, offset int) ([]models.Revision, error)
+	GetRevision(id, rev int) (*models.Revision, error)
+	// Atomic runs fn with a repository and an audit log whose writes are
+	// committed together if fn returns nil, and not at all otherwise.
+	Atomic(fn func(movies MovieRepositoryI, audit auditRep.AuditRepositoryI) error) error
+	// Bulk runs fn like Atomic, but as a bulk load of source: the writes of
+	// fn add no event each, the one event of them all lists the movies fn
+	// returns. An Upsert that fails is undone alone.
+	Bulk(source string, fn func(movies MovieRepositoryI) ([]int, error)) error
 }
@@ -1,33 +1,90 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	auditRep "intern/internal/audit/repository"
+	auditUseCase "intern/internal/audit/usecase"
 	movieRep "intern/internal/movie/repository"
 	"intern/models"
+	"intern/pkg/jsondiff"
+	"strings"
 )
 
+// MovieUseCaseI records every change in the audit log, as made by the
+// signed in user of the ctx passed along, and every version of the movie as
+// a revision.
 type MovieUseCaseI interface {
-	Create(a *models.Movie) error
+	Create(ctx context.Context, a *models.Movie) error
 	Get(id int) (*models.Movie, error)
-	Update(a *models.Movie) error
-	Delete(id int) error
+	Update(ctx context.Context, a *models.Movie) error
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (*models.Movie, error)
 	GetMoviesSorted(sortingColumn string) ([]models.Movie, error)
 	GetActorsByMovie(id int) ([]models.Actor, error)
 	GetMoviesByTitle(title string) ([]models.Movie, error)
+	SearchMovies(query string, limit, offset int) ([]models.MovieSearchResult, error)
+	Each(filter models.MovieFilter, fn func(m models.Movie) error) error
+	EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error
+	GetByExternalID(ext models.ExternalID) (*models.Movie, error)
+	AddExternalID(ctx context.Context, id int, ext models.ExternalID) error
+	DeleteExternalID(ctx context.Context, id int, ext models.ExternalID) error
+	Revisions(id, limit, offset int) ([]models.Revision, error)
+	// RevisionDiff compares revision rev with revision against, 0 being
+	// the state before the first revision.
+	RevisionDiff(id, rev, against int) (*models.RevisionDiff, error)
+	// Revert updates the movie to revision rev, which makes a new revision.
+	Revert(ctx context.Context, id, rev int) (*models.Movie, error)
 }
 
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+var ErrInvalidFilter = errors.New("invalid movie filter")
+
+var (
+	ErrInvalidExternalID = errors.New("invalid external id")
+	ErrExternalIDTaken   = errors.New("external id belongs to another movie")
+)
+
+var ErrInvalidRevision = errors.New("invalid revision")
+
 type movieUseCase struct {
 	movieRepository movieRep.MovieRepositoryI
+	audit           auditUseCase.AuditUseCaseI
+	context         ContextManager
 }
 
-func New(aRep movieRep.MovieRepositoryI) MovieUseCaseI {
+func New(aRep movieRep.MovieRepositoryI, audit auditUseCase.AuditUseCaseI, cm ContextManager) MovieUseCaseI {
 	return &movieUseCase{
 		movieRepository: aRep,
+		audit:           audit,
+		context:         cm,
 	}
 }
 
-func (mUC *movieUseCase) Create(a *models.Movie) error {
-	err := mUC.movieRepository.Create(a)
+func (mUC *movieUseCase) Create(ctx context.Context, a *models.Movie) error {
+	// The audience rating starts empty, only user ratings move it.
+	a.RatingStats = models.RatingStats{}
+
+	err := mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := movies.Create(a)
+
+		if err != nil {
+			return err
+		}
+
+		err = mUC.record(ctx, audit, models.AuditCreate, a.ID, nil, a)
+
+		if err != nil {
+			return err
+		}
+
+		return mUC.addRevision(ctx, movies, a)
+	})
 
 	if err != nil {
 		return errors.Wrap(err, "movieUseCase.Create error")
@@ -43,41 +100,177 @@ func (mUC *movieUseCase) Get(id int) (*models.Movie, error) {
 		return nil, errors.Wrap(err, "movieUseCase.Get error")
 	}
 
+	resMovie.ExternalIDs, err = mUC.movieRepository.GetExternalIDs(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.Get error: can't get external ids")
+	}
+
 	return resMovie, nil
 }
 
-func (mUC *movieUseCase) Update(a *models.Movie) error {
-	_, err := mUC.movieRepository.Get(a.ID)
+func (mUC *movieUseCase) Update(ctx context.Context, a *models.Movie) error {
+	_, err := mUC.update(ctx, a, models.AuditUpdate, movieRep.MovieRepositoryI.Update)
 
 	if err != nil {
-		return errors.Wrap(err, "movieUseCase.Update error: Movie not found")
+		return errors.Wrap(err, "movieUseCase.Update error")
 	}
 
-	err = mUC.movieRepository.Update(a)
+	return nil
+}
+
+// update writes a with write and records it as action, all in one
+// transaction, and returns the updated movie.
+func (mUC *movieUseCase) update(ctx context.Context, a *models.Movie, action string,
+	write func(movieRep.MovieRepositoryI, *models.Movie) error) (*models.Movie, error) {
+	var after *models.Movie
+
+	err := mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		before, err := movies.Get(a.ID)
+
+		if err != nil {
+			return errors.Wrap(err, "Movie not found")
+		}
+
+		// A movie stored before revisions were kept, or by an import, gets
+		// its current state as the first revision, by an unknown user.
+		revisions, err := movies.GetRevisions(a.ID, 1, 0)
+
+		if err != nil {
+			return errors.Wrap(err, "can't get revisions")
+		}
+
+		if len(revisions) == 0 {
+			if err = mUC.addRevision(context.Background(), movies, before); err != nil {
+				return err
+			}
+		}
+
+		err = write(movies, a)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't update in repo")
+		}
+
+		// The stored row, as a carries only the fields that can be updated.
+		after, err = movies.Get(a.ID)
+
+		if err != nil {
+			return errors.Wrap(err, "Movie not found")
+		}
+
+		err = mUC.record(ctx, audit, action, a.ID, before, after)
+
+		if err != nil {
+			return err
+		}
+
+		return mUC.addRevision(ctx, movies, after)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// record appends the entry of a change made by the user of ctx to audit,
+// the log of the transaction making it.
+func (mUC *movieUseCase) record(ctx context.Context, audit auditRep.AuditRepositoryI, action string, id int, before, after interface{}) error {
+	e, err := mUC.audit.Entry(ctx, action, models.AuditMovie, id, before, after)
 
 	if err != nil {
-		return errors.Wrap(err, "movieUseCase.Update error: Can't update in repo")
+		return errors.Wrap(err, "can't record audit")
+	}
+
+	err = audit.Append(e)
+
+	if err != nil {
+		return errors.Wrap(err, "can't record audit")
 	}
 
 	return nil
 }
 
-func (mUC *movieUseCase) Delete(id int) error {
-	_, err := mUC.movieRepository.Get(id)
+// addRevision stores a in movies as the next revision, by the user of ctx.
+func (mUC *movieUseCase) addRevision(ctx context.Context, movies movieRep.MovieRepositoryI, a *models.Movie) error {
+	snapshot := *a
+	snapshot.RatingStats = models.RatingStats{}
+	snapshot.ExternalIDs = nil
+
+	data, err := json.Marshal(snapshot)
 
 	if err != nil {
-		return errors.Wrap(err, "movieUseCase.Delete error: Movie not found")
+		return errors.Wrap(err, "can't marshal revision")
 	}
 
-	err = mUC.movieRepository.Delete(id)
+	userID, err := mUC.context.UserIDFromContext(ctx)
 
 	if err != nil {
-		return errors.Wrap(err, "movieUseCase.Delete error: Can't delete in repo")
+		userID = 0
+	}
+
+	err = movies.AddRevision(a.ID, &models.Revision{UserID: userID, Data: data})
+
+	if err != nil {
+		return errors.Wrap(err, "can't add revision")
 	}
 
 	return nil
 }
 
+func (mUC *movieUseCase) Delete(ctx context.Context, id int) error {
+	err := mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		before, err := movies.Get(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Movie not found")
+		}
+
+		err = movies.Delete(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't delete in repo")
+		}
+
+		return mUC.record(ctx, audit, models.AuditDelete, id, before, nil)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.Delete error")
+	}
+
+	return nil
+}
+
+// Restore takes the movie out of the trash and returns it.
+func (mUC *movieUseCase) Restore(ctx context.Context, id int) (*models.Movie, error) {
+	var resMovie *models.Movie
+
+	err := mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := movies.Restore(id)
+
+		if err != nil {
+			return err
+		}
+
+		resMovie, err = movies.Get(id)
+
+		if err != nil {
+			return errors.Wrap(err, "Movie not found")
+		}
+
+		return mUC.record(ctx, audit, models.AuditRestore, id, nil, resMovie)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.Restore error")
+	}
+
+	return resMovie, nil
+}
+
 func (mUC *movieUseCase) GetMoviesSorted(sortingColumn string) ([]models.Movie, error) {
 	movies, err := mUC.movieRepository.GetMoviesSorted(sortingColumn)
 
@@ -107,3 +300,211 @@ func (mUC *movieUseCase) GetMoviesByTitle(title string) ([]models.Movie, error)
 
 	return movies, nil
 }
+
+func (mUC *movieUseCase) SearchMovies(query string, limit, offset int) ([]models.MovieSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("movieUseCase.SearchMovies error: empty query")
+	}
+
+	results, err := mUC.movieRepository.SearchMovies(query, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.SearchMovies error")
+	}
+
+	return results, nil
+}
+
+func (mUC *movieUseCase) Each(filter models.MovieFilter, fn func(m models.Movie) error) error {
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = models.MovieSortID
+	case models.MovieSortID, models.MovieSortTitle, models.MovieSortReleaseDate, models.MovieSortRating, models.MovieSortWeighted:
+	default:
+		return errors.Wrapf(ErrInvalidFilter, "movieUseCase.Each error: unknown sort %q", filter.SortBy)
+	}
+
+	err := mUC.movieRepository.Each(filter, fn)
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.Each error")
+	}
+
+	return nil
+}
+
+func (mUC *movieUseCase) EachCredit(filter models.CreditFilter, fn func(ma models.MovieActor) error) error {
+	if filter.MovieID < 0 || filter.ActorID < 0 {
+		return errors.Wrap(ErrInvalidFilter, "movieUseCase.EachCredit error: negative id")
+	}
+
+	err := mUC.movieRepository.EachCredit(filter, fn)
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.EachCredit error")
+	}
+
+	return nil
+}
+
+func (mUC *movieUseCase) GetByExternalID(ext models.ExternalID) (*models.Movie, error) {
+	if !ext.Valid() {
+		return nil, errors.Wrapf(ErrInvalidExternalID, "movieUseCase.GetByExternalID error: %s %q", ext.Source, ext.ID)
+	}
+
+	resMovie, err := mUC.movieRepository.GetByExternalID(ext)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.GetByExternalID error")
+	}
+
+	resMovie.ExternalIDs, err = mUC.movieRepository.GetExternalIDs(resMovie.ID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.GetByExternalID error: can't get external ids")
+	}
+
+	return resMovie, nil
+}
+
+// AddExternalID links ext to the movie. Adding a link that already exists is
+// a no-op, an id of the source can not point to two movies.
+func (mUC *movieUseCase) AddExternalID(ctx context.Context, id int, ext models.ExternalID) error {
+	if !ext.Valid() {
+		return errors.Wrapf(ErrInvalidExternalID, "movieUseCase.AddExternalID error: %s %q", ext.Source, ext.ID)
+	}
+
+	_, err := mUC.movieRepository.Get(id)
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.AddExternalID error: Movie not found")
+	}
+
+	owner, err := mUC.movieRepository.GetByExternalID(ext)
+
+	switch {
+	case err == nil && owner.ID == id:
+		return nil
+	case err == nil:
+		return errors.Wrapf(ErrExternalIDTaken, "movieUseCase.AddExternalID error: %s %q is linked to movie %d", ext.Source, ext.ID, owner.ID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.Wrap(err, "movieUseCase.AddExternalID error")
+	}
+
+	err = mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := movies.AddExternalID(id, ext)
+
+		if err != nil {
+			return errors.Wrap(err, "Can't add in repo")
+		}
+
+		return mUC.record(ctx, audit, models.AuditAddExternalID, id, nil, ext)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.AddExternalID error")
+	}
+
+	return nil
+}
+
+func (mUC *movieUseCase) DeleteExternalID(ctx context.Context, id int, ext models.ExternalID) error {
+	err := mUC.movieRepository.Atomic(func(movies movieRep.MovieRepositoryI, audit auditRep.AuditRepositoryI) error {
+		err := movies.DeleteExternalID(id, ext)
+
+		if err != nil {
+			return err
+		}
+
+		return mUC.record(ctx, audit, models.AuditDeleteExternalID, id, ext, nil)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "movieUseCase.DeleteExternalID error")
+	}
+
+	return nil
+}
+
+func (mUC *movieUseCase) Revisions(id, limit, offset int) ([]models.Revision, error) {
+	_, err := mUC.movieRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.Revisions error: Movie not found")
+	}
+
+	revisions, err := mUC.movieRepository.GetRevisions(id, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.Revisions error")
+	}
+
+	return revisions, nil
+}
+
+func (mUC *movieUseCase) RevisionDiff(id, rev, against int) (*models.RevisionDiff, error) {
+	if against < 0 {
+		return nil, errors.Wrapf(ErrInvalidRevision, "movieUseCase.RevisionDiff error: against %d", against)
+	}
+
+	_, err := mUC.movieRepository.Get(id)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.RevisionDiff error: Movie not found")
+	}
+
+	to, err := mUC.movieRepository.GetRevision(id, rev)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "movieUseCase.RevisionDiff error: revision %d", rev)
+	}
+
+	var before interface{}
+
+	if against > 0 {
+		from, err := mUC.movieRepository.GetRevision(id, against)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "movieUseCase.RevisionDiff error: revision %d", against)
+		}
+
+		before = from.Data
+	}
+
+	diff := &models.RevisionDiff{From: against, To: rev}
+	diff.Before, diff.After, err = jsondiff.Diff(before, to.Data)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.RevisionDiff error")
+	}
+
+	return diff, nil
+}
+
+// Revert writes every field of the revision, empty ones included.
+func (mUC *movieUseCase) Revert(ctx context.Context, id, rev int) (*models.Movie, error) {
+	r, err := mUC.movieRepository.GetRevision(id, rev)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "movieUseCase.Revert error: revision %d", rev)
+	}
+
+	var a models.Movie
+
+	err = json.Unmarshal(r.Data, &a)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "movieUseCase.Revert error: can't unmarshal revision %d", rev)
+	}
+
+	a.ID = id
+
+	res, err := mUC.update(ctx, &a, models.AuditRevert, movieRep.MovieRepositoryI.Replace)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "movieUseCase.Revert error")
+	}
+
+	return res, nil
+}
@@ -1 +1,138 @@
 package usecase
+
+import (
+	"context"
+	memAudit "intern/internal/audit/repository/memory"
+	auditUseCase "intern/internal/audit/usecase"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	"intern/models"
+	ctxManager "intern/pkg/context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAudit(t *testing.T) {
+	db := memdb.New()
+	audit := auditUseCase.New(memAudit.New(nil, db), ctxManager.Manager{})
+	uc := New(memMovie.New(nil, db), audit, ctxManager.Manager{})
+	ctx := ctxManager.Manager{}.ContextWithUserID(context.Background(), 3)
+
+	movie := &models.Movie{Title: "Alien", Description: "In space", ReleaseDate: time.Date(1979, time.May, 25, 0, 0, 0, 0, time.UTC), Rating: 8}
+	require.NoError(t, uc.Create(ctx, movie))
+
+	update := *movie
+	update.Rating = 9
+	require.NoError(t, uc.Update(ctx, &update))
+	require.NoError(t, uc.AddExternalID(ctx, movie.ID, models.ExternalID{Source: models.ExternalSourceIMDb, ID: "tt0078748"}))
+	require.NoError(t, uc.Delete(ctx, movie.ID))
+	_, err := uc.Restore(context.Background(), movie.ID)
+	require.NoError(t, err)
+
+	entries, err := audit.List(models.AuditFilter{Entity: models.AuditMovie, EntityID: movie.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	actions := make([]string, len(entries))
+	for i, e := range entries {
+		actions[i] = e.Action
+	}
+	assert.Equal(t, []string{models.AuditRestore, models.AuditDelete, models.AuditAddExternalID, models.AuditUpdate, models.AuditCreate}, actions)
+
+	// The restore came without a signed in user.
+	assert.Equal(t, 0, entries[0].UserID)
+	assert.Equal(t, 3, entries[1].UserID)
+
+	assert.JSONEq(t, `{"rating":8}`, string(entries[3].Before))
+	assert.JSONEq(t, `{"rating":9}`, string(entries[3].After))
+	assert.Nil(t, entries[4].Before)
+	assert.Contains(t, string(entries[4].After), `"title":"Alien"`)
+	assert.Nil(t, entries[1].After)
+}
+
+func TestRevisions(t *testing.T) {
+	db := memdb.New()
+	audit := auditUseCase.New(memAudit.New(nil, db), ctxManager.Manager{})
+	rep := memMovie.New(nil, db)
+	uc := New(rep, audit, ctxManager.Manager{})
+	ctx := ctxManager.Manager{}.ContextWithUserID(context.Background(), 3)
+
+	// Added before revisions were kept, so the first update stores it too.
+	movie := &models.Movie{Title: "Alien", Description: "In space", ReleaseDate: time.Date(1979, time.May, 25, 0, 0, 0, 0, time.UTC), Rating: 8}
+	require.NoError(t, rep.Create(movie))
+
+	update := *movie
+	update.Rating = 9
+	require.NoError(t, uc.Update(ctx, &update))
+
+	update.Title = "Aliens"
+	require.NoError(t, uc.Update(ctx, &update))
+
+	revisions, err := uc.Revisions(movie.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{revisions[0].Rev, revisions[1].Rev, revisions[2].Rev})
+	assert.Equal(t, 3, revisions[0].UserID)
+	assert.Equal(t, 0, revisions[2].UserID)
+
+	diff, err := uc.RevisionDiff(movie.ID, 3, 1)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Alien","rating":8}`, string(diff.Before))
+	assert.JSONEq(t, `{"title":"Aliens","rating":9}`, string(diff.After))
+
+	diff, err = uc.RevisionDiff(movie.ID, 1, 0)
+	require.NoError(t, err)
+	assert.Nil(t, diff.Before)
+	assert.Contains(t, string(diff.After), `"title":"Alien"`)
+
+	_, err = uc.RevisionDiff(movie.ID, 1, -1)
+	assert.ErrorIs(t, err, ErrInvalidRevision)
+	_, err = uc.RevisionDiff(movie.ID, 4, 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	reverted, err := uc.Revert(ctx, movie.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Alien", reverted.Title)
+	assert.Equal(t, 8, reverted.Rating)
+
+	revisions, err = uc.Revisions(movie.ID, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, 4, revisions[0].Rev)
+
+	entries, err := audit.List(models.AuditFilter{Entity: models.AuditMovie, EntityID: movie.ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditRevert, entries[0].Action)
+
+	_, err = uc.Revert(ctx, movie.ID, 5)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestRevertClearsFields(t *testing.T) {
+	db := memdb.New()
+	uc := New(memMovie.New(nil, db), auditUseCase.New(memAudit.New(nil, db), ctxManager.Manager{}), ctxManager.Manager{})
+	ctx := context.Background()
+
+	movie := &models.Movie{Title: "Alien", ReleaseDate: time.Date(1979, time.May, 25, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, uc.Create(ctx, movie))
+
+	update := *movie
+	update.Description = "In space"
+	update.Rating = 8
+	require.NoError(t, uc.Update(ctx, &update))
+
+	reverted, err := uc.Revert(ctx, movie.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "", reverted.Description)
+	assert.Equal(t, 0, reverted.Rating)
+
+	stored, err := uc.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "", stored.Description)
+	assert.Equal(t, 0, stored.Rating)
+}
@@ -0,0 +1,219 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	ratingUseCase "intern/internal/rating/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type RatingForm struct {
+	Score int `json:"score"`
+}
+
+// RatingResponse carries the rating of the user, if any, and the rating
+// stats of the movie after the change.
+type RatingResponse struct {
+	Rating *models.Rating     `json:"rating,omitempty"`
+	Stats  models.RatingStats `json:"stats"`
+}
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type RatingHandler struct {
+	RatingUseCase ratingUseCase.RatingUseCaseI
+	Logger        logger.Logger
+	Context       ContextManager
+}
+
+// Get godoc
+// @Summary      Get my rating
+// @Description  Get the rating the signed in user gave to a movie
+// @Tags     ratings
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} models.Rating "success get rating"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not rated"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/my-rating [get]
+func (rh *RatingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := rh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	rating, err := rh.RatingUseCase.Get(userID, movieID)
+	if err != nil {
+		rh.Logger.Infow("can`t get rating",
+			"err:", err.Error())
+		http.Error(w, "can`t get rating", http.StatusNotFound)
+		return
+	}
+
+	rh.write(w, rating)
+}
+
+// Set godoc
+// @Summary      Rate a movie
+// @Description  Create or replace the rating (1 to 10) the signed in user gives to a movie.
+// @Description  Returns the rating and the updated votes, average and weighted rating of the movie.
+// @Tags     ratings
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param rating body RatingForm true "score from 1 to 10"
+// @Success 200 {object} RatingResponse "movie rated"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/my-rating [put]
+func (rh *RatingHandler) Set(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := rh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	form := RatingForm{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		rh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return
+	}
+
+	err = json.Unmarshal(body, &form)
+	if err != nil {
+		rh.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	rating := &models.Rating{UserID: userID, MovieID: movieID, Score: form.Score}
+
+	stats, err := rh.RatingUseCase.Set(rating)
+	switch {
+	case errors.Is(err, ratingUseCase.ErrInvalidScore):
+		rh.Logger.Infow("invalid rating",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		rh.Logger.Infow("can`t rate movie",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	case err != nil:
+		rh.Logger.Errorw("can`t rate movie",
+			"err:", err.Error())
+		http.Error(w, "can`t rate movie", http.StatusInternalServerError)
+		return
+	}
+
+	rh.write(w, RatingResponse{Rating: rating, Stats: *stats})
+}
+
+// Delete godoc
+// @Summary      Remove my rating
+// @Description  Remove the rating the signed in user gave to a movie, returns the updated stats of the movie
+// @Tags     ratings
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} RatingResponse "rating removed"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not rated"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/my-rating [delete]
+func (rh *RatingHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := rh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := rh.RatingUseCase.Delete(userID, movieID)
+	if err != nil {
+		rh.Logger.Infow("can`t delete rating",
+			"err:", err.Error())
+		http.Error(w, "can`t delete rating", http.StatusNotFound)
+		return
+	}
+
+	rh.write(w, RatingResponse{Stats: *stats})
+}
+
+// ids reads the signed in user and the movie of the path, on failure the
+// error is already written.
+func (rh *RatingHandler) ids(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, err := rh.Context.UserIDFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Errorw("can`t get user",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		rh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		rh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	return userID, movieId, true
+}
+
+func (rh *RatingHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		rh.Logger.Errorw("can`t marshal rating",
+			"err:", err.Error())
+		http.Error(w, "can`t make rating", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		rh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
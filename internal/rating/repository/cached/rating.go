@@ -0,0 +1,43 @@
+package cached
+
+import (
+	movieRep "intern/internal/movie/repository"
+	cachedMovie "intern/internal/movie/repository/cached"
+	"intern/internal/rating/repository"
+	"intern/models"
+	"intern/pkg/cache"
+	"intern/pkg/logger"
+)
+
+type cachedRatingRepo struct {
+	repository.RatingRepositoryI
+	Logger logger.Logger
+	Movies movieRep.MovieRepositoryI
+	Cache  *cache.Cache
+}
+
+// New invalidates the movie of each rating set or deleted through it, as
+// the rating stats of the movie change along. movies lists the actors of
+// the movie, whose movie lists show the stats too.
+func New(logger logger.Logger, repo repository.RatingRepositoryI, movies movieRep.MovieRepositoryI, c *cache.Cache) repository.RatingRepositoryI {
+	return &cachedRatingRepo{
+		RatingRepositoryI: repo,
+		Logger:            logger,
+		Movies:            movies,
+		Cache:             c,
+	}
+}
+
+func (cr *cachedRatingRepo) Set(r *models.Rating) (*models.RatingStats, error) {
+	stats, err := cr.RatingRepositoryI.Set(r)
+	cachedMovie.Invalidate(cr.Logger, cr.Movies, cr.Cache, r.MovieID)
+
+	return stats, err
+}
+
+func (cr *cachedRatingRepo) Delete(userID, movieID int) (*models.RatingStats, error) {
+	stats, err := cr.RatingRepositoryI.Delete(userID, movieID)
+	cachedMovie.Invalidate(cr.Logger, cr.Movies, cr.Cache, movieID)
+
+	return stats, err
+}
@@ -0,0 +1,44 @@
+package cached
+
+import (
+	"intern/internal/memdb"
+	cachedMovie "intern/internal/movie/repository/cached"
+	memMovie "intern/internal/movie/repository/memory"
+	memRating "intern/internal/rating/repository/memory"
+	"intern/models"
+	"intern/pkg/cache"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingsInvalidateMovie(t *testing.T) {
+	db := memdb.New()
+	c := cache.New(cache.NewLRU(100), time.Minute, nil)
+	movies := memMovie.New(nil, db)
+	cached := cachedMovie.New(nil, movies, c)
+	ratings := New(nil, memRating.New(nil, db), movies, c)
+
+	movie := &models.Movie{Title: "Alien"}
+	require.NoError(t, movies.Create(movie))
+
+	m, err := cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, m.Votes)
+
+	_, err = ratings.Set(&models.Rating{UserID: 1, MovieID: movie.ID, Score: 8})
+	require.NoError(t, err)
+
+	m, err = cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Votes)
+
+	_, err = ratings.Delete(1, movie.ID)
+	require.NoError(t, err)
+
+	m, err = cached.Get(movie.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, m.Votes)
+}
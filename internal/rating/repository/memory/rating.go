@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"intern/internal/memdb"
+	"intern/internal/rating/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memRatingRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.RatingRepositoryI {
+	return &memRatingRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (rr *memRatingRepo) Get(userID, movieID int) (*models.Rating, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	r, ok := rr.DB.Ratings[memdb.UserMovieKey{UserID: userID, MovieID: movieID}]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memRatingRepo.Get error")
+	}
+
+	return &r, nil
+}
+
+func (rr *memRatingRepo) Set(r *models.Rating) (*models.RatingStats, error) {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	m, ok := rr.DB.Movies[r.MovieID]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memRatingRepo.Set error")
+	}
+
+	key := memdb.UserMovieKey{UserID: r.UserID, MovieID: r.MovieID}
+	if previous, ok := rr.DB.Ratings[key]; ok {
+		m.RatingStats = m.RatingStats.Apply(0, r.Score-previous.Score)
+	} else {
+		m.RatingStats = m.RatingStats.Apply(1, r.Score)
+	}
+
+	r.UpdatedAt = time.Now()
+	rr.DB.Ratings[key] = *r
+	m.UpdatedAt = time.Now()
+	rr.DB.Movies[m.ID] = m
+
+	return &m.RatingStats, nil
+}
+
+func (rr *memRatingRepo) Delete(userID, movieID int) (*models.RatingStats, error) {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	key := memdb.UserMovieKey{UserID: userID, MovieID: movieID}
+	removed, ok := rr.DB.Ratings[key]
+	m, found := rr.DB.Movies[movieID]
+	if !ok || !found {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memRatingRepo.Delete error")
+	}
+
+	m.RatingStats = m.RatingStats.Apply(-1, -removed.Score)
+	delete(rr.DB.Ratings, key)
+	m.UpdatedAt = time.Now()
+	rr.DB.Movies[m.ID] = m
+
+	return &m.RatingStats, nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/rating/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+const upsertRatingQuery = `INSERT INTO ratings (user_id, movie_id, score) VALUES (?, ?, ?)
+ON CONFLICT (user_id, movie_id) DO UPDATE SET score = EXCLUDED.score, updated_at = now()
+RETURNING updated_at`
+
+type pgRatingRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.RatingRepositoryI {
+	return &pgRatingRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (rr *pgRatingRepo) Get(userID, movieID int) (*models.Rating, error) {
+	var r models.Rating
+	tx := rr.DB.Where("user_id = ? AND movie_id = ?", userID, movieID).Take(&r)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRatingRepo.Get error")
+	}
+
+	return &r, nil
+}
+
+func (rr *pgRatingRepo) Set(r *models.Rating) (*models.RatingStats, error) {
+	var stats models.RatingStats
+
+	err := rr.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		stats, err = lockStats(tx, r.MovieID)
+		if err != nil {
+			return err
+		}
+
+		var previous []int
+		err = tx.Raw("SELECT score FROM ratings WHERE user_id = ? AND movie_id = ?", r.UserID, r.MovieID).Scan(&previous).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Raw(upsertRatingQuery, r.UserID, r.MovieID, r.Score).Scan(&r.UpdatedAt).Error
+		if err != nil {
+			return err
+		}
+
+		if len(previous) == 0 {
+			stats = stats.Apply(1, r.Score)
+		} else {
+			stats = stats.Apply(0, r.Score-previous[0])
+		}
+
+		return saveStats(tx, r.MovieID, stats)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgRatingRepo.Set error")
+	}
+
+	return &stats, nil
+}
+
+func (rr *pgRatingRepo) Delete(userID, movieID int) (*models.RatingStats, error) {
+	var stats models.RatingStats
+
+	err := rr.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		stats, err = lockStats(tx, movieID)
+		if err != nil {
+			return err
+		}
+
+		var removed []int
+		err = tx.Raw("DELETE FROM ratings WHERE user_id = ? AND movie_id = ? RETURNING score", userID, movieID).Scan(&removed).Error
+		if err != nil {
+			return err
+		}
+
+		if len(removed) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		stats = stats.Apply(-1, -removed[0])
+
+		return saveStats(tx, movieID, stats)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgRatingRepo.Delete error")
+	}
+
+	return &stats, nil
+}
+
+// lockStats reads the stats of the movie and locks its row until the end
+// of tx, so concurrent ratings of one movie apply their changes in turn.
+func lockStats(tx *gorm.DB, movieID int) (models.RatingStats, error) {
+	var stats models.RatingStats
+
+	res := tx.Raw("SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = ? FOR UPDATE", movieID).Scan(&stats)
+	if res.Error != nil {
+		return stats, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return stats, gorm.ErrRecordNotFound
+	}
+
+	return stats, nil
+}
+
+func saveStats(tx *gorm.DB, movieID int, stats models.RatingStats) error {
+	return tx.Exec("UPDATE movies SET votes = ?, score_sum = ?, average_rating = ?, weighted_rating = ? WHERE id = ?",
+		stats.Votes, stats.ScoreSum, stats.AverageRating, stats.WeightedRating, movieID).Error
+}
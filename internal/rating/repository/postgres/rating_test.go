@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	ratingRep "intern/internal/rating/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type RatingRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   ratingRep.RatingRepositoryI
+}
+
+func TestRatingRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(RatingRepoTestSuite))
+}
+
+func (s *RatingRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *RatingRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *RatingRepoTestSuite) TestSetNewRating(t provider.T) {
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rating := &models.Rating{UserID: 7, MovieID: 3, Score: 9}
+	want := models.RatingStats{}.Apply(1, 5).Apply(1, 9)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = $1 FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"votes", "score_sum", "average_rating", "weighted_rating"}).AddRow(1, 5, 5.0, 65.0/11))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT score FROM ratings WHERE user_id = $1 AND movie_id = $2`)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"score"}))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO ratings (user_id, movie_id, score) VALUES ($1, $2, $3)`)).
+		WithArgs(7, 3, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updated))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE movies SET votes = $1, score_sum = $2, average_rating = $3, weighted_rating = $4 WHERE id = $5`)).
+		WithArgs(2, 14, 7.0, want.WeightedRating, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	stats, err := s.repo.Set(rating)
+	t.Assert().NoError(err)
+	t.Assert().Equal(want, *stats)
+	t.Assert().Equal(updated, rating.UpdatedAt)
+}
+
+func (s *RatingRepoTestSuite) TestSetChangedRating(t provider.T) {
+	rating := &models.Rating{UserID: 7, MovieID: 3, Score: 2}
+	want := models.RatingStats{}.Apply(1, 5).Apply(1, 2)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = $1 FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"votes", "score_sum", "average_rating", "weighted_rating"}).AddRow(2, 14, 7.0, 74.0/12))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT score FROM ratings WHERE user_id = $1 AND movie_id = $2`)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"score"}).AddRow(9))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO ratings (user_id, movie_id, score) VALUES ($1, $2, $3)`)).
+		WithArgs(7, 3, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE movies SET votes = $1, score_sum = $2, average_rating = $3, weighted_rating = $4 WHERE id = $5`)).
+		WithArgs(2, 7, 3.5, want.WeightedRating, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	stats, err := s.repo.Set(rating)
+	t.Assert().NoError(err)
+	t.Assert().Equal(want, *stats)
+}
+
+func (s *RatingRepoTestSuite) TestSetMissingMovie(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = $1 FOR UPDATE`)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"votes", "score_sum", "average_rating", "weighted_rating"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.Set(&models.Rating{UserID: 7, MovieID: 42, Score: 5})
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (s *RatingRepoTestSuite) TestDelete(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = $1 FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"votes", "score_sum", "average_rating", "weighted_rating"}).AddRow(1, 9, 9.0, 69.0/11))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM ratings WHERE user_id = $1 AND movie_id = $2 RETURNING score`)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"score"}).AddRow(9))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE movies SET votes = $1, score_sum = $2, average_rating = $3, weighted_rating = $4 WHERE id = $5`)).
+		WithArgs(0, 0, 0.0, 0.0, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	stats, err := s.repo.Delete(7, 3)
+	t.Assert().NoError(err)
+	t.Assert().Equal(models.RatingStats{}, *stats)
+}
+
+func (s *RatingRepoTestSuite) TestDeleteNotRated(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT votes, score_sum, average_rating, weighted_rating FROM movies WHERE id = $1 FOR UPDATE`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"votes", "score_sum", "average_rating", "weighted_rating"}).AddRow(0, 0, 0.0, 0.0))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM ratings WHERE user_id = $1 AND movie_id = $2 RETURNING score`)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"score"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.Delete(7, 3)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (s *RatingRepoTestSuite) TestGet(t provider.T) {
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "ratings" WHERE user_id = $1 AND movie_id = $2 LIMIT $3`)).
+		WithArgs(7, 3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "movie_id", "score", "updated_at"}).AddRow(7, 3, 9, updated))
+
+	rating, err := s.repo.Get(7, 3)
+	t.Assert().NoError(err)
+	t.Assert().Equal(&models.Rating{UserID: 7, MovieID: 3, Score: 9, UpdatedAt: updated}, rating)
+}
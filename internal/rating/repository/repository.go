@@ -0,0 +1,12 @@
+package repository
+
+import "intern/models"
+
+// RatingRepositoryI stores user ratings. Set and Delete move the rating
+// stats of the movie by the change in the same transaction, so the stats
+// never have to be recomputed from all ratings.
+type RatingRepositoryI interface {
+	Get(userID, movieID int) (*models.Rating, error)
+	Set(r *models.Rating) (*models.RatingStats, error)
+	Delete(userID, movieID int) (*models.RatingStats, error)
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	ratingRep "intern/internal/rating/repository"
+	"intern/models"
+)
+
+type RatingUseCaseI interface {
+	Get(userID, movieID int) (*models.Rating, error)
+	Set(r *models.Rating) (*models.RatingStats, error)
+	Delete(userID, movieID int) (*models.RatingStats, error)
+}
+
+var ErrInvalidScore = errors.New("invalid rating score")
+
+type ratingUseCase struct {
+	ratingRepository ratingRep.RatingRepositoryI
+}
+
+func New(rRep ratingRep.RatingRepositoryI) RatingUseCaseI {
+	return &ratingUseCase{
+		ratingRepository: rRep,
+	}
+}
+
+func (rUC *ratingUseCase) Get(userID, movieID int) (*models.Rating, error) {
+	rating, err := rUC.ratingRepository.Get(userID, movieID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "ratingUseCase.Get error")
+	}
+
+	return rating, nil
+}
+
+// Set creates or replaces the rating of the user and returns the new
+// stats of the movie.
+func (rUC *ratingUseCase) Set(r *models.Rating) (*models.RatingStats, error) {
+	if r.Score < models.MinRatingScore || r.Score > models.MaxRatingScore {
+		return nil, errors.Wrapf(ErrInvalidScore, "ratingUseCase.Set error: %d is not within %d..%d",
+			r.Score, models.MinRatingScore, models.MaxRatingScore)
+	}
+
+	stats, err := rUC.ratingRepository.Set(r)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "ratingUseCase.Set error")
+	}
+
+	return stats, nil
+}
+
+func (rUC *ratingUseCase) Delete(userID, movieID int) (*models.RatingStats, error) {
+	stats, err := rUC.ratingRepository.Delete(userID, movieID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "ratingUseCase.Delete error")
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"intern/internal/memdb"
+	memRating "intern/internal/rating/repository/memory"
+	"intern/models"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newDB() *memdb.DB {
+	db := memdb.New()
+	db.Movies[1] = models.Movie{ID: 1, Title: "Alien", ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC), Rating: 9}
+	db.SeenID("movies", 1)
+
+	return db
+}
+
+func weighted(votes, sum int) float64 {
+	return (models.RatingPriorVotes*models.RatingPriorMean + float64(sum)) / float64(models.RatingPriorVotes+votes)
+}
+
+func TestSetAndDelete(t *testing.T) {
+	db := newDB()
+	uc := New(memRating.New(nil, db))
+
+	stats, err := uc.Set(&models.Rating{UserID: 1, MovieID: 1, Score: 8})
+	require.NoError(t, err)
+	assert.Equal(t, models.RatingStats{Votes: 1, ScoreSum: 8, AverageRating: 8, WeightedRating: weighted(1, 8)}, *stats)
+
+	_, err = uc.Set(&models.Rating{UserID: 2, MovieID: 1, Score: 4})
+	require.NoError(t, err)
+
+	// Changing a rating does not add a vote.
+	stats, err = uc.Set(&models.Rating{UserID: 1, MovieID: 1, Score: 10})
+	require.NoError(t, err)
+	assert.Equal(t, models.RatingStats{Votes: 2, ScoreSum: 14, AverageRating: 7, WeightedRating: weighted(2, 14)}, *stats)
+
+	rating, err := uc.Get(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 10, rating.Score)
+
+	stats, err = uc.Delete(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.RatingStats{Votes: 1, ScoreSum: 10, AverageRating: 10, WeightedRating: weighted(1, 10)}, *stats)
+
+	stats, err = uc.Delete(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.RatingStats{}, *stats)
+
+	// The editorial rating is left alone.
+	assert.Equal(t, 9, db.Movies[1].Rating)
+	assert.Equal(t, models.RatingStats{}, db.Movies[1].RatingStats)
+}
+
+func TestSetErrors(t *testing.T) {
+	uc := New(memRating.New(nil, newDB()))
+
+	for _, score := range []int{0, 11, -3} {
+		_, err := uc.Set(&models.Rating{UserID: 1, MovieID: 1, Score: score})
+		assert.True(t, errors.Is(err, ErrInvalidScore), "score %d: %v", score, err)
+	}
+
+	_, err := uc.Set(&models.Rating{UserID: 1, MovieID: 42, Score: 5})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	_, err = uc.Delete(1, 1)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	_, err = uc.Get(1, 1)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+// The incrementally kept stats must match stats computed from scratch.
+func TestStatsMatchRecount(t *testing.T) {
+	db := newDB()
+	uc := New(memRating.New(nil, db))
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		userID := rnd.Intn(20) + 1
+		if rnd.Intn(4) == 0 {
+			_, _ = uc.Delete(userID, 1)
+			continue
+		}
+		_, err := uc.Set(&models.Rating{UserID: userID, MovieID: 1, Score: rnd.Intn(10) + 1})
+		require.NoError(t, err)
+	}
+
+	var recount models.RatingStats
+	for _, r := range db.Ratings {
+		recount = recount.Apply(1, r.Score)
+	}
+
+	stats := db.Movies[1].RatingStats
+	assert.Equal(t, recount.Votes, stats.Votes)
+	assert.Equal(t, recount.ScoreSum, stats.ScoreSum)
+	assert.InDelta(t, recount.AverageRating, stats.AverageRating, 1e-9)
+	assert.InDelta(t, recount.WeightedRating, stats.WeightedRating, 1e-9)
+}
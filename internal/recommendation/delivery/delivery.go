@@ -0,0 +1,154 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	recommendationUseCase "intern/internal/recommendation/usecase"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type RecommendationHandler struct {
+	RecommendationUseCase recommendationUseCase.RecommendationUseCaseI
+	Logger                logger.Logger
+	Context               ContextManager
+}
+
+// Similar godoc
+// @Summary      Similar movies
+// @Description  Movies sharing cast with the movie, closer release years ranking higher; each comes with a reason.
+// @Description  Genres are not stored, so they play no part. Precomputed by the similarity job.
+// @Tags     recommendations
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.SimilarMovie "success get similar movies"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/similar [get]
+func (rh *RecommendationHandler) Similar(w http.ResponseWriter, r *http.Request) {
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		rh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		rh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	page, ok := rh.page(w, r)
+	if !ok {
+		return
+	}
+
+	movies, err := rh.RecommendationUseCase.Similar(movieId, page.Limit, page.Offset)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		rh.Logger.Infow("can`t get similar movies",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	case err != nil:
+		rh.Logger.Errorw("can`t get similar movies",
+			"err:", err.Error())
+		http.Error(w, "can`t get similar movies", http.StatusInternalServerError)
+		return
+	}
+
+	rh.write(w, movies)
+}
+
+// Recommend godoc
+// @Summary      My recommendations
+// @Description  Movies the signed in user has not rated, watched or put on the watchlist, best predicted rating first.
+// @Description  They are found through the movies the user rated 7 or more ("because you liked ..."), using the
+// @Description  ratings of other users; the highest rated movies fill up the list.
+// @Tags     recommendations
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Recommendation "success get recommendations"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/recommendations [get]
+func (rh *RecommendationHandler) Recommend(w http.ResponseWriter, r *http.Request) {
+	userID, err := rh.Context.UserIDFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Errorw("can`t get user",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return
+	}
+
+	page, ok := rh.page(w, r)
+	if !ok {
+		return
+	}
+
+	recs, err := rh.RecommendationUseCase.Recommend(userID, page.Limit, page.Offset)
+	if err != nil {
+		rh.Logger.Errorw("can`t get recommendations",
+			"err:", err.Error())
+		http.Error(w, "can`t get recommendations", http.StatusInternalServerError)
+		return
+	}
+
+	rh.write(w, recs)
+}
+
+func (rh *RecommendationHandler) page(w http.ResponseWriter, r *http.Request) (pagination.Params, bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		rh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return page, false
+	}
+
+	return page, true
+}
+
+func (rh *RecommendationHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		rh.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		rh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
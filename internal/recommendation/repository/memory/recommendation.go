@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/recommendation/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+)
+
+type memRecommendationRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.RecommendationRepositoryI {
+	return &memRecommendationRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (rr *memRecommendationRepo) Credits() ([]models.MovieActor, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	credits := make([]models.MovieActor, 0, len(rr.DB.MoviesActors))
+	for _, ma := range rr.DB.MoviesActors {
+		_, okMovie := rr.DB.Movies[ma.MovieID]
+		_, okActor := rr.DB.Actors[ma.ActorID]
+		if !okMovie || !okActor {
+			continue
+		}
+
+		credits = append(credits, models.MovieActor{MovieID: ma.MovieID, ActorID: ma.ActorID})
+	}
+
+	return credits, nil
+}
+
+func (rr *memRecommendationRepo) ReleaseDates() ([]models.Movie, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	movies := make([]models.Movie, 0, len(rr.DB.Movies))
+	for _, m := range rr.DB.Movies {
+		movies = append(movies, models.Movie{ID: m.ID, ReleaseDate: m.ReleaseDate})
+	}
+
+	return movies, nil
+}
+
+func (rr *memRecommendationRepo) Ratings() ([]models.Rating, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	ratings := make([]models.Rating, 0, len(rr.DB.Ratings))
+	for _, r := range rr.DB.Ratings {
+		if _, ok := rr.DB.Movies[r.MovieID]; !ok {
+			continue
+		}
+
+		ratings = append(ratings, models.Rating{UserID: r.UserID, MovieID: r.MovieID, Score: r.Score})
+	}
+
+	return ratings, nil
+}
+
+func (rr *memRecommendationRepo) ReplaceSimilarities(kind string, sims []models.Similarity) error {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	for key := range rr.DB.Similarities {
+		if key.Kind == kind {
+			delete(rr.DB.Similarities, key)
+		}
+	}
+
+	for _, s := range sims {
+		_, okMovie := rr.DB.Movies[s.MovieID]
+		_, okSimilar := rr.DB.Movies[s.SimilarID]
+		if !okMovie || !okSimilar {
+			continue
+		}
+
+		s.Kind = kind
+		rr.DB.Similarities[memdb.SimilarityKey{Kind: kind, MovieID: s.MovieID, SimilarID: s.SimilarID}] = s
+	}
+
+	return nil
+}
+
+func (rr *memRecommendationRepo) Similar(kind string, movieID, limit, offset int) ([]models.SimilarMovie, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	movies := []models.SimilarMovie{}
+	for key, s := range rr.DB.Similarities {
+		if key.Kind != kind || key.MovieID != movieID {
+			continue
+		}
+
+		if m, ok := rr.DB.Movies[s.SimilarID]; ok {
+			movies = append(movies, models.SimilarMovie{Movie: m, Score: s.Score, SharedActors: s.Shared})
+		}
+	}
+
+	slices.SortFunc(movies, func(a, b models.SimilarMovie) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(movies, limit, offset), nil
+}
+
+func (rr *memRecommendationRepo) Neighbours(kind string, movieIDs []int) ([]models.Similarity, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	sims := []models.Similarity{}
+	for key, s := range rr.DB.Similarities {
+		if key.Kind == kind && slices.Contains(movieIDs, key.MovieID) {
+			sims = append(sims, s)
+		}
+	}
+
+	return sims, nil
+}
+
+func (rr *memRecommendationRepo) UserRatings(userID int) ([]models.Rating, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	ratings := []models.Rating{}
+	for key, r := range rr.DB.Ratings {
+		if key.UserID == userID {
+			ratings = append(ratings, r)
+		}
+	}
+
+	return ratings, nil
+}
+
+func (rr *memRecommendationRepo) Known(userID int) ([]int, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	known := make(map[int]bool)
+	for key := range rr.DB.Ratings {
+		if key.UserID == userID {
+			known[key.MovieID] = true
+		}
+	}
+	for key := range rr.DB.History {
+		if key.UserID == userID {
+			known[key.MovieID] = true
+		}
+	}
+	for key := range rr.DB.Watchlist {
+		if key.UserID == userID {
+			known[key.MovieID] = true
+		}
+	}
+
+	ids := make([]int, 0, len(known))
+	for id := range known {
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (rr *memRecommendationRepo) Movies(ids []int) ([]models.Movie, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	movies := []models.Movie{}
+	for _, id := range ids {
+		if m, ok := rr.DB.Movies[id]; ok {
+			movies = append(movies, m)
+		}
+	}
+
+	return movies, nil
+}
+
+func (rr *memRecommendationRepo) Popular(exclude []int, limit, offset int) ([]models.Movie, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	movies := []models.Movie{}
+	for _, m := range rr.DB.Movies {
+		if !slices.Contains(exclude, m.ID) {
+			movies = append(movies, m)
+		}
+	}
+
+	slices.SortFunc(movies, func(a, b models.Movie) int {
+		return cmp.Or(cmp.Compare(b.WeightedRating, a.WeightedRating), cmp.Compare(b.Rating, a.Rating), cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(movies, limit, offset), nil
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/recommendation/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+// saveBatchSize bounds the arrays of one insert statement.
+const saveBatchSize = 5000
+
+// The joins drop pairs whose movies were deleted or trashed while the job
+// ran.
+const saveSimilaritiesQuery = `INSERT INTO movie_similarity (kind, movie_id, similar_id, score, shared)
+SELECT ?, s.movie_id, s.similar_id, s.score, s.shared
+FROM unnest(?::int[], ?::int[], ?::float8[], ?::int[]) AS s(movie_id, similar_id, score, shared)
+JOIN movies a ON a.id = s.movie_id AND a.deleted_at IS NULL
+JOIN movies b ON b.id = s.similar_id AND b.deleted_at IS NULL`
+
+const similarQuery = `SELECT m.*, s.score, s.shared AS shared_actors
+FROM movie_similarity s JOIN movies m ON m.id = s.similar_id
+WHERE s.kind = ? AND s.movie_id = ? AND m.deleted_at IS NULL
+ORDER BY s.score DESC, m.id
+LIMIT ? OFFSET ?`
+
+const knownQuery = `SELECT movie_id FROM ratings WHERE user_id = ?
+UNION SELECT movie_id FROM watch_history WHERE user_id = ?
+UNION SELECT movie_id FROM watchlist WHERE user_id = ?`
+
+type pgRecommendationRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.RecommendationRepositoryI {
+	return &pgRecommendationRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+// Credits, ReleaseDates and Ratings leave out the trash, its movies get no
+// neighbours until restored and the job run again.
+func (rr *pgRecommendationRepo) Credits() ([]models.MovieActor, error) {
+	credits := []models.MovieActor{}
+	tx := rr.DB.Table("movies_actors ma").Select("ma.movie_id, ma.actor_id").
+		Joins("JOIN movies m ON m.id = ma.movie_id AND m.deleted_at IS NULL").
+		Joins("JOIN actors a ON a.id = ma.actor_id AND a.deleted_at IS NULL").
+		Find(&credits)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Credits error")
+	}
+
+	return credits, nil
+}
+
+func (rr *pgRecommendationRepo) ReleaseDates() ([]models.Movie, error) {
+	movies := []models.Movie{}
+	tx := rr.DB.Table("movies").Select("id, release_date").Find(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.ReleaseDates error")
+	}
+
+	return movies, nil
+}
+
+func (rr *pgRecommendationRepo) Ratings() ([]models.Rating, error) {
+	ratings := []models.Rating{}
+	tx := rr.DB.Table("ratings r").Select("r.user_id, r.movie_id, r.score").
+		Joins("JOIN movies m ON m.id = r.movie_id AND m.deleted_at IS NULL").
+		Find(&ratings)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Ratings error")
+	}
+
+	return ratings, nil
+}
+
+// ReplaceSimilarities locks the table against a concurrent run of the job
+// on another instance; readers are not blocked and see the old rows until
+// the commit.
+func (rr *pgRecommendationRepo) ReplaceSimilarities(kind string, sims []models.Similarity) error {
+	err := rr.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE movie_similarity IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM movie_similarity WHERE kind = ?", kind).Error; err != nil {
+			return err
+		}
+
+		for start := 0; start < len(sims); start += saveBatchSize {
+			batch := sims[start:min(start+saveBatchSize, len(sims))]
+
+			movieIDs := make(pq.Int64Array, len(batch))
+			similarIDs := make(pq.Int64Array, len(batch))
+			scores := make(pq.Float64Array, len(batch))
+			shared := make(pq.Int64Array, len(batch))
+
+			for i, s := range batch {
+				movieIDs[i] = int64(s.MovieID)
+				similarIDs[i] = int64(s.SimilarID)
+				scores[i] = s.Score
+				shared[i] = int64(s.Shared)
+			}
+
+			if err := tx.Exec(saveSimilaritiesQuery, kind, movieIDs, similarIDs, scores, shared).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgRecommendationRepo.ReplaceSimilarities error")
+	}
+
+	return nil
+}
+
+func (rr *pgRecommendationRepo) Similar(kind string, movieID, limit, offset int) ([]models.SimilarMovie, error) {
+	movies := []models.SimilarMovie{}
+	tx := rr.DB.Raw(similarQuery, kind, movieID, limit, offset).Scan(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Similar error")
+	}
+
+	return movies, nil
+}
+
+func (rr *pgRecommendationRepo) Neighbours(kind string, movieIDs []int) ([]models.Similarity, error) {
+	sims := []models.Similarity{}
+	if len(movieIDs) == 0 {
+		return sims, nil
+	}
+
+	tx := rr.DB.Table("movie_similarity").Where("kind = ? AND movie_id IN ?", kind, movieIDs).Find(&sims)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Neighbours error")
+	}
+
+	return sims, nil
+}
+
+func (rr *pgRecommendationRepo) UserRatings(userID int) ([]models.Rating, error) {
+	ratings := []models.Rating{}
+	tx := rr.DB.Where("user_id = ?", userID).Find(&ratings)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.UserRatings error")
+	}
+
+	return ratings, nil
+}
+
+func (rr *pgRecommendationRepo) Known(userID int) ([]int, error) {
+	ids := []int{}
+	tx := rr.DB.Raw(knownQuery, userID, userID, userID).Scan(&ids)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Known error")
+	}
+
+	return ids, nil
+}
+
+func (rr *pgRecommendationRepo) Movies(ids []int) ([]models.Movie, error) {
+	movies := []models.Movie{}
+	if len(ids) == 0 {
+		return movies, nil
+	}
+
+	tx := rr.DB.Where("id IN ?", ids).Find(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Movies error")
+	}
+
+	return movies, nil
+}
+
+func (rr *pgRecommendationRepo) Popular(exclude []int, limit, offset int) ([]models.Movie, error) {
+	movies := []models.Movie{}
+
+	query := rr.DB.Order("weighted_rating DESC, rating DESC, id").Limit(limit).Offset(offset)
+	// NOT IN of an empty list would be NOT IN (NULL), which matches nothing.
+	if len(exclude) > 0 {
+		query = query.Where("id NOT IN ?", exclude)
+	}
+
+	tx := query.Find(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgRecommendationRepo.Popular error")
+	}
+
+	return movies, nil
+}
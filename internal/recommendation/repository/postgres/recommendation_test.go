@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	recommendationRep "intern/internal/recommendation/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+)
+
+type RecommendationRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   recommendationRep.RecommendationRepositoryI
+}
+
+func TestRecommendationRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(RecommendationRepoTestSuite))
+}
+
+func (s *RecommendationRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *RecommendationRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *RecommendationRepoTestSuite) TestReplaceSimilarities(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`LOCK TABLE movie_similarity IN SHARE ROW EXCLUSIVE MODE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM movie_similarity WHERE kind = $1`)).
+		WithArgs(models.SimilarityCast).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO movie_similarity (kind, movie_id, similar_id, score, shared)`)).
+		WithArgs(models.SimilarityCast, "{1,2}", "{2,1}", "{0.5,0.5}", "{1,1}").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectCommit()
+
+	err := s.repo.ReplaceSimilarities(models.SimilarityCast, []models.Similarity{
+		{MovieID: 1, SimilarID: 2, Score: 0.5, Shared: 1},
+		{MovieID: 2, SimilarID: 1, Score: 0.5, Shared: 1},
+	})
+	t.Assert().NoError(err)
+}
+
+func (s *RecommendationRepoTestSuite) TestReplaceSimilaritiesEmpty(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`LOCK TABLE movie_similarity IN SHARE ROW EXCLUSIVE MODE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM movie_similarity WHERE kind = $1`)).
+		WithArgs(models.SimilarityRatings).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mock.ExpectCommit()
+
+	err := s.repo.ReplaceSimilarities(models.SimilarityRatings, nil)
+	t.Assert().NoError(err)
+}
+
+func (s *RecommendationRepoTestSuite) TestSimilar(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "title", "score", "shared_actors"}).
+		AddRow(2, "Aliens", 0.75, 3).
+		AddRow(3, "Alien³", 0.5, 2)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.*, s.score, s.shared AS shared_actors
+FROM movie_similarity s JOIN movies m ON m.id = s.similar_id
+WHERE s.kind = $1 AND s.movie_id = $2 AND m.deleted_at IS NULL
+ORDER BY s.score DESC, m.id
+LIMIT $3 OFFSET $4`)).
+		WithArgs(models.SimilarityCast, 1, 20, 0).
+		WillReturnRows(rows)
+
+	movies, err := s.repo.Similar(models.SimilarityCast, 1, 20, 0)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.SimilarMovie{
+		{Movie: models.Movie{ID: 2, Title: "Aliens"}, Score: 0.75, SharedActors: 3},
+		{Movie: models.Movie{ID: 3, Title: "Alien³"}, Score: 0.5, SharedActors: 2},
+	}, movies)
+}
+
+func (s *RecommendationRepoTestSuite) TestNeighboursNone(t provider.T) {
+	sims, err := s.repo.Neighbours(models.SimilarityRatings, nil)
+	t.Assert().NoError(err)
+	t.Assert().Empty(sims)
+}
+
+func (s *RecommendationRepoTestSuite) TestKnown(t provider.T) {
+	rows := sqlmock.NewRows([]string{"movie_id"}).AddRow(1).AddRow(4)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT movie_id FROM ratings WHERE user_id = $1
+UNION SELECT movie_id FROM watch_history WHERE user_id = $2
+UNION SELECT movie_id FROM watchlist WHERE user_id = $3`)).
+		WithArgs(7, 7, 7).
+		WillReturnRows(rows)
+
+	ids, err := s.repo.Known(7)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]int{1, 4}, ids)
+}
+
+func (s *RecommendationRepoTestSuite) TestPopular(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "title"}).AddRow(5, "Heat")
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE id NOT IN ($1,$2) AND "movies"."deleted_at" IS NULL ORDER BY weighted_rating DESC, rating DESC, id LIMIT $3 OFFSET $4`)).
+		WithArgs(1, 4, 10, 5).
+		WillReturnRows(rows)
+
+	movies, err := s.repo.Popular([]int{1, 4}, 10, 5)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.Movie{{ID: 5, Title: "Heat"}}, movies)
+}
+
+func (s *RecommendationRepoTestSuite) TestPopularExcludesNothing(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "title"}).AddRow(5, "Heat")
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "movies" WHERE "movies"."deleted_at" IS NULL ORDER BY weighted_rating DESC, rating DESC, id LIMIT $1`)).
+		WithArgs(10).
+		WillReturnRows(rows)
+
+	movies, err := s.repo.Popular(nil, 10, 0)
+	t.Assert().NoError(err)
+	t.Assert().Len(movies, 1)
+}
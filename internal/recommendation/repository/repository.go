@@ -0,0 +1,26 @@
+package repository
+
+import "intern/models"
+
+// RecommendationRepositoryI reads the inputs of the similarity job, stores
+// its results and serves the per-user data recommendations are built from.
+type RecommendationRepositoryI interface {
+	Credits() ([]models.MovieActor, error)
+	// ReleaseDates returns every movie with only ID and ReleaseDate set.
+	ReleaseDates() ([]models.Movie, error)
+	Ratings() ([]models.Rating, error)
+	// ReplaceSimilarities swaps all similarities of a kind for sims in one
+	// transaction; pairs of movies deleted meanwhile are dropped.
+	ReplaceSimilarities(kind string, sims []models.Similarity) error
+
+	// Similar returns the neighbours of a movie, best first.
+	Similar(kind string, movieID, limit, offset int) ([]models.SimilarMovie, error)
+	Neighbours(kind string, movieIDs []int) ([]models.Similarity, error)
+	UserRatings(userID int) ([]models.Rating, error)
+	// Known returns the movies the user rated, watched or put on the
+	// watchlist.
+	Known(userID int) ([]int, error)
+	Movies(ids []int) ([]models.Movie, error)
+	// Popular returns movies by weighted rating, leaving out exclude.
+	Popular(exclude []int, limit, offset int) ([]models.Movie, error)
+}
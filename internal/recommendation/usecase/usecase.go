@@ -0,0 +1,470 @@
+package usecase
+
+import (
+	"cmp"
+	"fmt"
+	movieRep "intern/internal/movie/repository"
+	recommendationRep "intern/internal/recommendation/repository"
+	"intern/models"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type RecommendationUseCaseI interface {
+	Similar(movieID, limit, offset int) ([]models.SimilarMovie, error)
+	Recommend(userID, limit, offset int) ([]models.Recommendation, error)
+	// Recompute rebuilds the similarity tables the other methods read.
+	Recompute() (*models.SimilarityStats, error)
+}
+
+const (
+	// maxNeighbours is the number of similar movies kept per movie and kind.
+	maxNeighbours = 20
+
+	// The cast score of a pair is scaled down by up to yearWeight as the
+	// release years drift apart; at yearScale years the year part halves.
+	yearWeight = 0.25
+	yearScale  = 10.0
+	// Actors in more movies link too many of them to tell anything apart,
+	// and the pairs they add grow with the square of their movies.
+	maxActorMovies = 200
+
+	// Users with more ratings are left out of the ratings similarity for the
+	// same reason.
+	maxUserRatings = 500
+	// Pairs rated by few users get a similarity shrunk towards zero.
+	minCommonRaters = 2
+	ratingShrink    = 5.0
+
+	// likedScore is the lowest rating that makes a movie a reason for a
+	// recommendation.
+	likedScore = 7
+	// The predicted rating counts the mean rating of the user as one more
+	// neighbour of similarity predictionPrior, which keeps a movie found
+	// through one weak neighbour from the top of the list.
+	predictionPrior = 1.0
+	maxBecause      = 3
+)
+
+type recommendationUseCase struct {
+	recommendationRepository recommendationRep.RecommendationRepositoryI
+	movieRepository          movieRep.MovieRepositoryI
+}
+
+func New(rRep recommendationRep.RecommendationRepositoryI, mRep movieRep.MovieRepositoryI) RecommendationUseCaseI {
+	return &recommendationUseCase{
+		recommendationRepository: rRep,
+		movieRepository:          mRep,
+	}
+}
+
+// Similar returns the movies that share cast with the movie, closer release
+// years ranking higher. The list is as fresh as the last Recompute.
+func (rUC *recommendationUseCase) Similar(movieID, limit, offset int) ([]models.SimilarMovie, error) {
+	movie, err := rUC.movieRepository.Get(movieID)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Similar error: Movie not found")
+	}
+
+	movies, err := rUC.recommendationRepository.Similar(models.SimilarityCast, movieID, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Similar error")
+	}
+
+	for i := range movies {
+		movies[i].Reason = castReason(movies[i].SharedActors, yearGap(movie.ReleaseDate.Year(), movies[i].ReleaseDate.Year()))
+	}
+
+	return movies, nil
+}
+
+// candidate collects the rated neighbours of a movie the user does not know.
+type candidate struct {
+	weighted float64
+	weights  float64
+	because  []contribution
+}
+
+type contribution struct {
+	movieID int
+	weight  float64
+}
+
+// Recommend predicts ratings for the neighbours of the movies the user
+// rated (item-based collaborative filtering) and lists the ones found
+// through a liked movie best first. Movies the user already rated, watched
+// or put on the watchlist are left out; the highest rated movies fill up
+// the list when the ratings do not give enough.
+func (rUC *recommendationUseCase) Recommend(userID, limit, offset int) ([]models.Recommendation, error) {
+	ratings, err := rUC.recommendationRepository.UserRatings(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recommend error: can't get ratings")
+	}
+
+	knownIDs, err := rUC.recommendationRepository.Known(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recommend error: can't get known movies")
+	}
+
+	known := make(map[int]bool, len(knownIDs))
+	for _, id := range knownIDs {
+		known[id] = true
+	}
+
+	recs, err := rUC.personal(ratings, known)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recommend error")
+	}
+
+	end := offset + limit
+	if end <= len(recs) {
+		return recs[offset:end], nil
+	}
+
+	exclude := knownIDs
+	for _, rec := range recs {
+		exclude = append(exclude, rec.ID)
+	}
+
+	popular, err := rUC.recommendationRepository.Popular(exclude, end-max(offset, len(recs)), max(offset-len(recs), 0))
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recommend error: can't get highest rated movies")
+	}
+
+	recs = recs[min(offset, len(recs)):]
+	for _, m := range popular {
+		recs = append(recs, models.Recommendation{Movie: m, Score: m.WeightedRating, Reason: "highly rated"})
+	}
+
+	return recs, nil
+}
+
+func (rUC *recommendationUseCase) personal(ratings []models.Rating, known map[int]bool) ([]models.Recommendation, error) {
+	scores := make(map[int]int, len(ratings))
+	rated := make([]int, 0, len(ratings))
+	sum, liked := 0, 0
+
+	for _, r := range ratings {
+		scores[r.MovieID] = r.Score
+		rated = append(rated, r.MovieID)
+		sum += r.Score
+		if r.Score >= likedScore {
+			liked++
+		}
+	}
+
+	if liked == 0 {
+		return nil, nil
+	}
+
+	mean := float64(sum) / float64(len(ratings))
+
+	sims, err := rUC.recommendationRepository.Neighbours(models.SimilarityRatings, rated)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get neighbours")
+	}
+
+	candidates := make(map[int]*candidate)
+	for _, s := range sims {
+		if known[s.SimilarID] {
+			continue
+		}
+
+		c, ok := candidates[s.SimilarID]
+		if !ok {
+			c = &candidate{}
+			candidates[s.SimilarID] = c
+		}
+
+		score := scores[s.MovieID]
+		c.weighted += s.Score * float64(score)
+		c.weights += s.Score
+		if score >= likedScore {
+			c.because = append(c.because, contribution{movieID: s.MovieID, weight: s.Score * float64(score)})
+		}
+	}
+
+	ids := make([]int, 0, len(candidates))
+	for id, c := range candidates {
+		if len(c.because) == 0 {
+			continue
+		}
+
+		slices.SortFunc(c.because, func(a, b contribution) int {
+			return cmp.Or(cmp.Compare(b.weight, a.weight), cmp.Compare(a.movieID, b.movieID))
+		})
+		c.because = c.because[:min(len(c.because), maxBecause)]
+
+		ids = append(ids, id)
+		for _, b := range c.because {
+			ids = append(ids, b.movieID)
+		}
+	}
+
+	movies, err := rUC.recommendationRepository.Movies(ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get movies")
+	}
+
+	byID := make(map[int]models.Movie, len(movies))
+	for _, m := range movies {
+		byID[m.ID] = m
+	}
+
+	recs := make([]models.Recommendation, 0, len(candidates))
+	weights := make(map[int]float64, len(candidates))
+
+	for id, c := range candidates {
+		movie, ok := byID[id]
+		if len(c.because) == 0 || !ok {
+			continue
+		}
+
+		rec := models.Recommendation{
+			Movie: movie,
+			Score: (c.weighted + predictionPrior*mean) / (c.weights + predictionPrior),
+		}
+
+		titles := make([]string, 0, len(c.because))
+		for _, b := range c.because {
+			rec.Because = append(rec.Because, models.MovieRef{ID: b.movieID, Title: byID[b.movieID].Title})
+			titles = append(titles, byID[b.movieID].Title)
+		}
+		rec.Reason = "because you liked " + enumerate(titles)
+
+		recs = append(recs, rec)
+		weights[id] = c.weights
+	}
+
+	slices.SortFunc(recs, func(a, b models.Recommendation) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(weights[b.ID], weights[a.ID]), cmp.Compare(a.ID, b.ID))
+	})
+
+	return recs, nil
+}
+
+func (rUC *recommendationUseCase) Recompute() (*models.SimilarityStats, error) {
+	cast, err := rUC.castSimilarities()
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recompute error")
+	}
+
+	err = rUC.recommendationRepository.ReplaceSimilarities(models.SimilarityCast, cast)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recompute error")
+	}
+
+	ratings, err := rUC.ratingsSimilarities()
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recompute error")
+	}
+
+	err = rUC.recommendationRepository.ReplaceSimilarities(models.SimilarityRatings, ratings)
+	if err != nil {
+		return nil, errors.Wrap(err, "recommendationUseCase.Recompute error")
+	}
+
+	return &models.SimilarityStats{CastPairs: len(cast), RatingsPairs: len(ratings)}, nil
+}
+
+// castSimilarities scores the movies sharing an actor by the cosine of
+// their casts, scaled by how close their release years are.
+func (rUC *recommendationUseCase) castSimilarities() ([]models.Similarity, error) {
+	credits, err := rUC.recommendationRepository.Credits()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get credits")
+	}
+
+	movies, err := rUC.recommendationRepository.ReleaseDates()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get release dates")
+	}
+
+	years := make(map[int]int, len(movies))
+	for _, m := range movies {
+		years[m.ID] = m.ReleaseDate.Year()
+	}
+
+	linked := make(map[[2]int]bool, len(credits))
+	cast := make(map[int][]int)
+	filmography := make(map[int][]int)
+
+	for _, ma := range credits {
+		if linked[[2]int{ma.MovieID, ma.ActorID}] {
+			continue
+		}
+		linked[[2]int{ma.MovieID, ma.ActorID}] = true
+
+		cast[ma.MovieID] = append(cast[ma.MovieID], ma.ActorID)
+		filmography[ma.ActorID] = append(filmography[ma.ActorID], ma.MovieID)
+	}
+
+	all := []models.Similarity{}
+	for movieID, actors := range cast {
+		if _, ok := years[movieID]; !ok {
+			continue
+		}
+
+		shared := make(map[int]int)
+		for _, actorID := range actors {
+			films := filmography[actorID]
+			if len(films) > maxActorMovies {
+				continue
+			}
+
+			for _, other := range films {
+				if other != movieID {
+					shared[other]++
+				}
+			}
+		}
+
+		sims := make([]models.Similarity, 0, len(shared))
+		for other, n := range shared {
+			year, ok := years[other]
+			if !ok {
+				continue
+			}
+
+			castScore := float64(n) / math.Sqrt(float64(len(actors)*len(cast[other])))
+			proximity := 1 / (1 + float64(yearGap(years[movieID], year))/yearScale)
+
+			sims = append(sims, models.Similarity{
+				Kind:      models.SimilarityCast,
+				MovieID:   movieID,
+				SimilarID: other,
+				Score:     castScore * (1 - yearWeight + yearWeight*proximity),
+				Shared:    n,
+			})
+		}
+
+		all = append(all, top(sims)...)
+	}
+
+	return all, nil
+}
+
+// pair sums the products and squares of the mean-centred ratings of the
+// users who rated both movies of a pair.
+type pair struct {
+	dot   float64
+	normA float64
+	normB float64
+	n     int
+}
+
+// ratingsSimilarities scores the movies rated by the same users by the
+// adjusted cosine of their ratings: every rating minus the mean rating of
+// its user, so generous and harsh raters count alike.
+func (rUC *recommendationUseCase) ratingsSimilarities() ([]models.Similarity, error) {
+	ratings, err := rUC.recommendationRepository.Ratings()
+	if err != nil {
+		return nil, errors.Wrap(err, "can't get ratings")
+	}
+
+	byUser := make(map[int][]models.Rating)
+	for _, r := range ratings {
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+
+	pairs := make(map[[2]int]*pair)
+	for _, rated := range byUser {
+		if len(rated) < 2 || len(rated) > maxUserRatings {
+			continue
+		}
+
+		sum := 0
+		for _, r := range rated {
+			sum += r.Score
+		}
+		mean := float64(sum) / float64(len(rated))
+
+		slices.SortFunc(rated, func(a, b models.Rating) int { return cmp.Compare(a.MovieID, b.MovieID) })
+
+		for i, a := range rated {
+			ca := float64(a.Score) - mean
+			for _, b := range rated[i+1:] {
+				cb := float64(b.Score) - mean
+
+				p, ok := pairs[[2]int{a.MovieID, b.MovieID}]
+				if !ok {
+					p = &pair{}
+					pairs[[2]int{a.MovieID, b.MovieID}] = p
+				}
+
+				p.dot += ca * cb
+				p.normA += ca * ca
+				p.normB += cb * cb
+				p.n++
+			}
+		}
+	}
+
+	byMovie := make(map[int][]models.Similarity)
+	for key, p := range pairs {
+		if p.n < minCommonRaters || p.dot <= 0 {
+			continue
+		}
+
+		score := p.dot / math.Sqrt(p.normA*p.normB) * float64(p.n) / (float64(p.n) + ratingShrink)
+
+		byMovie[key[0]] = append(byMovie[key[0]], models.Similarity{
+			Kind: models.SimilarityRatings, MovieID: key[0], SimilarID: key[1], Score: score, Shared: p.n,
+		})
+		byMovie[key[1]] = append(byMovie[key[1]], models.Similarity{
+			Kind: models.SimilarityRatings, MovieID: key[1], SimilarID: key[0], Score: score, Shared: p.n,
+		})
+	}
+
+	all := []models.Similarity{}
+	for _, sims := range byMovie {
+		all = append(all, top(sims)...)
+	}
+
+	return all, nil
+}
+
+// top keeps the maxNeighbours best similarities.
+func top(sims []models.Similarity) []models.Similarity {
+	slices.SortFunc(sims, func(a, b models.Similarity) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.SimilarID, b.SimilarID))
+	})
+
+	return sims[:min(len(sims), maxNeighbours)]
+}
+
+func yearGap(a, b int) int {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
+
+func castReason(shared, gap int) string {
+	actors := "1 shared actor"
+	if shared != 1 {
+		actors = fmt.Sprintf("%d shared actors", shared)
+	}
+
+	switch gap {
+	case 0:
+		return actors + ", released the same year"
+	case 1:
+		return actors + ", released 1 year apart"
+	default:
+		return fmt.Sprintf("%s, released %d years apart", actors, gap)
+	}
+}
+
+// enumerate joins titles as "A", "A and B" or "A, B and C".
+func enumerate(titles []string) string {
+	if len(titles) < 2 {
+		return strings.Join(titles, "")
+	}
+
+	return strings.Join(titles[:len(titles)-1], ", ") + " and " + titles[len(titles)-1]
+}
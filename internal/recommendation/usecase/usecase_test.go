@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memRecommendation "intern/internal/recommendation/repository/memory"
+	"intern/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newUseCase stores six movies; Alien, Aliens and Alien³ share cast, the
+// others share none.
+func newUseCase() (RecommendationUseCaseI, *memdb.DB) {
+	db := memdb.New()
+
+	movies := []models.Movie{
+		{ID: 1, Title: "Alien", ReleaseDate: time.Date(1979, time.May, 25, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Title: "Aliens", ReleaseDate: time.Date(1986, time.July, 18, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, Title: "Alien³", ReleaseDate: time.Date(1992, time.May, 22, 0, 0, 0, 0, time.UTC),
+			RatingStats: models.RatingStats{WeightedRating: 7.5}},
+		{ID: 4, Title: "Prometheus", ReleaseDate: time.Date(2012, time.June, 8, 0, 0, 0, 0, time.UTC)},
+		{ID: 5, Title: "Heat", ReleaseDate: time.Date(1995, time.December, 15, 0, 0, 0, 0, time.UTC)},
+		{ID: 6, Title: "Ronin", ReleaseDate: time.Date(1998, time.September, 25, 0, 0, 0, 0, time.UTC),
+			RatingStats: models.RatingStats{WeightedRating: 8}},
+	}
+	for _, m := range movies {
+		db.Movies[m.ID] = m
+		db.SeenID("movies", m.ID)
+	}
+
+	for id := 1; id <= 4; id++ {
+		db.Actors[id] = models.Actor{ID: id, FirstName: "Actor", LastName: strconv.Itoa(id)}
+	}
+
+	// Actor 1 plays in the three Alien movies, actor 2 in the sequels only.
+	for i, link := range [][2]int{{1, 1}, {1, 3}, {2, 1}, {2, 2}, {3, 1}, {3, 2}, {4, 4}, {2, 2}} {
+		db.MoviesActors[i+1] = models.MovieActor{ID: i + 1, MovieID: link[0], ActorID: link[1]}
+	}
+
+	return New(memRecommendation.New(nil, db), memMovie.New(nil, db)), db
+}
+
+func rate(db *memdb.DB, userID int, scores map[int]int) {
+	for movieID, score := range scores {
+		db.Ratings[memdb.UserMovieKey{UserID: userID, MovieID: movieID}] = models.Rating{UserID: userID, MovieID: movieID, Score: score}
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	uc, _ := newUseCase()
+
+	stats, err := uc.Recompute()
+	require.NoError(t, err)
+	assert.Equal(t, 6, stats.CastPairs)
+
+	movies, err := uc.Similar(2, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, movies, 2)
+
+	assert.Equal(t, 3, movies[0].ID)
+	assert.Equal(t, 2, movies[0].SharedActors)
+	assert.InDelta(t, 0.75+0.25/1.6, movies[0].Score, 1e-9)
+	assert.Equal(t, "2 shared actors, released 6 years apart", movies[0].Reason)
+
+	assert.Equal(t, 1, movies[1].ID)
+	assert.Equal(t, "1 shared actor, released 7 years apart", movies[1].Reason)
+
+	movies, err = uc.Similar(2, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, movies, 1)
+	assert.Equal(t, 1, movies[0].ID)
+
+	movies, err = uc.Similar(4, 20, 0)
+	require.NoError(t, err)
+	assert.Empty(t, movies)
+
+	_, err = uc.Similar(42, 20, 0)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+func TestRecompute(t *testing.T) {
+	uc, db := newUseCase()
+
+	// Users 1 and 2 like Alien and Aliens and dislike Alien³, user 3 the
+	// other way round: Alien and Aliens go together, Alien³ with neither.
+	rate(db, 1, map[int]int{1: 9, 2: 9, 3: 3})
+	rate(db, 2, map[int]int{1: 8, 2: 9, 3: 2, 4: 5})
+	rate(db, 3, map[int]int{1: 2, 2: 3, 3: 9})
+
+	stats, err := uc.Recompute()
+	require.NoError(t, err)
+	assert.Equal(t, &models.SimilarityStats{CastPairs: 6, RatingsPairs: 2}, stats)
+
+	key := memdb.SimilarityKey{Kind: models.SimilarityRatings, MovieID: 1, SimilarID: 2}
+	require.Contains(t, db.Similarities, key)
+	assert.Equal(t, 3, db.Similarities[key].Shared)
+	assert.Equal(t, db.Similarities[key].Score, db.Similarities[memdb.SimilarityKey{Kind: models.SimilarityRatings, MovieID: 2, SimilarID: 1}].Score)
+
+	// A movie in the trash is left out of the similar movies right away,
+	// a rerun drops its similarities.
+	require.NoError(t, memMovie.New(nil, db).Delete(2))
+
+	movies, err := uc.Similar(1, 20, 0)
+	require.NoError(t, err)
+	for _, m := range movies {
+		assert.NotEqual(t, 2, m.ID)
+	}
+
+	stats, err = uc.Recompute()
+	require.NoError(t, err)
+	assert.Equal(t, &models.SimilarityStats{CastPairs: 2, RatingsPairs: 0}, stats)
+	assert.Len(t, db.Similarities, 2)
+}
+
+func TestRecommend(t *testing.T) {
+	uc, db := newUseCase()
+
+	rate(db, 1, map[int]int{1: 9, 2: 9, 3: 3})
+	rate(db, 2, map[int]int{1: 8, 2: 9, 3: 2, 4: 5})
+	rate(db, 3, map[int]int{1: 2, 2: 3, 3: 9})
+
+	// User 10 liked Alien and has seen Prometheus.
+	rate(db, 10, map[int]int{1: 9, 5: 4})
+	db.History[memdb.UserMovieKey{UserID: 10, MovieID: 4}] = time.Now()
+
+	_, err := uc.Recompute()
+	require.NoError(t, err)
+
+	recs, err := uc.Recommend(10, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+
+	assert.Equal(t, 2, recs[0].ID)
+	assert.Equal(t, []models.MovieRef{{ID: 1, Title: "Alien"}}, recs[0].Because)
+	assert.Equal(t, "because you liked Alien", recs[0].Reason)
+	assert.Greater(t, recs[0].Score, 6.5)
+	assert.Less(t, recs[0].Score, 9.0)
+
+	// The highest rated movies fill up the list.
+	assert.Equal(t, 6, recs[1].ID)
+	assert.Equal(t, 3, recs[2].ID)
+	assert.Empty(t, recs[1].Because)
+	assert.Equal(t, "highly rated", recs[1].Reason)
+	assert.Equal(t, 8.0, recs[1].Score)
+
+	recs, err = uc.Recommend(10, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, 6, recs[0].ID)
+
+	recs, err = uc.Recommend(10, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, 3, recs[0].ID)
+
+	recs, err = uc.Recommend(10, 20, 5)
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+}
+
+func TestRecommendWithoutLikes(t *testing.T) {
+	uc, db := newUseCase()
+
+	rate(db, 1, map[int]int{1: 9, 2: 9})
+	rate(db, 2, map[int]int{1: 8, 2: 9})
+	rate(db, 10, map[int]int{1: 5})
+	db.Watchlist[memdb.UserMovieKey{UserID: 10, MovieID: 6}] = time.Now()
+
+	_, err := uc.Recompute()
+	require.NoError(t, err)
+
+	// Rating Alien 5 is no reason to suggest Aliens.
+	recs, err := uc.Recommend(10, 3, 0)
+	require.NoError(t, err)
+
+	movieIDs := make([]int, len(recs))
+	for i, rec := range recs {
+		movieIDs[i] = rec.ID
+		assert.Equal(t, "highly rated", rec.Reason)
+	}
+	assert.Equal(t, []int{3, 2, 4}, movieIDs)
+}
@@ -0,0 +1,499 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	reviewUseCase "intern/internal/review/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ReviewForm struct {
+	Text   string `json:"text"`
+	Rating int    `json:"rating"`
+}
+
+type StatusForm struct {
+	Status string `json:"status"`
+}
+
+type VoteForm struct {
+	Helpful bool `json:"helpful"`
+}
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+	UserRoleFromContext(context.Context) (string, error)
+}
+
+type ReviewHandler struct {
+	ReviewUseCase reviewUseCase.ReviewUseCaseI
+	Logger        logger.Logger
+	Context       ContextManager
+}
+
+// Create godoc
+// @Summary      Review a movie
+// @Description  Write a review with a rating from 1 to 10. The review is pending until an admin approves it,
+// @Description  only one review per user and movie.
+// @Tags     reviews
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param review body ReviewForm true "review text and rating"
+// @Success 201 {object} models.Review "review created"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 409 {object} nil "movie already reviewed"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/reviews [post]
+func (rh *ReviewHandler) Create(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	movieID, ok := rh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	form := ReviewForm{}
+	if !rh.readForm(w, r, &form) {
+		return
+	}
+
+	review := &models.Review{MovieID: movieID, UserID: viewer.UserID, Text: form.Text, Rating: form.Rating}
+
+	err := rh.ReviewUseCase.Create(review)
+	if err != nil {
+		rh.fail(w, err, "create review")
+		return
+	}
+
+	rh.write(w, http.StatusCreated, review)
+}
+
+// ListByMovie godoc
+// @Summary      List reviews of a movie
+// @Description  Approved reviews of a movie plus the own ones of the user, newest first or by helpfulness.
+// @Description  Admins see every review and may filter by status.
+// @Tags     reviews
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param sort query string false "newest (default) or helpful"
+// @Param status query string false "pending, approved or rejected (admins only)"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Review "success list reviews"
+// @Failure 400 {object} nil "invalid filter"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /movies/{id}/reviews [get]
+func (rh *ReviewHandler) ListByMovie(w http.ResponseWriter, r *http.Request) {
+	movieID, ok := rh.pathID(w, r, "MOV_ID")
+	if !ok {
+		return
+	}
+
+	rh.list(w, r, movieID)
+}
+
+// List godoc
+// @Summary      Moderation queue
+// @Description  Reviews of all movies, for admins. Filter by status=pending to get the reviews waiting for moderation.
+// @Tags     reviews
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param status query string false "pending, approved or rejected"
+// @Param sort query string false "newest (default) or helpful"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Review "success list reviews"
+// @Failure 400 {object} nil "invalid filter"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews [get]
+func (rh *ReviewHandler) List(w http.ResponseWriter, r *http.Request) {
+	rh.list(w, r, 0)
+}
+
+func (rh *ReviewHandler) list(w http.ResponseWriter, r *http.Request, movieID int) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		rh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.ReviewFilter{
+		MovieID: movieID,
+		Status:  r.FormValue("status"),
+		SortBy:  r.FormValue("sort"),
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+	}
+
+	reviews, err := rh.ReviewUseCase.List(filter, viewer)
+	if err != nil {
+		rh.fail(w, err, "list reviews")
+		return
+	}
+
+	rh.write(w, http.StatusOK, reviews)
+}
+
+// Get godoc
+// @Summary      Get review
+// @Description  Get a review; pending and rejected reviews are only visible to their author and admins
+// @Tags     reviews
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Success 200 {object} models.Review "success get review"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Review not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id} [get]
+func (rh *ReviewHandler) Get(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	review, err := rh.ReviewUseCase.Get(reviewID, viewer)
+	if err != nil {
+		rh.fail(w, err, "get review")
+		return
+	}
+
+	rh.write(w, http.StatusOK, review)
+}
+
+// Update godoc
+// @Summary      Edit my review
+// @Description  Replace the text and rating of an own review. The review goes back to pending moderation.
+// @Tags     reviews
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Param review body ReviewForm true "review text and rating"
+// @Success 200 {object} models.Review "review updated"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the author"
+// @Failure 404 {object} nil "Review not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id} [put]
+func (rh *ReviewHandler) Update(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	form := ReviewForm{}
+	if !rh.readForm(w, r, &form) {
+		return
+	}
+
+	review := &models.Review{ID: reviewID, Text: form.Text, Rating: form.Rating}
+
+	err := rh.ReviewUseCase.Update(review, viewer)
+	if err != nil {
+		rh.fail(w, err, "update review")
+		return
+	}
+
+	rh.write(w, http.StatusOK, review)
+}
+
+// Delete godoc
+// @Summary      Delete review
+// @Description  Delete an own review; admins can delete any review
+// @Tags     reviews
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Success 200 {object} nil "review deleted"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "not the author"
+// @Failure 404 {object} nil "Review not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id} [delete]
+func (rh *ReviewHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	err := rh.ReviewUseCase.Delete(reviewID, viewer)
+	if err != nil {
+		rh.fail(w, err, "delete review")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetStatus godoc
+// @Summary      Moderate review
+// @Description  Set the moderation status of a review: pending, approved or rejected
+// @Tags     reviews
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Param status body StatusForm true "new status"
+// @Success 200 {object} models.Review "status changed"
+// @Failure 400 {object} nil "invalid status"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Review not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id}/status [put]
+func (rh *ReviewHandler) SetStatus(w http.ResponseWriter, r *http.Request) {
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	form := StatusForm{}
+	if !rh.readForm(w, r, &form) {
+		return
+	}
+
+	review, err := rh.ReviewUseCase.SetStatus(reviewID, form.Status)
+	if err != nil {
+		rh.fail(w, err, "moderate review")
+		return
+	}
+
+	rh.write(w, http.StatusOK, review)
+}
+
+// Vote godoc
+// @Summary      Vote on review
+// @Description  Mark an approved review of another user as helpful or unhelpful, replacing an earlier vote.
+// @Description  Returns the review with the updated vote counts.
+// @Tags     reviews
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Param vote body VoteForm true "helpful or not"
+// @Success 200 {object} models.Review "vote saved"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "own review"
+// @Failure 404 {object} nil "Review not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id}/vote [put]
+func (rh *ReviewHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	form := VoteForm{}
+	if !rh.readForm(w, r, &form) {
+		return
+	}
+
+	review, err := rh.ReviewUseCase.Vote(reviewID, viewer, form.Helpful)
+	if err != nil {
+		rh.fail(w, err, "vote on review")
+		return
+	}
+
+	rh.write(w, http.StatusOK, review)
+}
+
+// DeleteVote godoc
+// @Summary      Remove my vote
+// @Description  Remove the vote of the user on a review, returns the review with the updated vote counts
+// @Tags     reviews
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "REVIEW_ID"
+// @Success 200 {object} models.Review "vote removed"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "own review"
+// @Failure 404 {object} nil "Review or vote not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /reviews/{id}/vote [delete]
+func (rh *ReviewHandler) DeleteVote(w http.ResponseWriter, r *http.Request) {
+	viewer, ok := rh.viewer(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := rh.pathID(w, r, "REVIEW_ID")
+	if !ok {
+		return
+	}
+
+	review, err := rh.ReviewUseCase.DeleteVote(reviewID, viewer)
+	if err != nil {
+		rh.fail(w, err, "delete vote")
+		return
+	}
+
+	rh.write(w, http.StatusOK, review)
+}
+
+// viewer reads the signed in user and role, on failure the error is
+// already written.
+func (rh *ReviewHandler) viewer(w http.ResponseWriter, r *http.Request) (reviewUseCase.Viewer, bool) {
+	userID, err := rh.Context.UserIDFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Errorw("can`t get user",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return reviewUseCase.Viewer{}, false
+	}
+
+	role, err := rh.Context.UserRoleFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Errorw("can`t get user role",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return reviewUseCase.Viewer{}, false
+	}
+
+	return reviewUseCase.Viewer{UserID: userID, Admin: role == "admin"}, true
+}
+
+func (rh *ReviewHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	idString := r.PathValue(name)
+	if idString == "" {
+		rh.Logger.Errorw("no " + name + " var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		rh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (rh *ReviewHandler) readForm(w http.ResponseWriter, r *http.Request, form interface{}) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		rh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return false
+	}
+
+	err = json.Unmarshal(body, form)
+	if err != nil {
+		rh.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// fail maps the errors of the use case to a status.
+func (rh *ReviewHandler) fail(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, reviewUseCase.ErrInvalidReview), errors.Is(err, reviewUseCase.ErrInvalidFilter):
+		rh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+	case errors.Is(err, reviewUseCase.ErrNotAuthor), errors.Is(err, reviewUseCase.ErrOwnReview):
+		rh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case errors.Is(err, reviewUseCase.ErrReviewExists):
+		rh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "movie already reviewed", http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		rh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		rh.Logger.Errorw("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "can`t "+action, http.StatusInternalServerError)
+	}
+}
+
+func (rh *ReviewHandler) write(w http.ResponseWriter, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		rh.Logger.Errorw("can`t marshal review",
+			"err:", err.Error())
+		http.Error(w, "can`t make review", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		rh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
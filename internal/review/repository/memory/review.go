@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/review/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memReviewRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.ReviewRepositoryI {
+	return &memReviewRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (rr *memReviewRepo) Create(r *models.Review) error {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	for _, other := range rr.DB.Reviews {
+		if other.MovieID == r.MovieID && other.UserID == r.UserID {
+			return errors.Errorf("memReviewRepo.Create error: user %d already reviewed movie %d", r.UserID, r.MovieID)
+		}
+	}
+
+	now := time.Now()
+	r.ID = rr.DB.NextID("reviews")
+	r.Helpful, r.Unhelpful = 0, 0
+	r.CreatedAt, r.UpdatedAt = now, now
+	rr.DB.Reviews[r.ID] = *r
+
+	return nil
+}
+
+func (rr *memReviewRepo) Get(id int) (*models.Review, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	r, ok := rr.DB.Reviews[id]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.Get error")
+	}
+
+	return &r, nil
+}
+
+func (rr *memReviewRepo) GetByAuthor(movieID, userID int) (*models.Review, error) {
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	for _, r := range rr.DB.Reviews {
+		if r.MovieID == movieID && r.UserID == userID {
+			return &r, nil
+		}
+	}
+
+	return nil, errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.GetByAuthor error")
+}
+
+func (rr *memReviewRepo) Update(r *models.Review) error {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	stored, ok := rr.DB.Reviews[r.ID]
+	if !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.Update error")
+	}
+
+	stored.Text = r.Text
+	stored.Rating = r.Rating
+	stored.Status = r.Status
+	stored.UpdatedAt = r.UpdatedAt
+	rr.DB.Reviews[r.ID] = stored
+
+	return nil
+}
+
+func (rr *memReviewRepo) Delete(id int) error {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	if _, ok := rr.DB.Reviews[id]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.Delete error")
+	}
+
+	delete(rr.DB.Reviews, id)
+	for key := range rr.DB.ReviewVotes {
+		if key.ReviewID == id {
+			delete(rr.DB.ReviewVotes, key)
+		}
+	}
+
+	return nil
+}
+
+func (rr *memReviewRepo) List(filter models.ReviewFilter) ([]models.Review, error) {
+	var compare func(a, b models.Review) int
+
+	switch filter.SortBy {
+	case models.ReviewSortNewest:
+		compare = func(a, b models.Review) int { return 0 }
+	case models.ReviewSortHelpful:
+		compare = func(a, b models.Review) int { return cmp.Compare(b.Helpful-b.Unhelpful, a.Helpful-a.Unhelpful) }
+	default:
+		return nil, errors.Errorf("memReviewRepo.List error: unknown sort %q", filter.SortBy)
+	}
+
+	rr.DB.RLock()
+	defer rr.DB.RUnlock()
+
+	reviews := []models.Review{}
+	for _, r := range rr.DB.Reviews {
+		if filter.MovieID > 0 && r.MovieID != filter.MovieID {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status && (filter.OrUserID == 0 || r.UserID != filter.OrUserID) {
+			continue
+		}
+		reviews = append(reviews, r)
+	}
+
+	slices.SortFunc(reviews, func(a, b models.Review) int {
+		return cmp.Or(compare(a, b), b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return memdb.Page(reviews, filter.Limit, filter.Offset), nil
+}
+
+func (rr *memReviewRepo) SetVote(reviewID, userID int, helpful bool) (*models.Review, error) {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	review, ok := rr.DB.Reviews[reviewID]
+	if !ok {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.SetVote error")
+	}
+
+	key := memdb.ReviewVoteKey{ReviewID: reviewID, UserID: userID}
+	if previous, ok := rr.DB.ReviewVotes[key]; ok {
+		count(&review, previous, -1)
+	}
+	count(&review, helpful, 1)
+
+	rr.DB.ReviewVotes[key] = helpful
+	rr.DB.Reviews[reviewID] = review
+
+	return &review, nil
+}
+
+func (rr *memReviewRepo) DeleteVote(reviewID, userID int) (*models.Review, error) {
+	rr.DB.Lock()
+	defer rr.DB.Unlock()
+
+	key := memdb.ReviewVoteKey{ReviewID: reviewID, UserID: userID}
+	removed, ok := rr.DB.ReviewVotes[key]
+	review, found := rr.DB.Reviews[reviewID]
+	if !ok || !found {
+		return nil, errors.Wrap(gorm.ErrRecordNotFound, "memReviewRepo.DeleteVote error")
+	}
+
+	count(&review, removed, -1)
+	delete(rr.DB.ReviewVotes, key)
+	rr.DB.Reviews[reviewID] = review
+
+	return &review, nil
+}
+
+func count(r *models.Review, helpful bool, delta int) {
+	if helpful {
+		r.Helpful += delta
+	} else {
+		r.Unhelpful += delta
+	}
+}
@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/review/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+const upsertVoteQuery = `INSERT INTO review_votes (review_id, user_id, helpful) VALUES (?, ?, ?)
+ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful`
+
+var reviewSortOrders = map[string]string{
+	models.ReviewSortNewest:  "created_at DESC, id DESC",
+	models.ReviewSortHelpful: "helpful - unhelpful DESC, created_at DESC, id DESC",
+}
+
+type pgReviewRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.ReviewRepositoryI {
+	return &pgReviewRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (rr *pgReviewRepo) Create(r *models.Review) error {
+	tx := rr.DB.Omit("helpful", "unhelpful").Create(r)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgReviewRepo.Create error")
+	}
+
+	return nil
+}
+
+func (rr *pgReviewRepo) Get(id int) (*models.Review, error) {
+	var r models.Review
+	tx := rr.DB.Where("id = ?", id).Take(&r)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgReviewRepo.Get error")
+	}
+
+	return &r, nil
+}
+
+func (rr *pgReviewRepo) GetByAuthor(movieID, userID int) (*models.Review, error) {
+	var r models.Review
+	tx := rr.DB.Where("movie_id = ? AND user_id = ?", movieID, userID).Take(&r)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgReviewRepo.GetByAuthor error")
+	}
+
+	return &r, nil
+}
+
+// Update saves the text, rating and status; the vote counts are only
+// changed by SetVote and DeleteVote.
+func (rr *pgReviewRepo) Update(r *models.Review) error {
+	tx := rr.DB.Model(r).Select("text", "rating", "status", "updated_at").Updates(r)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgReviewRepo.Update error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgReviewRepo.Update error")
+	}
+
+	return nil
+}
+
+func (rr *pgReviewRepo) Delete(id int) error {
+	tx := rr.DB.Delete(&models.Review{}, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgReviewRepo.Delete error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgReviewRepo.Delete error")
+	}
+
+	return nil
+}
+
+func (rr *pgReviewRepo) List(filter models.ReviewFilter) ([]models.Review, error) {
+	order, ok := reviewSortOrders[filter.SortBy]
+	if !ok {
+		return nil, errors.Errorf("pgReviewRepo.List error: unknown sort %q", filter.SortBy)
+	}
+
+	reviews := []models.Review{}
+	tx := rr.DB.Model(&models.Review{})
+
+	if filter.MovieID > 0 {
+		tx = tx.Where("movie_id = ?", filter.MovieID)
+	}
+
+	switch {
+	case filter.Status != "" && filter.OrUserID > 0:
+		tx = tx.Where("status = ? OR user_id = ?", filter.Status, filter.OrUserID)
+	case filter.Status != "":
+		tx = tx.Where("status = ?", filter.Status)
+	}
+
+	if filter.Limit > 0 {
+		tx = tx.Limit(filter.Limit)
+	}
+
+	tx = tx.Order(order).Offset(filter.Offset).Find(&reviews)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgReviewRepo.List error")
+	}
+
+	return reviews, nil
+}
+
+func (rr *pgReviewRepo) SetVote(reviewID, userID int, helpful bool) (*models.Review, error) {
+	var review models.Review
+
+	err := rr.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		review, err = lockReview(tx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		var previous []bool
+		err = tx.Raw("SELECT helpful FROM review_votes WHERE review_id = ? AND user_id = ?", reviewID, userID).Scan(&previous).Error
+		if err != nil {
+			return err
+		}
+
+		if len(previous) > 0 && previous[0] == helpful {
+			return nil
+		}
+
+		err = tx.Exec(upsertVoteQuery, reviewID, userID, helpful).Error
+		if err != nil {
+			return err
+		}
+
+		if len(previous) > 0 {
+			count(&review, previous[0], -1)
+		}
+		count(&review, helpful, 1)
+
+		return saveCounts(tx, review)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgReviewRepo.SetVote error")
+	}
+
+	return &review, nil
+}
+
+func (rr *pgReviewRepo) DeleteVote(reviewID, userID int) (*models.Review, error) {
+	var review models.Review
+
+	err := rr.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		review, err = lockReview(tx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		var removed []bool
+		err = tx.Raw("DELETE FROM review_votes WHERE review_id = ? AND user_id = ? RETURNING helpful", reviewID, userID).Scan(&removed).Error
+		if err != nil {
+			return err
+		}
+
+		if len(removed) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		count(&review, removed[0], -1)
+
+		return saveCounts(tx, review)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "pgReviewRepo.DeleteVote error")
+	}
+
+	return &review, nil
+}
+
+// lockReview reads the review and locks its row until the end of tx, so
+// concurrent votes on one review apply their changes in turn.
+func lockReview(tx *gorm.DB, id int) (models.Review, error) {
+	var review models.Review
+
+	res := tx.Raw("SELECT * FROM reviews WHERE id = ? FOR UPDATE", id).Scan(&review)
+	if res.Error != nil {
+		return review, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return review, gorm.ErrRecordNotFound
+	}
+
+	return review, nil
+}
+
+func count(r *models.Review, helpful bool, delta int) {
+	if helpful {
+		r.Helpful += delta
+	} else {
+		r.Unhelpful += delta
+	}
+}
+
+func saveCounts(tx *gorm.DB, r models.Review) error {
+	return tx.Exec("UPDATE reviews SET helpful = ?, unhelpful = ? WHERE id = ?", r.Helpful, r.Unhelpful, r.ID).Error
+}
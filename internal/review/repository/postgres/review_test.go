@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	reviewRep "intern/internal/review/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type ReviewRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   reviewRep.ReviewRepositoryI
+}
+
+func TestReviewRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(ReviewRepoTestSuite))
+}
+
+func (s *ReviewRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *ReviewRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+var reviewColumns = []string{"id", "movie_id", "user_id", "text", "rating", "status", "helpful", "unhelpful", "created_at", "updated_at"}
+
+func (s *ReviewRepoTestSuite) TestCreate(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	review := &models.Review{MovieID: 3, UserID: 7, Text: "Great", Rating: 9, Status: models.ReviewPending, CreatedAt: created, UpdatedAt: created}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reviews" ("movie_id","user_id","text","rating","status","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id"`)).
+		WithArgs(3, 7, "Great", 9, models.ReviewPending, created, created).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Create(review)
+	t.Assert().NoError(err)
+	t.Assert().Equal(11, review.ID)
+}
+
+func (s *ReviewRepoTestSuite) TestListApprovedOrOwn(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reviews" WHERE movie_id = $1 AND (status = $2 OR user_id = $3) ORDER BY helpful - unhelpful DESC, created_at DESC, id DESC LIMIT $4 OFFSET $5`)).
+		WithArgs(3, models.ReviewApproved, 7, 20, 40).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(11, 3, 7, "Great", 9, models.ReviewPending, 0, 0, created, created))
+
+	reviews, err := s.repo.List(models.ReviewFilter{
+		MovieID: 3, Status: models.ReviewApproved, OrUserID: 7, SortBy: models.ReviewSortHelpful, Limit: 20, Offset: 40,
+	})
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.Review{
+		{ID: 11, MovieID: 3, UserID: 7, Text: "Great", Rating: 9, Status: models.ReviewPending, CreatedAt: created, UpdatedAt: created},
+	}, reviews)
+}
+
+func (s *ReviewRepoTestSuite) TestListUnknownSort(t provider.T) {
+	_, err := s.repo.List(models.ReviewFilter{SortBy: "rating"})
+	t.Assert().Error(err)
+}
+
+func (s *ReviewRepoTestSuite) TestUpdateMissing(t provider.T) {
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE "reviews" SET "text"=$1,"rating"=$2,"status"=$3,"updated_at"=$4 WHERE "id" = $5`)).
+		WithArgs("Great", 9, models.ReviewApproved, sqlmock.AnyArg(), 42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Update(&models.Review{ID: 42, Text: "Great", Rating: 9, Status: models.ReviewApproved, UpdatedAt: updated})
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (s *ReviewRepoTestSuite) TestSetVoteChanged(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reviews WHERE id = $1 FOR UPDATE`)).
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(11, 3, 7, "Great", 9, models.ReviewApproved, 4, 1, created, created))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT helpful FROM review_votes WHERE review_id = $1 AND user_id = $2`)).
+		WithArgs(11, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"helpful"}).AddRow(false))
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO review_votes (review_id, user_id, helpful) VALUES ($1, $2, $3)`)).
+		WithArgs(11, 8, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`UPDATE reviews SET helpful = $1, unhelpful = $2 WHERE id = $3`)).
+		WithArgs(5, 0, 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	review, err := s.repo.SetVote(11, 8, true)
+	t.Assert().NoError(err)
+	t.Assert().Equal(5, review.Helpful)
+	t.Assert().Equal(0, review.Unhelpful)
+}
+
+func (s *ReviewRepoTestSuite) TestSetVoteUnchanged(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reviews WHERE id = $1 FOR UPDATE`)).
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(11, 3, 7, "Great", 9, models.ReviewApproved, 4, 1, created, created))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT helpful FROM review_votes WHERE review_id = $1 AND user_id = $2`)).
+		WithArgs(11, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"helpful"}).AddRow(true))
+	s.mock.ExpectCommit()
+
+	review, err := s.repo.SetVote(11, 8, true)
+	t.Assert().NoError(err)
+	t.Assert().Equal(4, review.Helpful)
+}
+
+func (s *ReviewRepoTestSuite) TestDeleteVoteMissing(t provider.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM reviews WHERE id = $1 FOR UPDATE`)).
+		WithArgs(11).
+		WillReturnRows(sqlmock.NewRows(reviewColumns).
+			AddRow(11, 3, 7, "Great", 9, models.ReviewApproved, 4, 1, created, created))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2 RETURNING helpful`)).
+		WithArgs(11, 8).
+		WillReturnRows(sqlmock.NewRows([]string{"helpful"}))
+	s.mock.ExpectRollback()
+
+	_, err := s.repo.DeleteVote(11, 8)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
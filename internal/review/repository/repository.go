@@ -0,0 +1,17 @@
+package repository
+
+import "intern/models"
+
+// ReviewRepositoryI stores reviews and the helpful votes on them. SetVote
+// and DeleteVote move the vote counts of the review by the change in the
+// same transaction, like the rating stats of a movie.
+type ReviewRepositoryI interface {
+	Create(r *models.Review) error
+	Get(id int) (*models.Review, error)
+	GetByAuthor(movieID, userID int) (*models.Review, error)
+	Update(r *models.Review) error
+	Delete(id int) error
+	List(filter models.ReviewFilter) ([]models.Review, error)
+	SetVote(reviewID, userID int, helpful bool) (*models.Review, error)
+	DeleteVote(reviewID, userID int) (*models.Review, error)
+}
@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	movieRep "intern/internal/movie/repository"
+	reviewRep "intern/internal/review/repository"
+	"intern/models"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ReviewUseCaseI interface {
+	Create(r *models.Review) error
+	Get(id int, viewer Viewer) (*models.Review, error)
+	Update(r *models.Review, viewer Viewer) error
+	Delete(id int, viewer Viewer) error
+	List(filter models.ReviewFilter, viewer Viewer) ([]models.Review, error)
+	SetStatus(id int, status string) (*models.Review, error)
+	Vote(reviewID int, viewer Viewer, helpful bool) (*models.Review, error)
+	DeleteVote(reviewID int, viewer Viewer) (*models.Review, error)
+}
+
+var (
+	ErrInvalidReview = errors.New("invalid review")
+	ErrInvalidFilter = errors.New("invalid review filter")
+	ErrReviewExists  = errors.New("movie already reviewed by the user")
+	ErrNotAuthor     = errors.New("review belongs to another user")
+	ErrOwnReview     = errors.New("can't vote on own review")
+)
+
+// Viewer is the signed in user a request is made for. Users see approved
+// reviews and their own ones, admins see everything.
+type Viewer struct {
+	UserID int
+	Admin  bool
+}
+
+func (v Viewer) canSee(r *models.Review) bool {
+	return v.Admin || r.Status == models.ReviewApproved || r.UserID == v.UserID
+}
+
+type reviewUseCase struct {
+	reviewRepository reviewRep.ReviewRepositoryI
+	movieRepository  movieRep.MovieRepositoryI
+}
+
+func New(rRep reviewRep.ReviewRepositoryI, mRep movieRep.MovieRepositoryI) ReviewUseCaseI {
+	return &reviewUseCase{
+		reviewRepository: rRep,
+		movieRepository:  mRep,
+	}
+}
+
+// Create stores a new review of r.UserID, which waits for moderation. A
+// user has at most one review per movie.
+func (rUC *reviewUseCase) Create(r *models.Review) error {
+	err := validate(r)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Create error")
+	}
+
+	_, err = rUC.movieRepository.Get(r.MovieID)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Create error: Movie not found")
+	}
+
+	_, err = rUC.reviewRepository.GetByAuthor(r.MovieID, r.UserID)
+	switch {
+	case err == nil:
+		return errors.Wrapf(ErrReviewExists, "reviewUseCase.Create error: user %d, movie %d", r.UserID, r.MovieID)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return errors.Wrap(err, "reviewUseCase.Create error")
+	}
+
+	r.Status = models.ReviewPending
+
+	err = rUC.reviewRepository.Create(r)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Create error")
+	}
+
+	return nil
+}
+
+// Get hides reviews the viewer may not see behind a not found error.
+func (rUC *reviewUseCase) Get(id int, viewer Viewer) (*models.Review, error) {
+	review, err := rUC.reviewRepository.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.Get error")
+	}
+
+	if !viewer.canSee(review) {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "reviewUseCase.Get error: review %d is %s", id, review.Status)
+	}
+
+	return review, nil
+}
+
+// Update replaces the text and rating of a review of the viewer. The
+// changed review goes back to moderation.
+func (rUC *reviewUseCase) Update(r *models.Review, viewer Viewer) error {
+	err := validate(r)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Update error")
+	}
+
+	stored, err := rUC.reviewRepository.Get(r.ID)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Update error: Review not found")
+	}
+
+	if stored.UserID != viewer.UserID {
+		return errors.Wrapf(ErrNotAuthor, "reviewUseCase.Update error: review %d", r.ID)
+	}
+
+	stored.Text = r.Text
+	stored.Rating = r.Rating
+	stored.Status = models.ReviewPending
+	stored.UpdatedAt = time.Now()
+
+	err = rUC.reviewRepository.Update(stored)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Update error: Can't update in repo")
+	}
+
+	*r = *stored
+
+	return nil
+}
+
+// Delete removes a review of the viewer, admins can remove any review.
+func (rUC *reviewUseCase) Delete(id int, viewer Viewer) error {
+	stored, err := rUC.reviewRepository.Get(id)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Delete error: Review not found")
+	}
+
+	if stored.UserID != viewer.UserID && !viewer.Admin {
+		return errors.Wrapf(ErrNotAuthor, "reviewUseCase.Delete error: review %d", id)
+	}
+
+	err = rUC.reviewRepository.Delete(id)
+	if err != nil {
+		return errors.Wrap(err, "reviewUseCase.Delete error: Can't delete in repo")
+	}
+
+	return nil
+}
+
+// List pages through reviews, newest first unless sorted by helpfulness.
+// Users only get approved reviews plus their own; admins may filter by
+// any status, an empty status lists all of them.
+func (rUC *reviewUseCase) List(filter models.ReviewFilter, viewer Viewer) ([]models.Review, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = models.ReviewSortNewest
+	}
+
+	if filter.SortBy != models.ReviewSortNewest && filter.SortBy != models.ReviewSortHelpful {
+		return nil, errors.Wrapf(ErrInvalidFilter, "reviewUseCase.List error: unknown sort %q", filter.SortBy)
+	}
+
+	if filter.Status != "" && !validStatus(filter.Status) {
+		return nil, errors.Wrapf(ErrInvalidFilter, "reviewUseCase.List error: unknown status %q", filter.Status)
+	}
+
+	if !viewer.Admin {
+		filter.Status = models.ReviewApproved
+		filter.OrUserID = viewer.UserID
+	}
+
+	if filter.MovieID > 0 {
+		_, err := rUC.movieRepository.Get(filter.MovieID)
+		if err != nil {
+			return nil, errors.Wrap(err, "reviewUseCase.List error: Movie not found")
+		}
+	}
+
+	reviews, err := rUC.reviewRepository.List(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.List error")
+	}
+
+	return reviews, nil
+}
+
+// SetStatus moves a review through moderation; it is meant for admins.
+func (rUC *reviewUseCase) SetStatus(id int, status string) (*models.Review, error) {
+	if !validStatus(status) {
+		return nil, errors.Wrapf(ErrInvalidReview, "reviewUseCase.SetStatus error: unknown status %q", status)
+	}
+
+	stored, err := rUC.reviewRepository.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.SetStatus error: Review not found")
+	}
+
+	if stored.Status == status {
+		return stored, nil
+	}
+
+	stored.Status = status
+	stored.UpdatedAt = time.Now()
+
+	err = rUC.reviewRepository.Update(stored)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.SetStatus error: Can't update in repo")
+	}
+
+	return stored, nil
+}
+
+// Vote marks an approved review of another user as helpful or not,
+// replacing an earlier vote of the viewer.
+func (rUC *reviewUseCase) Vote(reviewID int, viewer Viewer, helpful bool) (*models.Review, error) {
+	err := rUC.checkVote(reviewID, viewer)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.Vote error")
+	}
+
+	review, err := rUC.reviewRepository.SetVote(reviewID, viewer.UserID, helpful)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.Vote error")
+	}
+
+	return review, nil
+}
+
+func (rUC *reviewUseCase) DeleteVote(reviewID int, viewer Viewer) (*models.Review, error) {
+	err := rUC.checkVote(reviewID, viewer)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.DeleteVote error")
+	}
+
+	review, err := rUC.reviewRepository.DeleteVote(reviewID, viewer.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "reviewUseCase.DeleteVote error")
+	}
+
+	return review, nil
+}
+
+// checkVote only lets votes on approved reviews through, whoever the viewer
+// is, and never on the own review.
+func (rUC *reviewUseCase) checkVote(reviewID int, viewer Viewer) error {
+	review, err := rUC.reviewRepository.Get(reviewID)
+	if err != nil {
+		return err
+	}
+
+	if review.Status != models.ReviewApproved {
+		return errors.Wrapf(gorm.ErrRecordNotFound, "review %d is %s", reviewID, review.Status)
+	}
+
+	if review.UserID == viewer.UserID {
+		return errors.Wrapf(ErrOwnReview, "review %d", reviewID)
+	}
+
+	return nil
+}
+
+func validate(r *models.Review) error {
+	r.Text = strings.TrimSpace(r.Text)
+
+	if r.Text == "" || utf8.RuneCountInString(r.Text) > models.MaxReviewLength {
+		return errors.Wrapf(ErrInvalidReview, "text must have 1 to %d characters", models.MaxReviewLength)
+	}
+
+	if r.Rating < models.MinRatingScore || r.Rating > models.MaxRatingScore {
+		return errors.Wrapf(ErrInvalidReview, "rating %d is not within %d..%d",
+			r.Rating, models.MinRatingScore, models.MaxRatingScore)
+	}
+
+	return nil
+}
+
+func validStatus(status string) bool {
+	switch status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+		return true
+	}
+
+	return false
+}
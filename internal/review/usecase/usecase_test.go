@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memReview "intern/internal/review/repository/memory"
+	memTrash "intern/internal/trash/repository/memory"
+	"intern/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var (
+	admin  = Viewer{UserID: 1, Admin: true}
+	author = Viewer{UserID: 2}
+	reader = Viewer{UserID: 3}
+)
+
+func newUseCase() (ReviewUseCaseI, *memdb.DB) {
+	db := memdb.New()
+	db.Movies[1] = models.Movie{ID: 1, Title: "Alien", ReleaseDate: time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC), Rating: 9}
+	db.SeenID("movies", 1)
+
+	return New(memReview.New(nil, db), memMovie.New(nil, db)), db
+}
+
+func create(t *testing.T, uc ReviewUseCaseI, userID int, status string) *models.Review {
+	review := &models.Review{MovieID: 1, UserID: userID, Text: "  In space no one can hear you scream ", Rating: 8}
+	require.NoError(t, uc.Create(review))
+
+	if status != models.ReviewPending {
+		_, err := uc.SetStatus(review.ID, status)
+		require.NoError(t, err)
+		review.Status = status
+	}
+
+	return review
+}
+
+func ids(reviews []models.Review) []int {
+	res := make([]int, len(reviews))
+	for i, r := range reviews {
+		res[i] = r.ID
+	}
+
+	return res
+}
+
+func TestCreate(t *testing.T) {
+	uc, _ := newUseCase()
+
+	review := create(t, uc, author.UserID, models.ReviewPending)
+	assert.Equal(t, "In space no one can hear you scream", review.Text)
+	assert.Equal(t, models.ReviewPending, review.Status)
+
+	err := uc.Create(&models.Review{MovieID: 1, UserID: author.UserID, Text: "Again", Rating: 5})
+	assert.True(t, errors.Is(err, ErrReviewExists), err)
+
+	err = uc.Create(&models.Review{MovieID: 42, UserID: author.UserID, Text: "Missing", Rating: 5})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	for _, r := range []models.Review{
+		{MovieID: 1, UserID: reader.UserID, Text: " ", Rating: 5},
+		{MovieID: 1, UserID: reader.UserID, Text: strings.Repeat("é", models.MaxReviewLength+1), Rating: 5},
+		{MovieID: 1, UserID: reader.UserID, Text: "Fine", Rating: 11},
+	} {
+		err = uc.Create(&r)
+		assert.True(t, errors.Is(err, ErrInvalidReview), err)
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	uc, _ := newUseCase()
+
+	pending := create(t, uc, author.UserID, models.ReviewPending)
+	approved := create(t, uc, 4, models.ReviewApproved)
+	rejected := create(t, uc, 5, models.ReviewRejected)
+
+	_, err := uc.Get(pending.ID, reader)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+	_, err = uc.Get(pending.ID, author)
+	assert.NoError(t, err)
+	_, err = uc.Get(rejected.ID, admin)
+	assert.NoError(t, err)
+
+	reviews, err := uc.List(models.ReviewFilter{MovieID: 1}, reader)
+	require.NoError(t, err)
+	assert.Equal(t, []int{approved.ID}, ids(reviews))
+
+	reviews, err = uc.List(models.ReviewFilter{MovieID: 1, Status: models.ReviewRejected}, author)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{pending.ID, approved.ID}, ids(reviews))
+
+	reviews, err = uc.List(models.ReviewFilter{Status: models.ReviewPending}, admin)
+	require.NoError(t, err)
+	assert.Equal(t, []int{pending.ID}, ids(reviews))
+
+	reviews, err = uc.List(models.ReviewFilter{Limit: 2}, admin)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	_, err = uc.List(models.ReviewFilter{Status: "hidden"}, admin)
+	assert.True(t, errors.Is(err, ErrInvalidFilter), err)
+	_, err = uc.List(models.ReviewFilter{SortBy: "rating"}, admin)
+	assert.True(t, errors.Is(err, ErrInvalidFilter), err)
+	_, err = uc.List(models.ReviewFilter{MovieID: 42}, reader)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	uc, db := newUseCase()
+	review := create(t, uc, author.UserID, models.ReviewApproved)
+
+	err := uc.Update(&models.Review{ID: review.ID, Text: "Changed my mind", Rating: 6}, reader)
+	assert.True(t, errors.Is(err, ErrNotAuthor), err)
+	err = uc.Update(&models.Review{ID: review.ID, Text: "Changed my mind", Rating: 6}, admin)
+	assert.True(t, errors.Is(err, ErrNotAuthor), err)
+
+	// An edit has to be moderated again.
+	updated := &models.Review{ID: review.ID, Text: "Changed my mind", Rating: 6}
+	require.NoError(t, uc.Update(updated, author))
+	assert.Equal(t, models.ReviewPending, updated.Status)
+	assert.Equal(t, author.UserID, updated.UserID)
+	assert.Equal(t, models.Review{
+		ID: review.ID, MovieID: 1, UserID: author.UserID, Text: "Changed my mind", Rating: 6,
+		Status: models.ReviewPending, CreatedAt: review.CreatedAt, UpdatedAt: updated.UpdatedAt,
+	}, db.Reviews[review.ID])
+
+	err = uc.Delete(review.ID, reader)
+	assert.True(t, errors.Is(err, ErrNotAuthor), err)
+	require.NoError(t, uc.Delete(review.ID, author))
+
+	other := create(t, uc, reader.UserID, models.ReviewPending)
+	require.NoError(t, uc.Delete(other.ID, admin))
+
+	err = uc.Delete(other.ID, admin)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+	assert.Empty(t, db.Reviews)
+}
+
+func TestSetStatus(t *testing.T) {
+	uc, _ := newUseCase()
+	review := create(t, uc, author.UserID, models.ReviewPending)
+
+	moderated, err := uc.SetStatus(review.ID, models.ReviewRejected)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReviewRejected, moderated.Status)
+
+	_, err = uc.SetStatus(review.ID, "hidden")
+	assert.True(t, errors.Is(err, ErrInvalidReview), err)
+
+	_, err = uc.SetStatus(42, models.ReviewApproved)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+func TestVotes(t *testing.T) {
+	uc, db := newUseCase()
+	pending := create(t, uc, 4, models.ReviewPending)
+	review := create(t, uc, author.UserID, models.ReviewApproved)
+
+	_, err := uc.Vote(pending.ID, reader, true)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+	_, err = uc.Vote(review.ID, author, true)
+	assert.True(t, errors.Is(err, ErrOwnReview), err)
+
+	voted, err := uc.Vote(review.ID, reader, true)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 0}, [2]int{voted.Helpful, voted.Unhelpful})
+
+	// Voting again replaces the vote instead of adding one.
+	voted, err = uc.Vote(review.ID, reader, false)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{0, 1}, [2]int{voted.Helpful, voted.Unhelpful})
+
+	voted, err = uc.Vote(review.ID, admin, true)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 1}, [2]int{voted.Helpful, voted.Unhelpful})
+
+	voted, err = uc.DeleteVote(review.ID, reader)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 0}, [2]int{voted.Helpful, voted.Unhelpful})
+
+	_, err = uc.DeleteVote(review.ID, reader)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	// The most helpful reviews come first.
+	other := create(t, uc, 5, models.ReviewApproved)
+	reviews, err := uc.List(models.ReviewFilter{MovieID: 1, SortBy: models.ReviewSortHelpful}, reader)
+	require.NoError(t, err)
+	assert.Equal(t, []int{review.ID, other.ID}, ids(reviews))
+
+	// Purging the movie from the trash takes its reviews and their votes
+	// along.
+	require.NoError(t, memMovie.New(nil, db).Delete(1))
+	_, err = memTrash.New(nil, db).Purge(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, db.Reviews)
+	assert.Empty(t, db.ReviewVotes)
+}
@@ -0,0 +1,96 @@
+package seed
+
+import (
+	"encoding/csv"
+	"intern/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WriteCSV stores the dataset in dir using the ';'-separated build/data
+// layout. users.csv gets bcrypt hashes; the plain-text passwords go to
+// credentials.csv, see WriteCredentials. The repository ignores
+// credentials.csv, run `main seed -out build/data` to get it.
+func WriteCSV(dir string, ds *Dataset, hashCost int) error {
+	hashes, err := HashPasswords(ds.Users, hashCost)
+	if err != nil {
+		return errors.Wrap(err, "seed.WriteCSV error")
+	}
+
+	files := map[string][][]string{}
+
+	for i, u := range ds.Users {
+		files["users.csv"] = append(files["users.csv"], []string{strconv.Itoa(u.ID), u.Login, hashes[i], u.Role})
+	}
+
+	for _, a := range ds.Actors {
+		files["actors.csv"] = append(files["actors.csv"], []string{
+			strconv.Itoa(a.ID), a.FirstName, a.LastName, string(a.Gender), a.Birthday.Format(time.DateOnly),
+		})
+	}
+
+	for _, m := range ds.Movies {
+		files["movies.csv"] = append(files["movies.csv"], []string{
+			strconv.Itoa(m.ID), m.Title, m.Description, m.ReleaseDate.Format(time.DateOnly), strconv.Itoa(m.Rating),
+		})
+	}
+
+	for _, ma := range ds.MoviesActors {
+		files["moviesActors.csv"] = append(files["moviesActors.csv"], []string{
+			strconv.Itoa(ma.ID), strconv.Itoa(ma.MovieID), strconv.Itoa(ma.ActorID),
+		})
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrap(err, "seed.WriteCSV error")
+	}
+
+	for name, records := range files {
+		if err := writeFile(filepath.Join(dir, name), records, 0o644); err != nil {
+			return errors.Wrapf(err, "seed.WriteCSV error while writing %s", name)
+		}
+	}
+
+	if err := WriteCredentials(filepath.Join(dir, "credentials.csv"), ds.Users); err != nil {
+		return errors.Wrap(err, "seed.WriteCSV error")
+	}
+
+	return nil
+}
+
+// WriteCredentials stores the logins, plain-text passwords and roles of
+// users at path, readable by the owner only, so that the generated accounts
+// can be used locally.
+func WriteCredentials(path string, users []models.User) error {
+	records := make([][]string, len(users))
+	for i, u := range users {
+		records[i] = []string{u.Login, u.Password, u.Role}
+	}
+
+	if err := writeFile(path, records, 0o600); err != nil {
+		return errors.Wrapf(err, "seed.WriteCredentials error while writing %s", path)
+	}
+
+	return nil
+}
+
+func writeFile(path string, records [][]string, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+
+	if err := w.WriteAll(records); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,195 @@
+package seed
+
+import (
+	crand "crypto/rand"
+	"fmt"
+	"intern/models"
+	"math/big"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const passwordAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!*+_"
+
+// minAgeAtRelease keeps toddlers out of the cast lists.
+const minAgeAtRelease = 6
+
+type Config struct {
+	Seed   int64
+	Users  int
+	Actors int
+	Movies int
+	Links  int
+}
+
+// Dataset is a generated catalogue. IDs are 1-based positions in the
+// slices; MoviesActors refer to them. Users carry plain-text passwords,
+// hashing happens when the dataset is written out. The passwords come from
+// crypto/rand rather than the seed: seeds are public, the default above
+// all, and would give the accounts away.
+type Dataset struct {
+	Users        []models.User
+	Actors       []models.Actor
+	Movies       []models.Movie
+	MoviesActors []models.MovieActor
+}
+
+// Generate builds a dataset that depends only on cfg: the same seed and
+// counts always yield the same rows, all but the passwords.
+func Generate(cfg Config) (*Dataset, error) {
+	if cfg.Users < 0 || cfg.Actors < 0 || cfg.Movies < 0 || cfg.Links < 0 {
+		return nil, errors.New("seed.Generate error: counts must not be negative")
+	}
+
+	if cfg.Links > 0 && (cfg.Actors == 0 || cfg.Movies == 0) {
+		return nil, errors.New("seed.Generate error: links need at least one actor and one movie")
+	}
+
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+
+	ds := &Dataset{
+		Users:  generateUsers(rnd, cfg.Users),
+		Actors: generateActors(rnd, cfg.Actors),
+		Movies: generateMovies(rnd, cfg.Movies),
+	}
+
+	links, err := generateLinks(rnd, cfg.Links, ds.Actors, ds.Movies)
+	if err != nil {
+		return nil, err
+	}
+	ds.MoviesActors = links
+
+	for i := range ds.Users {
+		ds.Users[i].Password, err = password(10)
+		if err != nil {
+			return nil, errors.Wrap(err, "seed.Generate error: can't draw password")
+		}
+	}
+
+	return ds, nil
+}
+
+func generateUsers(rnd *rand.Rand, n int) []models.User {
+	users := make([]models.User, n)
+
+	for i := range users {
+		gender := pickGender(rnd)
+		first := pick(rnd, firstNames[gender])
+		last := pick(rnd, lastNames)
+
+		role := "user"
+		if i == 0 || rnd.Intn(10) == 0 {
+			role = "admin"
+		}
+
+		users[i] = models.User{
+			ID:    i + 1,
+			Login: fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1),
+			Role:  role,
+		}
+	}
+
+	return users
+}
+
+func generateActors(rnd *rand.Rand, n int) []models.Actor {
+	actors := make([]models.Actor, n)
+
+	for i := range actors {
+		gender := pickGender(rnd)
+
+		actors[i] = models.Actor{
+			ID:        i + 1,
+			FirstName: pick(rnd, firstNames[gender]),
+			LastName:  pick(rnd, lastNames),
+			Gender:    gender,
+			Birthday:  date(rnd, 1930, 2005),
+		}
+	}
+
+	return actors
+}
+
+func generateMovies(rnd *rand.Rand, n int) []models.Movie {
+	movies := make([]models.Movie, n)
+
+	for i := range movies {
+		title := fmt.Sprintf(pick(rnd, titlePatterns),
+			pick(rnd, titleAdjectives), pick(rnd, titleNouns), pick(rnd, places), pick(rnd, titleNouns))
+
+		description := strings.TrimSpace(fmt.Sprintf("%s %s %s. %s",
+			pick(rnd, descriptionSubjects), pick(rnd, descriptionActions), pick(rnd, descriptionObjects),
+			pick(rnd, descriptionEndings)))
+
+		movies[i] = models.Movie{
+			ID:          i + 1,
+			Title:       title,
+			Description: description,
+			ReleaseDate: date(rnd, 1950, 2024),
+			Rating:      rnd.Intn(11),
+		}
+	}
+
+	return movies
+}
+
+// generateLinks casts random actors into random movies. A link is only
+// kept if the actor was born at least minAgeAtRelease years before the
+// release and the pair is not cast yet.
+func generateLinks(rnd *rand.Rand, n int, actors []models.Actor, movies []models.Movie) ([]models.MovieActor, error) {
+	links := make([]models.MovieActor, 0, n)
+	seen := make(map[[2]int]bool, n)
+
+	for attempts := 0; len(links) < n; attempts++ {
+		if attempts >= n*50 {
+			return nil, errors.Errorf("seed.Generate error: only %d of %d links fit the actors and movies", len(links), n)
+		}
+
+		m := movies[rnd.Intn(len(movies))]
+		a := actors[rnd.Intn(len(actors))]
+
+		if a.Birthday.AddDate(minAgeAtRelease, 0, 0).After(m.ReleaseDate) || seen[[2]int{m.ID, a.ID}] {
+			continue
+		}
+		seen[[2]int{m.ID, a.ID}] = true
+
+		links = append(links, models.MovieActor{ID: len(links) + 1, MovieID: m.ID, ActorID: a.ID})
+	}
+
+	return links, nil
+}
+
+func pick[T any](rnd *rand.Rand, items []T) T {
+	return items[rnd.Intn(len(items))]
+}
+
+func pickGender(rnd *rand.Rand) byte {
+	if rnd.Intn(2) == 0 {
+		return 'm'
+	}
+
+	return 'f'
+}
+
+func date(rnd *rand.Rand, fromYear, toYear int) time.Time {
+	from := time.Date(fromYear, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := int(time.Date(toYear+1, 1, 1, 0, 0, 0, 0, time.UTC).Sub(from).Hours() / 24)
+
+	return from.AddDate(0, 0, rnd.Intn(days))
+}
+
+func password(length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := crand.Int(crand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = passwordAlphabet[n.Int64()]
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,77 @@
+package seed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = Config{Seed: 42, Users: 50, Actors: 100, Movies: 60, Links: 400}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	first, err := Generate(testConfig)
+	require.NoError(t, err)
+
+	second, err := Generate(testConfig)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Users[0].Password, second.Users[0].Password)
+
+	for i := range first.Users {
+		assert.Len(t, first.Users[i].Password, 10)
+		first.Users[i].Password, second.Users[i].Password = "", ""
+	}
+	assert.Equal(t, first, second)
+
+	other := testConfig
+	other.Seed++
+	third, err := Generate(other)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Movies, third.Movies)
+}
+
+func TestGenerateCounts(t *testing.T) {
+	ds, err := Generate(testConfig)
+	require.NoError(t, err)
+
+	assert.Len(t, ds.Users, testConfig.Users)
+	assert.Len(t, ds.Actors, testConfig.Actors)
+	assert.Len(t, ds.Movies, testConfig.Movies)
+	assert.Len(t, ds.MoviesActors, testConfig.Links)
+	assert.Equal(t, "admin", ds.Users[0].Role)
+
+	logins := map[string]bool{}
+	for _, u := range ds.Users {
+		assert.False(t, logins[u.Login], "duplicate login %s", u.Login)
+		logins[u.Login] = true
+	}
+}
+
+func TestGenerateLinks(t *testing.T) {
+	ds, err := Generate(testConfig)
+	require.NoError(t, err)
+
+	seen := map[[2]int]bool{}
+	for _, ma := range ds.MoviesActors {
+		pair := [2]int{ma.MovieID, ma.ActorID}
+		assert.False(t, seen[pair], "duplicate link %v", pair)
+		seen[pair] = true
+
+		actor := ds.Actors[ma.ActorID-1]
+		movie := ds.Movies[ma.MovieID-1]
+		assert.False(t, actor.Birthday.AddDate(minAgeAtRelease, 0, 0).After(movie.ReleaseDate),
+			"actor %d is too young for movie %d", actor.ID, movie.ID)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate(Config{Users: -1})
+	assert.Error(t, err)
+
+	_, err = Generate(Config{Links: 1})
+	assert.Error(t, err)
+
+	// Two pairs at most, so three distinct links can never be found.
+	_, err = Generate(Config{Actors: 1, Movies: 2, Links: 3})
+	assert.Error(t, err)
+}
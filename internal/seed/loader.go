@@ -0,0 +1,161 @@
+package seed
+
+import (
+	"fmt"
+	pgEvent "intern/internal/event/repository/postgres"
+	"intern/models"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const truncateQuery = `TRUNCATE movies_actors, movies, actors, users RESTART IDENTITY CASCADE`
+
+type LoadOptions struct {
+	BatchSize int
+	// Truncate empties the catalogue and user tables before loading.
+	Truncate bool
+	HashCost int
+}
+
+// Load inserts the dataset in one transaction using multi-row INSERTs of
+// BatchSize rows. Database identities are used for the new rows and the
+// cast links are remapped onto them. It is a bulk load: one event lists
+// the movies and actors loaded.
+func Load(db *gorm.DB, ds *Dataset, opts LoadOptions) error {
+	if opts.BatchSize <= 0 {
+		return errors.New("seed.Load error: batch size must be positive")
+	}
+
+	hashes, err := HashPasswords(ds.Users, opts.HashCost)
+	if err != nil {
+		return errors.Wrap(err, "seed.Load error")
+	}
+
+	err = pgEvent.BulkLoad(db, models.BulkSourceSeed, func(tx *gorm.DB, e *models.BulkEvent) error {
+		if opts.Truncate {
+			if err := tx.Exec(truncateQuery).Error; err != nil {
+				return errors.Wrap(err, "can`t truncate tables")
+			}
+		}
+
+		_, err := insertBatches(tx, "users (login, password, user_role)", len(ds.Users), opts.BatchSize,
+			func(i int) []interface{} {
+				return []interface{}{ds.Users[i].Login, hashes[i], ds.Users[i].Role}
+			})
+		if err != nil {
+			return errors.Wrap(err, "can`t insert users")
+		}
+
+		actorIDs, err := insertBatches(tx, "actors (first_name, last_name, gender, birthday)", len(ds.Actors), opts.BatchSize,
+			func(i int) []interface{} {
+				a := ds.Actors[i]
+				return []interface{}{a.FirstName, a.LastName, string(a.Gender), a.Birthday.Format(time.DateOnly)}
+			})
+		if err != nil {
+			return errors.Wrap(err, "can`t insert actors")
+		}
+
+		movieIDs, err := insertBatches(tx, "movies (title, description, release_date, rating)", len(ds.Movies), opts.BatchSize,
+			func(i int) []interface{} {
+				m := ds.Movies[i]
+				return []interface{}{m.Title, m.Description, m.ReleaseDate.Format(time.DateOnly), m.Rating}
+			})
+		if err != nil {
+			return errors.Wrap(err, "can`t insert movies")
+		}
+
+		_, err = insertBatches(tx, "movies_actors (movie_id, actor_id)", len(ds.MoviesActors), opts.BatchSize,
+			func(i int) []interface{} {
+				ma := ds.MoviesActors[i]
+				return []interface{}{movieIDs[ma.MovieID-1], actorIDs[ma.ActorID-1]}
+			})
+		if err != nil {
+			return errors.Wrap(err, "can`t insert movies_actors")
+		}
+
+		e.Movies, e.Actors = movieIDs, actorIDs
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "seed.Load error")
+	}
+
+	return nil
+}
+
+// insertBatches inserts n rows into target ("table (columns)") and returns
+// the generated ids in row order.
+func insertBatches(tx *gorm.DB, target string, n, batchSize int, row func(i int) []interface{}) ([]int, error) {
+	ids := make([]int, 0, n)
+
+	for start := 0; start < n; start += batchSize {
+		end := min(start+batchSize, n)
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*4)
+
+		for i := start; i < end; i++ {
+			fields := row(i)
+			values = append(values, "("+strings.TrimSuffix(strings.Repeat("?, ", len(fields)), ", ")+")")
+			args = append(args, fields...)
+		}
+
+		var batchIDs []int
+		query := fmt.Sprintf("INSERT INTO %s VALUES %s RETURNING id", target, strings.Join(values, ", "))
+
+		if err := tx.Raw(query, args...).Scan(&batchIDs).Error; err != nil {
+			return nil, err
+		}
+
+		if len(batchIDs) != end-start {
+			return nil, errors.Errorf("inserted %d rows, got %d ids", end-start, len(batchIDs))
+		}
+
+		ids = append(ids, batchIDs...)
+	}
+
+	return ids, nil
+}
+
+// HashPasswords bcrypt-hashes the users' plain-text passwords in parallel,
+// keeping the order of users.
+func HashPasswords(users []models.User, cost int) ([]string, error) {
+	hashes := make([]string, len(users))
+	errs := make([]error, len(users))
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range next {
+				hash, err := bcrypt.GenerateFromPassword([]byte(users[i].Password), cost)
+				hashes[i], errs[i] = string(hash), err
+			}
+		}()
+	}
+
+	for i := range users {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "can`t hash password of %s", users[i].Login)
+		}
+	}
+
+	return hashes, nil
+}
@@ -0,0 +1,70 @@
+package seed
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	ds, err := Generate(Config{Seed: 1, Users: 1, Actors: 3, Movies: 1, Links: 1})
+	require.NoError(t, err)
+	// Make sure the only link can be cast whatever the generated dates are.
+	ds.Actors[0].Birthday = ds.Movies[0].ReleaseDate.AddDate(-30, 0, 0)
+	ds.MoviesActors[0].ActorID = 1
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SET LOCAL intern.bulk_load = on`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(truncateQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO users (login, password, user_role) VALUES ($1, $2, $3) RETURNING id`)).
+		WithArgs(ds.Users[0].Login, sqlmock.AnyArg(), "admin").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO actors (first_name, last_name, gender, birthday) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8) RETURNING id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21).AddRow(22))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO actors (first_name, last_name, gender, birthday) VALUES ($1, $2, $3, $4) RETURNING id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(23))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO movies (title, description, release_date, rating) VALUES ($1, $2, $3, $4) RETURNING id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(31))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO movies_actors (movie_id, actor_id) VALUES ($1, $2) RETURNING id`)).
+		WithArgs(31, 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(41))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO outbox_events (type, payload) VALUES ($1, $2)`)).
+		WithArgs("catalogue.loaded", `{"source":"seed","movies":[31],"actors":[21,22,23]}`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = Load(gormDB, ds, LoadOptions{BatchSize: 2, Truncate: true, HashCost: bcrypt.MinCost})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHashPasswords(t *testing.T) {
+	ds, err := Generate(Config{Seed: 3, Users: 4})
+	require.NoError(t, err)
+
+	hashes, err := HashPasswords(ds.Users, bcrypt.MinCost)
+	require.NoError(t, err)
+	require.Len(t, hashes, len(ds.Users))
+
+	for i, u := range ds.Users {
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashes[i]), []byte(u.Password)))
+	}
+}
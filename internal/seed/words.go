@@ -0,0 +1,70 @@
+package seed
+
+var firstNames = map[byte][]string{
+	'm': {
+		"James", "John", "Robert", "Michael", "William", "David", "Richard", "Joseph", "Thomas", "Charles",
+		"Daniel", "Matthew", "Anthony", "Mark", "Steven", "Paul", "Andrew", "Joshua", "Kevin", "Brian",
+		"George", "Edward", "Ronald", "Timothy", "Jason", "Jeffrey", "Ryan", "Jacob", "Gary", "Nicholas",
+		"Eric", "Jonathan", "Stephen", "Larry", "Justin", "Scott", "Brandon", "Frank", "Benjamin", "Gregory",
+	},
+	'f': {
+		"Mary", "Patricia", "Jennifer", "Linda", "Elizabeth", "Barbara", "Susan", "Jessica", "Sarah", "Karen",
+		"Lisa", "Nancy", "Betty", "Margaret", "Sandra", "Ashley", "Kimberly", "Emily", "Donna", "Michelle",
+		"Carol", "Amanda", "Dorothy", "Melissa", "Deborah", "Stephanie", "Rebecca", "Sharon", "Laura", "Cynthia",
+		"Kathleen", "Amy", "Angela", "Shirley", "Anna", "Brenda", "Pamela", "Emma", "Nicole", "Helen",
+	},
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez",
+	"Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin",
+	"Lee", "Perez", "Thompson", "White", "Harris", "Sanchez", "Clark", "Ramirez", "Lewis", "Robinson",
+	"Walker", "Young", "Allen", "King", "Wright", "Scott", "Torres", "Nguyen", "Hill", "Flores",
+	"Green", "Adams", "Nelson", "Baker", "Hall", "Rivera", "Campbell", "Mitchell", "Carter", "Roberts",
+	"Mueller", "Higgins", "Townsend", "Fischer", "Weber", "Novak", "Kowalski", "Ivanov", "Rossi", "Dubois",
+}
+
+var titleAdjectives = []string{
+	"Silent", "Broken", "Golden", "Last", "Hidden", "Dark", "Endless", "Lost", "Crimson", "Frozen",
+	"Wild", "Secret", "Burning", "Forgotten", "Eternal", "Midnight", "Distant", "Savage", "Quiet", "Final",
+}
+
+var titleNouns = []string{
+	"River", "Empire", "Night", "Summer", "Kingdom", "Horizon", "Storm", "Garden", "Road", "Island",
+	"Promise", "Shadow", "City", "Heart", "Winter", "Frontier", "Mirror", "Harbor", "Voyage", "Legacy",
+}
+
+var titlePatterns = []string{
+	"The %[1]s %[2]s",
+	"%[1]s %[2]s",
+	"A %[2]s in %[3]s",
+	"Return to the %[2]s",
+	"%[2]s of the %[1]s %[4]s",
+	"Beyond the %[2]s",
+}
+
+var places = []string{
+	"Paris", "Berlin", "Tokyo", "Moscow", "Chicago", "Cairo", "Lisbon", "Havana", "Vienna", "Seoul",
+}
+
+var descriptionSubjects = []string{
+	"A retired detective", "Two estranged brothers", "A young nurse", "A disgraced pilot", "An ambitious journalist",
+	"A small-town teacher", "A team of scientists", "A stubborn farmer", "A runaway heiress", "An aging boxer",
+}
+
+var descriptionActions = []string{
+	"is drawn into", "must survive", "uncovers", "tries to escape", "sets out to stop",
+	"falls in love during", "stumbles upon", "fights to expose", "is haunted by", "races against",
+}
+
+var descriptionObjects = []string{
+	"a conspiracy that reaches the highest office", "a brutal winter in the mountains", "a decades-old family secret",
+	"a heist gone wrong", "the collapse of a once-great city", "an invasion nobody saw coming",
+	"a dangerous game of lies", "a mysterious signal from deep space", "the war that divided their village",
+	"a storm that changes everything",
+}
+
+var descriptionEndings = []string{
+	"Nothing will ever be the same.", "Time is running out.", "Trust no one.",
+	"Based on a true story.", "Some secrets should stay buried.", "",
+}
@@ -0,0 +1,165 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	statsUseCase "intern/internal/stats/usecase"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+)
+
+type StatsHandler struct {
+	StatsUseCase statsUseCase.StatsUseCaseI
+	Logger       logger.Logger
+}
+
+// MoviesPerYear godoc
+// @Summary      Movies per release year
+// @Description  Number of movies, average editorial rating and average user rating per release year.
+// @Description  Live or as of the last refresh, see STATS_REFRESH_INTERVAL.
+// @Tags     stats
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Success 200 {object} []models.MoviesPerYear "success get stats"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /stats/movies/years [get]
+func (sh *StatsHandler) MoviesPerYear(w http.ResponseWriter, r *http.Request) {
+	years, err := sh.StatsUseCase.MoviesPerYear()
+	if err != nil {
+		sh.Logger.Errorw("can`t get movies per year",
+			"err:", err.Error())
+		http.Error(w, "can`t get stats", http.StatusInternalServerError)
+		return
+	}
+
+	sh.write(w, years)
+}
+
+// ProlificActors godoc
+// @Summary      Most prolific actors
+// @Description  Actors by the number of movies they played in, most first
+// @Tags     stats
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.ActorListItem "success get stats"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /stats/actors/prolific [get]
+func (sh *StatsHandler) ProlificActors(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		sh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	actors, err := sh.StatsUseCase.ProlificActors(page.Limit, page.Offset)
+	if err != nil {
+		sh.Logger.Errorw("can`t get prolific actors",
+			"err:", err.Error())
+		http.Error(w, "can`t get stats", http.StatusInternalServerError)
+		return
+	}
+
+	sh.write(w, actors)
+}
+
+// CastGenders godoc
+// @Summary      Gender distribution of casts
+// @Description  Per gender the actors with at least one movie and their cast links
+// @Tags     stats
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Success 200 {object} []models.CastGender "success get stats"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /stats/cast/genders [get]
+func (sh *StatsHandler) CastGenders(w http.ResponseWriter, r *http.Request) {
+	genders, err := sh.StatsUseCase.CastGenders()
+	if err != nil {
+		sh.Logger.Errorw("can`t get cast genders",
+			"err:", err.Error())
+		http.Error(w, "can`t get stats", http.StatusInternalServerError)
+		return
+	}
+
+	sh.write(w, genders)
+}
+
+// CastAges godoc
+// @Summary      Age of actors at release
+// @Description  Age in full years of the actors when their movies were released, over all cast links,
+// @Description  with the number of links per age bucket.
+// @Tags     stats
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param bucket query int false "bucket width in years, 1 to 100, default 10"
+// @Success 200 {object} models.CastAgeStats "success get stats"
+// @Failure 400 {object} nil "invalid bucket"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /stats/cast/ages [get]
+func (sh *StatsHandler) CastAges(w http.ResponseWriter, r *http.Request) {
+	bucket := statsUseCase.DefaultAgeBucket
+
+	if bucketString := r.FormValue("bucket"); bucketString != "" {
+		var err error
+		bucket, err = strconv.Atoi(bucketString)
+		if err != nil {
+			sh.Logger.Infow("can`t parse bucket",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := sh.StatsUseCase.CastAges(bucket)
+	switch {
+	case errors.Is(err, statsUseCase.ErrInvalidBucket):
+		sh.Logger.Infow("can`t get cast ages",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case err != nil:
+		sh.Logger.Errorw("can`t get cast ages",
+			"err:", err.Error())
+		http.Error(w, "can`t get stats", http.StatusInternalServerError)
+		return
+	}
+
+	sh.write(w, stats)
+}
+
+func (sh *StatsHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		sh.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		sh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/stats/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+)
+
+type memStatsRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.StatsRepositoryI {
+	return &memStatsRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (sr *memStatsRepo) MoviesPerYear() ([]models.MoviesPerYear, error) {
+	sr.DB.RLock()
+	defer sr.DB.RUnlock()
+
+	type totals struct {
+		movies, ratingSum, votes, scoreSum int
+	}
+
+	byYear := make(map[int]*totals)
+	for _, m := range sr.DB.Movies {
+		t, ok := byYear[m.ReleaseDate.Year()]
+		if !ok {
+			t = &totals{}
+			byYear[m.ReleaseDate.Year()] = t
+		}
+
+		t.movies++
+		t.ratingSum += m.Rating
+		t.votes += m.Votes
+		t.scoreSum += m.ScoreSum
+	}
+
+	years := make([]models.MoviesPerYear, 0, len(byYear))
+	for year, t := range byYear {
+		stat := models.MoviesPerYear{
+			Year:          year,
+			Movies:        t.movies,
+			AverageRating: float64(t.ratingSum) / float64(t.movies),
+			Votes:         t.votes,
+		}
+		if t.votes > 0 {
+			stat.AverageUserRating = float64(t.scoreSum) / float64(t.votes)
+		}
+
+		years = append(years, stat)
+	}
+
+	slices.SortFunc(years, func(a, b models.MoviesPerYear) int { return cmp.Compare(a.Year, b.Year) })
+
+	return years, nil
+}
+
+func (sr *memStatsRepo) ProlificActors(limit, offset int) ([]models.ActorListItem, error) {
+	sr.DB.RLock()
+	defer sr.DB.RUnlock()
+
+	counts := make(map[int]int)
+	for link := range sr.credits() {
+		counts[link[1]]++
+	}
+
+	actors := make([]models.ActorListItem, 0, len(counts))
+	for id, movies := range counts {
+		if a, ok := sr.DB.Actors[id]; ok {
+			actors = append(actors, models.ActorListItem{Actor: a, MoviesCount: movies})
+		}
+	}
+
+	slices.SortFunc(actors, func(a, b models.ActorListItem) int {
+		return cmp.Or(cmp.Compare(b.MoviesCount, a.MoviesCount), cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(actors, limit, offset), nil
+}
+
+func (sr *memStatsRepo) CastGenders() ([]models.CastGender, error) {
+	sr.DB.RLock()
+	defer sr.DB.RUnlock()
+
+	byGender := make(map[string]*models.CastGender)
+	seen := make(map[int]bool)
+
+	for link := range sr.credits() {
+		a, ok := sr.DB.Actors[link[1]]
+		if !ok {
+			continue
+		}
+
+		g, ok := byGender[string(a.Gender)]
+		if !ok {
+			g = &models.CastGender{Gender: string(a.Gender)}
+			byGender[g.Gender] = g
+		}
+
+		g.Credits++
+		if !seen[a.ID] {
+			seen[a.ID] = true
+			g.Actors++
+		}
+	}
+
+	genders := make([]models.CastGender, 0, len(byGender))
+	for _, g := range byGender {
+		genders = append(genders, *g)
+	}
+
+	slices.SortFunc(genders, func(a, b models.CastGender) int { return cmp.Compare(a.Gender, b.Gender) })
+
+	return genders, nil
+}
+
+func (sr *memStatsRepo) CastAges() ([]models.CastAge, error) {
+	sr.DB.RLock()
+	defer sr.DB.RUnlock()
+
+	counts := make(map[int]int)
+	for link := range sr.credits() {
+		m, okMovie := sr.DB.Movies[link[0]]
+		a, okActor := sr.DB.Actors[link[1]]
+		if !okMovie || !okActor || m.ReleaseDate.Before(a.Birthday) {
+			continue
+		}
+
+		counts[ageAt(a.Birthday, m.ReleaseDate)]++
+	}
+
+	ages := make([]models.CastAge, 0, len(counts))
+	for age, credits := range counts {
+		ages = append(ages, models.CastAge{Age: age, Credits: credits})
+	}
+
+	slices.SortFunc(ages, func(a, b models.CastAge) int { return cmp.Compare(a.Age, b.Age) })
+
+	return ages, nil
+}
+
+func (sr *memStatsRepo) Refresh() error {
+	return nil
+}
+
+// credits is the set of distinct movie and actor pairs, a cast link may be
+// stored twice. Links of the trash are left out.
+func (sr *memStatsRepo) credits() map[[2]int]bool {
+	links := make(map[[2]int]bool, len(sr.DB.MoviesActors))
+	for _, ma := range sr.DB.MoviesActors {
+		_, okMovie := sr.DB.Movies[ma.MovieID]
+		_, okActor := sr.DB.Actors[ma.ActorID]
+		if !okMovie || !okActor {
+			continue
+		}
+
+		links[[2]int{ma.MovieID, ma.ActorID}] = true
+	}
+
+	return links
+}
+
+// ageAt is the age in full years on a date, like EXTRACT(YEAR FROM age(on,
+// birthday)) in Postgres.
+func ageAt(birthday, on time.Time) int {
+	age := on.Year() - birthday.Year()
+	if on.Month() < birthday.Month() || on.Month() == birthday.Month() && on.Day() < birthday.Day() {
+		age--
+	}
+
+	return age
+}
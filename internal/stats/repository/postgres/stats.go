@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/stats/repository"
+	"intern/models"
+	"intern/pkg/logger"
+)
+
+// The live aggregations, leaving the trash out. Migration 0014 stores the
+// same queries as materialized views, keep both in step.
+const (
+	moviesPerYearQuery = `SELECT EXTRACT(YEAR FROM release_date)::int AS year,
+count(*) AS movies,
+COALESCE(avg(rating), 0)::double precision AS average_rating,
+COALESCE(sum(votes), 0)::int AS votes,
+COALESCE(sum(score_sum)::double precision / NULLIF(sum(votes), 0), 0) AS average_user_rating
+FROM movies
+WHERE deleted_at IS NULL
+GROUP BY 1`
+
+	actorMoviesQuery = `SELECT ma.actor_id, count(DISTINCT ma.movie_id)::int AS movies_count
+FROM movies_actors ma
+JOIN movies m ON m.id = ma.movie_id
+WHERE m.deleted_at IS NULL
+GROUP BY ma.actor_id`
+
+	castGendersQuery = `SELECT a.gender, count(DISTINCT a.id)::int AS actors, count(*)::int AS credits
+FROM (SELECT DISTINCT movie_id, actor_id FROM movies_actors) ma
+JOIN movies m ON m.id = ma.movie_id
+JOIN actors a ON a.id = ma.actor_id
+WHERE m.deleted_at IS NULL AND a.deleted_at IS NULL
+GROUP BY a.gender`
+
+	castAgesQuery = `SELECT EXTRACT(YEAR FROM age(m.release_date, a.birthday))::int AS age, count(*)::int AS credits
+FROM (SELECT DISTINCT movie_id, actor_id FROM movies_actors) ma
+JOIN movies m ON m.id = ma.movie_id
+JOIN actors a ON a.id = ma.actor_id
+WHERE m.release_date >= a.birthday AND m.deleted_at IS NULL AND a.deleted_at IS NULL
+GROUP BY 1`
+)
+
+var views = []string{"stats_movies_per_year", "stats_actor_movies", "stats_cast_genders", "stats_cast_ages"}
+
+type pgStatsRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+	// Materialized reads the views of migration 0014 instead of
+	// aggregating, they are as fresh as the last Refresh.
+	Materialized bool
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.StatsRepositoryI {
+	return &pgStatsRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func NewMaterialized(logger logger.Logger, db *gorm.DB) repository.StatsRepositoryI {
+	return &pgStatsRepo{
+		Logger:       logger,
+		DB:           db,
+		Materialized: true,
+	}
+}
+
+// from is the source of a statistic aliased as s: its view or its query.
+func (sr *pgStatsRepo) from(view, query string) string {
+	if sr.Materialized {
+		return view + " s"
+	}
+
+	return "(" + query + ") s"
+}
+
+func (sr *pgStatsRepo) MoviesPerYear() ([]models.MoviesPerYear, error) {
+	years := []models.MoviesPerYear{}
+	tx := sr.DB.Raw("SELECT s.* FROM " + sr.from("stats_movies_per_year", moviesPerYearQuery) + " ORDER BY s.year").Scan(&years)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgStatsRepo.MoviesPerYear error")
+	}
+
+	return years, nil
+}
+
+func (sr *pgStatsRepo) ProlificActors(limit, offset int) ([]models.ActorListItem, error) {
+	actors := []models.ActorListItem{}
+	tx := sr.DB.Raw("SELECT a.*, s.movies_count FROM "+sr.from("stats_actor_movies", actorMoviesQuery)+
+		" JOIN actors a ON a.id = s.actor_id WHERE a.deleted_at IS NULL ORDER BY s.movies_count DESC, a.id LIMIT ? OFFSET ?", limit, offset).
+		Scan(&actors)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgStatsRepo.ProlificActors error")
+	}
+
+	return actors, nil
+}
+
+func (sr *pgStatsRepo) CastGenders() ([]models.CastGender, error) {
+	genders := []models.CastGender{}
+	tx := sr.DB.Raw("SELECT s.* FROM " + sr.from("stats_cast_genders", castGendersQuery) + " ORDER BY s.gender").Scan(&genders)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgStatsRepo.CastGenders error")
+	}
+
+	return genders, nil
+}
+
+func (sr *pgStatsRepo) CastAges() ([]models.CastAge, error) {
+	ages := []models.CastAge{}
+	tx := sr.DB.Raw("SELECT s.* FROM " + sr.from("stats_cast_ages", castAgesQuery) + " ORDER BY s.age").Scan(&ages)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgStatsRepo.CastAges error")
+	}
+
+	return ages, nil
+}
+
+// Refresh recomputes the views one by one; concurrently, so the readers
+// keep the previous snapshot meanwhile.
+func (sr *pgStatsRepo) Refresh() error {
+	if !sr.Materialized {
+		return nil
+	}
+
+	for _, view := range views {
+		if err := sr.DB.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + view).Error; err != nil {
+			return errors.Wrapf(err, "pgStatsRepo.Refresh error: %s", view)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	statsRep "intern/internal/stats/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+)
+
+type StatsRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   statsRep.StatsRepositoryI
+}
+
+func TestStatsRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(StatsRepoTestSuite))
+}
+
+func (s *StatsRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *StatsRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *StatsRepoTestSuite) TestMoviesPerYear(t provider.T) {
+	rows := sqlmock.NewRows([]string{"year", "movies", "average_rating", "votes", "average_user_rating"}).
+		AddRow(1999, 2, 8.5, 3, 8.75)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.* FROM (` + moviesPerYearQuery + `) s ORDER BY s.year`)).
+		WillReturnRows(rows)
+
+	years, err := s.repo.MoviesPerYear()
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.MoviesPerYear{{Year: 1999, Movies: 2, AverageRating: 8.5, Votes: 3, AverageUserRating: 8.75}}, years)
+}
+
+func (s *StatsRepoTestSuite) TestProlificActors(t provider.T) {
+	rows := sqlmock.NewRows([]string{"id", "first_name", "last_name", "gender", "movies_count"}).
+		AddRow(1, "Keanu", "Reeves", byte('m'), 7)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT a.*, s.movies_count FROM (`+actorMoviesQuery+`) s `+
+		`JOIN actors a ON a.id = s.actor_id WHERE a.deleted_at IS NULL ORDER BY s.movies_count DESC, a.id LIMIT $1 OFFSET $2`)).
+		WithArgs(10, 20).
+		WillReturnRows(rows)
+
+	actors, err := s.repo.ProlificActors(10, 20)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.ActorListItem{{
+		Actor:       models.Actor{ID: 1, FirstName: "Keanu", LastName: "Reeves", Gender: 'm'},
+		MoviesCount: 7,
+	}}, actors)
+}
+
+func (s *StatsRepoTestSuite) TestMaterialized(t provider.T) {
+	repo := NewMaterialized(nil, s.gormDB)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.* FROM stats_cast_genders s ORDER BY s.gender`)).
+		WillReturnRows(sqlmock.NewRows([]string{"gender", "actors", "credits"}).
+			AddRow("f", 1, 2).
+			AddRow("m", 2, 3))
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT s.* FROM stats_cast_ages s ORDER BY s.age`)).
+		WillReturnRows(sqlmock.NewRows([]string{"age", "credits"}).AddRow(34, 1))
+
+	genders, err := repo.CastGenders()
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.CastGender{{Gender: "f", Actors: 1, Credits: 2}, {Gender: "m", Actors: 2, Credits: 3}}, genders)
+
+	ages, err := repo.CastAges()
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.CastAge{{Age: 34, Credits: 1}}, ages)
+}
+
+func (s *StatsRepoTestSuite) TestRefresh(t provider.T) {
+	t.Assert().NoError(s.repo.Refresh())
+
+	for _, view := range views {
+		s.mock.ExpectExec(regexp.QuoteMeta(`REFRESH MATERIALIZED VIEW CONCURRENTLY ` + view)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Assert().NoError(NewMaterialized(nil, s.gormDB).Refresh())
+}
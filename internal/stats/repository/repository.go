@@ -0,0 +1,15 @@
+package repository
+
+import "intern/models"
+
+// StatsRepositoryI aggregates the catalogue. The results are sorted: years
+// ascending, actors by movies descending, genders and ages ascending.
+type StatsRepositoryI interface {
+	MoviesPerYear() ([]models.MoviesPerYear, error)
+	ProlificActors(limit, offset int) ([]models.ActorListItem, error)
+	CastGenders() ([]models.CastGender, error)
+	CastAges() ([]models.CastAge, error)
+	// Refresh updates cached aggregates. Repositories aggregating on every
+	// read have nothing to do.
+	Refresh() error
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	statsRep "intern/internal/stats/repository"
+	"intern/models"
+)
+
+const (
+	DefaultAgeBucket = 10
+	MaxAgeBucket     = 100
+)
+
+type StatsUseCaseI interface {
+	MoviesPerYear() ([]models.MoviesPerYear, error)
+	ProlificActors(limit, offset int) ([]models.ActorListItem, error)
+	CastGenders() ([]models.CastGender, error)
+	// CastAges groups the ages in buckets of bucket years, starting at 0.
+	CastAges(bucket int) (*models.CastAgeStats, error)
+	Refresh() error
+}
+
+var ErrInvalidBucket = errors.New("invalid age bucket")
+
+type statsUseCase struct {
+	statsRepository statsRep.StatsRepositoryI
+}
+
+func New(sRep statsRep.StatsRepositoryI) StatsUseCaseI {
+	return &statsUseCase{
+		statsRepository: sRep,
+	}
+}
+
+func (sUC *statsUseCase) MoviesPerYear() ([]models.MoviesPerYear, error) {
+	years, err := sUC.statsRepository.MoviesPerYear()
+	if err != nil {
+		return nil, errors.Wrap(err, "statsUseCase.MoviesPerYear error")
+	}
+
+	return years, nil
+}
+
+func (sUC *statsUseCase) ProlificActors(limit, offset int) ([]models.ActorListItem, error) {
+	actors, err := sUC.statsRepository.ProlificActors(limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "statsUseCase.ProlificActors error")
+	}
+
+	return actors, nil
+}
+
+func (sUC *statsUseCase) CastGenders() ([]models.CastGender, error) {
+	genders, err := sUC.statsRepository.CastGenders()
+	if err != nil {
+		return nil, errors.Wrap(err, "statsUseCase.CastGenders error")
+	}
+
+	return genders, nil
+}
+
+func (sUC *statsUseCase) CastAges(bucket int) (*models.CastAgeStats, error) {
+	if bucket < 1 || bucket > MaxAgeBucket {
+		return nil, errors.Wrapf(ErrInvalidBucket, "statsUseCase.CastAges error: %d", bucket)
+	}
+
+	ages, err := sUC.statsRepository.CastAges()
+	if err != nil {
+		return nil, errors.Wrap(err, "statsUseCase.CastAges error")
+	}
+
+	stats := &models.CastAgeStats{Buckets: []models.AgeBucket{}}
+	if len(ages) == 0 {
+		return stats, nil
+	}
+
+	// The ages come sorted, so the youngest and oldest are at the ends and
+	// the buckets are filled in order.
+	stats.Youngest = ages[0].Age
+	stats.Oldest = ages[len(ages)-1].Age
+
+	ageSum := 0
+	for _, a := range ages {
+		stats.Credits += a.Credits
+		ageSum += a.Age * a.Credits
+
+		from := a.Age / bucket * bucket
+		if n := len(stats.Buckets); n == 0 || stats.Buckets[n-1].From != from {
+			stats.Buckets = append(stats.Buckets, models.AgeBucket{From: from, To: from + bucket - 1})
+		}
+		stats.Buckets[len(stats.Buckets)-1].Credits += a.Credits
+	}
+
+	stats.AverageAge = float64(ageSum) / float64(stats.Credits)
+
+	// The median is the lower middle credit, an age every credit has.
+	middle := (stats.Credits - 1) / 2
+	for _, a := range ages {
+		if middle < a.Credits {
+			stats.MedianAge = a.Age
+			break
+		}
+		middle -= a.Credits
+	}
+
+	return stats, nil
+}
+
+func (sUC *statsUseCase) Refresh() error {
+	err := sUC.statsRepository.Refresh()
+	if err != nil {
+		return errors.Wrap(err, "statsUseCase.Refresh error")
+	}
+
+	return nil
+}
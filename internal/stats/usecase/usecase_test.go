@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"intern/internal/memdb"
+	memStats "intern/internal/stats/repository/memory"
+	"intern/internal/stats/repository/mocks"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// newUseCase stores three movies of 1999 and 2003 and three actors; the
+// link of Carrie to The Matrix is stored twice.
+func newUseCase() StatsUseCaseI {
+	db := memdb.New()
+
+	movies := []models.Movie{
+		{ID: 1, Title: "The Matrix", ReleaseDate: date(1999, time.March, 31), Rating: 9,
+			RatingStats: models.RatingStats{Votes: 2, ScoreSum: 19}},
+		{ID: 2, Title: "Fight Club", ReleaseDate: date(1999, time.October, 15), Rating: 8,
+			RatingStats: models.RatingStats{Votes: 1, ScoreSum: 7}},
+		{ID: 3, Title: "The Matrix Reloaded", ReleaseDate: date(2003, time.May, 15), Rating: 7},
+	}
+	for _, m := range movies {
+		db.Movies[m.ID] = m
+	}
+
+	actors := []models.Actor{
+		{ID: 1, FirstName: "Keanu", LastName: "Reeves", Gender: 'm', Birthday: date(1964, time.September, 2)},
+		{ID: 2, FirstName: "Carrie-Anne", LastName: "Moss", Gender: 'f', Birthday: date(1967, time.August, 21)},
+		{ID: 3, FirstName: "Edward", LastName: "Norton", Gender: 'm', Birthday: date(1969, time.August, 18)},
+	}
+	for _, a := range actors {
+		db.Actors[a.ID] = a
+	}
+
+	for i, link := range [][2]int{{1, 1}, {1, 2}, {1, 2}, {3, 1}, {3, 2}, {2, 3}} {
+		db.MoviesActors[i+1] = models.MovieActor{ID: i + 1, MovieID: link[0], ActorID: link[1]}
+	}
+
+	return New(memStats.New(nil, db))
+}
+
+func TestMoviesPerYear(t *testing.T) {
+	uc := newUseCase()
+
+	years, err := uc.MoviesPerYear()
+	require.NoError(t, err)
+	assert.Equal(t, []models.MoviesPerYear{
+		{Year: 1999, Movies: 2, AverageRating: 8.5, Votes: 3, AverageUserRating: 26.0 / 3},
+		{Year: 2003, Movies: 1, AverageRating: 7},
+	}, years)
+}
+
+func TestProlificActors(t *testing.T) {
+	uc := newUseCase()
+
+	actors, err := uc.ProlificActors(2, 0)
+	require.NoError(t, err)
+	require.Len(t, actors, 2)
+	assert.Equal(t, 1, actors[0].ID)
+	assert.Equal(t, 2, actors[0].MoviesCount)
+	assert.Equal(t, 2, actors[1].ID)
+	assert.Equal(t, 2, actors[1].MoviesCount)
+
+	actors, err = uc.ProlificActors(2, 2)
+	require.NoError(t, err)
+	require.Len(t, actors, 1)
+	assert.Equal(t, 3, actors[0].ID)
+	assert.Equal(t, 1, actors[0].MoviesCount)
+}
+
+func TestCastGenders(t *testing.T) {
+	uc := newUseCase()
+
+	genders, err := uc.CastGenders()
+	require.NoError(t, err)
+	assert.Equal(t, []models.CastGender{
+		{Gender: "f", Actors: 1, Credits: 2},
+		{Gender: "m", Actors: 2, Credits: 3},
+	}, genders)
+}
+
+func TestCastAges(t *testing.T) {
+	uc := newUseCase()
+
+	// Keanu was 34 and 38, Carrie-Anne 31 and 35, Edward 30.
+	stats, err := uc.CastAges(5)
+	require.NoError(t, err)
+	assert.Equal(t, &models.CastAgeStats{
+		Credits:    5,
+		AverageAge: 33.6,
+		MedianAge:  34,
+		Youngest:   30,
+		Oldest:     38,
+		Buckets: []models.AgeBucket{
+			{From: 30, To: 34, Credits: 3},
+			{From: 35, To: 39, Credits: 2},
+		},
+	}, stats)
+
+	stats, err = uc.CastAges(DefaultAgeBucket)
+	require.NoError(t, err)
+	assert.Equal(t, []models.AgeBucket{{From: 30, To: 39, Credits: 5}}, stats.Buckets)
+
+	for _, bucket := range []int{0, -1, MaxAgeBucket + 1} {
+		_, err = uc.CastAges(bucket)
+		assert.True(t, errors.Is(err, ErrInvalidBucket), err)
+	}
+}
+
+func TestCastAgesEmpty(t *testing.T) {
+	uc := New(memStats.New(nil, memdb.New()))
+
+	stats, err := uc.CastAges(DefaultAgeBucket)
+	require.NoError(t, err)
+	assert.Equal(t, &models.CastAgeStats{Buckets: []models.AgeBucket{}}, stats)
+}
+
+func TestRefresh(t *testing.T) {
+	repo := &mocks.StatsRepositoryI{}
+	repo.On("Refresh").Return(errors.New("refresh failed")).Once()
+
+	err := New(repo).Refresh()
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
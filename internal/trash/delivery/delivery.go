@@ -0,0 +1,76 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+
+	trashUseCase "intern/internal/trash/usecase"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+)
+
+type TrashHandler struct {
+	TrashUseCase trashUseCase.TrashUseCaseI
+	Logger       logger.Logger
+}
+
+// List godoc
+// @Summary      Trash
+// @Description  Deleted movies and actors, most recently deleted first. They can be restored
+// @Description  until the purge job deletes them for good, see TRASH_RETENTION.
+// @Tags     trash
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param type query string false "movie or actor, both if empty"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.TrashItem "success get trash"
+// @Failure 400 {object} nil "invalid type or pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /trash [get]
+func (th *TrashHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		th.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	items, err := th.TrashUseCase.List(r.FormValue("type"), page.Limit, page.Offset)
+	switch {
+	case errors.Is(err, trashUseCase.ErrInvalidType):
+		th.Logger.Infow("can`t get trash",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case err != nil:
+		th.Logger.Errorw("can`t get trash",
+			"err:", err.Error())
+		http.Error(w, "can`t get trash", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := json.Marshal(items)
+
+	if err != nil {
+		th.Logger.Errorw("can`t marshal trash",
+			"err:", err.Error())
+		http.Error(w, "can`t make trash", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		th.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
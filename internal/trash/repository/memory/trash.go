@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/trash/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+)
+
+type memTrashRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.TrashRepositoryI {
+	return &memTrashRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (tr *memTrashRepo) List(itemType string, limit, offset int) ([]models.TrashItem, error) {
+	tr.DB.RLock()
+	defer tr.DB.RUnlock()
+
+	items := []models.TrashItem{}
+	if itemType == "" || itemType == models.TrashMovie {
+		for _, m := range tr.DB.TrashedMovies {
+			items = append(items, models.TrashItem{Type: models.TrashMovie, ID: m.ID, Name: m.Title, DeletedAt: m.DeletedAt.Time})
+		}
+	}
+	if itemType == "" || itemType == models.TrashActor {
+		for _, a := range tr.DB.TrashedActors {
+			items = append(items, models.TrashItem{Type: models.TrashActor, ID: a.ID, Name: a.FirstName + " " + a.LastName, DeletedAt: a.DeletedAt.Time})
+		}
+	}
+
+	slices.SortFunc(items, func(a, b models.TrashItem) int {
+		return cmp.Or(b.DeletedAt.Compare(a.DeletedAt), cmp.Compare(a.Type, b.Type), cmp.Compare(a.ID, b.ID))
+	})
+
+	return memdb.Page(items, limit, offset), nil
+}
+
+// Purge mirrors the cascading foreign keys of Postgres, with the events
+// of their triggers.
+func (tr *memTrashRepo) Purge(before time.Time) (models.PurgeStats, error) {
+	tr.DB.Lock()
+	defer tr.DB.Unlock()
+
+	stats := models.PurgeStats{}
+
+	for id, m := range tr.DB.TrashedMovies {
+		if m.DeletedAt.Time.Before(before) {
+			tr.purgeMovie(id)
+			stats.Movies++
+		}
+	}
+
+	for id, a := range tr.DB.TrashedActors {
+		if a.DeletedAt.Time.Before(before) {
+			tr.purgeActor(id)
+			stats.Actors++
+		}
+	}
+
+	return stats, nil
+}
+
+// purgeMovie must be called with the lock held.
+func (tr *memTrashRepo) purgeMovie(id int) {
+	delete(tr.DB.TrashedMovies, id)
+	tr.DB.AddEntityEvent(models.EventMoviePurged, id)
+
+	for linkID, ma := range tr.DB.MoviesActors {
+		if ma.MovieID == id {
+			delete(tr.DB.MoviesActors, linkID)
+			tr.DB.AddCastEvent(ma.MovieID, ma.ActorID, models.CastRemoved)
+		}
+	}
+	for ext, owner := range tr.DB.MovieExternalIDs {
+		if owner == id {
+			delete(tr.DB.MovieExternalIDs, ext)
+		}
+	}
+	delete(tr.DB.MovieRevisions, id)
+	for key := range tr.DB.Ratings {
+		if key.MovieID == id {
+			delete(tr.DB.Ratings, key)
+		}
+	}
+	for key := range tr.DB.Watchlist {
+		if key.MovieID == id {
+			delete(tr.DB.Watchlist, key)
+		}
+	}
+	for key := range tr.DB.History {
+		if key.MovieID == id {
+			delete(tr.DB.History, key)
+		}
+	}
+	for key := range tr.DB.ListEntries {
+		if key.MovieID == id {
+			delete(tr.DB.ListEntries, key)
+		}
+	}
+	for key := range tr.DB.Similarities {
+		if key.MovieID == id || key.SimilarID == id {
+			delete(tr.DB.Similarities, key)
+		}
+	}
+	for reviewID, review := range tr.DB.Reviews {
+		if review.MovieID != id {
+			continue
+		}
+		delete(tr.DB.Reviews, reviewID)
+		for key := range tr.DB.ReviewVotes {
+			if key.ReviewID == reviewID {
+				delete(tr.DB.ReviewVotes, key)
+			}
+		}
+	}
+}
+
+// purgeActor must be called with the lock held.
+func (tr *memTrashRepo) purgeActor(id int) {
+	delete(tr.DB.TrashedActors, id)
+	tr.DB.AddEntityEvent(models.EventActorPurged, id)
+
+	for linkID, ma := range tr.DB.MoviesActors {
+		if ma.ActorID == id {
+			delete(tr.DB.MoviesActors, linkID)
+			tr.DB.AddCastEvent(ma.MovieID, ma.ActorID, models.CastRemoved)
+		}
+	}
+	for ext, owner := range tr.DB.ActorExternalIDs {
+		if owner == id {
+			delete(tr.DB.ActorExternalIDs, ext)
+		}
+	}
+	delete(tr.DB.ActorRevisions, id)
+}
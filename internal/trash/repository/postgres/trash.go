@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/trash/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+// trashQuery is served by the partial deleted_at indexes of migration 0014.
+const trashQuery = `SELECT t.* FROM (
+	SELECT 'movie' AS type, id, title AS name, deleted_at FROM movies WHERE deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'actor' AS type, id, first_name || ' ' || last_name AS name, deleted_at FROM actors WHERE deleted_at IS NOT NULL
+) t
+WHERE ? = '' OR t.type = ?
+ORDER BY t.deleted_at DESC, t.type, t.id
+LIMIT ? OFFSET ?`
+
+type pgTrashRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.TrashRepositoryI {
+	return &pgTrashRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (tr *pgTrashRepo) List(itemType string, limit, offset int) ([]models.TrashItem, error) {
+	items := []models.TrashItem{}
+	tx := tr.DB.Raw(trashQuery, itemType, itemType, limit, offset).Scan(&items)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgTrashRepo.List error")
+	}
+
+	return items, nil
+}
+
+// Purge relies on the foreign keys to cascade, see migration 0014.
+func (tr *pgTrashRepo) Purge(before time.Time) (models.PurgeStats, error) {
+	stats := models.PurgeStats{}
+
+	err := tr.DB.Transaction(func(tx *gorm.DB) error {
+		movies := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Movie{})
+		if movies.Error != nil {
+			return movies.Error
+		}
+
+		actors := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Actor{})
+		if actors.Error != nil {
+			return actors.Error
+		}
+
+		stats.Movies, stats.Actors = int(movies.RowsAffected), int(actors.RowsAffected)
+
+		return nil
+	})
+
+	if err != nil {
+		return models.PurgeStats{}, errors.Wrap(err, "pgTrashRepo.Purge error")
+	}
+
+	return stats, nil
+}
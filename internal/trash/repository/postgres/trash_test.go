@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	trashRep "intern/internal/trash/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type TrashRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   trashRep.TrashRepositoryI
+}
+
+func TestTrashRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(TrashRepoTestSuite))
+}
+
+func (s *TrashRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *TrashRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *TrashRepoTestSuite) TestList(t provider.T) {
+	deleted := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`WHERE $1 = '' OR t.type = $2 ORDER BY t.deleted_at DESC, t.type, t.id LIMIT $3 OFFSET $4`)).
+		WithArgs(models.TrashActor, models.TrashActor, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "name", "deleted_at"}).
+			AddRow(models.TrashActor, 3, "Sigourney Weaver", deleted))
+
+	items, err := s.repo.List(models.TrashActor, 20, 0)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.TrashItem{{Type: models.TrashActor, ID: 3, Name: "Sigourney Weaver", DeletedAt: deleted}}, items)
+}
+
+func (s *TrashRepoTestSuite) TestPurge(t provider.T) {
+	before := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "movies" WHERE deleted_at < $1`)).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "actors" WHERE deleted_at < $1`)).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	stats, err := s.repo.Purge(before)
+	t.Assert().NoError(err)
+	t.Assert().Equal(models.PurgeStats{Movies: 2, Actors: 1}, stats)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"intern/models"
+	"time"
+)
+
+// TrashRepositoryI reads and empties the trash, the soft-deleted movies and
+// actors. Deleting and restoring are done by their own repositories.
+type TrashRepositoryI interface {
+	// List returns the items of itemType, of both types if empty, the most
+	// recently deleted first.
+	List(itemType string, limit, offset int) ([]models.TrashItem, error)
+	// Purge deletes for good the items deleted before the given time, with
+	// every row referring to them.
+	Purge(before time.Time) (models.PurgeStats, error)
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	trashRep "intern/internal/trash/repository"
+	"intern/models"
+	"time"
+)
+
+type TrashUseCaseI interface {
+	// List returns the trash, filtered by itemType unless it is empty.
+	List(itemType string, limit, offset int) ([]models.TrashItem, error)
+	// Purge deletes for good what has been in the trash longer than the
+	// retention.
+	Purge() (models.PurgeStats, error)
+}
+
+var ErrInvalidType = errors.New("invalid trash item type")
+
+type trashUseCase struct {
+	trashRepository trashRep.TrashRepositoryI
+	// retention is how long items stay in the trash; zero keeps them.
+	retention time.Duration
+}
+
+func New(tRep trashRep.TrashRepositoryI, retention time.Duration) TrashUseCaseI {
+	return &trashUseCase{
+		trashRepository: tRep,
+		retention:       retention,
+	}
+}
+
+func (tUC *trashUseCase) List(itemType string, limit, offset int) ([]models.TrashItem, error) {
+	if itemType != "" && itemType != models.TrashMovie && itemType != models.TrashActor {
+		return nil, errors.Wrapf(ErrInvalidType, "trashUseCase.List error: %q", itemType)
+	}
+
+	items, err := tUC.trashRepository.List(itemType, limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "trashUseCase.List error")
+	}
+
+	if tUC.retention > 0 {
+		for i := range items {
+			purgeAt := items[i].DeletedAt.Add(tUC.retention)
+			items[i].PurgeAt = &purgeAt
+		}
+	}
+
+	return items, nil
+}
+
+func (tUC *trashUseCase) Purge() (models.PurgeStats, error) {
+	if tUC.retention <= 0 {
+		return models.PurgeStats{}, nil
+	}
+
+	stats, err := tUC.trashRepository.Purge(time.Now().Add(-tUC.retention))
+	if err != nil {
+		return models.PurgeStats{}, errors.Wrap(err, "trashUseCase.Purge error")
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	memActor "intern/internal/actor/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memTrash "intern/internal/trash/repository/memory"
+	"intern/internal/trash/repository/mocks"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newUseCase stores Alien with Sigourney Weaver and moves both to the trash,
+// the movie two days before the actor.
+func newUseCase(t *testing.T) (TrashUseCaseI, *memdb.DB) {
+	db := memdb.New()
+	db.Movies[1] = models.Movie{ID: 1, Title: "Alien"}
+	db.Actors[2] = models.Actor{ID: 2, FirstName: "Sigourney", LastName: "Weaver"}
+	db.MoviesActors[1] = models.MovieActor{ID: 1, MovieID: 1, ActorID: 2}
+
+	require.NoError(t, memMovie.New(nil, db).Delete(1))
+	require.NoError(t, memActor.New(nil, db).Delete(2))
+
+	m := db.TrashedMovies[1]
+	m.DeletedAt.Time = m.DeletedAt.Time.Add(-48 * time.Hour)
+	db.TrashedMovies[1] = m
+
+	return New(memTrash.New(nil, db), 36*time.Hour), db
+}
+
+func TestList(t *testing.T) {
+	uc, db := newUseCase(t)
+
+	items, err := uc.List("", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, models.TrashActor, items[0].Type)
+	assert.Equal(t, "Sigourney Weaver", items[0].Name)
+	assert.Equal(t, models.TrashMovie, items[1].Type)
+	assert.Equal(t, "Alien", items[1].Name)
+	assert.Equal(t, items[1].DeletedAt.Add(36*time.Hour), *items[1].PurgeAt)
+
+	items, err = uc.List(models.TrashMovie, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, 1, items[0].ID)
+
+	_, err = uc.List("review", 20, 0)
+	assert.True(t, errors.Is(err, ErrInvalidType), err)
+
+	// A restored movie is out of the trash.
+	require.NoError(t, memMovie.New(nil, db).Restore(1))
+	items, err = uc.List("", 20, 0)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, models.TrashActor, items[0].Type)
+}
+
+func TestPurge(t *testing.T) {
+	uc, db := newUseCase(t)
+
+	// Only the movie has been in the trash longer than the retention.
+	stats, err := uc.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeStats{Movies: 1}, stats)
+	assert.Empty(t, db.TrashedMovies)
+	assert.Len(t, db.TrashedActors, 1)
+	assert.Empty(t, db.MoviesActors)
+
+	err = memMovie.New(nil, db).Restore(1)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+}
+
+func TestPurgeWithoutRetention(t *testing.T) {
+	repo := &mocks.TrashRepositoryI{}
+
+	stats, err := New(repo, 0).Purge()
+	require.NoError(t, err)
+	assert.Equal(t, models.PurgeStats{}, stats)
+	repo.AssertNotCalled(t, "Purge", mock.Anything)
+}
@@ -0,0 +1,64 @@
+package contract
+
+import (
+	"intern/internal/user/repository"
+	"intern/models"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Backend adapts a UserRepositoryI implementation to the contract suite.
+// Seed stores a user by whatever means the backend has (the repository
+// cannot create users). The Expect* hooks are called right before the
+// matching repository call so that scripted backends (sqlmock) can prepare
+// the database conversation; in-memory backends implement them as no-ops.
+type Backend interface {
+	Repo() repository.UserRepositoryI
+	Seed(u models.User)
+	ExpectGetByLogin(login string, u *models.User)
+	Verify() error
+}
+
+// Run checks the behaviour every UserRepositoryI implementation must share.
+// newBackend is called once per case and must return an empty repository.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	cases := map[string]func(t *testing.T, b Backend){
+		"GetByLogin":   testGetByLogin,
+		"UnknownLogin": testUnknownLogin,
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t)
+			test(t, b)
+			assert.NoError(t, b.Verify())
+		})
+	}
+}
+
+func user() models.User {
+	return models.User{ID: 7, Login: "ripley", Password: "nostromo", Role: "user"}
+}
+
+func testGetByLogin(t *testing.T, b Backend) {
+	u := user()
+	b.Seed(u)
+
+	b.ExpectGetByLogin(u.Login, &u)
+	got, err := b.Repo().GetByLogin(u.Login)
+	require.NoError(t, err)
+	assert.Equal(t, u, *got)
+}
+
+func testUnknownLogin(t *testing.T, b Backend) {
+	u := user()
+	b.Seed(u)
+
+	b.ExpectGetByLogin("hicks", nil)
+	_, err := b.Repo().GetByLogin("hicks")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "want ErrRecordNotFound, got %v", err)
+}
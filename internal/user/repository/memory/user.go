@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"intern/internal/memdb"
+	"intern/internal/user/repository"
+	"intern/models"
+	"intern/pkg/logger"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memUserRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.UserRepositoryI {
+	return &memUserRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (ur *memUserRepo) GetByLogin(login string) (*models.User, error) {
+	ur.DB.RLock()
+	defer ur.DB.RUnlock()
+
+	for _, u := range ur.DB.Users {
+		if u.Login == login {
+			return &u, nil
+		}
+	}
+
+	return nil, errors.Wrap(gorm.ErrRecordNotFound, "memUserRepo.GetByLogin error")
+}
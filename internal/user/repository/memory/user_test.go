@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"intern/internal/memdb"
+	"intern/internal/user/repository"
+	"intern/internal/user/repository/contract"
+	"intern/models"
+	"testing"
+)
+
+type backend struct {
+	db   *memdb.DB
+	repo repository.UserRepositoryI
+}
+
+func (b backend) Repo() repository.UserRepositoryI      { return b.repo }
+func (b backend) Seed(u models.User)                    { b.db.Users[u.ID] = u }
+func (b backend) ExpectGetByLogin(string, *models.User) {}
+func (b backend) Verify() error                         { return nil }
+
+func TestUserRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db := memdb.New()
+		return backend{db: db, repo: New(nil, db)}
+	})
+}
@@ -13,25 +13,25 @@ type UserRepositoryI struct {
 	mock.Mock
 }
 
-// GetByLoginAndPassword provides a mock function with given fields: login, password
-func (_m *UserRepositoryI) GetByLoginAndPassword(login string, password string) (*models.User, error) {
-	ret := _m.Called(login, password)
+// GetByLogin provides a mock function with given fields: login
+func (_m *UserRepositoryI) GetByLogin(login string) (*models.User, error) {
+	ret := _m.Called(login)
 
 	var r0 *models.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*models.User, error)); ok {
-		return rf(login, password)
+	if rf, ok := ret.Get(0).(func(string) (*models.User, error)); ok {
+		return rf(login)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *models.User); ok {
-		r0 = rf(login, password)
+	if rf, ok := ret.Get(0).(func(string) *models.User); ok {
+		r0 = rf(login)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(login, password)
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(login)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"intern/internal/user/repository"
+	"intern/internal/user/repository/contract"
+	"intern/models"
+	"regexp"
+	"testing"
+)
+
+type sqlmockBackend struct {
+	repo repository.UserRepositoryI
+	mock sqlmock.Sqlmock
+}
+
+func (b *sqlmockBackend) Repo() repository.UserRepositoryI { return b.repo }
+
+func (b *sqlmockBackend) Seed(models.User) {}
+
+func (b *sqlmockBackend) ExpectGetByLogin(login string, u *models.User) {
+	rows := sqlmock.NewRows([]string{"id", "login", "password", "user_role"})
+	if u != nil {
+		rows.AddRow(u.ID, u.Login, u.Password, u.Role)
+	}
+
+	b.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE login = $1 LIMIT $2`)).
+		WithArgs(login, 1).
+		WillReturnRows(rows)
+}
+
+func (b *sqlmockBackend) Verify() error { return b.mock.ExpectationsWereMet() }
+
+func TestUserRepoContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Backend {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatal("error while creating sql mock")
+		}
+		t.Cleanup(func() { db.Close() })
+
+		gormDB, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_0",
+			DriverName:           "postgres",
+			Conn:                 db,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{})
+		if err != nil {
+			t.Fatal("error gorm open")
+		}
+
+		return &sqlmockBackend{repo: New(nil, gormDB), mock: mock}
+	})
+}
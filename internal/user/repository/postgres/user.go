@@ -20,12 +20,12 @@ func New(logger logger.Logger, db *gorm.DB) repository.UserRepositoryI {
 	}
 }
 
-func (ur *pgUserRepo) GetByLoginAndPassword(login, password string) (*models.User, error) {
+func (ur *pgUserRepo) GetByLogin(login string) (*models.User, error) {
 	var u models.User
-	tx := ur.DB.Where("login = ?", login).Where("password = ?", password).Take(&u)
+	tx := ur.DB.Where("login = ?", login).Take(&u)
 
 	if tx.Error != nil {
-		return nil, errors.Wrap(tx.Error, "pgUserRepo.GetByLoginAndPassword error")
+		return nil, errors.Wrap(tx.Error, "pgUserRepo.GetByLogin error")
 	}
 
 	return &u, nil
@@ -76,11 +76,11 @@ func (s *UserRepoTestSuite) TestGetUser(t provider.T) {
 		)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(
-		`select * from users where login = $1 and password = $2`)).
-		WithArgs(user.Login, user.Password).
+		`SELECT * FROM "users" WHERE login = $1 LIMIT $2`)).
+		WithArgs(user.Login, 1).
 		WillReturnRows(rows)
 
-	resUser, err := s.repo.GetByLoginAndPassword(user.Login, user.Password)
+	resUser, err := s.repo.GetByLogin(user.Login)
 	t.Assert().NoError(err)
 	t.Assert().Equal(user, resUser)
 }
@@ -3,5 +3,5 @@ package repository
 import "intern/models"
 
 type UserRepositoryI interface {
-	GetByLoginAndPassword(login, password string) (*models.User, error)
+	GetByLogin(login string) (*models.User, error)
 }
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 	userRep "intern/internal/user/repository"
 	"intern/models"
 )
@@ -20,12 +21,20 @@ func New(uRep userRep.UserRepositoryI) UserUseCaseI {
 	}
 }
 
+// GetByLoginAndPassword returns the user only if password matches the
+// bcrypt hash stored for login.
 func (u *userUseCase) GetByLoginAndPassword(login, password string) (*models.User, error) {
-	resUser, err := u.userRepository.GetByLoginAndPassword(login, password)
+	resUser, err := u.userRepository.GetByLogin(login)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "userUseCase.GetByLoginAndPassword error")
 	}
 
+	err = bcrypt.CompareHashAndPassword([]byte(resUser.Password), []byte(password))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "userUseCase.GetByLoginAndPassword error: wrong password")
+	}
+
 	return resUser, nil
 }
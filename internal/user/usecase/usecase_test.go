@@ -1 +1,42 @@
 package usecase
+
+import (
+	"intern/internal/memdb"
+	memUser "intern/internal/user/repository/memory"
+	"intern/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func newUseCase(t *testing.T) UserUseCaseI {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	db := memdb.New()
+	db.Users[1] = models.User{ID: 1, Login: "ann@example.com", Password: string(hash), Role: "admin"}
+
+	return New(memUser.New(nil, db))
+}
+
+func TestGetByLoginAndPassword(t *testing.T) {
+	user, err := newUseCase(t).GetByLoginAndPassword("ann@example.com", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	assert.Equal(t, "admin", user.Role)
+}
+
+func TestGetByLoginAndPasswordWrongPassword(t *testing.T) {
+	user, err := newUseCase(t).GetByLoginAndPassword("ann@example.com", "Secret")
+	assert.ErrorIs(t, err, bcrypt.ErrMismatchedHashAndPassword)
+	assert.Nil(t, user)
+}
+
+func TestGetByLoginAndPasswordUnknownLogin(t *testing.T) {
+	user, err := newUseCase(t).GetByLoginAndPassword("bob@example.com", "secret")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Nil(t, user)
+}
@@ -0,0 +1,378 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	watchlistUseCase "intern/internal/watchlist/usecase"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// WatchedForm carries the day a movie was watched as YYYY-MM-DD, empty
+// means today.
+type WatchedForm struct {
+	WatchedOn string `json:"watchedOn"`
+}
+
+type ContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type WatchlistHandler struct {
+	WatchlistUseCase watchlistUseCase.WatchlistUseCaseI
+	Logger           logger.Logger
+	Context          ContextManager
+}
+
+// Watchlist godoc
+// @Summary      My watchlist
+// @Description  Movies the signed in user wants to watch, most recently added first
+// @Tags     watchlist
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.WatchlistItem "success get watchlist"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/watchlist [get]
+func (wh *WatchlistHandler) Watchlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := wh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	page, ok := wh.page(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := wh.WatchlistUseCase.Watchlist(userID, page.Limit, page.Offset)
+	if err != nil {
+		wh.Logger.Errorw("can`t get watchlist",
+			"err:", err.Error())
+		http.Error(w, "can`t get watchlist", http.StatusInternalServerError)
+		return
+	}
+
+	wh.write(w, items)
+}
+
+// Add godoc
+// @Summary      Add to watchlist
+// @Description  Put a movie on the watchlist of the signed in user; adding it again keeps the first date
+// @Tags     watchlist
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} nil "movie added"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/watchlist/{id} [put]
+func (wh *WatchlistHandler) Add(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := wh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	err := wh.WatchlistUseCase.Add(userID, movieID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		wh.Logger.Infow("can`t add to watchlist",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	case err != nil:
+		wh.Logger.Errorw("can`t add to watchlist",
+			"err:", err.Error())
+		http.Error(w, "can`t add to watchlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Remove godoc
+// @Summary      Remove from watchlist
+// @Description  Take a movie off the watchlist of the signed in user
+// @Tags     watchlist
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} nil "movie removed"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not on the watchlist"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/watchlist/{id} [delete]
+func (wh *WatchlistHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := wh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	err := wh.WatchlistUseCase.Remove(userID, movieID)
+	if err != nil {
+		wh.Logger.Infow("can`t remove from watchlist",
+			"err:", err.Error())
+		http.Error(w, "can`t remove from watchlist", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// History godoc
+// @Summary      My watched history
+// @Description  Movies the signed in user watched, most recently watched first
+// @Tags     watchlist
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.WatchedMovie "success get history"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/history [get]
+func (wh *WatchlistHandler) History(w http.ResponseWriter, r *http.Request) {
+	userID, ok := wh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	page, ok := wh.page(w, r)
+	if !ok {
+		return
+	}
+
+	movies, err := wh.WatchlistUseCase.History(userID, page.Limit, page.Offset)
+	if err != nil {
+		wh.Logger.Errorw("can`t get history",
+			"err:", err.Error())
+		http.Error(w, "can`t get history", http.StatusInternalServerError)
+		return
+	}
+
+	wh.write(w, movies)
+}
+
+// SetWatched godoc
+// @Summary      Mark as watched
+// @Description  Record the day the signed in user watched a movie (today by default) and take it off the watchlist.
+// @Description  Marking a movie again moves the date.
+// @Tags     watchlist
+// @Accept	 application/json
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Param watched body WatchedForm false "watch date, YYYY-MM-DD"
+// @Success 200 {object} nil "movie marked as watched"
+// @Failure 400 {object} nil "invalid or future date"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/history/{id} [put]
+func (wh *WatchlistHandler) SetWatched(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := wh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		wh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		wh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return
+	}
+
+	form := WatchedForm{}
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &form)
+		if err != nil {
+			wh.Logger.Infow("can`t unmarshal form",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var watchedOn time.Time
+	if form.WatchedOn != "" {
+		watchedOn, err = time.Parse(time.DateOnly, form.WatchedOn)
+		if err != nil {
+			wh.Logger.Infow("can`t parse watch date",
+				"err:", err.Error())
+			http.Error(w, "bad data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = wh.WatchlistUseCase.SetWatched(userID, movieID, watchedOn)
+	switch {
+	case errors.Is(err, watchlistUseCase.ErrInvalidDate):
+		wh.Logger.Infow("invalid watch date",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		wh.Logger.Infow("can`t mark as watched",
+			"err:", err.Error())
+		http.Error(w, "can`t get movie", http.StatusNotFound)
+		return
+	case err != nil:
+		wh.Logger.Errorw("can`t mark as watched",
+			"err:", err.Error())
+		http.Error(w, "can`t mark as watched", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveWatched godoc
+// @Summary      Remove from history
+// @Description  Forget that the signed in user watched a movie
+// @Tags     watchlist
+// @Param    Authorization header string true "token"
+// @Param id path int true "MOV_ID"
+// @Success 200 {object} nil "movie removed"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "Movie not watched"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/history/{id} [delete]
+func (wh *WatchlistHandler) RemoveWatched(w http.ResponseWriter, r *http.Request) {
+	userID, movieID, ok := wh.ids(w, r)
+	if !ok {
+		return
+	}
+
+	err := wh.WatchlistUseCase.RemoveWatched(userID, movieID)
+	if err != nil {
+		wh.Logger.Infow("can`t remove from history",
+			"err:", err.Error())
+		http.Error(w, "can`t remove from history", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Stats godoc
+// @Summary      My watch stats
+// @Description  Number of movies the signed in user watched, in total and per calendar year
+// @Tags     watchlist
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Success 200 {object} models.WatchStats "success get stats"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /users/me/history/stats [get]
+func (wh *WatchlistHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := wh.userID(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := wh.WatchlistUseCase.Stats(userID)
+	if err != nil {
+		wh.Logger.Errorw("can`t get watch stats",
+			"err:", err.Error())
+		http.Error(w, "can`t get stats", http.StatusInternalServerError)
+		return
+	}
+
+	wh.write(w, stats)
+}
+
+func (wh *WatchlistHandler) userID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := wh.Context.UserIDFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Errorw("can`t get user",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// ids reads the signed in user and the movie of the path, on failure the
+// error is already written.
+func (wh *WatchlistHandler) ids(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := wh.userID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+
+	movieIdString := r.PathValue("MOV_ID")
+	if movieIdString == "" {
+		wh.Logger.Errorw("no MOV_ID var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	movieId, err := strconv.Atoi(movieIdString)
+	if err != nil {
+		wh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	return userID, movieId, true
+}
+
+func (wh *WatchlistHandler) page(w http.ResponseWriter, r *http.Request) (pagination.Params, bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		wh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return page, false
+	}
+
+	return page, true
+}
+
+func (wh *WatchlistHandler) write(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		wh.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		wh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
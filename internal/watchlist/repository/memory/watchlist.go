@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/watchlist/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memWatchlistRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.WatchlistRepositoryI {
+	return &memWatchlistRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (wr *memWatchlistRepo) AddToWatchlist(userID, movieID int) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	if _, ok := wr.DB.Movies[movieID]; !ok {
+		return errors.Errorf("memWatchlistRepo.AddToWatchlist error: movie %d does not exist", movieID)
+	}
+
+	key := memdb.UserMovieKey{UserID: userID, MovieID: movieID}
+	if _, ok := wr.DB.Watchlist[key]; !ok {
+		wr.DB.Watchlist[key] = time.Now()
+	}
+
+	return nil
+}
+
+func (wr *memWatchlistRepo) RemoveFromWatchlist(userID, movieID int) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	key := memdb.UserMovieKey{UserID: userID, MovieID: movieID}
+	if _, ok := wr.DB.Watchlist[key]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memWatchlistRepo.RemoveFromWatchlist error")
+	}
+
+	delete(wr.DB.Watchlist, key)
+
+	return nil
+}
+
+func (wr *memWatchlistRepo) Watchlist(userID, limit, offset int) ([]models.WatchlistItem, error) {
+	wr.DB.RLock()
+	defer wr.DB.RUnlock()
+
+	items := []models.WatchlistItem{}
+	for key, added := range wr.DB.Watchlist {
+		if m, ok := wr.DB.Movies[key.MovieID]; ok && key.UserID == userID {
+			items = append(items, models.WatchlistItem{Movie: m, AddedAt: added})
+		}
+	}
+
+	slices.SortFunc(items, func(a, b models.WatchlistItem) int {
+		return cmp.Or(b.AddedAt.Compare(a.AddedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return memdb.Page(items, limit, offset), nil
+}
+
+func (wr *memWatchlistRepo) SetWatched(userID, movieID int, watchedOn time.Time) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	if _, ok := wr.DB.Movies[movieID]; !ok {
+		return errors.Errorf("memWatchlistRepo.SetWatched error: movie %d does not exist", movieID)
+	}
+
+	key := memdb.UserMovieKey{UserID: userID, MovieID: movieID}
+	wr.DB.History[key] = watchedOn
+	delete(wr.DB.Watchlist, key)
+
+	return nil
+}
+
+func (wr *memWatchlistRepo) RemoveWatched(userID, movieID int) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	key := memdb.UserMovieKey{UserID: userID, MovieID: movieID}
+	if _, ok := wr.DB.History[key]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memWatchlistRepo.RemoveWatched error")
+	}
+
+	delete(wr.DB.History, key)
+
+	return nil
+}
+
+func (wr *memWatchlistRepo) History(userID, limit, offset int) ([]models.WatchedMovie, error) {
+	wr.DB.RLock()
+	defer wr.DB.RUnlock()
+
+	movies := []models.WatchedMovie{}
+	for key, watched := range wr.DB.History {
+		if m, ok := wr.DB.Movies[key.MovieID]; ok && key.UserID == userID {
+			movies = append(movies, models.WatchedMovie{Movie: m, WatchedOn: watched})
+		}
+	}
+
+	slices.SortFunc(movies, func(a, b models.WatchedMovie) int {
+		return cmp.Or(b.WatchedOn.Compare(a.WatchedOn), cmp.Compare(b.ID, a.ID))
+	})
+
+	return memdb.Page(movies, limit, offset), nil
+}
+
+func (wr *memWatchlistRepo) WatchedPerYear(userID int) ([]models.WatchedPerYear, error) {
+	wr.DB.RLock()
+	defer wr.DB.RUnlock()
+
+	counts := make(map[int]int)
+	for key, watched := range wr.DB.History {
+		if key.UserID == userID {
+			counts[watched.Year()]++
+		}
+	}
+
+	years := []models.WatchedPerYear{}
+	for year, movies := range counts {
+		years = append(years, models.WatchedPerYear{Year: year, Movies: movies})
+	}
+
+	slices.SortFunc(years, func(a, b models.WatchedPerYear) int { return cmp.Compare(a.Year, b.Year) })
+
+	return years, nil
+}
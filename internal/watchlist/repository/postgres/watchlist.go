@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/watchlist/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+const watchlistQuery = `SELECT m.*, w.added_at
+FROM watchlist w JOIN movies m ON m.id = w.movie_id
+WHERE w.user_id = ? AND m.deleted_at IS NULL
+ORDER BY w.added_at DESC, m.id DESC
+LIMIT ? OFFSET ?`
+
+const historyQuery = `SELECT m.*, h.watched_on
+FROM watch_history h JOIN movies m ON m.id = h.movie_id
+WHERE h.user_id = ? AND m.deleted_at IS NULL
+ORDER BY h.watched_on DESC, m.id DESC
+LIMIT ? OFFSET ?`
+
+const watchedPerYearQuery = `SELECT EXTRACT(YEAR FROM watched_on)::int AS year, count(*) AS movies
+FROM watch_history
+WHERE user_id = ?
+GROUP BY year
+ORDER BY year`
+
+type pgWatchlistRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.WatchlistRepositoryI {
+	return &pgWatchlistRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (wr *pgWatchlistRepo) AddToWatchlist(userID, movieID int) error {
+	tx := wr.DB.Exec("INSERT INTO watchlist (user_id, movie_id) VALUES (?, ?) ON CONFLICT (user_id, movie_id) DO NOTHING", userID, movieID)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWatchlistRepo.AddToWatchlist error")
+	}
+
+	return nil
+}
+
+func (wr *pgWatchlistRepo) RemoveFromWatchlist(userID, movieID int) error {
+	tx := wr.DB.Exec("DELETE FROM watchlist WHERE user_id = ? AND movie_id = ?", userID, movieID)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWatchlistRepo.RemoveFromWatchlist error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgWatchlistRepo.RemoveFromWatchlist error")
+	}
+
+	return nil
+}
+
+func (wr *pgWatchlistRepo) Watchlist(userID, limit, offset int) ([]models.WatchlistItem, error) {
+	items := []models.WatchlistItem{}
+	tx := wr.DB.Raw(watchlistQuery, userID, limit, offset).Scan(&items)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWatchlistRepo.Watchlist error")
+	}
+
+	return items, nil
+}
+
+func (wr *pgWatchlistRepo) SetWatched(userID, movieID int, watchedOn time.Time) error {
+	err := wr.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO watch_history (user_id, movie_id, watched_on) VALUES (?, ?, ?)
+ON CONFLICT (user_id, movie_id) DO UPDATE SET watched_on = EXCLUDED.watched_on`, userID, movieID, watchedOn.Format(time.DateOnly)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec("DELETE FROM watchlist WHERE user_id = ? AND movie_id = ?", userID, movieID).Error
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "pgWatchlistRepo.SetWatched error")
+	}
+
+	return nil
+}
+
+func (wr *pgWatchlistRepo) RemoveWatched(userID, movieID int) error {
+	tx := wr.DB.Exec("DELETE FROM watch_history WHERE user_id = ? AND movie_id = ?", userID, movieID)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWatchlistRepo.RemoveWatched error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgWatchlistRepo.RemoveWatched error")
+	}
+
+	return nil
+}
+
+func (wr *pgWatchlistRepo) History(userID, limit, offset int) ([]models.WatchedMovie, error) {
+	movies := []models.WatchedMovie{}
+	tx := wr.DB.Raw(historyQuery, userID, limit, offset).Scan(&movies)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWatchlistRepo.History error")
+	}
+
+	return movies, nil
+}
+
+func (wr *pgWatchlistRepo) WatchedPerYear(userID int) ([]models.WatchedPerYear, error) {
+	years := []models.WatchedPerYear{}
+	tx := wr.DB.Raw(watchedPerYearQuery, userID).Scan(&years)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWatchlistRepo.WatchedPerYear error")
+	}
+
+	return years, nil
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	watchlistRep "intern/internal/watchlist/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type WatchlistRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   watchlistRep.WatchlistRepositoryI
+}
+
+func TestWatchlistRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(WatchlistRepoTestSuite))
+}
+
+func (s *WatchlistRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *WatchlistRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *WatchlistRepoTestSuite) TestAddToWatchlist(t provider.T) {
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO watchlist (user_id, movie_id) VALUES ($1, $2) ON CONFLICT (user_id, movie_id) DO NOTHING`)).
+		WithArgs(7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.repo.AddToWatchlist(7, 3)
+	t.Assert().NoError(err)
+}
+
+func (s *WatchlistRepoTestSuite) TestRemoveFromWatchlistMissing(t provider.T) {
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM watchlist WHERE user_id = $1 AND movie_id = $2`)).
+		WithArgs(7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.repo.RemoveFromWatchlist(7, 3)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (s *WatchlistRepoTestSuite) TestWatchlist(t provider.T) {
+	released := time.Date(1979, 5, 25, 0, 0, 0, 0, time.UTC)
+	added := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT m.*, w.added_at FROM watchlist w JOIN movies m ON m.id = w.movie_id WHERE w.user_id = $1 AND m.deleted_at IS NULL ORDER BY w.added_at DESC, m.id DESC LIMIT $2 OFFSET $3`)).
+		WithArgs(7, 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "release_date", "rating", "added_at"}).
+			AddRow(3, "Alien", "In space", released, 8, added))
+
+	items, err := s.repo.Watchlist(7, 20, 0)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.WatchlistItem{{
+		Movie:   models.Movie{ID: 3, Title: "Alien", Description: "In space", ReleaseDate: released, Rating: 8},
+		AddedAt: added,
+	}}, items)
+}
+
+func (s *WatchlistRepoTestSuite) TestSetWatched(t provider.T) {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO watch_history (user_id, movie_id, watched_on) VALUES ($1, $2, $3) ON CONFLICT (user_id, movie_id) DO UPDATE SET watched_on = EXCLUDED.watched_on`)).
+		WithArgs(7, 3, "2024-03-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM watchlist WHERE user_id = $1 AND movie_id = $2`)).
+		WithArgs(7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SetWatched(7, 3, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	t.Assert().NoError(err)
+}
+
+func (s *WatchlistRepoTestSuite) TestWatchedPerYear(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXTRACT(YEAR FROM watched_on)::int AS year, count(*) AS movies FROM watch_history WHERE user_id = $1 GROUP BY year ORDER BY year`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"year", "movies"}).AddRow(2023, 4).AddRow(2024, 1))
+
+	years, err := s.repo.WatchedPerYear(7)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.WatchedPerYear{{Year: 2023, Movies: 4}, {Year: 2024, Movies: 1}}, years)
+}
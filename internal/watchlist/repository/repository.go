@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"intern/models"
+	"time"
+)
+
+// WatchlistRepositoryI stores the movies a user wants to watch and the ones
+// they watched, one row per user and movie in each.
+type WatchlistRepositoryI interface {
+	AddToWatchlist(userID, movieID int) error
+	RemoveFromWatchlist(userID, movieID int) error
+	Watchlist(userID, limit, offset int) ([]models.WatchlistItem, error)
+	// SetWatched records or moves the watch date and takes the movie off
+	// the watchlist.
+	SetWatched(userID, movieID int, watchedOn time.Time) error
+	RemoveWatched(userID, movieID int) error
+	History(userID, limit, offset int) ([]models.WatchedMovie, error)
+	WatchedPerYear(userID int) ([]models.WatchedPerYear, error)
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"github.com/pkg/errors"
+	movieRep "intern/internal/movie/repository"
+	watchlistRep "intern/internal/watchlist/repository"
+	"intern/models"
+	"time"
+)
+
+type WatchlistUseCaseI interface {
+	Add(userID, movieID int) error
+	Remove(userID, movieID int) error
+	Watchlist(userID, limit, offset int) ([]models.WatchlistItem, error)
+	SetWatched(userID, movieID int, watchedOn time.Time) error
+	RemoveWatched(userID, movieID int) error
+	History(userID, limit, offset int) ([]models.WatchedMovie, error)
+	Stats(userID int) (*models.WatchStats, error)
+}
+
+var ErrInvalidDate = errors.New("invalid watch date")
+
+type watchlistUseCase struct {
+	watchlistRepository watchlistRep.WatchlistRepositoryI
+	movieRepository     movieRep.MovieRepositoryI
+	now                 func() time.Time
+}
+
+func New(wRep watchlistRep.WatchlistRepositoryI, mRep movieRep.MovieRepositoryI) WatchlistUseCaseI {
+	return &watchlistUseCase{
+		watchlistRepository: wRep,
+		movieRepository:     mRep,
+		now:                 time.Now,
+	}
+}
+
+// Add puts a movie on the watchlist of the user, adding it twice keeps the
+// first date.
+func (wUC *watchlistUseCase) Add(userID, movieID int) error {
+	_, err := wUC.movieRepository.Get(movieID)
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.Add error: Movie not found")
+	}
+
+	err = wUC.watchlistRepository.AddToWatchlist(userID, movieID)
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.Add error")
+	}
+
+	return nil
+}
+
+func (wUC *watchlistUseCase) Remove(userID, movieID int) error {
+	err := wUC.watchlistRepository.RemoveFromWatchlist(userID, movieID)
+
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.Remove error")
+	}
+
+	return nil
+}
+
+func (wUC *watchlistUseCase) Watchlist(userID, limit, offset int) ([]models.WatchlistItem, error) {
+	items, err := wUC.watchlistRepository.Watchlist(userID, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "watchlistUseCase.Watchlist error")
+	}
+
+	return items, nil
+}
+
+// SetWatched records the day the user watched a movie, today when
+// watchedOn is zero. A later call moves the date.
+func (wUC *watchlistUseCase) SetWatched(userID, movieID int, watchedOn time.Time) error {
+	now := wUC.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if watchedOn.IsZero() {
+		watchedOn = today
+	}
+
+	if watchedOn.After(today) {
+		return errors.Wrapf(ErrInvalidDate, "watchlistUseCase.SetWatched error: %s is in the future", watchedOn.Format(time.DateOnly))
+	}
+
+	_, err := wUC.movieRepository.Get(movieID)
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.SetWatched error: Movie not found")
+	}
+
+	err = wUC.watchlistRepository.SetWatched(userID, movieID, watchedOn)
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.SetWatched error")
+	}
+
+	return nil
+}
+
+func (wUC *watchlistUseCase) RemoveWatched(userID, movieID int) error {
+	err := wUC.watchlistRepository.RemoveWatched(userID, movieID)
+
+	if err != nil {
+		return errors.Wrap(err, "watchlistUseCase.RemoveWatched error")
+	}
+
+	return nil
+}
+
+func (wUC *watchlistUseCase) History(userID, limit, offset int) ([]models.WatchedMovie, error) {
+	movies, err := wUC.watchlistRepository.History(userID, limit, offset)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "watchlistUseCase.History error")
+	}
+
+	return movies, nil
+}
+
+func (wUC *watchlistUseCase) Stats(userID int) (*models.WatchStats, error) {
+	years, err := wUC.watchlistRepository.WatchedPerYear(userID)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "watchlistUseCase.Stats error")
+	}
+
+	stats := &models.WatchStats{PerYear: years}
+	for _, y := range years {
+		stats.Watched += y.Movies
+	}
+
+	return stats, nil
+}
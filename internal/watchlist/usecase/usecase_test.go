@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memWatchlist "intern/internal/watchlist/repository/memory"
+	"intern/models"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newUseCase() (*watchlistUseCase, *memdb.DB) {
+	db := memdb.New()
+	for id, title := range map[int]string{1: "Alien", 2: "Aliens", 3: "Alien³"} {
+		db.Movies[id] = models.Movie{ID: id, Title: title, ReleaseDate: date(1979+id, time.May, 25)}
+		db.SeenID("movies", id)
+	}
+
+	uc := New(memWatchlist.New(nil, db), memMovie.New(nil, db)).(*watchlistUseCase)
+	uc.now = func() time.Time { return time.Date(2024, time.March, 10, 18, 30, 0, 0, time.UTC) }
+
+	return uc, db
+}
+
+func TestWatchlist(t *testing.T) {
+	uc, _ := newUseCase()
+
+	for _, id := range []int{1, 2, 3, 1} {
+		require.NoError(t, uc.Add(7, id))
+	}
+	require.NoError(t, uc.Add(8, 2))
+
+	err := uc.Add(7, 42)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	items, err := uc.Watchlist(7, 0, 0)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{1, 2, 3}, ids(items))
+
+	items, err = uc.Watchlist(7, 2, 2)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	require.NoError(t, uc.Remove(7, 2))
+	err = uc.Remove(7, 2)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	items, err = uc.Watchlist(8, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, ids(items))
+}
+
+func TestHistory(t *testing.T) {
+	uc, db := newUseCase()
+	require.NoError(t, uc.Add(7, 1))
+
+	require.NoError(t, uc.SetWatched(7, 1, date(2023, time.December, 31)))
+	require.NoError(t, uc.SetWatched(7, 2, date(2024, time.January, 2)))
+	require.NoError(t, uc.SetWatched(7, 3, time.Time{}))
+
+	// Watching a movie takes it off the watchlist.
+	assert.Empty(t, db.Watchlist)
+
+	movies, err := uc.History(7, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 1}, watchedIDs(movies))
+	assert.Equal(t, date(2024, time.March, 10), movies[0].WatchedOn)
+
+	err = uc.SetWatched(7, 1, date(2024, time.March, 11))
+	assert.True(t, errors.Is(err, ErrInvalidDate), err)
+	err = uc.SetWatched(7, 42, time.Time{})
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	stats, err := uc.Stats(7)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WatchStats{Watched: 3, PerYear: []models.WatchedPerYear{{Year: 2023, Movies: 1}, {Year: 2024, Movies: 2}}}, stats)
+
+	// Watching again moves the date.
+	require.NoError(t, uc.SetWatched(7, 1, date(2024, time.February, 1)))
+	require.NoError(t, uc.RemoveWatched(7, 2))
+	err = uc.RemoveWatched(7, 2)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), err)
+
+	stats, err = uc.Stats(7)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WatchStats{Watched: 2, PerYear: []models.WatchedPerYear{{Year: 2024, Movies: 2}}}, stats)
+
+	stats, err = uc.Stats(8)
+	require.NoError(t, err)
+	assert.Equal(t, &models.WatchStats{PerYear: []models.WatchedPerYear{}}, stats)
+}
+
+func ids(items []models.WatchlistItem) []int {
+	res := make([]int, len(items))
+	for i, item := range items {
+		res[i] = item.ID
+	}
+
+	return res
+}
+
+func watchedIDs(movies []models.WatchedMovie) []int {
+	res := make([]int, len(movies))
+	for i, m := range movies {
+		res[i] = m.ID
+	}
+
+	return res
+}
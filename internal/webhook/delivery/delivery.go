@@ -0,0 +1,266 @@
+package delivery
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	webhookUseCase "intern/internal/webhook/usecase"
+	"intern/models"
+	"intern/pkg/logger"
+	"intern/pkg/pagination"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type WebhookForm struct {
+	URL string `json:"url"`
+	// Events are the event types to send, every type if empty.
+	Events []string `json:"events"`
+	// Secret signs the requests, generated if empty.
+	Secret string `json:"secret"`
+}
+
+type WebhookHandler struct {
+	WebhookUseCase webhookUseCase.WebhookUseCaseI
+	Logger         logger.Logger
+}
+
+// Create godoc
+// @Summary      Create webhook
+// @Description  Register a URL to receive catalogue change events: movie.created, .updated, .deleted, .restored
+// @Description  and .purged, the same for actor, cast.changed, and catalogue.loaded, which a bulk load (seed, IMDb,
+// @Description  import) sends instead of the events of its rows. Each event is POSTed as JSON and signed,
+// @Description  see models.Webhook; failed deliveries are retried with backoff. The secret is only returned here.
+// @Tags     webhooks
+// @Accept	 application/json
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param webhook body WebhookForm true "webhook to create"
+// @Success 201 {object} models.Webhook "webhook created"
+// @Failure 400 {object} nil "invalid body"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /webhooks [post]
+func (wh *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	form := WebhookForm{}
+	if !wh.readForm(w, r, &form) {
+		return
+	}
+
+	webhook := &models.Webhook{URL: form.URL, Events: form.Events, Secret: form.Secret}
+
+	err := wh.WebhookUseCase.Create(webhook)
+	if err != nil {
+		wh.fail(w, err, "create webhook")
+		return
+	}
+
+	wh.write(w, http.StatusCreated, webhook)
+}
+
+// List godoc
+// @Summary      Webhooks
+// @Description  Registered webhooks, without their secrets
+// @Tags     webhooks
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.Webhook "success get webhooks"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /webhooks [get]
+func (wh *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		wh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	webhooks, err := wh.WebhookUseCase.List(page.Limit, page.Offset)
+	if err != nil {
+		wh.fail(w, err, "get webhooks")
+		return
+	}
+
+	wh.write(w, http.StatusOK, webhooks)
+}
+
+// Delete godoc
+// @Summary      Delete webhook
+// @Description  Delete a webhook with its pending and dead deliveries
+// @Tags     webhooks
+// @Param    Authorization header string true "token"
+// @Param id path int true "WEBHOOK_ID"
+// @Success 204 {object} nil "webhook deleted"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "webhook not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /webhooks/{id} [delete]
+func (wh *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := wh.pathID(w, r, "WEBHOOK_ID")
+	if !ok {
+		return
+	}
+
+	err := wh.WebhookUseCase.Delete(id)
+	if err != nil {
+		wh.fail(w, err, "delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeadLetters godoc
+// @Summary      Dead letters
+// @Description  Deliveries given up after the last attempt, most recent first, with their events
+// @Tags     webhooks
+// @Produce  application/json
+// @Param    Authorization header string true "token"
+// @Param limit query int false "page size"
+// @Param offset query int false "page offset"
+// @Success 200 {object} []models.WebhookDelivery "success get dead letters"
+// @Failure 400 {object} nil "invalid pagination"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /webhooks/dead-letters [get]
+func (wh *WebhookHandler) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		wh.Logger.Infow("can`t parse pagination",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := wh.WebhookUseCase.DeadLetters(page.Limit, page.Offset)
+	if err != nil {
+		wh.fail(w, err, "get dead letters")
+		return
+	}
+
+	wh.write(w, http.StatusOK, deliveries)
+}
+
+// Retry godoc
+// @Summary      Retry dead letter
+// @Description  Send a dead delivery again, with a new set of attempts
+// @Tags     webhooks
+// @Param    Authorization header string true "token"
+// @Param id path int true "DELIVERY_ID"
+// @Success 204 {object} nil "delivery pending again"
+// @Failure 401 {object} nil "no auth"
+// @Failure 403 {object} nil "forbidden"
+// @Failure 404 {object} nil "dead delivery not found"
+// @Failure 500 {object} nil "internal server error"
+// @Router   /webhooks/dead-letters/{id}/retry [post]
+func (wh *WebhookHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	id, ok := wh.pathID(w, r, "DELIVERY_ID")
+	if !ok {
+		return
+	}
+
+	err := wh.WebhookUseCase.Retry(id)
+	if err != nil {
+		wh.fail(w, err, "retry delivery")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (wh *WebhookHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	idString := r.PathValue(name)
+	if idString == "" {
+		wh.Logger.Errorw("no " + name + " var")
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		wh.Logger.Errorw("fail to convert id to int",
+			"err:", err.Error())
+		http.Error(w, "unknown error", http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (wh *WebhookHandler) readForm(w http.ResponseWriter, r *http.Request, form interface{}) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		wh.Logger.Errorw("can`t read body of request",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	err = r.Body.Close()
+	if err != nil {
+		wh.Logger.Errorw("can`t close body of request", "err:", err.Error())
+		http.Error(w, "close error", http.StatusInternalServerError)
+		return false
+	}
+
+	err = json.Unmarshal(body, form)
+	if err != nil {
+		wh.Logger.Infow("can`t unmarshal form",
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// fail maps the errors of the use case to a status.
+func (wh *WebhookHandler) fail(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, webhookUseCase.ErrInvalidWebhook):
+		wh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "bad data", http.StatusBadRequest)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		wh.Logger.Infow("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		wh.Logger.Errorw("can`t "+action,
+			"err:", err.Error())
+		http.Error(w, "can`t "+action, http.StatusInternalServerError)
+	}
+}
+
+func (wh *WebhookHandler) write(w http.ResponseWriter, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+
+	if err != nil {
+		wh.Logger.Errorw("can`t marshal response",
+			"err:", err.Error())
+		http.Error(w, "can`t make response", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+
+	_, err = w.Write(resp)
+	if err != nil {
+		wh.Logger.Errorw("can`t write response",
+			"err:", err.Error())
+		http.Error(w, "can`t write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"cmp"
+	"intern/internal/memdb"
+	"intern/internal/webhook/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type memWebhookRepo struct {
+	Logger logger.Logger
+	DB     *memdb.DB
+}
+
+func New(logger logger.Logger, db *memdb.DB) repository.WebhookRepositoryI {
+	return &memWebhookRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (wr *memWebhookRepo) Create(w *models.Webhook) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	w.ID = wr.DB.NextID("webhooks")
+	w.CreatedAt = time.Now()
+	wr.DB.Webhooks[w.ID] = *w
+
+	return nil
+}
+
+func (wr *memWebhookRepo) List(limit, offset int) ([]models.Webhook, error) {
+	wr.DB.RLock()
+	defer wr.DB.RUnlock()
+
+	webhooks := make([]models.Webhook, 0, len(wr.DB.Webhooks))
+	for _, w := range wr.DB.Webhooks {
+		webhooks = append(webhooks, w)
+	}
+
+	slices.SortFunc(webhooks, func(a, b models.Webhook) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return memdb.Page(webhooks, limit, offset), nil
+}
+
+func (wr *memWebhookRepo) Delete(id int) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	if _, ok := wr.DB.Webhooks[id]; !ok {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memWebhookRepo.Delete error")
+	}
+
+	delete(wr.DB.Webhooks, id)
+	for deliveryID, d := range wr.DB.WebhookDeliveries {
+		if d.WebhookID == id {
+			delete(wr.DB.WebhookDeliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (wr *memWebhookRepo) Fanout(limit int) (int, error) {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	events := memdb.Page(wr.DB.Events[wr.DB.DispatchedEvents:], limit, 0)
+
+	webhooks := make([]models.Webhook, 0, len(wr.DB.Webhooks))
+	for _, w := range wr.DB.Webhooks {
+		webhooks = append(webhooks, w)
+	}
+
+	slices.SortFunc(webhooks, func(a, b models.Webhook) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	now := time.Now()
+	for _, e := range events {
+		for _, w := range webhooks {
+			if len(w.Events) > 0 && !slices.Contains(w.Events, e.Type) {
+				continue
+			}
+
+			id := wr.DB.NextID("webhook_deliveries")
+			wr.DB.WebhookDeliveries[id] = models.WebhookDelivery{
+				ID:            id,
+				WebhookID:     w.ID,
+				Event:         e,
+				Status:        models.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			}
+		}
+	}
+	wr.DB.DispatchedEvents += len(events)
+
+	return len(events), nil
+}
+
+func (wr *memWebhookRepo) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	due := []models.WebhookDelivery{}
+	for _, d := range wr.DB.WebhookDeliveries {
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	slices.SortFunc(due, func(a, b models.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), cmp.Compare(a.ID, b.ID))
+	})
+	due = memdb.Page(due, limit, 0)
+
+	for i := range due {
+		due[i].NextAttemptAt = now.Add(lease)
+		wr.DB.WebhookDeliveries[due[i].ID] = due[i]
+
+		w := wr.DB.Webhooks[due[i].WebhookID]
+		due[i].URL, due[i].Secret = w.URL, w.Secret
+	}
+
+	return due, nil
+}
+
+// SaveAttempt does nothing if the delivery went with its webhook.
+func (wr *memWebhookRepo) SaveAttempt(d *models.WebhookDelivery) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	stored, ok := wr.DB.WebhookDeliveries[d.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = d.Status
+	stored.Attempts = d.Attempts
+	stored.NextAttemptAt = d.NextAttemptAt
+	stored.LastError = d.LastError
+	stored.DeliveredAt = d.DeliveredAt
+	wr.DB.WebhookDeliveries[d.ID] = stored
+
+	return nil
+}
+
+func (wr *memWebhookRepo) DeadLetters(limit, offset int) ([]models.WebhookDelivery, error) {
+	wr.DB.RLock()
+	defer wr.DB.RUnlock()
+
+	dead := []models.WebhookDelivery{}
+	for _, d := range wr.DB.WebhookDeliveries {
+		if d.Status == models.DeliveryDead {
+			dead = append(dead, d)
+		}
+	}
+
+	slices.SortFunc(dead, func(a, b models.WebhookDelivery) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	return memdb.Page(dead, limit, offset), nil
+}
+
+func (wr *memWebhookRepo) Retry(id int, now time.Time) error {
+	wr.DB.Lock()
+	defer wr.DB.Unlock()
+
+	d, ok := wr.DB.WebhookDeliveries[id]
+	if !ok || d.Status != models.DeliveryDead {
+		return errors.Wrap(gorm.ErrRecordNotFound, "memWebhookRepo.Retry error")
+	}
+
+	d.Status = models.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	wr.DB.WebhookDeliveries[id] = d
+
+	return nil
+}
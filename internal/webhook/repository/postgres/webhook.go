@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"intern/internal/webhook/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"time"
+)
+
+// fanoutQuery marks a batch of the outbox dispatched and adds its
+// deliveries in one statement; SKIP LOCKED lets concurrent dispatchers
+// take different batches.
+const fanoutQuery = `WITH events AS (
+	UPDATE outbox_events SET dispatched_at = now()
+	WHERE id IN (
+		SELECT id FROM outbox_events WHERE dispatched_at IS NULL
+		ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, type
+), deliveries AS (
+	INSERT INTO webhook_deliveries (webhook_id, event_id)
+	SELECT w.id, e.id FROM events e
+	JOIN webhooks w ON w.events = '{}' OR e.type = ANY(w.events)
+	ORDER BY e.id, w.id
+)
+SELECT count(*) FROM events`
+
+const claimDueQuery = `UPDATE webhook_deliveries d SET next_attempt_at = ?
+FROM webhooks w, outbox_events e
+WHERE d.id IN (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= ?
+	ORDER BY next_attempt_at, id LIMIT ? FOR UPDATE SKIP LOCKED
+) AND w.id = d.webhook_id AND e.id = d.event_id
+RETURNING d.id, d.webhook_id, d.status, d.attempts, d.next_attempt_at, d.last_error, d.delivered_at, d.created_at,
+	w.url, w.secret, e.id AS event_id, e.type AS event_type, e.payload AS event_payload, e.created_at AS event_created_at`
+
+// deliveryRow is a delivery joined with its webhook and event.
+type deliveryRow struct {
+	ID             int
+	WebhookID      int
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	URL            string
+	Secret         string
+	EventID        int
+	EventType      string
+	EventPayload   json.RawMessage
+	EventCreatedAt time.Time
+}
+
+func (r deliveryRow) delivery() models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:            r.ID,
+		WebhookID:     r.WebhookID,
+		Event:         models.Event{ID: r.EventID, Type: r.EventType, Payload: r.EventPayload, CreatedAt: r.EventCreatedAt},
+		Status:        r.Status,
+		Attempts:      r.Attempts,
+		NextAttemptAt: r.NextAttemptAt,
+		LastError:     r.LastError,
+		DeliveredAt:   r.DeliveredAt,
+		CreatedAt:     r.CreatedAt,
+		URL:           r.URL,
+		Secret:        r.Secret,
+	}
+}
+
+type pgWebhookRepo struct {
+	Logger logger.Logger
+	DB     *gorm.DB
+}
+
+func New(logger logger.Logger, db *gorm.DB) repository.WebhookRepositoryI {
+	return &pgWebhookRepo{
+		Logger: logger,
+		DB:     db,
+	}
+}
+
+func (wr *pgWebhookRepo) Create(w *models.Webhook) error {
+	tx := wr.DB.Create(w)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWebhookRepo.Create error")
+	}
+
+	return nil
+}
+
+func (wr *pgWebhookRepo) List(limit, offset int) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	tx := wr.DB.Model(&models.Webhook{})
+
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+
+	tx = tx.Order("id").Offset(offset).Find(&webhooks)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWebhookRepo.List error")
+	}
+
+	return webhooks, nil
+}
+
+func (wr *pgWebhookRepo) Delete(id int) error {
+	tx := wr.DB.Delete(&models.Webhook{}, id)
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWebhookRepo.Delete error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgWebhookRepo.Delete error")
+	}
+
+	return nil
+}
+
+func (wr *pgWebhookRepo) Fanout(limit int) (int, error) {
+	var events int
+	tx := wr.DB.Raw(fanoutQuery, limit).Scan(&events)
+
+	if tx.Error != nil {
+		return 0, errors.Wrap(tx.Error, "pgWebhookRepo.Fanout error")
+	}
+
+	return events, nil
+}
+
+func (wr *pgWebhookRepo) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var rows []deliveryRow
+	tx := wr.DB.Raw(claimDueQuery, now.Add(lease), now, limit).Scan(&rows)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWebhookRepo.ClaimDue error")
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(rows))
+	for i, r := range rows {
+		deliveries[i] = r.delivery()
+	}
+
+	return deliveries, nil
+}
+
+// SaveAttempt does nothing if the delivery went with its webhook.
+func (wr *pgWebhookRepo) SaveAttempt(d *models.WebhookDelivery) error {
+	tx := wr.DB.Table("webhook_deliveries").Where("id = ?", d.ID).Updates(map[string]interface{}{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_attempt_at": d.NextAttemptAt,
+		"last_error":      d.LastError,
+		"delivered_at":    d.DeliveredAt,
+	})
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWebhookRepo.SaveAttempt error")
+	}
+
+	return nil
+}
+
+func (wr *pgWebhookRepo) DeadLetters(limit, offset int) ([]models.WebhookDelivery, error) {
+	var rows []deliveryRow
+	tx := wr.DB.Table("webhook_dead_letters")
+
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+
+	tx = tx.Order("id DESC").Offset(offset).Find(&rows)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "pgWebhookRepo.DeadLetters error")
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(rows))
+	for i, r := range rows {
+		deliveries[i] = r.delivery()
+	}
+
+	return deliveries, nil
+}
+
+func (wr *pgWebhookRepo) Retry(id int, now time.Time) error {
+	tx := wr.DB.Table("webhook_deliveries").Where("id = ? AND status = ?", id, models.DeliveryDead).Updates(map[string]interface{}{
+		"status":          models.DeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	})
+
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "pgWebhookRepo.Retry error")
+	}
+
+	if tx.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "pgWebhookRepo.Retry error")
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ozontech/allure-go/pkg/framework/provider"
+	"github.com/ozontech/allure-go/pkg/framework/suite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	webhookRep "intern/internal/webhook/repository"
+	"intern/models"
+	"intern/pkg/logger"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type WebhookRepoTestSuite struct {
+	suite.Suite
+	db     *sql.DB
+	gormDB *gorm.DB
+	mock   sqlmock.Sqlmock
+	repo   webhookRep.WebhookRepositoryI
+}
+
+func TestWebhookRepoSuite(t *testing.T) {
+	suite.RunSuite(t, new(WebhookRepoTestSuite))
+}
+
+func (s *WebhookRepoTestSuite) BeforeEach(t provider.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error while creating sql mock")
+	}
+
+	dialector := postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	})
+	gormDB, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		t.Fatal("error gorm open")
+	}
+
+	var logger logger.Logger
+
+	s.db = db
+	s.gormDB = gormDB
+	s.mock = mock
+
+	s.repo = New(logger, gormDB)
+}
+
+func (s *WebhookRepoTestSuite) AfterEach(t provider.T) {
+	err := s.mock.ExpectationsWereMet()
+	t.Assert().NoError(err)
+	s.db.Close()
+}
+
+func (s *WebhookRepoTestSuite) TestFanout(t provider.T) {
+	s.mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(500).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	events, err := s.repo.Fanout(500)
+	t.Assert().NoError(err)
+	t.Assert().Equal(3, events)
+}
+
+func (s *WebhookRepoTestSuite) TestClaimDue(t provider.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(`UPDATE webhook_deliveries d SET next_attempt_at = $1`)).
+		WithArgs(now.Add(time.Minute), now, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "status", "attempts", "next_attempt_at", "last_error", "delivered_at", "created_at",
+			"url", "secret", "event_id", "event_type", "event_payload", "event_created_at"}).
+			AddRow(7, 2, models.DeliveryPending, 1, now.Add(time.Minute), "status 500", nil, now,
+				"https://example.com/hook", "s3cret", 5, models.EventMovieCreated, []byte(`{"id":1}`), now))
+
+	deliveries, err := s.repo.ClaimDue(now, time.Minute, 50)
+	t.Assert().NoError(err)
+	t.Assert().Equal([]models.WebhookDelivery{{
+		ID:            7,
+		WebhookID:     2,
+		Event:         models.Event{ID: 5, Type: models.EventMovieCreated, Payload: []byte(`{"id":1}`), CreatedAt: now},
+		Status:        models.DeliveryPending,
+		Attempts:      1,
+		NextAttemptAt: now.Add(time.Minute),
+		LastError:     "status 500",
+		CreatedAt:     now,
+		URL:           "https://example.com/hook",
+		Secret:        "s3cret",
+	}}, deliveries)
+}
+
+func (s *WebhookRepoTestSuite) TestRetry(t provider.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	retry := regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "attempts"=$1,"next_attempt_at"=$2,"status"=$3 WHERE id = $4 AND status = $5`)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(retry).WithArgs(0, now, models.DeliveryPending, 7, models.DeliveryDead).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Retry(7, now)
+	t.Assert().NoError(err)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(retry).WithArgs(0, now, models.DeliveryPending, 8, models.DeliveryDead).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err = s.repo.Retry(8, now)
+	t.Assert().ErrorIs(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"intern/models"
+	"time"
+)
+
+// WebhookRepositoryI stores the webhooks and their deliveries, and reads
+// the outbox the events are written to along with the changes.
+type WebhookRepositoryI interface {
+	// Create stores the webhook and sets its ID and CreatedAt.
+	Create(w *models.Webhook) error
+	List(limit, offset int) ([]models.Webhook, error)
+	// Delete deletes the webhook with its deliveries.
+	Delete(id int) error
+
+	// Fanout takes up to limit events from the outbox, oldest first, adds
+	// a pending delivery of each for every webhook of its type and returns
+	// the number of events taken.
+	Fanout(limit int) (int, error)
+	// ClaimDue returns up to limit pending deliveries due at now, with
+	// their event, URL and secret, and puts their next attempt off by
+	// lease so that other dispatchers leave them alone meanwhile.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	// SaveAttempt stores the status, attempts, next attempt, last error and
+	// delivery time of the delivery.
+	SaveAttempt(d *models.WebhookDelivery) error
+
+	// DeadLetters returns the dead deliveries, the most recent first.
+	DeadLetters(limit, offset int) ([]models.WebhookDelivery, error)
+	// Retry makes a dead delivery pending again, due at now.
+	Retry(id int, now time.Time) error
+}
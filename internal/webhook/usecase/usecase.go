@@ -0,0 +1,265 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/pkg/errors"
+	webhookRep "intern/internal/webhook/repository"
+	"intern/models"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type WebhookUseCaseI interface {
+	// Create registers the webhook, with a generated secret unless it has
+	// one.
+	Create(w *models.Webhook) error
+	List(limit, offset int) ([]models.Webhook, error)
+	Delete(id int) error
+	DeadLetters(limit, offset int) ([]models.WebhookDelivery, error)
+	// Retry sends a dead delivery again, with a new set of attempts.
+	Retry(id int) error
+	// Dispatch turns the new events of the outbox into deliveries and
+	// sends those that are due.
+	Dispatch() (models.DispatchStats, error)
+}
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+const (
+	// MaxAttempts is how many times a delivery is sent before it is dead.
+	MaxAttempts = 8
+	// A failed delivery is retried after retryDelay, doubled with every
+	// further attempt up to maxRetryDelay.
+	retryDelay    = 30 * time.Second
+	maxRetryDelay = 6 * time.Hour
+
+	fanoutBatch = 500
+	// Deliveries are sent concurrently in batches of deliveryBatch; lease
+	// must outlast the timeout of the client.
+	deliveryBatch = 50
+	lease         = 5 * time.Minute
+)
+
+type webhookUseCase struct {
+	webhookRepository webhookRep.WebhookRepositoryI
+	client            *http.Client
+}
+
+func New(wRep webhookRep.WebhookRepositoryI, client *http.Client) WebhookUseCaseI {
+	return &webhookUseCase{
+		webhookRepository: wRep,
+		client:            client,
+	}
+}
+
+func (wUC *webhookUseCase) Create(w *models.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrapf(ErrInvalidWebhook, "webhookUseCase.Create error: url %q", w.URL)
+	}
+
+	for _, eventType := range w.Events {
+		if !slices.Contains(models.EventTypes, eventType) {
+			return errors.Wrapf(ErrInvalidWebhook, "webhookUseCase.Create error: unknown event type %q", eventType)
+		}
+	}
+
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	if w.Secret == "" {
+		w.Secret, err = newSecret()
+		if err != nil {
+			return errors.Wrap(err, "webhookUseCase.Create error: can't make secret")
+		}
+	}
+
+	err = wUC.webhookRepository.Create(w)
+	if err != nil {
+		return errors.Wrap(err, "webhookUseCase.Create error")
+	}
+
+	return nil
+}
+
+func (wUC *webhookUseCase) List(limit, offset int) ([]models.Webhook, error) {
+	webhooks, err := wUC.webhookRepository.List(limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "webhookUseCase.List error")
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (wUC *webhookUseCase) Delete(id int) error {
+	err := wUC.webhookRepository.Delete(id)
+	if err != nil {
+		return errors.Wrap(err, "webhookUseCase.Delete error")
+	}
+
+	return nil
+}
+
+func (wUC *webhookUseCase) DeadLetters(limit, offset int) ([]models.WebhookDelivery, error) {
+	deliveries, err := wUC.webhookRepository.DeadLetters(limit, offset)
+	if err != nil {
+		return nil, errors.Wrap(err, "webhookUseCase.DeadLetters error")
+	}
+
+	return deliveries, nil
+}
+
+func (wUC *webhookUseCase) Retry(id int) error {
+	err := wUC.webhookRepository.Retry(id, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "webhookUseCase.Retry error")
+	}
+
+	return nil
+}
+
+func (wUC *webhookUseCase) Dispatch() (models.DispatchStats, error) {
+	stats := models.DispatchStats{}
+
+	for {
+		events, err := wUC.webhookRepository.Fanout(fanoutBatch)
+		if err != nil {
+			return stats, errors.Wrap(err, "webhookUseCase.Dispatch error")
+		}
+
+		stats.Events += events
+		if events < fanoutBatch {
+			break
+		}
+	}
+
+	for {
+		deliveries, err := wUC.webhookRepository.ClaimDue(time.Now(), lease, deliveryBatch)
+		if err != nil {
+			return stats, errors.Wrap(err, "webhookUseCase.Dispatch error")
+		}
+
+		results := make([]error, len(deliveries))
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = wUC.send(deliveries[i])
+			}()
+		}
+		wg.Wait()
+
+		for i := range deliveries {
+			d := &deliveries[i]
+			d.Attempts++
+
+			switch {
+			case results[i] == nil:
+				now := time.Now()
+				d.Status = models.DeliveryDelivered
+				d.DeliveredAt = &now
+				d.LastError = ""
+				stats.Delivered++
+			case d.Attempts >= MaxAttempts:
+				d.Status = models.DeliveryDead
+				d.LastError = results[i].Error()
+				stats.Dead++
+			default:
+				d.NextAttemptAt = time.Now().Add(retryAfter(d.Attempts))
+				d.LastError = results[i].Error()
+				stats.Retried++
+			}
+
+			err = wUC.webhookRepository.SaveAttempt(d)
+			if err != nil {
+				return stats, errors.Wrap(err, "webhookUseCase.Dispatch error")
+			}
+		}
+
+		if len(deliveries) < deliveryBatch {
+			return stats, nil
+		}
+	}
+}
+
+// send posts the event of the delivery, any status but 2xx is a failure.
+func (wUC *webhookUseCase) send(d models.WebhookDelivery) error {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", d.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(d.ID))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(d.Secret, timestamp, body))
+
+	resp, err := wUC.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the X-Webhook-Signature of a request, see models.Webhook.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryAfter is the delay before the next attempt after the given number
+// of failed ones.
+func retryAfter(attempts int) time.Duration {
+	delay := retryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
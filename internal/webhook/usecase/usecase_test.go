@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"encoding/json"
+	memActor "intern/internal/actor/repository/memory"
+	"intern/internal/memdb"
+	memMovie "intern/internal/movie/repository/memory"
+	memTrash "intern/internal/trash/repository/memory"
+	memWebhook "intern/internal/webhook/repository/memory"
+	"intern/internal/webhook/repository/mocks"
+	"intern/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// receiver records the events it is sent and answers with status.
+type receiver struct {
+	sync.Mutex
+	t      *testing.T
+	secret string
+	status int
+	events []models.Event
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(rc.t, err)
+
+	assert.Equal(rc.t, Sign(rc.secret, r.Header.Get("X-Webhook-Timestamp"), body), r.Header.Get("X-Webhook-Signature"))
+
+	var e models.Event
+	require.NoError(rc.t, json.Unmarshal(body, &e))
+	assert.Equal(rc.t, e.Type, r.Header.Get("X-Webhook-Event"))
+
+	rc.Lock()
+	defer rc.Unlock()
+
+	rc.events = append(rc.events, e)
+	w.WriteHeader(rc.status)
+}
+
+func (rc *receiver) types() []string {
+	rc.Lock()
+	defer rc.Unlock()
+
+	types := make([]string, len(rc.events))
+	for i, e := range rc.events {
+		types[i] = e.Type
+	}
+
+	return types
+}
+
+func TestDispatch(t *testing.T) {
+	db := memdb.New()
+	uc := New(memWebhook.New(nil, db), http.DefaultClient)
+
+	rc := &receiver{t: t, secret: "s3cret", status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	require.NoError(t, uc.Create(&models.Webhook{URL: srv.URL, Secret: rc.secret, Events: []string{models.EventMovieCreated, models.EventMovieDeleted, models.EventCastChanged}}))
+
+	movies, actors := memMovie.New(nil, db), memActor.New(nil, db)
+	movie := &models.Movie{Title: "Alien"}
+	require.NoError(t, movies.Create(movie))
+	require.NoError(t, actors.Create(&models.Actor{FirstName: "Sigourney"}))
+	db.MoviesActors[1] = models.MovieActor{ID: 1, MovieID: movie.ID, ActorID: 1}
+	// The title is unchanged, so the first update is no event.
+	require.NoError(t, movies.Update(&models.Movie{ID: movie.ID, Title: "Alien"}))
+	require.NoError(t, movies.Update(&models.Movie{ID: movie.ID, Rating: 8}))
+	require.NoError(t, movies.Delete(movie.ID))
+	_, err := memTrash.New(nil, db).Purge(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	stats, err := uc.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, models.DispatchStats{Events: 6, Delivered: 3}, stats)
+	// Deliveries are sent concurrently, in no particular order.
+	assert.ElementsMatch(t, []string{models.EventMovieCreated, models.EventMovieDeleted, models.EventCastChanged}, rc.types())
+	for _, e := range rc.events {
+		if e.Type == models.EventCastChanged {
+			assert.JSONEq(t, `{"movieId":1,"actorId":1,"change":"removed"}`, string(e.Payload))
+		}
+	}
+
+	// Delivered deliveries are not sent again.
+	stats, err = uc.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, models.DispatchStats{}, stats)
+}
+
+func TestDispatchRetry(t *testing.T) {
+	db := memdb.New()
+	uc := New(memWebhook.New(nil, db), http.DefaultClient)
+
+	rc := &receiver{t: t, status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	webhook := &models.Webhook{URL: srv.URL}
+	require.NoError(t, uc.Create(webhook))
+	rc.secret = webhook.Secret
+	assert.Len(t, webhook.Secret, 64)
+
+	require.NoError(t, memActor.New(nil, db).Create(&models.Actor{FirstName: "Sigourney"}))
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		start := time.Now()
+		stats, err := uc.Dispatch()
+		require.NoError(t, err)
+
+		d := db.WebhookDeliveries[1]
+		assert.Equal(t, attempt, d.Attempts)
+		assert.Equal(t, "status 500", d.LastError)
+
+		if attempt < MaxAttempts {
+			assert.Equal(t, 1, stats.Retried)
+			assert.WithinRange(t, d.NextAttemptAt, start.Add(retryAfter(attempt)), time.Now().Add(retryAfter(attempt)))
+
+			// Not due yet.
+			stats, err = uc.Dispatch()
+			require.NoError(t, err)
+			assert.Equal(t, models.DispatchStats{}, stats)
+
+			d.NextAttemptAt = time.Now()
+			db.WebhookDeliveries[1] = d
+		} else {
+			assert.Equal(t, 1, stats.Dead)
+		}
+	}
+
+	dead, err := uc.DeadLetters(20, 0)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, models.EventActorCreated, dead[0].Event.Type)
+	assert.Equal(t, models.DeliveryDead, dead[0].Status)
+
+	rc.status = http.StatusOK
+	require.NoError(t, uc.Retry(dead[0].ID))
+	assert.True(t, errors.Is(uc.Retry(dead[0].ID), gorm.ErrRecordNotFound))
+
+	stats, err := uc.Dispatch()
+	require.NoError(t, err)
+	assert.Equal(t, models.DispatchStats{Delivered: 1}, stats)
+	assert.Equal(t, models.DeliveryDelivered, db.WebhookDeliveries[1].Status)
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryAfter(1))
+	assert.Equal(t, time.Minute, retryAfter(2))
+	assert.Equal(t, 8*time.Minute, retryAfter(5))
+	assert.Equal(t, maxRetryDelay, retryAfter(20))
+}
+
+func TestCreateInvalid(t *testing.T) {
+	uc := New(memWebhook.New(nil, memdb.New()), http.DefaultClient)
+
+	for _, w := range []models.Webhook{
+		{URL: "example.com/hook"},
+		{URL: "ftp://example.com/hook"},
+		{URL: "https://example.com/hook", Events: []string{"movie.rated"}},
+	} {
+		err := uc.Create(&w)
+		assert.True(t, errors.Is(err, ErrInvalidWebhook), w)
+	}
+
+	webhooks, err := uc.List(20, 0)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+}
+
+func TestDispatchError(t *testing.T) {
+	rep := &mocks.WebhookRepositoryI{}
+	rep.On("Fanout", mock.Anything).Return(0, errors.New("connection refused"))
+
+	_, err := New(rep, http.DefaultClient).Dispatch()
+	assert.Error(t, err)
+}
@@ -0,0 +1,4 @@
+drop table if exists public.users;
+drop table if exists public.movies_actors;
+drop table if exists public.movies;
+drop table if exists public.actors;
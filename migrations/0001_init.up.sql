@@ -1,4 +1,3 @@
-drop table if exists actors cascade;
 create table public.actors(
     id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     first_name VARCHAR(35) NOT NULL,
@@ -7,7 +6,6 @@ create table public.actors(
     birthday DATE NOT NULL
 );
 
-drop table if exists movies cascade;
 create table public.movies(
     id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     title VARCHAR(150) NOT NULL,
@@ -16,7 +14,6 @@ create table public.movies(
     rating INT NOT NULL
 );
 
-drop table if exists movies_actors cascade;
 create table public.movies_actors(
     id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     movie_id INT NOT NULL,
@@ -25,10 +22,9 @@ create table public.movies_actors(
     foreign key (actor_id) references public.actors(id)
 );
 
-drop table if exists users cascade;
 create table public.users(
     id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
     login VARCHAR(256) NOT NULL UNIQUE,
     password VARCHAR(128) NOT NULL,
     user_role VARCHAR(20) NOT NULL
-);
\ No newline at end of file
+);
@@ -0,0 +1,2 @@
+drop index if exists public.movies_search_vector_idx;
+alter table public.movies drop column if exists search_vector;
@@ -0,0 +1,7 @@
+alter table public.movies
+    add column search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', description), 'B')
+    ) STORED;
+
+create index movies_search_vector_idx on public.movies using gin (search_vector);
@@ -0,0 +1,4 @@
+drop index if exists public.movies_title_trgm_idx;
+drop index if exists public.actors_full_name_trgm_idx;
+drop index if exists public.actors_last_name_trgm_idx;
+drop index if exists public.actors_first_name_trgm_idx;
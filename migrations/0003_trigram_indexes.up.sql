@@ -0,0 +1,6 @@
+create extension if not exists pg_trgm;
+
+create index actors_first_name_trgm_idx on public.actors using gin (first_name gin_trgm_ops);
+create index actors_last_name_trgm_idx on public.actors using gin (last_name gin_trgm_ops);
+create index actors_full_name_trgm_idx on public.actors using gin ((first_name || ' ' || last_name) gin_trgm_ops);
+create index movies_title_trgm_idx on public.movies using gin (title gin_trgm_ops);
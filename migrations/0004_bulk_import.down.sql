@@ -0,0 +1,4 @@
+drop table if exists public.import_jobs;
+
+drop index if exists public.actors_natural_key_idx;
+drop index if exists public.movies_natural_key_idx;
@@ -0,0 +1,18 @@
+create index movies_natural_key_idx on public.movies (title, release_date);
+create index actors_natural_key_idx on public.actors (first_name, last_name, birthday);
+
+create table public.import_jobs(
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    dry_run BOOLEAN NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    created INT NOT NULL DEFAULT 0,
+    updated INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
@@ -0,0 +1,3 @@
+drop table if exists public.imdb_import_progress;
+drop table if exists public.imdb_names;
+drop table if exists public.imdb_titles;
@@ -0,0 +1,21 @@
+create table public.imdb_titles(
+    tconst VARCHAR(16) PRIMARY KEY,
+    movie_id INT NOT NULL,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create table public.imdb_names(
+    nconst VARCHAR(16) PRIMARY KEY,
+    actor_id INT NOT NULL,
+    foreign key (actor_id) references public.actors(id) on delete cascade
+);
+
+create index imdb_titles_movie_id_idx on public.imdb_titles (movie_id);
+create index imdb_names_actor_id_idx on public.imdb_names (actor_id);
+
+create table public.imdb_import_progress(
+    file VARCHAR(64) PRIMARY KEY,
+    line BIGINT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
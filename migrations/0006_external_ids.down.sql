@@ -0,0 +1,23 @@
+create table public.imdb_titles(
+    tconst VARCHAR(16) PRIMARY KEY,
+    movie_id INT NOT NULL,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create table public.imdb_names(
+    nconst VARCHAR(16) PRIMARY KEY,
+    actor_id INT NOT NULL,
+    foreign key (actor_id) references public.actors(id) on delete cascade
+);
+
+create index imdb_titles_movie_id_idx on public.imdb_titles (movie_id);
+create index imdb_names_actor_id_idx on public.imdb_names (actor_id);
+
+insert into public.imdb_titles (tconst, movie_id)
+select external_id, movie_id from public.movie_external_ids where source = 'imdb';
+
+insert into public.imdb_names (nconst, actor_id)
+select external_id, actor_id from public.actor_external_ids where source = 'imdb';
+
+drop table if exists public.actor_external_ids;
+drop table if exists public.movie_external_ids;
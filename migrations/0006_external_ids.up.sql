@@ -0,0 +1,27 @@
+create table public.movie_external_ids(
+    source VARCHAR(20) NOT NULL,
+    external_id VARCHAR(64) NOT NULL,
+    movie_id INT NOT NULL,
+    PRIMARY KEY (source, external_id),
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create table public.actor_external_ids(
+    source VARCHAR(20) NOT NULL,
+    external_id VARCHAR(64) NOT NULL,
+    actor_id INT NOT NULL,
+    PRIMARY KEY (source, external_id),
+    foreign key (actor_id) references public.actors(id) on delete cascade
+);
+
+create index movie_external_ids_movie_id_idx on public.movie_external_ids (movie_id);
+create index actor_external_ids_actor_id_idx on public.actor_external_ids (actor_id);
+
+insert into public.movie_external_ids (source, external_id, movie_id)
+select 'imdb', tconst, movie_id from public.imdb_titles;
+
+insert into public.actor_external_ids (source, external_id, actor_id)
+select 'imdb', nconst, actor_id from public.imdb_names;
+
+drop table public.imdb_titles;
+drop table public.imdb_names;
@@ -0,0 +1,9 @@
+drop table if exists public.ratings;
+
+drop index if exists public.movies_weighted_rating_idx;
+
+alter table public.movies
+    drop column if exists weighted_rating,
+    drop column if exists average_rating,
+    drop column if exists score_sum,
+    drop column if exists votes;
@@ -0,0 +1,18 @@
+alter table public.movies
+    add column votes INT NOT NULL DEFAULT 0,
+    add column score_sum INT NOT NULL DEFAULT 0,
+    add column average_rating DOUBLE PRECISION NOT NULL DEFAULT 0,
+    add column weighted_rating DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+create table public.ratings(
+    user_id INT NOT NULL,
+    movie_id INT NOT NULL,
+    score SMALLINT NOT NULL CHECK (score BETWEEN 1 AND 10),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, movie_id),
+    foreign key (user_id) references public.users(id) on delete cascade,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create index ratings_movie_id_idx on public.ratings (movie_id);
+create index movies_weighted_rating_idx on public.movies (weighted_rating);
@@ -0,0 +1,2 @@
+drop table if exists public.review_votes;
+drop table if exists public.reviews;
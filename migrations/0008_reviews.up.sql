@@ -0,0 +1,26 @@
+create table public.reviews(
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    movie_id INT NOT NULL,
+    user_id INT NOT NULL,
+    text VARCHAR(5000) NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 10),
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    helpful INT NOT NULL DEFAULT 0,
+    unhelpful INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (movie_id, user_id),
+    foreign key (movie_id) references public.movies(id) on delete cascade,
+    foreign key (user_id) references public.users(id) on delete cascade
+);
+
+create index reviews_status_idx on public.reviews (status, created_at);
+
+create table public.review_votes(
+    review_id INT NOT NULL,
+    user_id INT NOT NULL,
+    helpful BOOLEAN NOT NULL,
+    PRIMARY KEY (review_id, user_id),
+    foreign key (review_id) references public.reviews(id) on delete cascade,
+    foreign key (user_id) references public.users(id) on delete cascade
+);
@@ -0,0 +1,2 @@
+drop table if exists public.watch_history;
+drop table if exists public.watchlist;
@@ -0,0 +1,19 @@
+create table public.watchlist(
+    user_id INT NOT NULL,
+    movie_id INT NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, movie_id),
+    foreign key (user_id) references public.users(id) on delete cascade,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create table public.watch_history(
+    user_id INT NOT NULL,
+    movie_id INT NOT NULL,
+    watched_on DATE NOT NULL,
+    PRIMARY KEY (user_id, movie_id),
+    foreign key (user_id) references public.users(id) on delete cascade,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create index watch_history_user_watched_idx on public.watch_history (user_id, watched_on);
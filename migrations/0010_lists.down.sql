@@ -0,0 +1,2 @@
+drop table if exists public.list_entries;
+drop table if exists public.lists;
@@ -0,0 +1,27 @@
+create table public.lists(
+    id INT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(1000) NOT NULL DEFAULT '',
+    visibility VARCHAR(10) NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'unlisted', 'private')),
+    share_token VARCHAR(32) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    foreign key (user_id) references public.users(id) on delete cascade
+);
+
+create index lists_user_id_idx on public.lists (user_id);
+
+-- Positions order the entries of a list. Moving entries shifts many of them
+-- in one transaction, so uniqueness is only checked at commit.
+create table public.list_entries(
+    list_id INT NOT NULL,
+    movie_id INT NOT NULL,
+    position INT NOT NULL,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (list_id, movie_id),
+    UNIQUE (list_id, position) DEFERRABLE INITIALLY DEFERRED,
+    foreign key (list_id) references public.lists(id) on delete cascade,
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
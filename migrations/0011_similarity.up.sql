@@ -0,0 +1,12 @@
+create table public.movie_similarity(
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('cast', 'ratings')),
+    movie_id INT NOT NULL,
+    similar_id INT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    shared INT NOT NULL,
+    PRIMARY KEY (kind, movie_id, similar_id),
+    foreign key (movie_id) references public.movies(id) on delete cascade,
+    foreign key (similar_id) references public.movies(id) on delete cascade
+);
+
+create index movie_similarity_rank_idx on public.movie_similarity (kind, movie_id, score DESC);
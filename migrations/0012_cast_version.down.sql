@@ -0,0 +1,3 @@
+drop trigger if exists movies_actors_cast_version on public.movies_actors;
+drop function if exists public.bump_cast_version();
+drop table if exists public.cast_version;
@@ -0,0 +1,20 @@
+-- A single row counting the statements that changed movies_actors, so the
+-- in-memory cast graph of the servers can tell when to rebuild. It is bumped
+-- by a trigger to catch every writer, the imdb and seed commands included.
+create table public.cast_version(
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    version BIGINT NOT NULL
+);
+
+insert into public.cast_version (id, version) values (true, 0);
+
+create function public.bump_cast_version() returns trigger language plpgsql as $$
+begin
+    update public.cast_version set version = version + 1;
+    return null;
+end
+$$;
+
+create trigger movies_actors_cast_version
+    after insert or update or delete or truncate on public.movies_actors
+    for each statement execute function public.bump_cast_version();
@@ -0,0 +1,4 @@
+drop materialized view if exists public.stats_cast_ages;
+drop materialized view if exists public.stats_cast_genders;
+drop materialized view if exists public.stats_actor_movies;
+drop materialized view if exists public.stats_movies_per_year;
@@ -0,0 +1,38 @@
+-- Snapshots of the catalogue statistics, read instead of the live
+-- aggregations when the server runs with STATS_REFRESH_INTERVAL. The unique
+-- indexes let them be refreshed concurrently, without blocking readers.
+create materialized view public.stats_movies_per_year as
+select extract(year from release_date)::int as year,
+       count(*) as movies,
+       coalesce(avg(rating), 0)::double precision as average_rating,
+       coalesce(sum(votes), 0)::int as votes,
+       coalesce(sum(score_sum)::double precision / nullif(sum(votes), 0), 0) as average_user_rating
+from public.movies
+group by 1;
+
+create unique index stats_movies_per_year_idx on public.stats_movies_per_year (year);
+
+create materialized view public.stats_actor_movies as
+select actor_id, count(distinct movie_id)::int as movies_count
+from public.movies_actors
+group by actor_id;
+
+create unique index stats_actor_movies_idx on public.stats_actor_movies (actor_id);
+
+create materialized view public.stats_cast_genders as
+select a.gender, count(distinct a.id)::int as actors, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.actors a on a.id = ma.actor_id
+group by a.gender;
+
+create unique index stats_cast_genders_idx on public.stats_cast_genders (gender);
+
+create materialized view public.stats_cast_ages as
+select extract(year from age(m.release_date, a.birthday))::int as age, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.movies m on m.id = ma.movie_id
+join public.actors a on a.id = ma.actor_id
+where m.release_date >= a.birthday
+group by 1;
+
+create unique index stats_cast_ages_idx on public.stats_cast_ages (age);
@@ -0,0 +1,56 @@
+-- Rows still in the trash come back when the column goes.
+drop materialized view if exists public.stats_cast_ages;
+drop materialized view if exists public.stats_cast_genders;
+drop materialized view if exists public.stats_actor_movies;
+drop materialized view if exists public.stats_movies_per_year;
+
+create materialized view public.stats_movies_per_year as
+select extract(year from release_date)::int as year,
+       count(*) as movies,
+       coalesce(avg(rating), 0)::double precision as average_rating,
+       coalesce(sum(votes), 0)::int as votes,
+       coalesce(sum(score_sum)::double precision / nullif(sum(votes), 0), 0) as average_user_rating
+from public.movies
+group by 1;
+
+create unique index stats_movies_per_year_idx on public.stats_movies_per_year (year);
+
+create materialized view public.stats_actor_movies as
+select actor_id, count(distinct movie_id)::int as movies_count
+from public.movies_actors
+group by actor_id;
+
+create unique index stats_actor_movies_idx on public.stats_actor_movies (actor_id);
+
+create materialized view public.stats_cast_genders as
+select a.gender, count(distinct a.id)::int as actors, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.actors a on a.id = ma.actor_id
+group by a.gender;
+
+create unique index stats_cast_genders_idx on public.stats_cast_genders (gender);
+
+create materialized view public.stats_cast_ages as
+select extract(year from age(m.release_date, a.birthday))::int as age, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.movies m on m.id = ma.movie_id
+join public.actors a on a.id = ma.actor_id
+where m.release_date >= a.birthday
+group by 1;
+
+create unique index stats_cast_ages_idx on public.stats_cast_ages (age);
+
+drop trigger if exists actors_cast_version on public.actors;
+drop trigger if exists movies_cast_version on public.movies;
+
+alter table public.movies_actors
+    drop constraint movies_actors_movie_id_fkey,
+    drop constraint movies_actors_actor_id_fkey,
+    add constraint movies_actors_movie_id_fkey foreign key (movie_id) references public.movies(id),
+    add constraint movies_actors_actor_id_fkey foreign key (actor_id) references public.actors(id);
+
+drop index if exists public.actors_deleted_at_idx;
+drop index if exists public.movies_deleted_at_idx;
+
+alter table public.actors drop column if exists deleted_at;
+alter table public.movies drop column if exists deleted_at;
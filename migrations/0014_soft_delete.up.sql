@@ -0,0 +1,72 @@
+-- Deleting a movie or an actor moves it to the trash: deleted_at is set and
+-- the row with everything hanging off it stays until the purge job.
+alter table public.movies add column deleted_at TIMESTAMPTZ;
+alter table public.actors add column deleted_at TIMESTAMPTZ;
+
+create index movies_deleted_at_idx on public.movies (deleted_at) where deleted_at is not null;
+create index actors_deleted_at_idx on public.actors (deleted_at) where deleted_at is not null;
+
+-- The purge takes the cast links along, like every other table already
+-- does with its references.
+alter table public.movies_actors
+    drop constraint movies_actors_movie_id_fkey,
+    drop constraint movies_actors_actor_id_fkey,
+    add constraint movies_actors_movie_id_fkey foreign key (movie_id) references public.movies(id) on delete cascade,
+    add constraint movies_actors_actor_id_fkey foreign key (actor_id) references public.actors(id) on delete cascade;
+
+-- Trashing and restoring hide and show cast links, the cast graph has to
+-- be rebuilt.
+create trigger movies_cast_version
+    after update of deleted_at on public.movies
+    for each statement execute function public.bump_cast_version();
+
+create trigger actors_cast_version
+    after update of deleted_at on public.actors
+    for each statement execute function public.bump_cast_version();
+
+-- The statistics leave the trash out.
+drop materialized view public.stats_cast_ages;
+drop materialized view public.stats_cast_genders;
+drop materialized view public.stats_actor_movies;
+drop materialized view public.stats_movies_per_year;
+
+create materialized view public.stats_movies_per_year as
+select extract(year from release_date)::int as year,
+       count(*) as movies,
+       coalesce(avg(rating), 0)::double precision as average_rating,
+       coalesce(sum(votes), 0)::int as votes,
+       coalesce(sum(score_sum)::double precision / nullif(sum(votes), 0), 0) as average_user_rating
+from public.movies
+where deleted_at is null
+group by 1;
+
+create unique index stats_movies_per_year_idx on public.stats_movies_per_year (year);
+
+create materialized view public.stats_actor_movies as
+select ma.actor_id, count(distinct ma.movie_id)::int as movies_count
+from public.movies_actors ma
+join public.movies m on m.id = ma.movie_id
+where m.deleted_at is null
+group by ma.actor_id;
+
+create unique index stats_actor_movies_idx on public.stats_actor_movies (actor_id);
+
+create materialized view public.stats_cast_genders as
+select a.gender, count(distinct a.id)::int as actors, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.movies m on m.id = ma.movie_id
+join public.actors a on a.id = ma.actor_id
+where m.deleted_at is null and a.deleted_at is null
+group by a.gender;
+
+create unique index stats_cast_genders_idx on public.stats_cast_genders (gender);
+
+create materialized view public.stats_cast_ages as
+select extract(year from age(m.release_date, a.birthday))::int as age, count(*)::int as credits
+from (select distinct movie_id, actor_id from public.movies_actors) ma
+join public.movies m on m.id = ma.movie_id
+join public.actors a on a.id = ma.actor_id
+where m.release_date >= a.birthday and m.deleted_at is null and a.deleted_at is null
+group by 1;
+
+create unique index stats_cast_ages_idx on public.stats_cast_ages (age);
@@ -0,0 +1,3 @@
+drop trigger if exists audit_entries_append_only on public.audit_entries;
+drop function if exists public.audit_entries_append_only();
+drop table if exists public.audit_entries;
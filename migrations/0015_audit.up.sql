@@ -0,0 +1,25 @@
+-- Changes of movies and actors made through the API. The log is
+-- append-only: a trigger rejects every update, delete and truncate. user_id
+-- has no foreign key, so entries outlive their users.
+create table public.audit_entries(
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    action TEXT NOT NULL,
+    entity TEXT NOT NULL,
+    entity_id INT NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+create index audit_entries_entity_idx on public.audit_entries (entity, entity_id, id);
+
+create function public.audit_entries_append_only() returns trigger language plpgsql as $$
+begin
+    raise exception 'audit_entries is append-only';
+end
+$$;
+
+create trigger audit_entries_append_only
+    before update or delete or truncate on public.audit_entries
+    for each statement execute function public.audit_entries_append_only();
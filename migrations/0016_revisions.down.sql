@@ -0,0 +1,2 @@
+drop table if exists public.actor_revisions;
+drop table if exists public.movie_revisions;
@@ -0,0 +1,21 @@
+-- Versions of movies and actors, written by every change through the API.
+-- They go along with their movie or actor when it is purged from the trash.
+create table public.movie_revisions(
+    movie_id INT NOT NULL,
+    rev INT NOT NULL,
+    user_id INT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (movie_id, rev),
+    foreign key (movie_id) references public.movies(id) on delete cascade
+);
+
+create table public.actor_revisions(
+    actor_id INT NOT NULL,
+    rev INT NOT NULL,
+    user_id INT NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (actor_id, rev),
+    foreign key (actor_id) references public.actors(id) on delete cascade
+);
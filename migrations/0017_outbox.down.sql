@@ -0,0 +1,11 @@
+drop view if exists public.webhook_dead_letters;
+drop table if exists public.webhook_deliveries;
+drop table if exists public.webhooks;
+drop trigger if exists movies_actors_outbox on public.movies_actors;
+drop trigger if exists actors_outbox_update on public.actors;
+drop trigger if exists actors_outbox on public.actors;
+drop trigger if exists movies_outbox_update on public.movies;
+drop trigger if exists movies_outbox on public.movies;
+drop function if exists public.outbox_cast_event();
+drop function if exists public.outbox_entity_event();
+drop table if exists public.outbox_events;
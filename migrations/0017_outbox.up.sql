@@ -0,0 +1,120 @@
+-- Change events of movies, actors and casts. Like cast_version they are
+-- written by triggers, in the transaction of the change, so every writer
+-- is covered. The dispatcher turns them into webhook deliveries and sets
+-- dispatched_at.
+create table public.outbox_events(
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+create index outbox_events_pending_idx on public.outbox_events (id) where dispatched_at is null;
+
+-- TG_ARGV[0] is the entity, movie or actor. Moving to the trash and back
+-- are updates of deleted_at, a purge is the delete.
+create function public.outbox_entity_event() returns trigger language plpgsql as $$
+declare
+    change TEXT;
+    entity_id INT;
+begin
+    if TG_OP = 'DELETE' then
+        change := 'purged';
+        entity_id := OLD.id;
+    else
+        entity_id := NEW.id;
+
+        if TG_OP = 'INSERT' then
+            change := 'created';
+        elsif OLD.deleted_at is null and NEW.deleted_at is not null then
+            change := 'deleted';
+        elsif OLD.deleted_at is not null and NEW.deleted_at is null then
+            change := 'restored';
+        else
+            change := 'updated';
+        end if;
+    end if;
+
+    insert into public.outbox_events (type, payload)
+    values (TG_ARGV[0] || '.' || change, jsonb_build_object('id', entity_id));
+
+    return null;
+end
+$$;
+
+create function public.outbox_cast_event() returns trigger language plpgsql as $$
+begin
+    if TG_OP = 'DELETE' then
+        insert into public.outbox_events (type, payload)
+        values ('cast.changed', jsonb_build_object('movieId', OLD.movie_id, 'actorId', OLD.actor_id, 'change', 'removed'));
+    else
+        insert into public.outbox_events (type, payload)
+        values ('cast.changed', jsonb_build_object('movieId', NEW.movie_id, 'actorId', NEW.actor_id, 'change', 'added'));
+    end if;
+
+    return null;
+end
+$$;
+
+create trigger movies_outbox
+    after insert or delete on public.movies
+    for each row execute function public.outbox_entity_event('movie');
+
+-- Updates of the audience rating statistics alone are not catalogue
+-- changes.
+create trigger movies_outbox_update
+    after update on public.movies
+    for each row
+    when ((OLD.title, OLD.description, OLD.release_date, OLD.rating, OLD.deleted_at)
+        is distinct from (NEW.title, NEW.description, NEW.release_date, NEW.rating, NEW.deleted_at))
+    execute function public.outbox_entity_event('movie');
+
+create trigger actors_outbox
+    after insert or delete on public.actors
+    for each row execute function public.outbox_entity_event('actor');
+
+create trigger actors_outbox_update
+    after update on public.actors
+    for each row
+    when ((OLD.first_name, OLD.last_name, OLD.gender, OLD.birthday, OLD.deleted_at)
+        is distinct from (NEW.first_name, NEW.last_name, NEW.gender, NEW.birthday, NEW.deleted_at))
+    execute function public.outbox_entity_event('actor');
+
+create trigger movies_actors_outbox
+    after insert or delete on public.movies_actors
+    for each row execute function public.outbox_cast_event();
+
+-- events lists the event types sent to the webhook, every type if empty.
+create table public.webhooks(
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One row per event and subscribed webhook. A pending delivery is sent at
+-- next_attempt_at; it is dead once the dispatcher gives up on it.
+create table public.webhook_deliveries(
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event_id BIGINT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    foreign key (webhook_id) references public.webhooks(id) on delete cascade,
+    foreign key (event_id) references public.outbox_events(id) on delete cascade
+);
+
+create index webhook_deliveries_due_idx on public.webhook_deliveries (next_attempt_at) where status = 'pending';
+
+create view public.webhook_dead_letters as
+select d.id, d.webhook_id, d.status, d.attempts, d.next_attempt_at, d.last_error, d.delivered_at, d.created_at,
+    e.id as event_id, e.type as event_type, e.payload as event_payload, e.created_at as event_created_at
+from public.webhook_deliveries d
+join public.outbox_events e on e.id = d.event_id
+where d.status = 'dead';
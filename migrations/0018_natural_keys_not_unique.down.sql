@@ -0,0 +1 @@
+-- 0004 now creates the same indexes, there is nothing to undo.
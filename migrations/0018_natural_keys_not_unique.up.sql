@@ -0,0 +1,6 @@
+-- 0004 used to make the natural keys unique, which real movies and people
+-- sharing a title or a name and date need not be.
+drop index if exists public.movies_natural_key_idx;
+drop index if exists public.actors_natural_key_idx;
+create index movies_natural_key_idx on public.movies (title, release_date);
+create index actors_natural_key_idx on public.actors (first_name, last_name, birthday);
@@ -0,0 +1,4 @@
+drop index if exists public.import_jobs_running_idx;
+
+alter table public.import_jobs drop column if exists updated_at;
+alter table public.import_jobs drop column if exists error;
@@ -0,0 +1,4 @@
+alter table public.import_jobs add column error TEXT NOT NULL DEFAULT '';
+alter table public.import_jobs add column updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+create index import_jobs_running_idx on public.import_jobs (updated_at) where status = 'running';
@@ -0,0 +1,8 @@
+drop trigger if exists actor_external_ids_touch on public.actor_external_ids;
+drop trigger if exists movie_external_ids_touch on public.movie_external_ids;
+drop function if exists public.touch_external_id_owner();
+drop trigger if exists actors_touch_updated_at on public.actors;
+drop trigger if exists movies_touch_updated_at on public.movies;
+drop function if exists public.touch_updated_at();
+alter table public.actors drop column if exists updated_at;
+alter table public.movies drop column if exists updated_at;
@@ -0,0 +1,46 @@
+-- When a movie or an actor last changed, for the Last-Modified of their
+-- responses. A trigger keeps it, so every writer is covered; the external
+-- ids are part of the responses and touch their movie or actor too.
+alter table public.movies add column updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+alter table public.actors add column updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+create function public.touch_updated_at() returns trigger language plpgsql as $$
+begin
+    NEW.updated_at := now();
+    return NEW;
+end
+$$;
+
+create trigger movies_touch_updated_at
+    before update on public.movies
+    for each row execute function public.touch_updated_at();
+
+create trigger actors_touch_updated_at
+    before update on public.actors
+    for each row execute function public.touch_updated_at();
+
+-- TG_ARGV[0] is the table of the owners, TG_ARGV[1] the column of the
+-- external ids referring to them.
+create function public.touch_external_id_owner() returns trigger language plpgsql as $$
+declare
+    owner_id INT;
+begin
+    if TG_OP = 'DELETE' then
+        owner_id := to_jsonb(OLD) ->> TG_ARGV[1];
+    else
+        owner_id := to_jsonb(NEW) ->> TG_ARGV[1];
+    end if;
+
+    execute format('update public.%I set updated_at = now() where id = $1', TG_ARGV[0]) using owner_id;
+
+    return null;
+end
+$$;
+
+create trigger movie_external_ids_touch
+    after insert or update or delete on public.movie_external_ids
+    for each row execute function public.touch_external_id_owner('movies', 'movie_id');
+
+create trigger actor_external_ids_touch
+    after insert or update or delete on public.actor_external_ids
+    for each row execute function public.touch_external_id_owner('actors', 'actor_id');
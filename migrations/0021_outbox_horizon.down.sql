@@ -0,0 +1,4 @@
+drop trigger if exists outbox_events_horizon on public.outbox_events;
+drop function if exists public.outbox_event_horizon();
+drop index if exists public.outbox_events_horizon_idx;
+alter table public.outbox_events drop column if exists horizon;
@@ -0,0 +1,24 @@
+-- The ids of the outbox are taken when the events are written, not when
+-- they commit: a reader that sees an event may not see one with a lower id
+-- yet. horizon is an xid above every transaction that may still write such
+-- an event: the xmax of a snapshot taken after the id. Once the oldest
+-- transaction in progress is past it, the event and every one before it
+-- are either committed or never will be. Events from before this column
+-- are settled.
+alter table public.outbox_events add column horizon xid8;
+
+-- The events past the oldest transaction in progress are the latest few.
+create index outbox_events_horizon_idx on public.outbox_events (horizon);
+
+-- The assignment runs after the id default and, in READ COMMITTED, gets a
+-- snapshot of its own.
+create function public.outbox_event_horizon() returns trigger language plpgsql as $$
+begin
+    NEW.horizon := pg_snapshot_xmax(pg_current_snapshot());
+    return NEW;
+end
+$$;
+
+create trigger outbox_events_horizon
+    before insert on public.outbox_events
+    for each row execute function public.outbox_event_horizon();
@@ -0,0 +1 @@
+alter table public.import_jobs drop column if exists restored;
@@ -0,0 +1 @@
+alter table public.import_jobs add column restored INT NOT NULL DEFAULT 0;
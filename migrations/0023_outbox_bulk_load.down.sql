@@ -0,0 +1,42 @@
+create or replace function public.outbox_entity_event() returns trigger language plpgsql as $$
+declare
+    change TEXT;
+    entity_id INT;
+begin
+    if TG_OP = 'DELETE' then
+        change := 'purged';
+        entity_id := OLD.id;
+    else
+        entity_id := NEW.id;
+
+        if TG_OP = 'INSERT' then
+            change := 'created';
+        elsif OLD.deleted_at is null and NEW.deleted_at is not null then
+            change := 'deleted';
+        elsif OLD.deleted_at is not null and NEW.deleted_at is null then
+            change := 'restored';
+        else
+            change := 'updated';
+        end if;
+    end if;
+
+    insert into public.outbox_events (type, payload)
+    values (TG_ARGV[0] || '.' || change, jsonb_build_object('id', entity_id));
+
+    return null;
+end
+$$;
+
+create or replace function public.outbox_cast_event() returns trigger language plpgsql as $$
+begin
+    if TG_OP = 'DELETE' then
+        insert into public.outbox_events (type, payload)
+        values ('cast.changed', jsonb_build_object('movieId', OLD.movie_id, 'actorId', OLD.actor_id, 'change', 'removed'));
+    else
+        insert into public.outbox_events (type, payload)
+        values ('cast.changed', jsonb_build_object('movieId', NEW.movie_id, 'actorId', NEW.actor_id, 'change', 'added'));
+    end if;
+
+    return null;
+end
+$$;
@@ -0,0 +1,8 @@
+// Package migrations holds the versioned schema of the database. Files are
+// named NNNN_name.up.sql / NNNN_name.down.sql and applied by pkg/migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Actor struct {
 	ID        int       `json:"id" db:"id"`
@@ -8,4 +12,39 @@ type Actor struct {
 	LastName  string    `json:"lastName" db:"last_name"`
 	Gender    byte      `json:"gender" db:"gender"`
 	Birthday  time.Time `json:"birthday" db:"birthday"`
+	// ExternalIDs is filled for single actor responses only.
+	ExternalIDs []ExternalID `json:"externalIds,omitempty" db:"-" gorm:"-"`
+	// DeletedAt is set while the actor is in the trash, see Movie.
+	DeletedAt gorm.DeletedAt `json:"-" db:"deleted_at"`
+	// UpdatedAt is when the actor last changed, kept by the database. It is
+	// sent as Last-Modified rather than in the body.
+	UpdatedAt time.Time `json:"-" db:"updated_at" gorm:"->"`
+}
+
+const (
+	ActorSortName     = "name"
+	ActorSortBirthday = "birthday"
+	ActorSortMovies   = "movies"
+)
+
+type ActorFilter struct {
+	Name       string
+	Gender     string
+	BornAfter  *time.Time
+	BornBefore *time.Time
+	SortBy     string
+	Desc       bool
+	Limit      int
+	Offset     int
+}
+
+type ActorListItem struct {
+	Actor
+	MoviesCount int `json:"moviesCount" db:"movies_count"`
+}
+
+// Costar is an actor who played in movies together with another one.
+type Costar struct {
+	Actor
+	SharedMovies int `json:"sharedMovies" db:"shared_movies"`
 }
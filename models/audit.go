@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate           = "create"
+	AuditUpdate           = "update"
+	AuditDelete           = "delete"
+	AuditRestore          = "restore"
+	AuditRevert           = "revert"
+	AuditAddExternalID    = "add_external_id"
+	AuditDeleteExternalID = "delete_external_id"
+	AuditImport           = "import"
+)
+
+const (
+	AuditMovie = "movie"
+	AuditActor = "actor"
+)
+
+// AuditEntry records one change of a movie or an actor. Before and After
+// hold the fields that changed with their old and new values; the side
+// that does not exist, like Before of a create, is null. UserID is the
+// signed in user that made the change, 0 if there was none. An import is
+// one entry of EntityID 0 for all the rows it writes, After tells which.
+type AuditEntry struct {
+	ID        int             `json:"id" db:"id"`
+	UserID    int             `json:"userId" db:"user_id"`
+	Action    string          `json:"action" db:"action"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityID  int             `json:"entityId" db:"entity_id"`
+	Before    json.RawMessage `json:"before" db:"before"`
+	After     json.RawMessage `json:"after" db:"after"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// AuditFilter selects the entries of one entity type (any when empty) and,
+// within it, of one id (any when zero). Newest entries come first.
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	Limit    int
+	Offset   int
+}
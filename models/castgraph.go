@@ -0,0 +1,10 @@
+package models
+
+// CastPath is a shortest chain of actors linked by the movies they played
+// in together: Actors[i] and Actors[i+1] both appear in Movies[i]. Degrees
+// is the number of movies on the way.
+type CastPath struct {
+	Degrees int     `json:"degrees"`
+	Actors  []Actor `json:"actors"`
+	Movies  []Movie `json:"movies"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types. movie.* and actor.* carry an EntityEvent, cast.changed a
+// CastEvent. deleted and restored move to and out of the trash, purged is
+// the delete for good. catalogue.loaded carries a BulkEvent and stands for
+// all the events of a bulk load, which writes no other.
+const (
+	EventMovieCreated    = "movie.created"
+	EventMovieUpdated    = "movie.updated"
+	EventMovieDeleted    = "movie.deleted"
+	EventMovieRestored   = "movie.restored"
+	EventMoviePurged     = "movie.purged"
+	EventActorCreated    = "actor.created"
+	EventActorUpdated    = "actor.updated"
+	EventActorDeleted    = "actor.deleted"
+	EventActorRestored   = "actor.restored"
+	EventActorPurged     = "actor.purged"
+	EventCastChanged     = "cast.changed"
+	EventCatalogueLoaded = "catalogue.loaded"
+)
+
+var EventTypes = []string{
+	EventMovieCreated, EventMovieUpdated, EventMovieDeleted, EventMovieRestored, EventMoviePurged,
+	EventActorCreated, EventActorUpdated, EventActorDeleted, EventActorRestored, EventActorPurged,
+	EventCastChanged, EventCatalogueLoaded,
+}
+
+// The entities of the events, the part of their type before the dot.
+const (
+	EventEntityMovie     = "movie"
+	EventEntityActor     = "actor"
+	EventEntityCast      = "cast"
+	EventEntityCatalogue = "catalogue"
+)
+
+// The sources of bulk loads.
+const (
+	BulkSourceSeed   = "seed"
+	BulkSourceImdb   = "imdb"
+	BulkSourceImport = "import"
+)
+
+const (
+	CastAdded   = "added"
+	CastRemoved = "removed"
+)
+
+// Event is a change of the catalogue from the outbox, written along with
+// the change itself. Payload only identifies what changed, receivers read
+// the rest from the API.
+type Event struct {
+	ID        int             `json:"id" db:"id"`
+	Type      string          `json:"type" db:"type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+type EntityEvent struct {
+	ID int `json:"id"`
+}
+
+type CastEvent struct {
+	MovieID int    `json:"movieId"`
+	ActorID int    `json:"actorId"`
+	Change  string `json:"change"`
+}
+
+// BulkEvent lists the movies and actors a bulk load created or changed,
+// the casts included.
+type BulkEvent struct {
+	Source string `json:"source"`
+	Movies []int  `json:"movies"`
+	Actors []int  `json:"actors"`
+}
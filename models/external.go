@@ -0,0 +1,30 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	ExternalSourceIMDb     = "imdb"
+	ExternalSourceTMDB     = "tmdb"
+	ExternalSourceWikidata = "wikidata"
+)
+
+// ExternalSources are the catalogues movies and actors can be linked to.
+var ExternalSources = []string{ExternalSourceIMDb, ExternalSourceTMDB, ExternalSourceWikidata}
+
+// ExternalID identifies a movie or an actor in another catalogue. An ID is
+// unique within its source, an entity may have several per source (merged
+// IMDb titles keep both ids).
+type ExternalID struct {
+	Source string `json:"source" db:"source"`
+	ID     string `json:"id" db:"external_id" gorm:"column:external_id"`
+}
+
+// Valid tells whether the source is known and the id fits the
+// external_id column.
+func (e ExternalID) Valid() bool {
+	return slices.Contains(ExternalSources, e.Source) &&
+		e.ID != "" && len(e.ID) <= 64 && strings.TrimSpace(e.ID) == e.ID
+}
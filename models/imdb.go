@@ -0,0 +1,26 @@
+package models
+
+// ImdbTitle is a movie read from title.basics together with its IMDb id.
+type ImdbTitle struct {
+	Tconst string
+	Movie  Movie
+}
+
+// ImdbName is an actor read from name.basics together with its IMDb id.
+type ImdbName struct {
+	Nconst string
+	Actor  Actor
+}
+
+// ImdbPrincipal is a cast link read from title.principals.
+type ImdbPrincipal struct {
+	Tconst string
+	Nconst string
+}
+
+// ImdbProgress tells how many data lines of a dump file have been stored.
+type ImdbProgress struct {
+	File string `json:"file" db:"file"`
+	Line int    `json:"line" db:"line"`
+	Done bool   `json:"done" db:"done"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	ImportMovies = "movies"
+	ImportActors = "actors"
+)
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const (
+	ImportStatusRunning = "running"
+	ImportStatusDone    = "done"
+	ImportStatusFailed  = "failed"
+)
+
+// ImportRowError describes why a row of an import was rejected. Row is the
+// 1-based line number in the uploaded file.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportJob is the progress of an import. Error tells why a failed job
+// stopped before the end of the file.
+type ImportJob struct {
+	ID         int              `json:"id" db:"id"`
+	Kind       string           `json:"kind" db:"kind"`
+	Format     string           `json:"format" db:"format"`
+	DryRun     bool             `json:"dryRun" db:"dry_run"`
+	Status     string           `json:"status" db:"status"`
+	Total      int              `json:"total" db:"total"`
+	Processed  int              `json:"processed" db:"processed"`
+	Created    int              `json:"created" db:"created"`
+	Updated    int              `json:"updated" db:"updated"`
+	Restored   int              `json:"restored" db:"restored"`
+	Failed     int              `json:"failed" db:"failed"`
+	Errors     []ImportRowError `json:"errors" db:"errors" gorm:"serializer:json"`
+	Error      string           `json:"error,omitempty" db:"error"`
+	CreatedAt  time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time        `json:"updatedAt" db:"updated_at"`
+	FinishedAt *time.Time       `json:"finishedAt,omitempty" db:"finished_at"`
+}
+
+// The outcomes of an upsert by natural key. A restore is an update of a
+// row in the trash.
+const (
+	UpsertCreated  = "created"
+	UpsertUpdated  = "updated"
+	UpsertRestored = "restored"
+)
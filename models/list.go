@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ListPublic   = "public"
+	ListUnlisted = "unlisted"
+	ListPrivate  = "private"
+)
+
+const (
+	MaxListName        = 100
+	MaxListDescription = 1000
+	MaxListNote        = 500
+)
+
+// List is a named, ordered selection of movies. Public lists can be read by
+// every signed in user; public and unlisted ones also by anyone holding the
+// share token, without signing in.
+type List struct {
+	ID          int    `json:"id" db:"id"`
+	UserID      int    `json:"userId" db:"user_id"`
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+	Visibility  string `json:"visibility" db:"visibility"`
+	// ShareToken is only shown to the owner.
+	ShareToken string    `json:"shareToken,omitempty" db:"share_token"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time `json:"updatedAt" db:"updated_at"`
+	// Entries is filled for single list responses only.
+	Entries []ListEntry `json:"entries,omitempty" db:"-" gorm:"-"`
+}
+
+type ListEntry struct {
+	ListID   int       `json:"-" db:"list_id"`
+	MovieID  int       `json:"movieId" db:"movie_id"`
+	Position int       `json:"position" db:"position"`
+	Note     string    `json:"note" db:"note"`
+	AddedAt  time.Time `json:"addedAt" db:"added_at"`
+	Movie    *Movie    `json:"movie,omitempty" db:"-" gorm:"-"`
+}
@@ -1,11 +1,48 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Movie struct {
 	ID          int       `json:"id" db:"id"`
 	Title       string    `json:"title" db:"title"`
 	Description string    `json:"description" db:"description"`
 	ReleaseDate time.Time `json:"releaseDate" db:"releaseDate"`
-	Rating      int       `json:"rating" db:"rating"`
+	// Rating is the editorial score, RatingStats the audience one.
+	Rating int `json:"rating" db:"rating"`
+	RatingStats
+	// ExternalIDs is filled for single movie responses only.
+	ExternalIDs []ExternalID `json:"externalIds,omitempty" db:"-" gorm:"-"`
+	// DeletedAt is set while the movie is in the trash; gorm leaves such
+	// rows out of its queries and turns Delete into setting it.
+	DeletedAt gorm.DeletedAt `json:"-" db:"deleted_at"`
+	// UpdatedAt is when the movie last changed, kept by the database. It is
+	// sent as Last-Modified rather than in the body.
+	UpdatedAt time.Time `json:"-" db:"updated_at" gorm:"->"`
+}
+
+const (
+	MovieSortID          = "id"
+	MovieSortTitle       = "title"
+	MovieSortReleaseDate = "release_date"
+	MovieSortRating      = "rating"
+	MovieSortWeighted    = "weighted_rating"
+)
+
+// MovieFilter selects movies by a fragment of the title, like
+// GET /movies/title, in the order of one of the MovieSort* columns.
+type MovieFilter struct {
+	Title  string
+	SortBy string
+	Desc   bool
+}
+
+type MovieSearchResult struct {
+	Movie
+	Rank           float64 `json:"rank" db:"rank"`
+	TitleHighlight string  `json:"titleHighlight" db:"title_highlight"`
+	Snippet        string  `json:"snippet" db:"snippet"`
 }
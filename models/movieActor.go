@@ -5,3 +5,10 @@ type MovieActor struct {
 	MovieID int `json:"movie_id" db:"movie_id"`
 	ActorID int `json:"actor_id" db:"actor_id"`
 }
+
+// CreditFilter narrows cast links down to a movie and/or an actor; zero
+// fields do not filter.
+type CreditFilter struct {
+	MovieID int
+	ActorID int
+}
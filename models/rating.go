@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	MinRatingScore = 1
+	MaxRatingScore = 10
+)
+
+// The weighted rating pulls the average of a movie with few votes towards
+// RatingPriorMean, as if it had RatingPriorVotes extra votes of that score.
+const (
+	RatingPriorMean  = 6.0
+	RatingPriorVotes = 10
+)
+
+type Rating struct {
+	UserID    int       `json:"userId" db:"user_id"`
+	MovieID   int       `json:"movieId" db:"movie_id"`
+	Score     int       `json:"score" db:"score"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// RatingStats aggregates the user ratings of a movie. The columns are only
+// written by the rating repositories, hence read-only for gorm.
+type RatingStats struct {
+	Votes          int     `json:"votes" db:"votes" gorm:"->"`
+	ScoreSum       int     `json:"-" db:"score_sum" gorm:"->"`
+	AverageRating  float64 `json:"averageRating" db:"average_rating" gorm:"->"`
+	WeightedRating float64 `json:"weightedRating" db:"weighted_rating" gorm:"->"`
+}
+
+// Apply moves the stats by a change of the ratings: votes is 1 for a new
+// rating, -1 for a removed one and 0 for a changed one, sum is the change
+// of the score total. Movies without votes have zero averages.
+func (s RatingStats) Apply(votes, sum int) RatingStats {
+	s.Votes += votes
+	s.ScoreSum += sum
+	s.AverageRating, s.WeightedRating = 0, 0
+
+	if s.Votes > 0 {
+		s.AverageRating = float64(s.ScoreSum) / float64(s.Votes)
+		s.WeightedRating = (RatingPriorVotes*RatingPriorMean + float64(s.ScoreSum)) / float64(RatingPriorVotes+s.Votes)
+	}
+
+	return s
+}
@@ -0,0 +1,49 @@
+package models
+
+// Kinds of precomputed movie similarity. Cast similarity stands in for
+// "movies like this one"; genres are not stored, so it is built from shared
+// actors and the release years only. Ratings similarity is the item-based
+// collaborative filtering behind the personal recommendations.
+const (
+	SimilarityCast    = "cast"
+	SimilarityRatings = "ratings"
+)
+
+// Similarity links a movie to one of its nearest neighbours. Shared counts
+// the actors both movies have (cast) or the users who rated both (ratings).
+type Similarity struct {
+	Kind      string  `json:"kind" db:"kind"`
+	MovieID   int     `json:"movieId" db:"movie_id"`
+	SimilarID int     `json:"similarId" db:"similar_id"`
+	Score     float64 `json:"score" db:"score"`
+	Shared    int     `json:"shared" db:"shared"`
+}
+
+type SimilarMovie struct {
+	Movie
+	Score        float64 `json:"score" db:"score"`
+	SharedActors int     `json:"sharedActors" db:"shared_actors"`
+	Reason       string  `json:"reason" db:"-" gorm:"-"`
+}
+
+type MovieRef struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// Recommendation is a movie the user has not rated, watched or put on the
+// watchlist. Score is the predicted rating; Because lists the liked movies
+// it was found through, empty for the highest rated movies that fill up short
+// lists.
+type Recommendation struct {
+	Movie
+	Score   float64    `json:"score"`
+	Because []MovieRef `json:"because,omitempty"`
+	Reason  string     `json:"reason"`
+}
+
+// SimilarityStats reports a run of the similarity job.
+type SimilarityStats struct {
+	CastPairs    int `json:"castPairs"`
+	RatingsPairs int `json:"ratingsPairs"`
+}
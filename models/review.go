@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortHelpful = "helpful"
+)
+
+const MaxReviewLength = 5000
+
+type Review struct {
+	ID        int       `json:"id" db:"id"`
+	MovieID   int       `json:"movieId" db:"movie_id"`
+	UserID    int       `json:"userId" db:"user_id"`
+	Text      string    `json:"text" db:"text"`
+	Rating    int       `json:"rating" db:"rating"`
+	Status    string    `json:"status" db:"status"`
+	Helpful   int       `json:"helpful" db:"helpful"`
+	Unhelpful int       `json:"unhelpful" db:"unhelpful"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// ReviewFilter selects reviews of a movie (any movie when zero) in one
+// moderation state (any when empty). OrUserID lets the reviews of that user
+// through whatever their state, so authors see their pending reviews.
+type ReviewFilter struct {
+	MovieID  int
+	Status   string
+	OrUserID int
+	SortBy   string
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Revision is a stored version of a movie or an actor, numbered from 1 per
+// movie or actor. Data is the movie or actor as JSON, without the fields
+// that are not edited, like the audience rating.
+type Revision struct {
+	Rev       int             `json:"rev" db:"rev"`
+	UserID    int             `json:"userId" db:"user_id"`
+	Data      json.RawMessage `json:"data" db:"data"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// RevisionDiff holds the fields that differ from revision From to revision
+// To, with their values in each. Before is null when From is 0, the state
+// before the first revision.
+type RevisionDiff struct {
+	From   int             `json:"from"`
+	To     int             `json:"to"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// MoviesPerYear summarises the movies released in one calendar year.
+// AverageRating is the mean editorial rating, AverageUserRating the mean of
+// all user ratings given to the movies of the year, 0 without any.
+type MoviesPerYear struct {
+	Year              int     `json:"year" db:"year"`
+	Movies            int     `json:"movies" db:"movies"`
+	AverageRating     float64 `json:"averageRating" db:"average_rating"`
+	Votes             int     `json:"votes" db:"votes"`
+	AverageUserRating float64 `json:"averageUserRating" db:"average_user_rating"`
+}
+
+// CastGender counts the actors of one gender with at least one movie and
+// the cast links they hold.
+type CastGender struct {
+	Gender  string `json:"gender" db:"gender"`
+	Actors  int    `json:"actors" db:"actors"`
+	Credits int    `json:"credits" db:"credits"`
+}
+
+// CastAge counts the cast links of actors who were Age years old, in full
+// years, when the movie was released.
+type CastAge struct {
+	Age     int `json:"age" db:"age"`
+	Credits int `json:"credits" db:"credits"`
+}
+
+// AgeBucket counts the cast links with an age at release from From to To,
+// both included.
+type AgeBucket struct {
+	From    int `json:"from"`
+	To      int `json:"to"`
+	Credits int `json:"credits"`
+}
+
+// CastAgeStats describes the age of actors at release time over all cast
+// links. Links of movies released before the actor was born are left out.
+type CastAgeStats struct {
+	Credits    int         `json:"credits"`
+	AverageAge float64     `json:"averageAge"`
+	MedianAge  int         `json:"medianAge"`
+	Youngest   int         `json:"youngest"`
+	Oldest     int         `json:"oldest"`
+	Buckets    []AgeBucket `json:"buckets"`
+}
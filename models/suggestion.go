@@ -0,0 +1,13 @@
+package models
+
+const (
+	SuggestionMovie = "movie"
+	SuggestionActor = "actor"
+)
+
+type Suggestion struct {
+	ID    int     `json:"id" db:"id"`
+	Type  string  `json:"type" db:"type"`
+	Text  string  `json:"text" db:"text"`
+	Score float64 `json:"score" db:"score"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	TrashMovie = "movie"
+	TrashActor = "actor"
+)
+
+// TrashItem is a movie or an actor in the trash; Name is the title or the
+// full name.
+type TrashItem struct {
+	Type      string    `json:"type" db:"type"`
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	DeletedAt time.Time `json:"deletedAt" db:"deleted_at"`
+	// PurgeAt is when the purge job deletes the item for good, unset if the
+	// trash is kept.
+	PurgeAt *time.Time `json:"purgeAt,omitempty" db:"-" gorm:"-"`
+}
+
+// PurgeStats counts what one run of the purge job deleted.
+type PurgeStats struct {
+	Movies int `json:"movies"`
+	Actors int `json:"actors"`
+}
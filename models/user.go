@@ -4,5 +4,5 @@ type User struct {
 	ID       int    `json:"id" db:"id"`
 	Login    string `json:"login" db:"login"`
 	Password string `json:"password" db:"password"`
-	Role     string `json:"role" db:"user_role"`
+	Role     string `json:"role" db:"user_role" gorm:"column:user_role"`
 }
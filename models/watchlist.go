@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type WatchlistItem struct {
+	Movie
+	AddedAt time.Time `json:"addedAt" db:"added_at"`
+}
+
+type WatchedMovie struct {
+	Movie
+	WatchedOn time.Time `json:"watchedOn" db:"watched_on"`
+}
+
+// WatchedPerYear counts the movies a user watched in one calendar year.
+type WatchedPerYear struct {
+	Year   int `json:"year" db:"year"`
+	Movies int `json:"movies" db:"movies"`
+}
+
+type WatchStats struct {
+	Watched int              `json:"watched"`
+	PerYear []WatchedPerYear `json:"perYear"`
+}
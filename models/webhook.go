@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook receives the events of its types as POST requests with the
+// Event as body. X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256,
+// keyed with Secret, of the X-Webhook-Timestamp header, a dot and the body.
+type Webhook struct {
+	ID  int    `json:"id" db:"id"`
+	URL string `json:"url" db:"url"`
+	// Secret is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty" db:"secret"`
+	// Events are the event types sent, every type if empty.
+	Events    pq.StringArray `json:"events" db:"events" gorm:"type:text[]"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is an event to send to a webhook. A failed attempt is
+// retried at NextAttemptAt until the dispatcher gives up and the delivery
+// is dead, a dead letter.
+type WebhookDelivery struct {
+	ID            int        `json:"id" db:"id"`
+	WebhookID     int        `json:"webhookId" db:"webhook_id"`
+	Event         Event      `json:"event" db:"-" gorm:"-"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastError     string     `json:"lastError,omitempty" db:"last_error"`
+	DeliveredAt   *time.Time `json:"deliveredAt,omitempty" db:"delivered_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	// URL and Secret are those of the webhook, filled for sending.
+	URL    string `json:"-" db:"-" gorm:"-"`
+	Secret string `json:"-" db:"-" gorm:"-"`
+}
+
+// DispatchStats counts what one run of the dispatcher did: the events
+// taken from the outbox and the outcome of the attempts.
+type DispatchStats struct {
+	Events    int `json:"events"`
+	Delivered int `json:"delivered"`
+	Retried   int `json:"retried"`
+	Dead      int `json:"dead"`
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"intern/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Keys of the values the movie and actor repositories share, a write to
+// one of them invalidates lists of the other.
+func MovieKey(id int) string       { return fmt.Sprintf("movie:%d", id) }
+func MovieActorsKey(id int) string { return fmt.Sprintf("movie:%d:actors", id) }
+func ActorKey(id int) string       { return fmt.Sprintf("actor:%d", id) }
+func ActorMoviesKey(id int) string { return fmt.Sprintf("actor:%d:movies", id) }
+
+// Stats counts the lookups of one kind of value. Errors are the lookups
+// the Store failed, they are served from the database like misses.
+type Stats struct {
+	Hits   atomic.Int64
+	Misses atomic.Int64
+	Errors atomic.Int64
+}
+
+func (s *Stats) HitRatio() float64 {
+	hits, misses := s.Hits.Load(), s.Misses.Load()
+	if hits+misses == 0 {
+		return 0
+	}
+
+	return float64(hits) / float64(hits+misses)
+}
+
+// Cache reads values through a Store: concurrent misses of a key share a
+// single load, and a load that overlaps an invalidation is not stored, as
+// it may have read what was just changed.
+type Cache struct {
+	store  Store
+	ttl    time.Duration
+	logger logger.Logger
+
+	group singleflight.Group
+	epoch atomic.Uint64
+
+	mu    sync.Mutex
+	stats map[string]*Stats
+}
+
+func New(store Store, ttl time.Duration, logger logger.Logger) *Cache {
+	return &Cache{
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+		stats:  make(map[string]*Stats),
+	}
+}
+
+// Load decodes the value of key into a T, calling load and storing its
+// result when it is missing. kind groups the key in the stats.
+func Load[T any](c *Cache, kind, key string, load func() (T, error)) (T, error) {
+	var v T
+	stats := c.Stats(kind)
+
+	b, ok, err := c.store.Get(key)
+	if err != nil {
+		stats.Errors.Add(1)
+		c.logError("can`t get cached value", key, err)
+	}
+
+	if ok {
+		if err = gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err == nil {
+			stats.Hits.Add(1)
+			return v, nil
+		}
+		c.logError("can`t decode cached value", key, err)
+	}
+	stats.Misses.Add(1)
+
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		epoch := c.epoch.Load()
+
+		v, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err = gob.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+
+		if c.epoch.Load() == epoch {
+			if err = c.store.Set(key, buf.Bytes(), c.ttl); err != nil {
+				c.logError("can`t cache value", key, err)
+			}
+		}
+
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		return v, err
+	}
+
+	// Each caller decodes its own copy, so that none can change another's.
+	err = gob.NewDecoder(bytes.NewReader(res.([]byte))).Decode(&v)
+
+	return v, err
+}
+
+// Delete invalidates keys, loads in flight are not stored.
+func (c *Cache) Delete(keys ...string) {
+	c.epoch.Add(1)
+	for _, key := range keys {
+		c.group.Forget(key)
+	}
+
+	if err := c.store.Delete(keys...); err != nil {
+		c.logError("can`t invalidate cached values", fmt.Sprint(keys), err)
+	}
+}
+
+func (c *Cache) Stats(kind string) *Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[kind]
+	if !ok {
+		s = &Stats{}
+		c.stats[kind] = s
+	}
+
+	return s
+}
+
+// String reports the stats as JSON, which makes the Cache an expvar.Var.
+func (c *Cache) String() string {
+	type kindStats struct {
+		Hits     int64   `json:"hits"`
+		Misses   int64   `json:"misses"`
+		Errors   int64   `json:"errors"`
+		HitRatio float64 `json:"hitRatio"`
+	}
+
+	c.mu.Lock()
+	res := make(map[string]kindStats, len(c.stats))
+	for kind, s := range c.stats {
+		res[kind] = kindStats{Hits: s.Hits.Load(), Misses: s.Misses.Load(), Errors: s.Errors.Load(), HitRatio: s.HitRatio()}
+	}
+	c.mu.Unlock()
+
+	b, _ := json.Marshal(res)
+
+	return string(b)
+}
+
+func (c *Cache) logError(msg, key string, err error) {
+	if c.logger != nil {
+		c.logger.Errorw(msg, "key", key, "err:", err.Error())
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+func TestLoad(t *testing.T) {
+	c := New(NewLRU(10), time.Minute, nil)
+
+	var loads atomic.Int32
+	load := func() (*item, error) {
+		loads.Add(1)
+		return &item{ID: 1, Name: "Alien"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := Load(c, "item", "item:1", load)
+		require.NoError(t, err)
+		assert.Equal(t, &item{ID: 1, Name: "Alien"}, v)
+	}
+	assert.EqualValues(t, 1, loads.Load())
+
+	// Callers get copies.
+	v, _ := Load(c, "item", "item:1", load)
+	v.Name = "Aliens"
+	v, _ = Load(c, "item", "item:1", load)
+	assert.Equal(t, "Alien", v.Name)
+
+	c.Delete("item:1")
+	_, err := Load(c, "item", "item:1", load)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, loads.Load())
+
+	stats := c.Stats("item")
+	assert.EqualValues(t, 4, stats.Hits.Load())
+	assert.EqualValues(t, 2, stats.Misses.Load())
+	assert.InDelta(t, 4.0/6, stats.HitRatio(), 0.001)
+	assert.JSONEq(t, `{"item":{"hits":4,"misses":2,"errors":0,"hitRatio":0.6666666666666666}}`, c.String())
+
+	// Errors are not cached.
+	_, err = Load(c, "item", "item:2", func() (*item, error) { return nil, errors.New("record not found") })
+	assert.Error(t, err)
+	_, ok, _ := c.store.Get("item:2")
+	assert.False(t, ok)
+}
+
+func TestLoadShared(t *testing.T) {
+	c := New(NewLRU(10), time.Minute, nil)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func() ([]item, error) {
+		loads.Add(1)
+		<-release
+		return []item{{ID: 1}}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := Load(c, "items", "items", load)
+			assert.NoError(t, err)
+			assert.Equal(t, []item{{ID: 1}}, v)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return c.Stats("items").Misses.Load() == 10 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, loads.Load())
+}
+
+func TestLoadInvalidated(t *testing.T) {
+	c := New(NewLRU(10), time.Minute, nil)
+
+	// A write invalidates the key while it is loaded.
+	v, err := Load(c, "item", "item:1", func() (*item, error) {
+		c.Delete("item:1")
+		return &item{ID: 1}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, v.ID)
+
+	_, ok, _ := c.store.Get("item:1")
+	assert.False(t, ok)
+}
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLRU(2)
+	l.now = func() time.Time { return now }
+
+	require.NoError(t, l.Set("a", []byte("1"), time.Minute))
+	require.NoError(t, l.Set("b", []byte("2"), time.Hour))
+	_, ok, _ := l.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used.
+	require.NoError(t, l.Set("c", []byte("3"), time.Hour))
+	_, ok, _ = l.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.Len())
+
+	now = now.Add(time.Minute)
+	_, ok, _ = l.Get("a")
+	assert.False(t, ok)
+	v, ok, _ := l.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), v)
+
+	require.NoError(t, l.Delete("c", "d"))
+	assert.Equal(t, 0, l.Len())
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRU is an in-process Store of at most size keys, the least recently used
+// key is evicted to make room for a new one.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+func (l *LRU) Get(key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*lruEntry)
+	if !l.now().Before(e.expiresAt) {
+		l.order.Remove(el)
+		delete(l.entries, key)
+		return nil, false, nil
+	}
+
+	l.order.MoveToFront(el)
+
+	return e.value, true, nil
+}
+
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.entries[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expiresAt = value, l.now().Add(ttl)
+		l.order.MoveToFront(el)
+		return nil
+	}
+
+	if l.order.Len() >= l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: l.now().Add(ttl)})
+
+	return nil
+}
+
+func (l *LRU) Delete(keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := l.entries[key]; ok {
+			l.order.Remove(el)
+			delete(l.entries, key)
+		}
+	}
+
+	return nil
+}
+
+// Len is the number of keys stored, expired ones included until they are
+// read or evicted.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
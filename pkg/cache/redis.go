@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// redisIdleConns is how many connections Redis keeps open between commands.
+const redisIdleConns = 8
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// Redis is a Store in a Redis server, shared by all the instances of the
+// service. It speaks just enough of the protocol for GET, SET and DEL, so
+// anything answering those, such as a local stand-in, can replace it.
+type Redis struct {
+	addr    string
+	timeout time.Duration
+	idle    chan *redisConn
+}
+
+// NewRedis connects lazily to the server at addr, timeout bounds each
+// command including the dial.
+func NewRedis(addr string, timeout time.Duration) *Redis {
+	return &Redis{
+		addr:    addr,
+		timeout: timeout,
+		idle:    make(chan *redisConn, redisIdleConns),
+	}
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", key)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "Redis.Get error")
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	return reply.([]byte), true, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	_, err := r.do("SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return errors.Wrap(err, "Redis.Set error")
+	}
+
+	return nil
+}
+
+func (r *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := r.do("DEL", keys...)
+	if err != nil {
+		return errors.Wrap(err, "Redis.Delete error")
+	}
+
+	return nil
+}
+
+// Eval runs a Lua script on the server, for the atomic updates of stores
+// other than the cache. It returns the reply as do does.
+func (r *Redis) Eval(script string, keys []string, args ...string) (interface{}, error) {
+	evalArgs := append([]string{script, strconv.Itoa(len(keys))}, keys...)
+
+	reply, err := r.do("EVAL", append(evalArgs, args...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Redis.Eval error")
+	}
+
+	return reply, nil
+}
+
+// do sends one command and reads its reply: a string, an int64, a []byte or
+// nil for a missing value. A connection that failed is not reused.
+func (r *Redis) do(cmd string, args ...string) (interface{}, error) {
+	c, err := r.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.SetDeadline(time.Now().Add(r.timeout)); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if _, err = c.Write(encodeCommand(cmd, args)); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	reply, err := readReply(c.r)
+	if err != nil {
+		var replyErr redisError
+		if !errors.As(err, &replyErr) {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	r.release(c)
+
+	return reply, err
+}
+
+func (r *Redis) conn() (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	c, err := net.DialTimeout("tcp", r.addr, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisConn{Conn: c, r: bufio.NewReader(c)}, nil
+}
+
+func (r *Redis) release(c *redisConn) {
+	select {
+	case r.idle <- c:
+	default:
+		c.Close()
+	}
+}
+
+func encodeCommand(cmd string, args []string) []byte {
+	b := []byte("*" + strconv.Itoa(len(args)+1) + "\r\n")
+	for _, arg := range append([]string{cmd}, args...) {
+		b = append(b, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		b = append(b, arg...)
+		b = append(b, "\r\n"...)
+	}
+
+	return b
+}
+
+// redisError is an error reply, the connection stays usable after it.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, line := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		return b[:n], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", kind)
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis answers GET, SET and DEL from a map, ignoring expiry.
+type fakeRedis struct {
+	sync.Mutex
+	values map[string]string
+	ttls   map[string]string
+}
+
+func (f *fakeRedis) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+
+			for {
+				args, err := readCommand(r)
+				if err != nil {
+					return
+				}
+
+				f.Lock()
+				switch strings.ToUpper(args[0]) {
+				case "GET":
+					if v, ok := f.values[args[1]]; ok {
+						conn.Write([]byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"))
+					} else {
+						conn.Write([]byte("$-1\r\n"))
+					}
+				case "SET":
+					f.values[args[1]] = args[2]
+					f.ttls[args[1]] = args[4]
+					conn.Write([]byte("+OK\r\n"))
+				case "DEL":
+					n := 0
+					for _, key := range args[1:] {
+						if _, ok := f.values[key]; ok {
+							delete(f.values, key)
+							n++
+						}
+					}
+					conn.Write([]byte(":" + strconv.Itoa(n) + "\r\n"))
+				default:
+					conn.Write([]byte("-ERR unknown command\r\n"))
+				}
+				f.Unlock()
+			}
+		}()
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		arg := make([]byte, size+2)
+		if _, err = io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	f := &fakeRedis{values: map[string]string{}, ttls: map[string]string{}}
+	go f.serve(l)
+
+	r := NewRedis(l.Addr().String(), time.Second)
+
+	_, ok, err := r.Get("movie:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, r.Set("movie:1", []byte("Alien\r\n"), time.Minute))
+	assert.Equal(t, "60000", f.ttls["movie:1"])
+
+	v, ok, err := r.Get("movie:1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("Alien\r\n"), v)
+
+	require.NoError(t, r.Delete("movie:1", "movie:1:actors"))
+	_, ok, err = r.Get("movie:1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = r.do("PING")
+	assert.ErrorContains(t, err, "unknown command")
+	// The connection is still used after an error reply.
+	assert.Len(t, r.idle, 1)
+
+	_, _, err = NewRedis("127.0.0.1:1", 100*time.Millisecond).Get("movie:1")
+	assert.Error(t, err)
+}
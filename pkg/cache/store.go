@@ -0,0 +1,11 @@
+package cache
+
+import "time"
+
+// Store keeps encoded values for a while. A missing or expired key is not an
+// error, Get reports it with ok false.
+type Store interface {
+	Get(key string) (value []byte, ok bool, err error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
@@ -0,0 +1,97 @@
+package config
+
+import "os"
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+type Config struct {
+	// Storage selects the repository implementations: postgres or memory.
+	Storage     string
+	PostgresDSN string
+	// MemorySeedDir is a directory with build/data style CSV files loaded
+	// into the in-memory storage on start. Empty means start with no data.
+	MemorySeedDir string
+	// SimilarityInterval is how often the server recomputes the similarity
+	// tables behind the recommendations, as a Go duration. "0" disables the
+	// job, e.g. when `main similarity` runs from cron instead.
+	SimilarityInterval string
+	// StatsRefreshInterval makes the Postgres storage serve the /stats
+	// endpoints from materialized views refreshed this often, as a Go
+	// duration. "0" aggregates on every request.
+	StatsRefreshInterval string
+	// TrashRetention is how long deleted movies and actors can be restored
+	// before the purge job deletes them for good, as a Go duration. "0"
+	// keeps them.
+	TrashRetention string
+	// WebhookInterval is how often the server sends the new events of the
+	// outbox and the deliveries due to the webhooks, as a Go duration. "0"
+	// leaves it to other instances; they can all dispatch at once.
+	WebhookInterval string
+	// WriteTimeout is how long the server has to write a response, as a Go
+	// duration. The event stream applies it to each of its writes instead.
+	WriteTimeout string
+	// CacheTTL is how long single movies and actors, and their casts and
+	// filmographies, are cached, as a Go duration. "0" disables the cache.
+	CacheTTL string
+	// CacheSize is how many of them the in-process cache keeps.
+	CacheSize string
+	// RedisAddr is a Redis server to cache them in instead, shared by all
+	// instances. Empty means the in-process cache.
+	RedisAddr string
+	// CacheControlEntities is the Cache-Control sent with single movies and
+	// actors and their casts and filmographies, CacheControlLists the one
+	// sent with the lists of movies and actors. Empty sends none, the
+	// responses still carry an ETag, single movies and actors a Last-Modified.
+	CacheControlEntities string
+	CacheControlLists    string
+	// RateLimit is how many requests each user, or IP address before
+	// signing in, may make, as requests/duration such as 600/1m.
+	// RateLimitLogin limits the sign in attempts and RateLimitLists the
+	// lists of movies and actors on top of it. "0" disables a limit. The
+	// limits are kept in RedisAddr when set, shared by all instances.
+	RateLimit      string
+	RateLimitLogin string
+	RateLimitLists string
+	// TrustedProxies are the proxies in front of the server, as comma
+	// separated addresses or CIDR ranges such as 172.16.0.0/12. The limits
+	// take the IP address of requests from them out of X-Forwarded-For.
+	// Empty trusts none and counts the peer of the connection.
+	TrustedProxies string
+}
+
+func FromEnv() Config {
+	return Config{
+		Storage:       getEnv("STORAGE", StoragePostgres),
+		PostgresDSN:   getEnv("POSTGRES_DSN", "host=db user=postgres password=postgres port=5432"),
+		MemorySeedDir: getEnv("MEMORY_SEED_DIR", ""),
+
+		SimilarityInterval:   getEnv("SIMILARITY_INTERVAL", "1h"),
+		StatsRefreshInterval: getEnv("STATS_REFRESH_INTERVAL", "0"),
+		TrashRetention:       getEnv("TRASH_RETENTION", "720h"),
+		WebhookInterval:      getEnv("WEBHOOK_INTERVAL", "5s"),
+		WriteTimeout:         getEnv("WRITE_TIMEOUT", "10s"),
+
+		CacheTTL:  getEnv("CACHE_TTL", "1m"),
+		CacheSize: getEnv("CACHE_SIZE", "10000"),
+		RedisAddr: getEnv("REDIS_ADDR", ""),
+
+		CacheControlEntities: getEnv("CACHE_CONTROL_ENTITIES", "private, max-age=60"),
+		CacheControlLists:    getEnv("CACHE_CONTROL_LISTS", "private, no-cache"),
+
+		RateLimit:      getEnv("RATE_LIMIT", "600/1m"),
+		RateLimitLogin: getEnv("RATE_LIMIT_LOGIN", "10/1m"),
+		RateLimitLists: getEnv("RATE_LIMIT_LISTS", "120/1m"),
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
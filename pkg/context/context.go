@@ -8,7 +8,10 @@ import (
 
 type contextKeyType string
 
-const contextUserKey contextKeyType = "contextUserKey"
+const (
+	contextUserKey contextKeyType = "contextUserKey"
+	contextRoleKey contextKeyType = "contextRoleKey"
+)
 
 type Manager struct{}
 
@@ -24,3 +27,16 @@ func (cu Manager) UserIDFromContext(ctx context.Context) (int, error) {
 
 	return user, nil
 }
+
+func (cu Manager) ContextWithUserRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, contextRoleKey, role)
+}
+
+func (cu Manager) UserRoleFromContext(ctx context.Context) (string, error) {
+	role, ok := ctx.Value(contextRoleKey).(string)
+	if !ok {
+		return "", errors.Errorf("can`t get user role from context")
+	}
+
+	return role, nil
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatJSON:   "application/json",
+}
+
+// Encoder writes rows one by one as they come from the database. CSV uses
+// record (the ';'-separated build/data layout without a header), the JSON
+// formats marshal v.
+type Encoder interface {
+	Encode(record []string, v interface{}) error
+	// Close flushes buffered output and terminates the document.
+	Close() error
+}
+
+func ContentType(format string) (string, error) {
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", errors.Wrapf(ErrUnknownFormat, "%q", format)
+	}
+
+	return contentType, nil
+}
+
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		writer.Comma = ';'
+		return &csvEncoder{w: writer}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}, nil
+	case FormatJSON:
+		return &jsonEncoder{w: w}, nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownFormat, "%q", format)
+	}
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(record []string, _ interface{}) error {
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(_ []string, v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// jsonEncoder streams a JSON array without holding it in memory.
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(_ []string, v interface{}) error {
+	item, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	separator := ","
+	if e.count == 0 {
+		separator = "["
+	}
+	e.count++
+
+	_, err = io.WriteString(e.w, separator)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(item)
+
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "]"
+	if e.count == 0 {
+		end = "[]"
+	}
+
+	_, err := io.WriteString(e.w, end)
+
+	return err
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type row struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func encodeAll(t *testing.T, format string, rows ...row) string {
+	var buf bytes.Buffer
+
+	enc, err := NewEncoder(format, &buf)
+	require.NoError(t, err)
+
+	for _, r := range rows {
+		require.NoError(t, enc.Encode([]string{"1", r.Title}, r))
+	}
+	require.NoError(t, enc.Close())
+
+	return buf.String()
+}
+
+func TestEncoders(t *testing.T) {
+	rows := []row{{ID: 1, Title: "Alien"}, {ID: 2, Title: "Say \"hi\"; bye"}}
+
+	assert.Equal(t, "1;Alien\n1;\"Say \"\"hi\"\"; bye\"\n", encodeAll(t, FormatCSV, rows...))
+	assert.Equal(t, "{\"id\":1,\"title\":\"Alien\"}\n{\"id\":2,\"title\":\"Say \\\"hi\\\"; bye\"}\n", encodeAll(t, FormatNDJSON, rows...))
+	assert.Equal(t, `[{"id":1,"title":"Alien"},{"id":2,"title":"Say \"hi\"; bye"}]`, encodeAll(t, FormatJSON, rows...))
+	assert.Equal(t, "[]", encodeAll(t, FormatJSON))
+	assert.Equal(t, "", encodeAll(t, FormatCSV))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewEncoder("xml", &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+
+	_, err = ContentType("xml")
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
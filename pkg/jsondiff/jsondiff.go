@@ -0,0 +1,53 @@
+// Package jsondiff compares values by their JSON encoding, field by field.
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Diff returns the JSON of before and after reduced to the fields that
+// differ. A nil side stays nil and the other is kept whole, as are values
+// that are not JSON objects.
+func Diff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	var beforeJSON, afterJSON json.RawMessage
+	var err error
+
+	if before != nil {
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if before == nil || after == nil {
+		return beforeJSON, afterJSON, nil
+	}
+
+	var beforeFields, afterFields map[string]json.RawMessage
+	if json.Unmarshal(beforeJSON, &beforeFields) != nil || json.Unmarshal(afterJSON, &afterFields) != nil {
+		return beforeJSON, afterJSON, nil
+	}
+
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; ok && bytes.Equal(value, afterValue) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	if beforeJSON, err = json.Marshal(beforeFields); err != nil {
+		return nil, nil, err
+	}
+
+	if afterJSON, err = json.Marshal(afterFields); err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
@@ -0,0 +1,31 @@
+package jsondiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestDiff(t *testing.T) {
+	before, after, err := Diff(item{Name: "a", Count: 1}, item{Name: "a", Count: 2})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":1}`, string(before))
+	assert.JSONEq(t, `{"count":2}`, string(after))
+
+	before, after, err = Diff(nil, item{Name: "a"})
+	require.NoError(t, err)
+	assert.Nil(t, before)
+	assert.JSONEq(t, `{"name":"a","count":0}`, string(after))
+
+	// Values that are not objects are kept whole.
+	before, after, err = Diff([]int{1, 2}, []int{1, 3})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[1,2]`, string(before))
+	assert.JSONEq(t, `[1,3]`, string(after))
+}
@@ -15,6 +15,7 @@ type AuthSessionsManager interface {
 
 type AuthContextManager interface {
 	ContextWithUserID(context.Context, int) context.Context
+	ContextWithUserRole(context.Context, string) context.Context
 }
 
 type AuthManager struct {
@@ -80,6 +81,7 @@ func (am *AuthManager) Auth(next http.Handler, roles ...string) http.Handler {
 			"userRole", userRole)
 
 		ctx := am.ContextManager.ContextWithUserID(r.Context(), userID)
+		ctx = am.ContextManager.ContextWithUserRole(ctx, userRole)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditional(t *testing.T) {
+	body := `{"id":1,"title":"Alien"}`
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/movies/2" {
+			http.Error(w, "can`t get movie", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}), "private, max-age=60")
+
+	get := func(header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/movies/1", nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[\w-]{43}"$`, etag)
+
+	w = get("If-None-Match", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = get("If-None-Match", `"other"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// If-Modified-Since is not answered without a Last-Modified.
+	w = get("If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body = `{"id":1,"title":"Aliens"}`
+	w = get("If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/movies/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+	assert.Equal(t, "can`t get movie\n", w.Body.String())
+}
+
+func TestConditionalLastModified(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 500, time.UTC)
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte(`{"id":1,"title":"Alien"}`))
+	}), "")
+
+	get := func(header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/movies/1", nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+
+	w = get("If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	w = get("If-Modified-Since", "Fri, 01 Mar 2024 11:59:59 GMT")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = get("If-Modified-Since", "yesterday")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// If-None-Match wins if both are sent.
+	w = get("If-None-Match", `"other"`, "If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = get("If-None-Match", etag, "If-Modified-Since", "Fri, 01 Mar 2024 11:59:59 GMT")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	modified = modified.Add(time.Second)
+	w = get("If-Modified-Since", "Fri, 01 Mar 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Fri, 01 Mar 2024 12:00:01 GMT", w.Header().Get("Last-Modified"))
+}
+
+func TestConditionalEmptyResponse(t *testing.T) {
+	h := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "")
+
+	w := httptest.NewRecorder()
+	require.NotPanics(t, func() { h.ServeHTTP(w, httptest.NewRequest("GET", "/movies", nil)) })
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"intern/pkg/logger"
+	"intern/pkg/ratelimit"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+type RateLimitContextManager interface {
+	UserIDFromContext(context.Context) (int, error)
+}
+
+type RateLimiter struct {
+	ContextManager RateLimitContextManager
+	Store          ratelimit.Store
+	Logger         logger.Logger
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed.
+	// None by default, the client is then the peer of the connection.
+	TrustedProxies []*net.IPNet
+}
+
+// Limit allows each client limit requests of next, counted apart from the
+// other routes as name. A client is the user Auth put in the context, so
+// next must run behind it to count users, or the IP address of requests
+// without one. Requests over the limit get 429 Too Many Requests; if the
+// store fails, they are let through.
+func (rl *RateLimiter) Limit(next http.Handler, name string, limit ratelimit.Limit) http.Handler {
+	if limit.Requests == 0 {
+		return next
+	}
+
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(limit.Per.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := rl.Store.Take(name+":"+rl.client(r), limit)
+		if err != nil {
+			rl.Logger.Errorw("can`t take rate limit token",
+				"err:", err.Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			rl.Logger.Infow("rate limited",
+				"url", r.URL.Path,
+				"method", r.Method,
+				"remote_addr", r.RemoteAddr,
+				"limit", name)
+
+			w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rl *RateLimiter) client(r *http.Request) string {
+	if userID, err := rl.ContextManager.UserIDFromContext(r.Context()); err == nil {
+		return "user:" + strconv.Itoa(userID)
+	}
+
+	return "ip:" + rl.clientIP(r)
+}
+
+// clientIP is the peer of the connection, or behind trusted proxies the
+// last address in X-Forwarded-For that none of them added. The addresses
+// before it are up to the client to send, so they can't be believed.
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values(forwardedForHeader), ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && rl.trusted(ip); i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+	}
+
+	return ip
+}
+
+func (rl *RateLimiter) trusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, proxy := range rl.TrustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ceilSeconds rounds d up to whole seconds, the headers have no fractions.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"intern/pkg/context"
+	"intern/pkg/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugw(string, ...interface{}) {}
+func (nopLogger) Infow(string, ...interface{})  {}
+func (nopLogger) Errorw(string, ...interface{}) {}
+
+func TestRateLimit(t *testing.T) {
+	rl := &RateLimiter{
+		ContextManager: context.Manager{},
+		Store:          ratelimit.NewMemory(),
+		Logger:         nopLogger{},
+	}
+	h := rl.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "login", ratelimit.Limit{Requests: 2, Per: time.Minute})
+
+	get := func(remoteAddr string, userID int) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/users/login", nil)
+		r.RemoteAddr = remoteAddr
+		if userID != 0 {
+			r = r.WithContext(context.Manager{}.ContextWithUserID(r.Context(), userID))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("10.0.0.1:1234", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, get("10.0.0.1:1235", 0).Code)
+	w = get("10.0.0.1:1236", 0)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// Users are limited wherever they come from.
+	assert.Equal(t, http.StatusOK, get("10.0.0.1:1237", 1).Code)
+	assert.Equal(t, http.StatusOK, get("10.0.0.2:1237", 1).Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.3:1237", 1).Code)
+	assert.Equal(t, http.StatusOK, get("10.0.0.3:1237", 0).Code)
+
+	rl.Store = failingStore{}
+	h = rl.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "login", ratelimit.Limit{Requests: 2, Per: time.Minute})
+	w = get("10.0.0.1:1234", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("172.18.0.0/16")
+	assert.NoError(t, err)
+
+	rl := &RateLimiter{
+		ContextManager: context.Manager{},
+		Store:          ratelimit.NewMemory(),
+		Logger:         nopLogger{},
+	}
+	h := rl.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "all", ratelimit.Limit{Requests: 1, Per: time.Minute})
+
+	get := func(remoteAddr, forwardedFor string) int {
+		r := httptest.NewRequest("GET", "/movies", nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Untrusted by default, every client behind the proxy is the proxy.
+	assert.Equal(t, http.StatusOK, get("172.18.0.1:1234", "10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get("172.18.0.1:1234", "10.0.0.2"))
+
+	rl.TrustedProxies = []*net.IPNet{proxies}
+	assert.Equal(t, http.StatusOK, get("172.18.0.1:1234", "10.0.0.1"))
+	assert.Equal(t, http.StatusOK, get("172.18.0.1:1234", "10.0.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("172.18.0.1:1234", "10.0.0.2"))
+
+	// Only the address the proxies added counts, the client sent the rest.
+	assert.Equal(t, http.StatusOK, get("172.18.0.1:1234", "10.0.0.9, 10.0.0.3, 172.18.0.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("172.18.0.1:1234", "10.0.0.8, 10.0.0.3"))
+
+	// Nor is it believed from anyone else.
+	assert.Equal(t, http.StatusOK, get("10.0.0.4:1234", "10.0.0.5"))
+	assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.4:1234", "10.0.0.6"))
+}
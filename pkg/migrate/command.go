@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Run executes a `migrate` subcommand: up, down or status.
+func (mg *Migrator) Run(command string, out io.Writer) error {
+	switch command {
+	case "up":
+		applied, err := mg.Up()
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "schema is up to date")
+		}
+	case "down":
+		m, err := mg.Down()
+		if err != nil {
+			return err
+		}
+
+		if m == nil {
+			fmt.Fprintln(out, "nothing to roll back")
+		} else {
+			fmt.Fprintf(out, "rolled back %04d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		statuses, err := mg.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			fmt.Fprintln(out, s)
+		}
+	default:
+		return errors.Errorf("unknown migrate command %q, want up, down or status", command)
+	}
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// lockID is the pg_advisory_xact_lock key that serializes concurrent
+// migrators (e.g. several app replicas starting at once).
+const lockID = 7243001
+
+const createTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	name VARCHAR(256) NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrVersionMismatch = errors.New("database schema version mismatch")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type appliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+}
+
+func New(db *gorm.DB, migrations fs.FS) (*Migrator, error) {
+	ms, err := Load(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: ms,
+	}, nil
+}
+
+// Load reads NNNN_name.up.sql / NNNN_name.down.sql pairs from the root of
+// fsys and returns them ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate.Load error while listing migrations")
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+
+		body, err := fs.ReadFile(fsys, path.Join(".", entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "migrate.Load error while reading %s", entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, errors.Errorf("migrate.Load error: version %d used by %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.Errorf("migrate.Load error: migration %d_%s needs both up and down files", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version the code expects the database to be at.
+func (mg *Migrator) Latest() int {
+	if len(mg.Migrations) == 0 {
+		return 0
+	}
+
+	return mg.Migrations[len(mg.Migrations)-1].Version
+}
+
+// Version returns the highest applied migration, 0 for an empty database.
+func (mg *Migrator) Version() (int, error) {
+	if err := mg.DB.Exec(createTableQuery).Error; err != nil {
+		return 0, errors.Wrap(err, "migrate.Version error while creating schema_migrations")
+	}
+
+	var version int
+	tx := mg.DB.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+
+	if tx.Error != nil {
+		return 0, errors.Wrap(tx.Error, "migrate.Version error")
+	}
+
+	return version, nil
+}
+
+// Check fails with ErrVersionMismatch unless every known migration has been
+// applied and the database is not ahead of the code.
+func (mg *Migrator) Check() error {
+	version, err := mg.Version()
+	if err != nil {
+		return err
+	}
+
+	if version != mg.Latest() {
+		return errors.Wrapf(ErrVersionMismatch, "database is at %d, code expects %d", version, mg.Latest())
+	}
+
+	return nil
+}
+
+// Up applies all pending migrations, each one in its own transaction.
+// It returns the migrations that were applied.
+func (mg *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+
+	for _, m := range mg.Migrations {
+		done := false
+
+		err := mg.transaction(func(tx *gorm.DB, version int) error {
+			if m.Version <= version {
+				return nil
+			}
+
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+
+			done = true
+
+			return tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name).Error
+		})
+		if err != nil {
+			return applied, errors.Wrapf(err, "migrate.Up error while applying %d_%s", m.Version, m.Name)
+		}
+
+		if done {
+			applied = append(applied, m)
+		}
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the latest applied migration and returns it, or nil when
+// the database is empty.
+func (mg *Migrator) Down() (*Migration, error) {
+	var rolledBack *Migration
+
+	err := mg.transaction(func(tx *gorm.DB, version int) error {
+		if version == 0 {
+			return nil
+		}
+
+		for i := range mg.Migrations {
+			if mg.Migrations[i].Version == version {
+				rolledBack = &mg.Migrations[i]
+			}
+		}
+
+		if rolledBack == nil {
+			return errors.Errorf("migration %d is applied but unknown to this binary", version)
+		}
+
+		if err := tx.Exec(rolledBack.Down).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", version).Error
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate.Down error")
+	}
+
+	return rolledBack, nil
+}
+
+// Status lists every known migration together with the time it was
+// applied, if it was.
+func (mg *Migrator) Status() ([]Status, error) {
+	if err := mg.DB.Exec(createTableQuery).Error; err != nil {
+		return nil, errors.Wrap(err, "migrate.Status error while creating schema_migrations")
+	}
+
+	var rows []appliedMigration
+	tx := mg.DB.Raw("SELECT version, name, applied_at FROM schema_migrations ORDER BY version").Scan(&rows)
+
+	if tx.Error != nil {
+		return nil, errors.Wrap(tx.Error, "migrate.Status error")
+	}
+
+	appliedAt := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(mg.Migrations))
+	for _, m := range mg.Migrations {
+		status := Status{Version: m.Version, Name: m.Name}
+		if at, ok := appliedAt[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// transaction runs fn in a transaction holding the migration lock and
+// passes it the schema version read under that lock.
+func (mg *Migrator) transaction(fn func(tx *gorm.DB, version int) error) error {
+	if err := mg.DB.Exec(createTableQuery).Error; err != nil {
+		return errors.Wrap(err, "can`t create schema_migrations")
+	}
+
+	return mg.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+			return errors.Wrap(err, "can`t take migration lock")
+		}
+
+		var version int
+		if err := tx.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error; err != nil {
+			return errors.Wrap(err, "can`t read schema version")
+		}
+
+		return fn(tx, version)
+	})
+}
+
+func (s Status) String() string {
+	if s.AppliedAt == nil {
+		return fmt.Sprintf("%04d_%s\tpending", s.Version, s.Name)
+	}
+
+	return fmt.Sprintf("%04d_%s\tapplied %s", s.Version, s.Name, s.AppliedAt.Format(time.RFC3339))
+}
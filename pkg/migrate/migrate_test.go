@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"intern/migrations"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT)")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT)")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+}
+
+func newMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  "sqlmock_db_0",
+		DriverName:           "postgres",
+		Conn:                 db,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	mg, err := New(gormDB, testFS())
+	require.NoError(t, err)
+
+	return mg, mock
+}
+
+func expectLockedVersion(mock sqlmock.Sqlmock, version int) {
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(lockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(version))
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	ms, err := Load(testFS())
+	require.NoError(t, err)
+	require.Len(t, ms, 2)
+	assert.Equal(t, Migration{Version: 1, Name: "first", Up: "CREATE TABLE a (id INT)", Down: "DROP TABLE a"}, ms[0])
+	assert.Equal(t, 2, ms[1].Version)
+}
+
+func TestLoadRequiresDown(t *testing.T) {
+	_, err := Load(fstest.MapFS{"0001_first.up.sql": {Data: []byte("SELECT 1")}})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	ms, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, ms)
+
+	for i, m := range ms {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+	}
+}
+
+func TestUpAppliesPending(t *testing.T) {
+	mg, mock := newMigrator(t)
+
+	expectLockedVersion(mock, 1)
+	mock.ExpectCommit()
+
+	expectLockedVersion(mock, 1)
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INT)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+		WithArgs(2, "second").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := mg.Up()
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, 2, applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDownRollsBackLatest(t *testing.T) {
+	mg, mock := newMigrator(t)
+
+	expectLockedVersion(mock, 2)
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE b")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	m, err := mg.Down()
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckDetectsMismatch(t *testing.T) {
+	mg, mock := newMigrator(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(1))
+
+	err := mg.Check()
+	assert.True(t, errors.Is(err, ErrVersionMismatch))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
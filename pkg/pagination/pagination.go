@@ -0,0 +1,42 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type Params struct {
+	Limit  int
+	Offset int
+}
+
+func FromRequest(r *http.Request) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+
+	if limitString := r.FormValue("limit"); limitString != "" {
+		limit, err := strconv.Atoi(limitString)
+		if err != nil || limit <= 0 {
+			return p, errors.Errorf("invalid limit %q", limitString)
+		}
+
+		p.Limit = min(limit, MaxLimit)
+	}
+
+	if offsetString := r.FormValue("offset"); offsetString != "" {
+		offset, err := strconv.Atoi(offsetString)
+		if err != nil || offset < 0 {
+			return p, errors.Errorf("invalid offset %q", offsetString)
+		}
+
+		p.Offset = offset
+	}
+
+	return p, nil
+}
@@ -0,0 +1,24 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRequest(t *testing.T) {
+	p, err := FromRequest(httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Params{Limit: DefaultLimit}, p)
+
+	p, err = FromRequest(httptest.NewRequest("GET", "/?limit=1000&offset=40", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Params{Limit: MaxLimit, Offset: 40}, p)
+
+	_, err = FromRequest(httptest.NewRequest("GET", "/?limit=-1", nil))
+	assert.Error(t, err)
+
+	_, err = FromRequest(httptest.NewRequest("GET", "/?offset=abc", nil))
+	assert.Error(t, err)
+}
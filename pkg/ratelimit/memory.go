@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops the buckets that are full again.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+	limit  Limit
+}
+
+// Memory keeps the buckets in the process, each instance of the service
+// limits on its own.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]bucket),
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Take(key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(limit.Requests), at: now}
+	}
+
+	tokens, res := take(refill(b.tokens, now.Sub(b.at), limit), limit)
+	m.buckets[key] = bucket{tokens: tokens, at: now, limit: limit}
+
+	return res, nil
+}
+
+// sweep drops the full buckets, which are the same as no bucket.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+	m.swept = now
+
+	for key, b := range m.buckets {
+		if refill(b.tokens, now.Sub(b.at), b.limit) >= float64(b.limit.Requests) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Limit is a token bucket: it allows Requests at once and refills them
+// evenly over Per. The zero Limit allows everything.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Parse reads a Limit written as requests/duration, such as 10/1m. "0" is
+// the zero Limit.
+func Parse(s string) (Limit, error) {
+	if s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, errors.Errorf("invalid rate limit %q, want requests/duration", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, errors.Errorf("invalid rate limit %q, want positive requests", s)
+	}
+
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, errors.Errorf("invalid rate limit %q, want positive duration", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+func (l Limit) String() string {
+	if l.Requests == 0 {
+		return "0"
+	}
+
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+// rate is the tokens the bucket gets back per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result of taking a token. RetryAfter is how long until a token is back
+// when none was left, Reset how long until the bucket is full again.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// Store keeps the buckets, keyed by client.
+type Store interface {
+	Take(key string, limit Limit) (Result, error)
+}
+
+// refill returns the tokens of a bucket elapsed after it had tokens.
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	return math.Min(float64(limit.Requests), tokens+elapsed.Seconds()*limit.rate())
+}
+
+// take takes a token out of tokens, returning what is left.
+func take(tokens float64, limit Limit) (float64, Result) {
+	var res Result
+
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+
+	res.Remaining = int(tokens)
+	res.Reset = seconds((float64(limit.Requests) - tokens) / limit.rate())
+
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	l, err := Parse("10/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 10, Per: time.Minute}, l)
+	assert.Equal(t, "10/1m0s", l.String())
+
+	l, err = Parse("0")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{}, l)
+
+	for _, s := range []string{"", "10", "ten/1m", "0/1m", "10/0s", "10/minute"} {
+		_, err = Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		res, err := m.Take("login:ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.Equal(t, Result{Allowed: true, Remaining: i, Reset: time.Duration(3-i) * time.Second}, res)
+	}
+
+	res, err := m.Take("login:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{RetryAfter: time.Second, Reset: 3 * time.Second}, res)
+
+	// Other clients have their own bucket.
+	res, err = m.Take("login:ip:10.0.0.2", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	res, err = m.Take("login:ip:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 0, Reset: 2500 * time.Millisecond}, res)
+
+	// Full buckets are dropped.
+	now = now.Add(sweepInterval)
+	_, err = m.Take("login:ip:10.0.0.3", limit)
+	require.NoError(t, err)
+	assert.Len(t, m.buckets, 1)
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"intern/pkg/cache"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// takeScript refills and takes from the bucket in KEYS[1] atomically, as
+// the Go code does, and forgets it once it would be full. ARGV holds the
+// requests, the period and the time, both in milliseconds. It returns
+// whether it took a token and the tokens left.
+const takeScript = `
+local requests, per, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens, at = tonumber(b[1]) or requests, tonumber(b[2]) or now
+tokens = math.min(requests, tokens + math.max(0, now - at) * requests / per)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], per)
+return allowed .. ' ' .. tostring(tokens)
+`
+
+// Redis keeps the buckets in a Redis server, so that the instances of the
+// service share the limits. The clocks of the instances should agree.
+type Redis struct {
+	redis *cache.Redis
+	now   func() time.Time
+}
+
+func NewRedis(redis *cache.Redis) *Redis {
+	return &Redis{
+		redis: redis,
+		now:   time.Now,
+	}
+}
+
+func (r *Redis) Take(key string, limit Limit) (Result, error) {
+	reply, err := r.redis.Eval(takeScript, []string{"ratelimit:" + key},
+		strconv.Itoa(limit.Requests),
+		strconv.FormatInt(limit.Per.Milliseconds(), 10),
+		strconv.FormatInt(r.now().UnixMilli(), 10))
+	if err != nil {
+		return Result{}, errors.Wrap(err, "Redis.Take error")
+	}
+
+	b, ok := reply.([]byte)
+	if !ok {
+		return Result{}, errors.Errorf("Redis.Take error: unexpected reply %v", reply)
+	}
+
+	allowed, left, _ := strings.Cut(string(b), " ")
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "Redis.Take error")
+	}
+
+	// The script took the token already, give it back to take it again.
+	if allowed == "1" {
+		tokens++
+	}
+	_, res := take(tokens, limit)
+
+	return res, nil
+}
@@ -0,0 +1,11 @@
+package sqlutil
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the LIKE/ILIKE wildcards in s so that user input is
+// matched literally.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}